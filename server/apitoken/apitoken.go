@@ -0,0 +1,119 @@
+package apitoken
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"sr/id"
+)
+
+// Scope is something an API token may be used for. Routes which accept API
+// tokens are registered with the scope they require.
+type Scope string
+
+const (
+	// ScopeRoll allows rolling dice and initiative.
+	ScopeRoll Scope = "roll"
+	// ScopeReadEvents allows reading the game and its events.
+	ScopeReadEvents Scope = "read-events"
+	// ScopeAdmin allows anything the player may do in the game, except
+	// managing their API tokens and the game's security.
+	ScopeAdmin Scope = "admin"
+)
+
+// Scopes are the scopes tokens may have.
+var Scopes = []Scope{ScopeRoll, ScopeReadEvents, ScopeAdmin}
+
+// ValidScope determines if the scope is one of Scopes.
+func ValidScope(scope Scope) bool {
+	for _, valid := range Scopes {
+		if scope == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// MaxTokens is the largest number of API tokens a player may have.
+const MaxTokens = 10
+
+// Prefix starts the secret of every API token, so they can be told apart from
+// session IDs in the Authentication header.
+const Prefix = "srt_"
+
+// Token allows bots and scripts to act as a player in one game, without a
+// session. Tokens do not expire, and are revoked by their player.
+//
+// Only a hash of the token's secret is stored; the secret is shown once, when
+// the token is created.
+type Token struct {
+	ID       id.UID  `json:"id"`
+	GameID   string  `json:"gameID"`
+	PlayerID id.UID  `json:"playerID"`
+	Name     string  `json:"name"`
+	Scopes   []Scope `json:"scopes"`
+	Verified bool    `json:"verified"` // Created with a verified session
+	Created  int64   `json:"created"`
+}
+
+// Make constructs a new Token, returning it and its secret.
+func Make(gameID string, playerID id.UID, name string, scopes []Scope, verified bool, now int64) (Token, string) {
+	token := Token{
+		ID:       id.GenUID(),
+		GameID:   gameID,
+		PlayerID: playerID,
+		Name:     name,
+		Scopes:   scopes,
+		Verified: verified,
+		Created:  now,
+	}
+	return token, Prefix + string(id.GenSessionID())
+}
+
+// IsSecret determines if a bearer value is the secret of an API token.
+func IsSecret(bearer string) bool {
+	return strings.HasPrefix(bearer, Prefix)
+}
+
+// Hash is the hash of a token's secret, which it is stored by.
+func Hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// RedisKey is the key of the token with the given secret hash.
+func RedisKey(hash string) string {
+	return "apitoken:" + hash
+}
+
+// PlayerKey is the key of the hash of a player's tokens, from their IDs to
+// their secret hashes.
+func PlayerKey(playerID id.UID) string {
+	return "apitokens:" + string(playerID)
+}
+
+// Allows determines if the token may be used for a route requiring the scope.
+// Admin tokens may be used for any route, including those without a scope.
+func (t *Token) Allows(scope Scope) bool {
+	for _, has := range t.Scopes {
+		if has == ScopeAdmin || (scope != "" && has == scope) {
+			return true
+		}
+	}
+	return false
+}
+
+// ScopeNames returns the token's scopes as strings, for logging.
+func (t *Token) ScopeNames() []string {
+	names := make([]string, len(t.Scopes))
+	for ix, scope := range t.Scopes {
+		names[ix] = string(scope)
+	}
+	return names
+}
+
+func (t *Token) String() string {
+	return fmt.Sprintf("API token %v (%v) of %v in %v", t.ID, t.Name, t.PlayerID, t.GameID)
+}
@@ -0,0 +1,86 @@
+package apitoken_test
+
+import (
+	"context"
+	"testing"
+
+	genGame "sr/gen/game"
+	genPlayer "sr/gen/player"
+
+	"sr/apitoken"
+	"sr/errs"
+	"sr/id"
+	"sr/test"
+)
+
+func TestAllows(t *testing.T) {
+	roll := apitoken.Token{Scopes: []apitoken.Scope{apitoken.ScopeRoll}}
+	test.AssertEqual(t, true, roll.Allows(apitoken.ScopeRoll))
+	test.AssertEqual(t, false, roll.Allows(apitoken.ScopeReadEvents))
+	test.AssertEqual(t, false, roll.Allows(""))
+
+	admin := apitoken.Token{Scopes: []apitoken.Scope{apitoken.ScopeAdmin}}
+	test.AssertEqual(t, true, admin.Allows(apitoken.ScopeRoll))
+	test.AssertEqual(t, true, admin.Allows(""))
+
+	test.AssertEqual(t, true, apitoken.ValidScope("read-events"))
+	test.AssertEqual(t, false, apitoken.ValidScope("everything"))
+}
+
+func TestCreate(t *testing.T) {
+	ctx := context.Background()
+	_, client := test.GetRedis(t)
+	rng := test.RNG()
+
+	plr := genPlayer.Player(rng)
+	gameID := genGame.GameID(rng)
+	token, secret := apitoken.Make(gameID, plr.ID, "Dice bot",
+		[]apitoken.Scope{apitoken.ScopeRoll}, true, id.TimestampNow(),
+	)
+	test.AssertEqual(t, true, apitoken.IsSecret(secret))
+	test.Must(t, apitoken.Create(ctx, client, &token, secret))
+
+	found, err := apitoken.Check(ctx, client, secret)
+	test.AssertSuccess(t, err, "checking token")
+	test.AssertEqual(t, &token, found)
+	_, err = apitoken.Check(ctx, client, secret+"x")
+	test.AssertErrorIs(t, err, errs.ErrNoAccess)
+	_, err = apitoken.Check(ctx, client, string(id.GenSessionID()))
+	test.AssertErrorIs(t, err, errs.ErrNoAccess)
+
+	stored, err := client.Exists(ctx, apitoken.RedisKey(secret)).Result()
+	test.AssertSuccess(t, err, "checking for secret")
+	test.AssertEqual(t, int64(0), stored)
+
+	tokens, err := apitoken.GetAll(ctx, client, plr.ID)
+	test.AssertSuccess(t, err, "getting tokens")
+	test.AssertEqual(t, []apitoken.Token{token}, tokens)
+
+	test.Must(t, apitoken.Revoke(ctx, client, plr.ID, token.ID))
+	_, err = apitoken.Check(ctx, client, secret)
+	test.AssertErrorIs(t, err, errs.ErrNoAccess)
+	err = apitoken.Revoke(ctx, client, plr.ID, token.ID)
+	test.AssertErrorIs(t, err, errs.ErrNotFound)
+}
+
+func TestCreate_Max(t *testing.T) {
+	ctx := context.Background()
+	_, client := test.GetRedis(t)
+	rng := test.RNG()
+
+	plr := genPlayer.Player(rng)
+	gameID := genGame.GameID(rng)
+	scopes := []apitoken.Scope{apitoken.ScopeReadEvents}
+	for i := 0; i < apitoken.MaxTokens; i++ {
+		token, secret := apitoken.Make(gameID, plr.ID, "Bot", scopes, false, int64(i))
+		test.Must(t, apitoken.Create(ctx, client, &token, secret))
+	}
+	token, secret := apitoken.Make(gameID, plr.ID, "Bot", scopes, false, 0)
+	err := apitoken.Create(ctx, client, &token, secret)
+	test.AssertErrorIs(t, err, errs.ErrBadRequest)
+
+	tokens, err := apitoken.GetAll(ctx, client, plr.ID)
+	test.AssertSuccess(t, err, "getting tokens")
+	test.AssertEqual(t, apitoken.MaxTokens, len(tokens))
+	test.AssertEqual(t, int64(0), tokens[0].Created)
+}
@@ -0,0 +1,161 @@
+package apitoken
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+
+	"sr/errs"
+	"sr/id"
+	srOtel "sr/otel"
+	redisUtil "sr/redis"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// GetAll retrieves the API tokens of a player, oldest first.
+func GetAll(ctx context.Context, client redis.Cmdable, playerID id.UID) ([]Token, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "apitoken.GetAll")
+	defer span.End()
+	hashes, err := client.HGetAll(ctx, PlayerKey(playerID)).Result()
+	if err != nil {
+		return nil, srOtel.WithSetErrorf(span, "getting API tokens: %w", err)
+	}
+	tokens := make([]Token, 0, len(hashes))
+	for _, hash := range hashes {
+		token, err := getByHash(ctx, client, hash)
+		if errors.Is(err, errs.ErrNotFound) {
+			continue
+		} else if err != nil {
+			return nil, srOtel.WithSetErrorf(span, "getting API token: %w", err)
+		}
+		tokens = append(tokens, *token)
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i].Created < tokens[j].Created
+	})
+	return tokens, nil
+}
+
+// GetHashesIn retrieves the secret hashes of a player's API tokens in a game,
+// by token ID.
+func GetHashesIn(ctx context.Context, client redis.Cmdable, gameID string, playerID id.UID) (map[string]string, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "apitoken.GetHashesIn")
+	defer span.End()
+	hashes, err := client.HGetAll(ctx, PlayerKey(playerID)).Result()
+	if err != nil {
+		return nil, srOtel.WithSetErrorf(span, "getting API tokens: %w", err)
+	}
+	inGame := make(map[string]string)
+	for tokenID, hash := range hashes {
+		token, err := getByHash(ctx, client, hash)
+		if errors.Is(err, errs.ErrNotFound) {
+			continue
+		} else if err != nil {
+			return nil, srOtel.WithSetErrorf(span, "getting API token: %w", err)
+		}
+		if token.GameID == gameID {
+			inGame[tokenID] = hash
+		}
+	}
+	return inGame, nil
+}
+
+// RevokeCommands queues the removal of a player's API tokens, given their
+// secret hashes by token ID as from GetHashesIn.
+func RevokeCommands(ctx context.Context, pipe redis.Pipeliner, playerID id.UID, hashes map[string]string) {
+	for tokenID, hash := range hashes {
+		pipe.HDel(ctx, PlayerKey(playerID), tokenID)
+		pipe.Del(ctx, RedisKey(hash))
+	}
+}
+
+func getByHash(ctx context.Context, client redis.Cmdable, hash string) (*Token, error) {
+	text, err := client.Get(ctx, RedisKey(hash)).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, errs.NotFoundf("API token")
+	} else if err != nil {
+		return nil, err
+	}
+	var token Token
+	if err := json.Unmarshal([]byte(text), &token); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// Check finds the API token with the given secret.
+// Returns ErrNoAccess if there is no such token.
+func Check(ctx context.Context, client redis.Cmdable, secret string) (*Token, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "apitoken.Check")
+	defer span.End()
+	if !IsSecret(secret) {
+		return nil, errs.NoAccessf("API token")
+	}
+	token, err := getByHash(ctx, client, Hash(secret))
+	if errors.Is(err, errs.ErrNotFound) {
+		return nil, errs.NoAccessf("API token")
+	} else if err != nil {
+		return nil, srOtel.WithSetErrorf(span, "getting API token: %w", err)
+	}
+	return token, nil
+}
+
+// Create adds a new API token for its player, given its secret.
+// Returns ErrBadRequest if the player would have more than MaxTokens.
+func Create(ctx context.Context, client *redis.Client, token *Token, secret string) error {
+	ctx, span := srOtel.Tracer.Start(ctx, "apitoken.Create")
+	defer span.End()
+	tokenBytes, err := json.Marshal(token)
+	if err != nil {
+		return srOtel.WithSetErrorf(span, "marshaling %v: %w", token, err)
+	}
+	hash := Hash(secret)
+	playerKey := PlayerKey(token.PlayerID)
+
+	watched := func(tx *redis.Tx) error {
+		count, err := tx.HLen(ctx, playerKey).Result()
+		if err != nil {
+			return srOtel.WithSetErrorf(span, "counting API tokens: %w", err)
+		}
+		if count >= MaxTokens {
+			return errs.BadRequestf("player already has %v API tokens", MaxTokens)
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, RedisKey(hash), tokenBytes, 0)
+			pipe.HSet(ctx, playerKey, string(token.ID), hash)
+			return nil
+		})
+		return err
+	}
+	err = redisUtil.RetryWatchTxn(ctx, client, watched, playerKey)
+	if errors.Is(err, errs.ErrBadRequest) {
+		return err
+	} else if err != nil {
+		return srOtel.WithSetErrorf(span, "running transaction: %w", err)
+	}
+	return nil
+}
+
+// Revoke removes one of a player's API tokens.
+// Returns ErrNotFound if the player does not have the token.
+func Revoke(ctx context.Context, client redis.Cmdable, playerID id.UID, tokenID id.UID) error {
+	ctx, span := srOtel.Tracer.Start(ctx, "apitoken.Revoke")
+	defer span.End()
+	hash, err := client.HGet(ctx, PlayerKey(playerID), string(tokenID)).Result()
+	if errors.Is(err, redis.Nil) {
+		return errs.NotFoundf("API token %v", tokenID)
+	} else if err != nil {
+		return srOtel.WithSetErrorf(span, "getting API token: %w", err)
+	}
+	_, err = client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HDel(ctx, PlayerKey(playerID), string(tokenID))
+		pipe.Del(ctx, RedisKey(hash))
+		return nil
+	})
+	if err != nil {
+		return srOtel.WithSetErrorf(span, "removing API token: %w", err)
+	}
+	return nil
+}
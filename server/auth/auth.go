@@ -3,21 +3,35 @@ package auth
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 
+	"sr/credential"
 	"sr/errs"
 	"sr/game"
+	"sr/id"
+	"sr/invite"
+	"sr/oidc"
 	srOtel "sr/otel"
 	"sr/player"
+	"sr/session"
 
 	"github.com/go-redis/redis/v8"
 )
 
 // LogPlayerIn checks username/gameID credentials and returns the relevant
-// GameInfo for the client.
+// GameInfo for the client. Spectators of the game may also log in. If gameID
+// is empty, the player is logged in without a game and GameInfo is nil, which
+// requires a password.
+//
+// Players who have set a password must give it, players who have only
+// registered passkeys must log in with LogPasskeyIn, and players who have only
+// linked a provider's account must log in with LogOIDCIn. The password of
+// players without credentials is ignored.
 //
 // Returns ErrNotFound if the game or player does not exist, ErrNoAccess if the
-// player does not have access to the game.
-func LogPlayerIn(ctx context.Context, client *redis.Client, gameID string, username string) (*game.Info, *player.Player, error) {
+// player does not have access to the game or gave the wrong password.
+func LogPlayerIn(ctx context.Context, client *redis.Client, gameID string, username string, password string) (*game.Info, *player.Player, error) {
 	ctx, span := srOtel.Tracer.Start(ctx, "auth.LogPlayerIn")
 	defer span.End()
 
@@ -27,19 +41,326 @@ func LogPlayerIn(ctx context.Context, client *redis.Client, gameID string, usern
 	} else if err != nil {
 		return nil, nil, srOtel.WithSetErrorf(span, "getting player %v: %w", username, err)
 	}
+	verified, err := checkPassword(ctx, client, plr, password)
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := gameForLogin(ctx, client, gameID, plr, verified)
+	if err != nil {
+		return nil, nil, err
+	}
+	return info, plr, nil
+}
+
+// checkPassword checks the password a player logs in with, returning whether
+// the player logged in with credentials.
+func checkPassword(ctx context.Context, client redis.Cmdable, plr *player.Player, password string) (bool, error) {
+	if plr.HasPassword() {
+		if !credential.CheckPassword(plr.PasswordHash, password) {
+			return false, errs.NoAccessf("wrong password for %v", plr.ID)
+		}
+		return true, nil
+	}
+	hasPasskeys, err := credential.HasPasskeys(ctx, client, plr.ID)
+	if err != nil {
+		return false, err
+	}
+	if hasPasskeys {
+		return false, errs.NoAccessf("player %v must log in with a passkey", plr.ID)
+	}
+	hasLinks, err := oidc.HasLinks(ctx, client, plr.ID)
+	if err != nil {
+		return false, err
+	}
+	if hasLinks {
+		return false, errs.NoAccessf("player %v must log in with their provider", plr.ID)
+	}
+	return false, nil
+}
 
+// gameForLogin checks that a player logging in has access to the game, and
+// returns its info. Returns nil info if gameID is empty; only players who
+// logged in with credentials may log in without a game, as the game's ID is
+// all that protects players without them.
+func gameForLogin(ctx context.Context, client *redis.Client, gameID string, plr *player.Player, verified bool) (*game.Info, error) {
+	if gameID == "" {
+		if !verified {
+			return nil, errs.NoAccessf("player %v must log in to a game", plr.ID)
+		}
+		return nil, nil
+	}
 	info, err := game.GetInfo(ctx, client, gameID)
 	if errors.Is(err, errs.ErrNotFound) || errors.Is(err, errs.ErrBadRequest) {
-		return nil, nil, errs.NotFoundf("game %v", gameID)
+		return nil, errs.NotFoundf("game %v", gameID)
 	} else if err != nil {
-		return nil, nil, srOtel.WithSetErrorf(span,
-			"fetching game info: %w", err)
+		return nil, fmt.Errorf("fetching game info: %w", err)
 	}
 
 	// Ensure player is in the game
-	if _, found := info.Players[string(plr.ID)]; !found {
-		return nil, nil, errs.NoAccessf(
-			"player %v (%v) to %v", plr.ID, username, gameID)
+	_, found := info.Players[string(plr.ID)]
+	_, spectating := info.Spectators[string(plr.ID)]
+	if !found && !spectating {
+		return nil, errs.NoAccessf(
+			"player %v (%v) to %v", plr.ID, plr.Username, gameID)
+	}
+	if info.RequireCredentials && !verified {
+		return nil, errs.NoAccessf("game %v requires a password or passkey", gameID)
+	}
+	return info, nil
+}
+
+// BeginPasskeyLogin creates a challenge for the player with the given username
+// to sign with one of their passkeys, which are returned.
+//
+// Returns ErrNotFound if the player does not exist, and ErrNoAccess if they
+// have not registered any passkeys.
+func BeginPasskeyLogin(ctx context.Context, client redis.Cmdable, username string) (*credential.Challenge, []credential.Passkey, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "auth.BeginPasskeyLogin")
+	defer span.End()
+
+	plr, err := player.GetByUsername(ctx, client, username)
+	if errors.Is(err, errs.ErrNotFound) {
+		return nil, nil, errs.NotFoundf("player %v", username)
+	} else if err != nil {
+		return nil, nil, srOtel.WithSetErrorf(span, "getting player %v: %w", username, err)
+	}
+	passkeys, err := credential.GetPasskeys(ctx, client, plr.ID)
+	if err != nil {
+		return nil, nil, srOtel.WithSetErrorf(span, "getting passkeys: %w", err)
+	}
+	if len(passkeys) == 0 {
+		return nil, nil, errs.NoAccessf("player %v has no passkeys", plr.ID)
+	}
+	challenge, err := credential.MakeChallenge(plr.ID, credential.PurposeLogin)
+	if err != nil {
+		return nil, nil, srOtel.WithSetErrorf(span, "making challenge: %w", err)
+	}
+	if err := credential.CreateChallenge(ctx, client, &challenge); err != nil {
+		return nil, nil, srOtel.WithSetErrorf(span, "saving challenge: %w", err)
+	}
+	return &challenge, passkeys, nil
+}
+
+// LogPasskeyIn checks a passkey's answer to a challenge from
+// BeginPasskeyLogin, and returns the player and the GameInfo for the client
+// as with LogPlayerIn.
+//
+// Returns ErrNotFound if the game does not exist, and ErrNoAccess if the
+// passkey's answer is not valid or the player does not have access to the
+// game.
+func LogPasskeyIn(ctx context.Context, client *redis.Client, rp *credential.RelyingParty, gameID string, assertion *credential.Assertion) (*game.Info, *player.Player, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "auth.LogPasskeyIn")
+	defer span.End()
+
+	challenge, err := credential.TakeChallenge(ctx, client, assertion.ChallengeID, credential.PurposeLogin)
+	if errors.Is(err, errs.ErrNotFound) {
+		return nil, nil, errs.NoAccess(err)
+	} else if err != nil {
+		return nil, nil, srOtel.WithSetErrorf(span, "getting challenge: %w", err)
+	}
+	plr, err := player.GetByID(ctx, client, string(challenge.PlayerID))
+	if errors.Is(err, errs.ErrNotFound) {
+		return nil, nil, errs.NoAccess(err)
+	} else if err != nil {
+		return nil, nil, srOtel.WithSetErrorf(span, "getting player: %w", err)
+	}
+	passkey, err := credential.GetPasskey(ctx, client, plr.ID, assertion.PasskeyID)
+	if errors.Is(err, errs.ErrNotFound) {
+		return nil, nil, errs.NoAccess(err)
+	} else if err != nil {
+		return nil, nil, srOtel.WithSetErrorf(span, "getting passkey: %w", err)
+	}
+	signCount, err := rp.VerifyAssertion(passkey, challenge.Challenge,
+		assertion.ClientDataJSON, assertion.AuthenticatorData, assertion.Signature,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	passkey.SignCount = signCount
+	passkey.LastUsed = id.TimestampNow()
+	if err := credential.UpdatePasskey(ctx, client, plr.ID, passkey); err != nil {
+		return nil, nil, srOtel.WithSetErrorf(span, "updating passkey: %w", err)
+	}
+	info, err := gameForLogin(ctx, client, gameID, plr, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	return info, plr, nil
+}
+
+// JoinWithInvite redeems an invite code for the player with the given
+// username, creating them with the given name if they do not exist yet, and
+// adds them to the invite's game as a player or spectator. Players who are
+// already in the game or spectating it do not use up the invite.
+//
+// New players may set a password as they join. Existing players may only join
+// from a session of theirs (loggedIn), or by giving the password they have set;
+// players without credentials must log in before joining. Games which require
+// credentials may only be joined by players with a password or a session made
+// with credentials.
+//
+// Returns ErrNoAccess if the invite is not valid or the player may not join,
+// and ErrBadRequest if the username, name, or password are not valid.
+func JoinWithInvite(ctx context.Context, client *redis.Client, loggedIn *session.Session, code id.UID, username string, name string, password string) (*game.Info, *player.Player, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "auth.JoinWithInvite")
+	defer span.End()
+
+	now := id.TimestampNow()
+	inv, err := invite.GetByCode(ctx, client, code)
+	if errors.Is(err, errs.ErrNotFound) {
+		return nil, nil, errs.NoAccessf("invite %v", code)
+	} else if err != nil {
+		return nil, nil, srOtel.WithSetErrorf(span, "getting invite: %w", err)
+	}
+	if !inv.IsUsable(now) {
+		return nil, nil, errs.NoAccessf("invite %v", code)
+	}
+
+	requireCredentials, err := game.RequiresCredentials(ctx, client, inv.GameID)
+	if err != nil {
+		return nil, nil, srOtel.WithSetErrorf(span, "getting game settings: %w", err)
+	}
+
+	if !player.ValidName(username) {
+		return nil, nil, errs.BadRequestf("username: invalid")
+	}
+	plr, err := player.GetByUsername(ctx, client, username)
+	if errors.Is(err, errs.ErrNotFound) {
+		if !player.ValidName(name) {
+			return nil, nil, errs.BadRequestf("name: invalid")
+		}
+		created := player.Make(username, name)
+		if password != "" || requireCredentials {
+			if !credential.ValidPassword(password) {
+				return nil, nil, errs.BadRequestf("password: must be %v to %v characters",
+					credential.MinPasswordLength, credential.MaxPasswordLength)
+			}
+			if created.PasswordHash, err = credential.HashPassword(password); err != nil {
+				return nil, nil, srOtel.WithSetErrorf(span, "hashing password: %w", err)
+			}
+		}
+		if err := player.Create(ctx, client, &created); err != nil {
+			return nil, nil, err
+		}
+		plr = &created
+	} else if err != nil {
+		return nil, nil, srOtel.WithSetErrorf(span, "getting player %v: %w", username, err)
+	} else {
+		var verified bool
+		if loggedIn != nil && loggedIn.PlayerID == plr.ID {
+			verified = loggedIn.Verified
+		} else {
+			verified, err = checkPassword(ctx, client, plr, password)
+			if err != nil {
+				return nil, nil, err
+			}
+			if !verified {
+				return nil, nil, errs.NoAccessf("player %v must log in to join", plr.ID)
+			}
+		}
+		if requireCredentials && !verified {
+			return nil, nil, errs.NoAccessf("game %v requires a password or passkey", inv.GameID)
+		}
+	}
+
+	inGame, err := game.HasPlayer(ctx, client, inv.GameID, plr.ID)
+	if err != nil {
+		return nil, nil, srOtel.WithSetErrorf(span, "checking player in game: %w", err)
+	}
+	spectating, err := game.HasSpectator(ctx, client, inv.GameID, plr.ID)
+	if err != nil {
+		return nil, nil, srOtel.WithSetErrorf(span, "checking spectator in game: %w", err)
+	}
+	if !inGame && !spectating {
+		if _, err := invite.Redeem(ctx, client, code, now); errors.Is(err, errs.ErrNotFound) {
+			return nil, nil, errs.NoAccessf("invite %v", code)
+		} else if err != nil {
+			return nil, nil, srOtel.WithSetErrorf(span, "redeeming invite: %w", err)
+		}
+		if inv.Spectate {
+			err = game.AddSpectator(ctx, client, inv.GameID, plr)
+		} else {
+			err = game.AddPlayer(ctx, client, inv.GameID, plr)
+		}
+		if err != nil {
+			return nil, nil, srOtel.WithSetErrorf(span, "adding player to game: %w", err)
+		}
+	}
+
+	info, err := game.GetInfo(ctx, client, inv.GameID)
+	if err != nil {
+		return nil, nil, srOtel.WithSetErrorf(span, "fetching game info: %w", err)
 	}
 	return info, plr, nil
 }
+
+// LogOIDCIn logs in the player linked to an OpenID Connect provider's subject,
+// and returns the player and the GameInfo for the client as with LogPlayerIn.
+//
+// Subjects which are not linked yet are linked to the player in the state if
+// they are linking their account, or else to a new player named from the
+// claims. The claimed username is only used if it is free.
+//
+// Returns ErrNotFound if the game does not exist, ErrNoAccess if the player
+// does not have access to the game, and ErrBadRequest if the subject is linked
+// to a different player than the one linking it.
+func LogOIDCIn(ctx context.Context, client *redis.Client, claims *oidc.Claims, state *oidc.State) (*game.Info, *player.Player, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "auth.LogOIDCIn")
+	defer span.End()
+
+	playerID, err := oidc.GetLinkedPlayer(ctx, client, claims.Issuer, claims.Subject)
+	if errors.Is(err, errs.ErrNotFound) {
+		playerID = state.PlayerID
+	} else if err != nil {
+		return nil, nil, srOtel.WithSetErrorf(span, "getting linked player: %w", err)
+	} else if state.PlayerID != "" && playerID != state.PlayerID {
+		return nil, nil, errs.BadRequestf("account is linked to another player")
+	}
+
+	var plr *player.Player
+	if playerID == "" {
+		plr, err = createOIDCPlayer(ctx, client, claims)
+	} else {
+		plr, err = player.GetByID(ctx, client, string(playerID))
+	}
+	if errors.Is(err, errs.ErrNotFound) {
+		return nil, nil, errs.NoAccess(err)
+	} else if err != nil {
+		return nil, nil, srOtel.WithSetErrorf(span, "getting player: %w", err)
+	}
+	if err := oidc.Link(ctx, client, claims.Issuer, claims.Subject, plr.ID); err != nil && !errors.Is(err, errs.ErrBadRequest) {
+		return nil, nil, srOtel.WithSetErrorf(span, "linking player: %w", err)
+	}
+
+	info, err := gameForLogin(ctx, client, state.GameID, plr, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	return info, plr, nil
+}
+
+// createOIDCPlayer creates a player for a subject logging in for the first time.
+func createOIDCPlayer(ctx context.Context, client redis.Cmdable, claims *oidc.Claims) (*player.Player, error) {
+	name := strings.TrimSpace(claims.Name)
+	if !player.ValidName(name) {
+		name = strings.TrimSpace(claims.PreferredUsername)
+	}
+	if !player.ValidName(name) {
+		name = "Player"
+	}
+	username := strings.TrimSpace(claims.PreferredUsername)
+	if player.ValidName(username) {
+		created := player.Make(username, name)
+		err := player.Create(ctx, client, &created)
+		if err == nil {
+			return &created, nil
+		} else if !errors.Is(err, errs.ErrBadRequest) {
+			return nil, err
+		}
+	}
+	created := player.Make("oidc-"+string(id.GenUID()), name)
+	if err := player.Create(ctx, client, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
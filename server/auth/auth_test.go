@@ -4,12 +4,17 @@ import (
 	"context"
 	"testing"
 
+	"sr/credential"
 	"sr/errs"
 	genGame "sr/gen/game"
 	genPlayer "sr/gen/player"
 
 	"sr/game"
+	"sr/id"
+	"sr/invite"
+	"sr/oidc"
 	"sr/player"
+	"sr/session"
 	"sr/test"
 )
 
@@ -38,7 +43,7 @@ func TestLogPlayerIn(t *testing.T) {
 	test.AssertSuccess(t, err, "adding plr to game")
 
 	test.RunParallel(t, "player in game", func(t *testing.T) {
-		info, foundPlr, err := LogPlayerIn(ctx, client, gameID, plr.Username)
+		info, foundPlr, err := LogPlayerIn(ctx, client, gameID, plr.Username, "")
 		test.AssertSuccess(t, err, "logging player in")
 		test.AssertEqual(t, plr, foundPlr)
 		expectedInfo := &game.Info{
@@ -51,19 +56,263 @@ func TestLogPlayerIn(t *testing.T) {
 	})
 
 	test.RunParallel(t, "player not in game", func(t *testing.T) {
-		_, _, err := LogPlayerIn(ctx, client, gameID, plr2.Username)
+		_, _, err := LogPlayerIn(ctx, client, gameID, plr2.Username, "")
 		test.AssertErrorIs(t, err, errs.ErrNoAccess)
 	})
 
 	test.RunParallel(t, "no game", func(t *testing.T) {
 		invalidGameID := genGame.GameID(rng)
-		_, _, err := LogPlayerIn(ctx, client, invalidGameID, plr.Username)
+		_, _, err := LogPlayerIn(ctx, client, invalidGameID, plr.Username, "")
 		test.AssertErrorIs(t, err, errs.ErrNotFound)
 	})
 
 	test.RunParallel(t, "no player", func(t *testing.T) {
 		invalidPlr := genPlayer.Player(rng)
-		_, _, err := LogPlayerIn(ctx, client, gameID, invalidPlr.Username)
+		_, _, err := LogPlayerIn(ctx, client, gameID, invalidPlr.Username, "")
 		test.AssertErrorIs(t, err, errs.ErrNotFound)
 	})
 }
+
+func TestJoinWithInvite(t *testing.T) {
+	ctx := context.Background()
+	_, client := test.GetRedis(t)
+	rng := test.RNG()
+
+	gameID := genGame.GameID(rng)
+	gm := genPlayer.Player(rng)
+	test.Must(t, player.Create(ctx, client, gm))
+	test.Must(t, game.CreateWithGM(ctx, client, gameID, gm))
+
+	now := id.TimestampNow()
+	inv := invite.Make(gameID, gm, 60000, 1, false, now)
+	test.Must(t, invite.Create(ctx, client, &inv, now))
+
+	info, plr, err := JoinWithInvite(ctx, client, nil, inv.Code, "joiner"+gameID, "Joiner", "")
+	test.AssertSuccess(t, err, "joining game")
+	_, found := info.Players[plr.ID.String()]
+	test.AssertEqual(t, true, found)
+
+	_, _, err = JoinWithInvite(ctx, client, nil, inv.Code, gm.Username, "", "")
+	test.AssertErrorIs(t, err, errs.ErrNoAccess)
+}
+
+func TestJoinWithInvite_ExistingPlayer(t *testing.T) {
+	ctx := context.Background()
+	_, client := test.GetRedis(t)
+	rng := test.RNG()
+
+	gameID := genGame.GameID(rng)
+	gm := genPlayer.Player(rng)
+	plr := genPlayer.Player(rng)
+	keyed := genPlayer.Player(rng)
+	test.Must(t,
+		player.Create(ctx, client, gm),
+		player.Create(ctx, client, plr),
+		player.Create(ctx, client, keyed),
+		game.CreateWithGM(ctx, client, gameID, gm),
+		credential.SetPassword(ctx, client, keyed.ID, "hunter22"),
+	)
+
+	now := id.TimestampNow()
+	inv := invite.Make(gameID, gm, 60000, 5, false, now)
+	test.Must(t, invite.Create(ctx, client, &inv, now))
+
+	_, _, err := JoinWithInvite(ctx, client, nil, inv.Code, plr.Username, "", "")
+	test.AssertErrorIs(t, err, errs.ErrNoAccess)
+	_, _, err = JoinWithInvite(ctx, client, nil, inv.Code, plr.Username, "", "hunter22")
+	test.AssertErrorIs(t, err, errs.ErrNoAccess)
+	_, _, err = JoinWithInvite(ctx, client, session.New(gm, "", false), inv.Code, plr.Username, "", "")
+	test.AssertErrorIs(t, err, errs.ErrNoAccess)
+	inGame, err := game.HasPlayer(ctx, client, gameID, plr.ID)
+	test.AssertSuccess(t, err, "checking player in game")
+	test.AssertEqual(t, false, inGame)
+
+	info, _, err := JoinWithInvite(ctx, client, session.New(plr, "", false), inv.Code, plr.Username, "", "")
+	test.AssertSuccess(t, err, "joining from a session")
+	_, found := info.Players[plr.ID.String()]
+	test.AssertEqual(t, true, found)
+
+	_, _, err = JoinWithInvite(ctx, client, nil, inv.Code, keyed.Username, "", "")
+	test.AssertErrorIs(t, err, errs.ErrNoAccess)
+	info, _, err = JoinWithInvite(ctx, client, nil, inv.Code, keyed.Username, "", "hunter22")
+	test.AssertSuccess(t, err, "joining with a password")
+	_, found = info.Players[keyed.ID.String()]
+	test.AssertEqual(t, true, found)
+}
+
+func TestJoinWithInvite_Spectate(t *testing.T) {
+	ctx := context.Background()
+	_, client := test.GetRedis(t)
+	rng := test.RNG()
+
+	gameID := genGame.GameID(rng)
+	gm := genPlayer.Player(rng)
+	test.Must(t, player.Create(ctx, client, gm))
+	test.Must(t, game.CreateWithGM(ctx, client, gameID, gm))
+
+	now := id.TimestampNow()
+	inv := invite.Make(gameID, gm, 60000, 1, true, now)
+	test.Must(t, invite.Create(ctx, client, &inv, now))
+
+	info, plr, err := JoinWithInvite(ctx, client, nil, inv.Code, "viewer"+gameID, "Viewer", "")
+	test.AssertSuccess(t, err, "joining game")
+	_, found := info.Spectators[plr.ID.String()]
+	test.AssertEqual(t, true, found)
+	_, found = info.Players[plr.ID.String()]
+	test.AssertEqual(t, false, found)
+
+	_, _, err = LogPlayerIn(ctx, client, gameID, plr.Username, "")
+	test.AssertSuccess(t, err, "spectator logging in")
+}
+
+func TestLogPlayerIn_NoGame(t *testing.T) {
+	ctx := context.Background()
+	_, client := test.GetRedis(t)
+	plr := genPlayer.Player(test.RNG())
+	test.Must(t, player.Create(ctx, client, plr))
+
+	_, _, err := LogPlayerIn(ctx, client, "", plr.Username, "")
+	test.AssertErrorIs(t, err, errs.ErrNoAccess)
+
+	test.Must(t, credential.SetPassword(ctx, client, plr.ID, "hunter22"))
+	info, found, err := LogPlayerIn(ctx, client, "", plr.Username, "hunter22")
+	test.AssertSuccess(t, err, "logging in without a game")
+	test.AssertEqual(t, plr.ID, found.ID)
+	test.AssertEqual(t, (*game.Info)(nil), info)
+}
+
+func TestLogPlayerIn_Password(t *testing.T) {
+	ctx := context.Background()
+	_, client := test.GetRedis(t)
+	rng := test.RNG()
+
+	gameID := genGame.GameID(rng)
+	plr := genPlayer.Player(rng)
+	keyed := genPlayer.Player(rng)
+	test.Must(t, player.Create(ctx, client, plr))
+	test.Must(t, player.Create(ctx, client, keyed))
+	test.Must(t, game.CreateWithGM(ctx, client, gameID, plr))
+	test.Must(t, game.AddPlayer(ctx, client, gameID, keyed))
+	test.Must(t, credential.SetPassword(ctx, client, plr.ID, "hunter22"))
+	passkey := credential.Passkey{ID: "key", Name: "Phone"}
+	test.Must(t, credential.AddPasskey(ctx, client, keyed.ID, &passkey))
+
+	_, found, err := LogPlayerIn(ctx, client, gameID, plr.Username, "hunter22")
+	test.AssertSuccess(t, err, "logging in with password")
+	test.AssertEqual(t, true, found.HasPassword())
+
+	_, _, err = LogPlayerIn(ctx, client, gameID, plr.Username, "hunter23")
+	test.AssertErrorIs(t, err, errs.ErrNoAccess)
+	_, _, err = LogPlayerIn(ctx, client, gameID, plr.Username, "")
+	test.AssertErrorIs(t, err, errs.ErrNoAccess)
+	_, _, err = LogPlayerIn(ctx, client, gameID, keyed.Username, "")
+	test.AssertErrorIs(t, err, errs.ErrNoAccess)
+}
+
+func TestLogPlayerIn_RequireCredentials(t *testing.T) {
+	ctx := context.Background()
+	_, client := test.GetRedis(t)
+	rng := test.RNG()
+
+	gameID := genGame.GameID(rng)
+	gm := genPlayer.Player(rng)
+	plr := genPlayer.Player(rng)
+	test.Must(t, player.Create(ctx, client, gm))
+	test.Must(t, player.Create(ctx, client, plr))
+	test.Must(t, game.CreateWithGM(ctx, client, gameID, gm))
+	test.Must(t, game.AddPlayer(ctx, client, gameID, plr))
+	test.Must(t, credential.SetPassword(ctx, client, gm.ID, "hunter22"))
+	test.Must(t, game.SetRequireCredentials(ctx, client, gameID, true))
+
+	info, _, err := LogPlayerIn(ctx, client, gameID, gm.Username, "hunter22")
+	test.AssertSuccess(t, err, "logging in with password")
+	test.AssertEqual(t, true, info.RequireCredentials)
+
+	_, _, err = LogPlayerIn(ctx, client, gameID, plr.Username, "")
+	test.AssertErrorIs(t, err, errs.ErrNoAccess)
+	_, _, err = LogPlayerIn(ctx, client, "", plr.Username, "")
+	test.AssertErrorIs(t, err, errs.ErrNoAccess)
+
+	now := id.TimestampNow()
+	inv := invite.Make(gameID, gm, 60000, 5, false, now)
+	test.Must(t, invite.Create(ctx, client, &inv, now))
+	_, _, err = JoinWithInvite(ctx, client, nil, inv.Code, "joiner"+gameID, "Joiner", "")
+	test.AssertErrorIs(t, err, errs.ErrBadRequest)
+	_, joined, err := JoinWithInvite(ctx, client, nil, inv.Code, "joiner"+gameID, "Joiner", "hunter22")
+	test.AssertSuccess(t, err, "joining with a password")
+	test.AssertEqual(t, true, joined.HasPassword())
+
+	other := genPlayer.Player(rng)
+	test.Must(t, player.Create(ctx, client, other))
+	_, _, err = JoinWithInvite(ctx, client, nil, inv.Code, other.Username, "", "")
+	test.AssertErrorIs(t, err, errs.ErrNoAccess)
+
+	test.Must(t, game.SetRequireCredentials(ctx, client, gameID, false))
+	_, _, err = LogPlayerIn(ctx, client, gameID, plr.Username, "")
+	test.AssertSuccess(t, err, "logging in after the requirement is lifted")
+}
+
+func TestLogPasskeyIn_NoChallenge(t *testing.T) {
+	ctx := context.Background()
+	_, client := test.GetRedis(t)
+
+	rp := &credential.RelyingParty{ID: "localhost", Origin: "http://localhost:3000"}
+	assertion := credential.Assertion{ChallengeID: id.GenSessionID()}
+	_, _, err := LogPasskeyIn(ctx, client, rp, "", &assertion)
+	test.AssertErrorIs(t, err, errs.ErrNoAccess)
+}
+
+func TestLogOIDCIn(t *testing.T) {
+	ctx := context.Background()
+	_, client := test.GetRedis(t)
+	rng := test.RNG()
+
+	gameID := genGame.GameID(rng)
+	gm := genPlayer.Player(rng)
+	test.Must(t, player.Create(ctx, client, gm))
+	test.Must(t, game.CreateWithGM(ctx, client, gameID, gm))
+	test.Must(t, game.SetRequireCredentials(ctx, client, gameID, true))
+
+	issuer := "https://id.example.com"
+	username := "oidc" + gameID
+	claims := &oidc.Claims{Issuer: issuer, Subject: "new-" + gameID, Name: "New Player", PreferredUsername: username}
+	_, created, err := LogOIDCIn(ctx, client, claims, &oidc.State{})
+	test.AssertSuccess(t, err, "logging in a new subject")
+	test.AssertEqual(t, username, created.Username)
+	test.AssertEqual(t, "New Player", created.Name)
+	hasCredentials, err := credential.HasCredentials(ctx, client, created)
+	test.AssertSuccess(t, err, "checking credentials")
+	test.AssertEqual(t, true, hasCredentials)
+	_, _, err = LogPlayerIn(ctx, client, "", username, "")
+	test.AssertErrorIs(t, err, errs.ErrNoAccess)
+
+	_, again, err := LogOIDCIn(ctx, client, claims, &oidc.State{})
+	test.AssertSuccess(t, err, "logging in a linked subject")
+	test.AssertEqual(t, created.ID, again.ID)
+
+	_, _, err = LogOIDCIn(ctx, client, claims, &oidc.State{GameID: gameID})
+	test.AssertErrorIs(t, err, errs.ErrNoAccess)
+	test.Must(t, game.AddPlayer(ctx, client, gameID, created))
+	info, _, err := LogOIDCIn(ctx, client, claims, &oidc.State{GameID: gameID})
+	test.AssertSuccess(t, err, "logging in to a game requiring credentials")
+	test.AssertEqual(t, gameID, info.ID)
+
+	taken := &oidc.Claims{Issuer: issuer, Subject: "taken-" + gameID, PreferredUsername: username}
+	_, other, err := LogOIDCIn(ctx, client, taken, &oidc.State{})
+	test.AssertSuccess(t, err, "logging in with a taken username")
+	test.AssertCheck(t, other.Username, other.Username != username, "username is not reused")
+	test.AssertEqual(t, username, other.Name)
+
+	linking := &oidc.Claims{Issuer: issuer, Subject: "gm-" + gameID}
+	_, linked, err := LogOIDCIn(ctx, client, linking, &oidc.State{GameID: gameID, PlayerID: gm.ID})
+	test.AssertSuccess(t, err, "linking an account")
+	test.AssertEqual(t, gm.ID, linked.ID)
+	_, linked, err = LogOIDCIn(ctx, client, linking, &oidc.State{GameID: gameID})
+	test.AssertSuccess(t, err, "logging in a linked account")
+	test.AssertEqual(t, gm.ID, linked.ID)
+
+	_, _, err = LogOIDCIn(ctx, client, claims, &oidc.State{PlayerID: gm.ID})
+	test.AssertErrorIs(t, err, errs.ErrBadRequest)
+	_, _, err = LogOIDCIn(ctx, client, claims, &oidc.State{GameID: genGame.GameID(rng)})
+	test.AssertErrorIs(t, err, errs.ErrNotFound)
+}
@@ -0,0 +1,338 @@
+package character
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"sr/errs"
+	"sr/id"
+)
+
+// MaxAttribute is the highest rating an attribute may have.
+const MaxAttribute = 20
+
+// MaxSkill is the highest rating a skill may have.
+const MaxSkill = 20
+
+// MaxSkills is the largest number of skills a character may have.
+const MaxSkills = 100
+
+// ConvergenceScore is the Overwatch Score at which a decker is convergenced.
+const ConvergenceScore = 40
+
+// Attributes are the ratings of a character's attributes.
+type Attributes struct {
+	Body      int     `json:"bod"`
+	Agility   int     `json:"agi"`
+	Reaction  int     `json:"rea"`
+	Strength  int     `json:"str"`
+	Willpower int     `json:"wil"`
+	Logic     int     `json:"log"`
+	Intuition int     `json:"int"`
+	Charisma  int     `json:"cha"`
+	Edge      int     `json:"edg"`
+	Magic     int     `json:"mag"`
+	Resonance int     `json:"res"`
+	Essence   float64 `json:"ess"`
+}
+
+// AttributeNames are the short names of the rollable attributes.
+var AttributeNames = []string{
+	"bod", "agi", "rea", "str", "wil", "log", "int", "cha", "edg", "mag", "res",
+}
+
+// attributeNames maps the short names of attributes to their full names.
+var attributeNames = map[string]string{
+	"bod": "Body", "agi": "Agility", "rea": "Reaction", "str": "Strength",
+	"wil": "Willpower", "log": "Logic", "int": "Intuition", "cha": "Charisma",
+	"edg": "Edge", "mag": "Magic", "res": "Resonance",
+}
+
+// AttributeName finds the full name of the attribute with the given short or
+// full name.
+func AttributeName(name string) (string, bool) {
+	name = strings.ToLower(name)
+	if fullName, found := attributeNames[name]; found {
+		return fullName, true
+	}
+	for _, fullName := range attributeNames {
+		if strings.ToLower(fullName) == name {
+			return fullName, true
+		}
+	}
+	return "", false
+}
+
+// Get retrieves the rating of the attribute with the given short or full name.
+func (a *Attributes) Get(name string) (int, bool) {
+	fullName, found := AttributeName(name)
+	if !found {
+		return 0, false
+	}
+	switch fullName {
+	case "Body":
+		return a.Body, true
+	case "Agility":
+		return a.Agility, true
+	case "Reaction":
+		return a.Reaction, true
+	case "Strength":
+		return a.Strength, true
+	case "Willpower":
+		return a.Willpower, true
+	case "Logic":
+		return a.Logic, true
+	case "Intuition":
+		return a.Intuition, true
+	case "Charisma":
+		return a.Charisma, true
+	case "Edge":
+		return a.Edge, true
+	case "Magic":
+		return a.Magic, true
+	default: // Resonance
+		return a.Resonance, true
+	}
+}
+
+// Set changes the rating of the attribute with the given short or full name.
+// Returns false if the attribute is not known.
+func (a *Attributes) Set(name string, rating int) bool {
+	fullName, found := AttributeName(name)
+	if !found {
+		return false
+	}
+	switch fullName {
+	case "Body":
+		a.Body = rating
+	case "Agility":
+		a.Agility = rating
+	case "Reaction":
+		a.Reaction = rating
+	case "Strength":
+		a.Strength = rating
+	case "Willpower":
+		a.Willpower = rating
+	case "Logic":
+		a.Logic = rating
+	case "Intuition":
+		a.Intuition = rating
+	case "Charisma":
+		a.Charisma = rating
+	case "Edge":
+		a.Edge = rating
+	case "Magic":
+		a.Magic = rating
+	default: // Resonance
+		a.Resonance = rating
+	}
+	return true
+}
+
+// Limits are a character's physical, mental, and social limits.
+type Limits struct {
+	Physical int `json:"physical"`
+	Mental   int `json:"mental"`
+	Social   int `json:"social"`
+}
+
+// Condition is the damage a character has taken on each condition monitor.
+type Condition struct {
+	Physical int `json:"physical"`
+	Stun     int `json:"stun"`
+}
+
+// Character is a player's character in a game.
+type Character struct {
+	ID       id.UID `json:"id"`
+	GameID   string `json:"gameID"`
+	PlayerID id.UID `json:"playerID"`
+	Name     string `json:"name"`
+
+	Attributes Attributes     `json:"attributes"`
+	Skills     map[string]int `json:"skills"`
+
+	Armor          int       `json:"armor"`      // Armor rating for soaking damage
+	EdgePoints     int       `json:"edgePoints"` // Edge points remaining
+	InitiativeMod  int       `json:"initMod"`    // Bonus to initiative base
+	InitiativeDice int       `json:"initDice"`   // Number of initiative dice
+	Condition      Condition `json:"condition"`
+	Overwatch      int       `json:"overwatch"` // Overwatch Score known to the player
+}
+
+// Make constructs a new Character object, giving it a UID.
+func Make(gameID string, playerID id.UID, name string) Character {
+	return Character{
+		ID:             id.GenUID(),
+		GameID:         gameID,
+		PlayerID:       playerID,
+		Name:           name,
+		Skills:         make(map[string]int),
+		InitiativeDice: 1,
+	}
+}
+
+// RedisKey is the key for accessing the character from redis.
+func (c *Character) RedisKey() string {
+	if c == nil || c.ID == "" {
+		panic("Attempted to call RedisKey() on nil character")
+	}
+	return "char:" + string(c.ID)
+}
+
+func ceilDiv(sum int, by int) int {
+	return int(math.Ceil(float64(sum) / float64(by)))
+}
+
+// Limits computes the character's inherent limits.
+func (c *Character) Limits() Limits {
+	a := &c.Attributes
+	return Limits{
+		Physical: ceilDiv(a.Strength*2+a.Body+a.Reaction, 3),
+		Mental:   ceilDiv(a.Logic*2+a.Intuition+a.Willpower, 3),
+		Social:   ceilDiv(a.Charisma*2+a.Willpower+int(math.Ceil(a.Essence)), 3),
+	}
+}
+
+// PhysicalBoxes is the size of the character's physical condition monitor.
+func (c *Character) PhysicalBoxes() int {
+	return 8 + ceilDiv(c.Attributes.Body, 2)
+}
+
+// StunBoxes is the size of the character's stun condition monitor.
+func (c *Character) StunBoxes() int {
+	return 8 + ceilDiv(c.Attributes.Willpower, 2)
+}
+
+// Skill finds the skill with the given name, ignoring case.
+func (c *Character) Skill(name string) (string, int, bool) {
+	for skill, rating := range c.Skills {
+		if strings.EqualFold(skill, name) {
+			return skill, rating, true
+		}
+	}
+	return "", 0, false
+}
+
+// WoundModifier is the dice pool modifier from the character's damage:
+// -1 for every 3 boxes filled on each condition monitor.
+func (c *Character) WoundModifier() int {
+	physical := c.Condition.Physical
+	if physical > c.PhysicalBoxes() {
+		physical = c.PhysicalBoxes()
+	}
+	return -(physical/3 + c.Condition.Stun/3)
+}
+
+// MaxOverflow is the number of overflow boxes past the character's physical
+// condition monitor.
+func (c *Character) MaxOverflow() int {
+	return c.Attributes.Body
+}
+
+// Overflow is the amount of damage the character has taken past their
+// physical condition monitor.
+func (c *Character) Overflow() int {
+	if c.Condition.Physical <= c.PhysicalBoxes() {
+		return 0
+	}
+	return c.Condition.Physical - c.PhysicalBoxes()
+}
+
+// ApplyDamage adds damage to the character's condition monitors.
+// Every two full boxes of stun damage past the stun monitor overflow as one box
+// onto the physical monitor, and physical damage past the overflow boxes is
+// not tracked.
+func (c *Character) ApplyDamage(amount int, stun bool) {
+	if amount <= 0 {
+		return
+	}
+	if stun {
+		c.Condition.Stun += amount
+		if excess := c.Condition.Stun - c.StunBoxes(); excess > 0 {
+			c.Condition.Stun = c.StunBoxes()
+			c.ApplyDamage(excess/2, false)
+		}
+		return
+	}
+	c.Condition.Physical += amount
+	if max := c.PhysicalBoxes() + c.MaxOverflow(); c.Condition.Physical > max {
+		c.Condition.Physical = max
+	}
+}
+
+// Unconscious determines if either of the character's condition monitors is full.
+func (c *Character) Unconscious() bool {
+	return c.Condition.Stun >= c.StunBoxes() || c.Condition.Physical >= c.PhysicalBoxes()
+}
+
+// Dead determines if the character has filled their overflow boxes.
+func (c *Character) Dead() bool {
+	return c.Overflow() >= c.MaxOverflow() && c.Condition.Physical > c.PhysicalBoxes()
+}
+
+// InitiativeBase is the base of the character's initiative rolls.
+func (c *Character) InitiativeBase() int {
+	return c.Attributes.Reaction + c.Attributes.Intuition + c.InitiativeMod
+}
+
+// ValidName determines if a character or skill name is valid.
+// It checks for 1-32 chars with no newlines.
+func ValidName(name string) bool {
+	return len(name) > 0 && len(name) <= 32 && !strings.ContainsAny(name, "\r\n")
+}
+
+// Validate checks that the character's values are in range.
+// Returns ErrBadRequest describing the first invalid value.
+func (c *Character) Validate() error {
+	if !ValidName(c.Name) {
+		return errs.BadRequestf("name: invalid")
+	}
+	for _, name := range AttributeNames {
+		rating, _ := c.Attributes.Get(name)
+		if rating < 0 || rating > MaxAttribute {
+			return errs.BadRequestf("attributes: %v: invalid", name)
+		}
+	}
+	if c.Attributes.Essence < 0 || c.Attributes.Essence > 6 {
+		return errs.BadRequestf("attributes: ess: invalid")
+	}
+	if len(c.Skills) > MaxSkills {
+		return errs.BadRequestf("skills: too many skills")
+	}
+	for skill, rating := range c.Skills {
+		if !ValidName(skill) {
+			return errs.BadRequestf("skills: %q: invalid name", skill)
+		}
+		if rating < 0 || rating > MaxSkill {
+			return errs.BadRequestf("skills: %q: invalid", skill)
+		}
+	}
+	if c.EdgePoints < 0 || c.EdgePoints > c.Attributes.Edge {
+		return errs.BadRequestf("edgePoints: invalid")
+	}
+	if c.InitiativeDice < 1 || c.InitiativeDice > 5 {
+		return errs.BadRequestf("initDice: invalid")
+	}
+	if c.InitiativeMod < -10 || c.InitiativeMod > 99 {
+		return errs.BadRequestf("initMod: invalid")
+	}
+	if c.Armor < 0 || c.Armor > 50 {
+		return errs.BadRequestf("armor: invalid")
+	}
+	if c.Condition.Physical < 0 || c.Condition.Physical > c.PhysicalBoxes()+c.MaxOverflow() {
+		return errs.BadRequestf("condition: physical: invalid")
+	}
+	if c.Condition.Stun < 0 || c.Condition.Stun > c.StunBoxes() {
+		return errs.BadRequestf("condition: stun: invalid")
+	}
+	if c.Overwatch < 0 {
+		return errs.BadRequestf("overwatch: invalid")
+	}
+	return nil
+}
+
+func (c *Character) String() string {
+	return fmt.Sprintf("%v (%v of %v)", c.ID, c.Name, c.PlayerID)
+}
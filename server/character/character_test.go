@@ -0,0 +1,213 @@
+package character_test
+
+import (
+	"context"
+	"testing"
+
+	genCharacter "sr/gen/character"
+	genGame "sr/gen/game"
+	genPlayer "sr/gen/player"
+
+	"sr/character"
+	"sr/errs"
+	"sr/event"
+	"sr/id"
+	"sr/test"
+)
+
+func TestCharacter_Derived(t *testing.T) {
+	char := character.Make("game", id.UID("plr"), "Sam")
+	char.Attributes = character.Attributes{
+		Body: 5, Agility: 6, Reaction: 4, Strength: 3, Willpower: 3,
+		Logic: 2, Intuition: 4, Charisma: 2, Edge: 3, Essence: 5.6,
+	}
+	char.InitiativeMod = 2
+
+	test.AssertEqual(t, character.Limits{Physical: 5, Mental: 4, Social: 5}, char.Limits())
+	test.AssertEqual(t, 11, char.PhysicalBoxes())
+	test.AssertEqual(t, 10, char.StunBoxes())
+	test.AssertEqual(t, 10, char.InitiativeBase())
+
+	rating, found := char.Attributes.Get("AGI")
+	test.AssertEqual(t, true, found)
+	test.AssertEqual(t, 6, rating)
+	_, found = char.Attributes.Get("luck")
+	test.AssertEqual(t, false, found)
+}
+
+func TestCharacter_Validate(t *testing.T) {
+	rng := test.RNG()
+
+	test.RunParallel(t, "generated characters are valid", func(t *testing.T) {
+		for i := 0; i < 50; i++ {
+			char := genCharacter.Character(rng, "game", id.UID("plr"))
+			test.AssertSuccess(t, char.Validate(), "validating character")
+		}
+	})
+	test.RunParallel(t, "invalid values are rejected", func(t *testing.T) {
+		invalid := []func(*character.Character){
+			func(c *character.Character) { c.Name = "" },
+			func(c *character.Character) { c.Attributes.Body = -1 },
+			func(c *character.Character) { c.Attributes.Magic = character.MaxAttribute + 1 },
+			func(c *character.Character) { c.Attributes.Essence = 6.5 },
+			func(c *character.Character) { c.Skills["Pistols"] = character.MaxSkill + 1 },
+			func(c *character.Character) { c.Skills["line\nbreak"] = 1 },
+			func(c *character.Character) { c.EdgePoints = c.Attributes.Edge + 1 },
+			func(c *character.Character) { c.InitiativeDice = 0 },
+			func(c *character.Character) { c.Condition.Stun = c.StunBoxes() + 1 },
+		}
+		for ix, makeInvalid := range invalid {
+			char := genCharacter.Character(rng, "game", id.UID("plr"))
+			makeInvalid(char)
+			err := char.Validate()
+			if err == nil {
+				t.Errorf("case %v: expected character %v to be invalid", ix, char)
+			}
+			test.AssertErrorIs(t, err, errs.ErrBadRequest)
+		}
+	})
+}
+
+func TestCRUD(t *testing.T) {
+	ctx := context.Background()
+	rng := test.RNG()
+	_, client := test.GetRedis(t)
+
+	gameID := genGame.GameID(rng)
+	plr := genPlayer.Player(rng)
+	other := genPlayer.Player(rng)
+
+	char := genCharacter.Character(rng, gameID, plr.ID)
+	otherChar := genCharacter.Character(rng, gameID, other.ID)
+	test.Must(t, character.Create(ctx, client, char))
+	test.Must(t, character.Create(ctx, client, otherChar))
+
+	t.Run("it finds created characters", func(t *testing.T) {
+		found, err := character.GetByID(ctx, client, char.ID)
+		test.AssertSuccess(t, err, "getting character")
+		test.AssertEqual(t, char, found)
+
+		inGame, err := character.GetInGame(ctx, client, gameID)
+		test.AssertSuccess(t, err, "getting characters in game")
+		test.AssertEqual(t, 2, len(inGame))
+
+		owned, err := character.GetOfPlayer(ctx, client, gameID, plr.ID)
+		test.AssertSuccess(t, err, "getting characters of player")
+		test.AssertEqual(t, []character.Character{*char}, owned)
+	})
+
+	t.Run("it does not create twice", func(t *testing.T) {
+		err := character.Create(ctx, client, char)
+		test.AssertErrorIs(t, err, errs.ErrBadRequest)
+	})
+
+	t.Run("it updates characters", func(t *testing.T) {
+		char.Name = "Updated"
+		test.Must(t, character.Update(ctx, client, char))
+		found, err := character.GetByID(ctx, client, char.ID)
+		test.AssertSuccess(t, err, "getting character")
+		test.AssertEqual(t, "Updated", found.Name)
+
+		missing := genCharacter.Character(rng, gameID, plr.ID)
+		err = character.Update(ctx, client, missing)
+		test.AssertErrorIs(t, err, errs.ErrNotFound)
+	})
+
+	t.Run("it deletes characters", func(t *testing.T) {
+		test.Must(t, character.Delete(ctx, client, otherChar))
+		_, err := character.GetByID(ctx, client, otherChar.ID)
+		test.AssertErrorIs(t, err, errs.ErrNotFound)
+		inGame, err := character.GetInGame(ctx, client, gameID)
+		test.AssertSuccess(t, err, "getting characters in game")
+		test.AssertEqual(t, 1, len(inGame))
+	})
+}
+
+func TestCharacter_Breakdown(t *testing.T) {
+	char := character.Make("game", id.UID("plr"), "Sam")
+	char.Attributes = character.Attributes{
+		Body: 5, Agility: 6, Reaction: 4, Strength: 3, Willpower: 3,
+		Logic: 2, Intuition: 4, Charisma: 2, Edge: 3, Essence: 6,
+	}
+	char.Skills["Firearms"] = 5
+	char.Condition = character.Condition{Physical: 4, Stun: 3}
+
+	test.RunParallel(t, "it sums attributes, skills and modifiers", func(t *testing.T) {
+		breakdown, err := char.Breakdown([]string{"agility", "firearms"}, "physical", 2)
+		test.AssertSuccess(t, err, "computing pool")
+		test.AssertEqual(t, []event.PoolPart{
+			{Name: "Agility", Dice: 6},
+			{Name: "Firearms", Dice: 5},
+			{Name: "Wounds", Dice: -2},
+			{Name: "Modifier", Dice: 2},
+		}, breakdown.Parts)
+		test.AssertEqual(t, 11, breakdown.Pool())
+		test.AssertEqual(t, 5, breakdown.Limit)
+		test.AssertEqual(t, "Physical", breakdown.LimitName)
+		test.AssertEqual(t, char.ID, breakdown.CharID)
+	})
+	test.RunParallel(t, "it rejects unknown names", func(t *testing.T) {
+		_, err := char.Breakdown([]string{"int", "Sneaking"}, "", 0)
+		test.AssertErrorIs(t, err, errs.ErrBadRequest)
+		_, err = char.Breakdown([]string{"int", "Firearm"}, "", 0)
+		test.AssertErrorIs(t, err, errs.ErrBadRequest)
+	})
+	test.RunParallel(t, "it rejects invalid pools", func(t *testing.T) {
+		_, err := char.Breakdown(nil, "", 0)
+		test.AssertErrorIs(t, err, errs.ErrBadRequest)
+		_, err = char.Breakdown([]string{"agi"}, "astral", 0)
+		test.AssertErrorIs(t, err, errs.ErrBadRequest)
+		_, err = char.Breakdown([]string{"log"}, "", -10)
+		test.AssertErrorIs(t, err, errs.ErrBadRequest)
+	})
+	test.RunParallel(t, "it computes initiative", func(t *testing.T) {
+		breakdown := char.InitiativeBreakdown()
+		test.AssertEqual(t, 6, breakdown.Pool())
+	})
+}
+
+func TestCharacter_ApplyDamage(t *testing.T) {
+	makeChar := func() character.Character {
+		char := character.Make("game", id.UID("plr"), "Sam")
+		char.Attributes = character.Attributes{Body: 4, Willpower: 4, Essence: 6}
+		char.Armor = 9
+		return char
+	}
+
+	test.RunParallel(t, "it fills condition monitors", func(t *testing.T) {
+		char := makeChar()
+		char.ApplyDamage(5, false)
+		char.ApplyDamage(3, true)
+		test.AssertEqual(t, character.Condition{Physical: 5, Stun: 3}, char.Condition)
+		test.AssertEqual(t, -2, char.WoundModifier())
+		test.AssertEqual(t, false, char.Unconscious())
+	})
+	test.RunParallel(t, "stun overflows onto physical", func(t *testing.T) {
+		char := makeChar()
+		char.ApplyDamage(char.StunBoxes()+4, true)
+		test.AssertEqual(t, character.Condition{Physical: 2, Stun: char.StunBoxes()}, char.Condition)
+		test.AssertEqual(t, true, char.Unconscious())
+	})
+	test.RunParallel(t, "odd stun overflow rounds down", func(t *testing.T) {
+		char := makeChar()
+		char.ApplyDamage(char.StunBoxes()+5, true)
+		test.AssertEqual(t, character.Condition{Physical: 2, Stun: char.StunBoxes()}, char.Condition)
+		char.ApplyDamage(1, true)
+		test.AssertEqual(t, 2, char.Condition.Physical)
+	})
+	test.RunParallel(t, "physical damage overflows up to body", func(t *testing.T) {
+		char := makeChar()
+		char.ApplyDamage(char.PhysicalBoxes()+2, false)
+		test.AssertEqual(t, 2, char.Overflow())
+		test.AssertEqual(t, false, char.Dead())
+		char.ApplyDamage(10, false)
+		test.AssertEqual(t, char.MaxOverflow(), char.Overflow())
+		test.AssertEqual(t, true, char.Dead())
+		test.AssertEqual(t, -3, char.WoundModifier())
+	})
+	test.RunParallel(t, "it soaks with body and armor", func(t *testing.T) {
+		char := makeChar()
+		test.AssertEqual(t, 11, char.SoakBreakdown(-2).Pool())
+		test.AssertEqual(t, 4, char.SoakBreakdown(-20).Pool())
+	})
+}
@@ -0,0 +1,179 @@
+package character
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"sr/errs"
+	"sr/id"
+)
+
+// chummerFile is the subset of a Chummer 5 `.chum5` save file which maps onto
+// a character sheet.
+type chummerFile struct {
+	XMLName    xml.Name           `xml:"character"`
+	Name       string             `xml:"name"`
+	Alias      string             `xml:"alias"`
+	Attributes []chummerAttribute `xml:"attributes>attribute"`
+	Skills     []chummerSkill     `xml:"newskills>skills>skill"`
+	OldSkills  []chummerSkill     `xml:"skills>skill"`
+	InitDice   string             `xml:"initdice"`
+	Essence    string             `xml:"totaless"`
+	Physical   int                `xml:"physicalcmfilled"`
+	Stun       int                `xml:"stuncmfilled"`
+	Other      []chummerElement   `xml:",any"`
+}
+
+type chummerAttribute struct {
+	Name        string `xml:"name"`
+	MetatypeMin int    `xml:"metatypemin"`
+	Base        int    `xml:"base"`
+	Karma       int    `xml:"karma"`
+	TotalValue  *int   `xml:"totalvalue"`
+}
+
+// rating is the attribute's total rating, including augmentations, or its
+// natural rating if Chummer did not record a total.
+func (a *chummerAttribute) rating() int {
+	if a.TotalValue != nil {
+		return *a.TotalValue
+	}
+	return a.MetatypeMin + a.Base + a.Karma
+}
+
+type chummerSkill struct {
+	Name            string   `xml:"name"`
+	SUID            string   `xml:"suid"`
+	IsKnowledge     string   `xml:"isknowledge"`
+	Rating          int      `xml:"rating"`
+	Base            int      `xml:"base"`
+	Karma           int      `xml:"karma"`
+	Specializations []string `xml:"specs>spec>name"`
+}
+
+func (s *chummerSkill) rating() int {
+	if s.Rating > 0 {
+		return s.Rating
+	}
+	return s.Base + s.Karma
+}
+
+// chummerElement is an element of the file which is not imported.
+type chummerElement struct {
+	XMLName  xml.Name
+	Children []struct {
+		XMLName xml.Name
+	} `xml:",any"`
+}
+
+// shorten cuts text down to at most max bytes without splitting a character.
+func shorten(text string, max int) string {
+	end := 0
+	for ix := range text {
+		if ix > max {
+			break
+		}
+		end = ix
+	}
+	return text[:end]
+}
+
+// ParseChummer parses a Chummer 5 save file into a character of the given
+// player. Limits and condition monitors are derived from the attributes.
+//
+// Along with the character, it returns descriptions of the parts of the file
+// which could not be imported, such as gear and knowledge skills.
+// Returns ErrBadRequest if the file cannot be parsed or the character is invalid.
+func ParseChummer(file io.Reader, gameID string, playerID id.UID) (*Character, []string, error) {
+	var parsed chummerFile
+	if err := xml.NewDecoder(file).Decode(&parsed); err != nil {
+		return nil, nil, errs.BadRequestf("parsing Chummer file: %v", err)
+	}
+	var unmapped []string
+
+	name := strings.TrimSpace(parsed.Alias)
+	if name == "" {
+		name = strings.TrimSpace(parsed.Name)
+	}
+	if len(name) > 32 {
+		unmapped = append(unmapped, fmt.Sprintf("name: %q shortened", name))
+		name = shorten(name, 32)
+	}
+	char := Make(gameID, playerID, name)
+
+	char.Attributes.Essence = 6
+	if parsed.Essence != "" {
+		essence, err := strconv.ParseFloat(strings.Replace(parsed.Essence, ",", ".", 1), 64)
+		if err != nil {
+			unmapped = append(unmapped, fmt.Sprintf("essence: %q", parsed.Essence))
+		} else {
+			char.Attributes.Essence = essence
+		}
+	}
+	for _, attribute := range parsed.Attributes {
+		if !char.Attributes.Set(attribute.Name, attribute.rating()) && attribute.rating() != 0 {
+			unmapped = append(unmapped, fmt.Sprintf("attribute %v", attribute.Name))
+		}
+	}
+	char.EdgePoints = char.Attributes.Edge
+
+	skills := parsed.Skills
+	if len(skills) == 0 {
+		skills = parsed.OldSkills
+	}
+	for _, skill := range skills {
+		rating := skill.rating()
+		if rating == 0 {
+			continue
+		}
+		if skill.Name == "" {
+			unmapped = append(unmapped, fmt.Sprintf("skill %v: no name", skill.SUID))
+			continue
+		}
+		if strings.EqualFold(skill.IsKnowledge, "true") {
+			unmapped = append(unmapped, fmt.Sprintf("knowledge skill %v", skill.Name))
+			continue
+		}
+		char.Skills[skill.Name] = rating
+		for _, spec := range skill.Specializations {
+			unmapped = append(unmapped, fmt.Sprintf("skill %v: specialization %v", skill.Name, spec))
+		}
+	}
+	if len(char.Skills) > MaxSkills {
+		return nil, unmapped, errs.BadRequestf("skills: too many skills")
+	}
+
+	if parsed.InitDice != "" {
+		dice, err := strconv.Atoi(parsed.InitDice)
+		if err != nil || dice < 1 || dice > 5 {
+			unmapped = append(unmapped, fmt.Sprintf("initiative dice: %q", parsed.InitDice))
+		} else {
+			char.InitiativeDice = dice
+		}
+	}
+
+	char.Condition.Physical = parsed.Physical
+	if maxPhysical := char.PhysicalBoxes() + char.MaxOverflow(); char.Condition.Physical > maxPhysical {
+		char.Condition.Physical = maxPhysical
+	}
+	char.Condition.Stun = parsed.Stun
+	if char.Condition.Stun > char.StunBoxes() {
+		char.Condition.Stun = char.StunBoxes()
+	}
+
+	for _, element := range parsed.Other {
+		if len(element.Children) > 0 {
+			unmapped = append(unmapped,
+				fmt.Sprintf("%v: %v entries", element.XMLName.Local, len(element.Children)),
+			)
+		}
+	}
+
+	if err := char.Validate(); err != nil {
+		return nil, unmapped, err
+	}
+	return &char, unmapped, nil
+}
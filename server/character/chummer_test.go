@@ -0,0 +1,82 @@
+package character_test
+
+import (
+	"strings"
+	"testing"
+
+	"sr/character"
+	"sr/errs"
+	"sr/id"
+	"sr/test"
+)
+
+const chummerFile = `<?xml version="1.0" encoding="utf-8"?>
+<character>
+  <name>Samuel Jones</name>
+  <alias>Sam</alias>
+  <totaless>5,6</totaless>
+  <physicalcmfilled>3</physicalcmfilled>
+  <stuncmfilled>2</stuncmfilled>
+  <attributes>
+    <attribute><name>BOD</name><metatypemin>1</metatypemin><base>3</base><karma>1</karma></attribute>
+    <attribute><name>AGI</name><metatypemin>1</metatypemin><base>4</base><karma>1</karma></attribute>
+    <attribute><name>REA</name><metatypemin>1</metatypemin><base>3</base><karma>0</karma></attribute>
+    <attribute><name>STR</name><metatypemin>1</metatypemin><base>2</base><karma>0</karma></attribute>
+    <attribute><name>CHA</name><metatypemin>1</metatypemin><base>1</base><karma>0</karma></attribute>
+    <attribute><name>INT</name><metatypemin>1</metatypemin><base>3</base><karma>0</karma></attribute>
+    <attribute><name>LOG</name><metatypemin>1</metatypemin><base>1</base><karma>0</karma></attribute>
+    <attribute><name>WIL</name><metatypemin>1</metatypemin><base>2</base><karma>0</karma></attribute>
+    <attribute><name>EDG</name><metatypemin>1</metatypemin><base>2</base><karma>0</karma></attribute>
+    <attribute><name>MAG</name><metatypemin>0</metatypemin><base>0</base><karma>0</karma></attribute>
+    <attribute><name>DEP</name><metatypemin>0</metatypemin><base>0</base><karma>0</karma></attribute>
+  </attributes>
+  <newskills>
+    <skills>
+      <skill><name>Pistols</name><isknowledge>False</isknowledge><base>5</base><karma>1</karma>
+        <specs><spec><name>Semi-Automatics</name></spec></specs>
+      </skill>
+      <skill><name>Sneaking</name><isknowledge>False</isknowledge><base>3</base><karma>0</karma></skill>
+      <skill><name>Hacking</name><isknowledge>False</isknowledge><base>0</base><karma>0</karma></skill>
+      <skill><name>Seattle Gangs</name><isknowledge>True</isknowledge><base>2</base><karma>0</karma></skill>
+    </skills>
+  </newskills>
+  <gears><gear><name>Commlink</name></gear><gear><name>Medkit</name></gear></gears>
+  <spells />
+  <karma>5</karma>
+</character>`
+
+func TestParseChummer(t *testing.T) {
+	char, unmapped, err := character.ParseChummer(strings.NewReader(chummerFile), "game", id.UID("plr"))
+	test.AssertSuccess(t, err, "parsing chummer file")
+
+	test.AssertEqual(t, "Sam", char.Name)
+	test.AssertEqual(t, character.Attributes{
+		Body: 5, Agility: 6, Reaction: 4, Strength: 3, Willpower: 3,
+		Logic: 2, Intuition: 4, Charisma: 2, Edge: 3, Essence: 5.6,
+	}, char.Attributes)
+	test.AssertEqual(t, map[string]int{"Pistols": 6, "Sneaking": 3}, char.Skills)
+	test.AssertEqual(t, 3, char.EdgePoints)
+	test.AssertEqual(t, 1, char.InitiativeDice)
+	test.AssertEqual(t, character.Condition{Physical: 3, Stun: 2}, char.Condition)
+	test.AssertEqual(t, character.Limits{Physical: 5, Mental: 4, Social: 5}, char.Limits())
+	test.AssertEqual(t, []string{
+		"skill Pistols: specialization Semi-Automatics",
+		"knowledge skill Seattle Gangs",
+		"gears: 2 entries",
+	}, unmapped)
+
+	t.Run("it shortens long names by character", func(t *testing.T) {
+		file := strings.Replace(chummerFile, "<alias>Sam</alias>", "<alias>"+strings.Repeat("é", 20)+"</alias>", 1)
+		char, unmapped, err := character.ParseChummer(strings.NewReader(file), "game", id.UID("plr"))
+		test.AssertSuccess(t, err, "parsing chummer file")
+		test.AssertEqual(t, strings.Repeat("é", 16), char.Name)
+		test.AssertCheck(t, unmapped[0], strings.HasPrefix(unmapped[0], "name: "), "shortening is reported")
+	})
+
+	t.Run("it rejects invalid files", func(t *testing.T) {
+		_, _, err := character.ParseChummer(strings.NewReader("<character><name>"), "game", id.UID("plr"))
+		test.AssertErrorIs(t, err, errs.ErrBadRequest)
+		_, _, err = character.ParseChummer(strings.NewReader("<character></character>"), "game", id.UID("plr"))
+		test.AssertErrorIs(t, err, errs.ErrBadRequest)
+	})
+}
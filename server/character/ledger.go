@@ -0,0 +1,114 @@
+package character
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"sr/id"
+	srOtel "sr/otel"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Currencies which may be awarded to characters.
+const (
+	CurrencyKarma = "karma"
+	CurrencyNuyen = "nuyen"
+)
+
+// MaxAward is the largest amount which may be awarded or spent at once.
+const MaxAward = 100_000_000
+
+// ValidCurrency determines if the given currency may be awarded.
+func ValidCurrency(currency string) bool {
+	return currency == CurrencyKarma || currency == CurrencyNuyen
+}
+
+// LedgerEntry is a change in a character's karma or nuyen.
+type LedgerEntry struct {
+	EventID  int64  `json:"eventID"` // ID of the award event, a millisecond timestamp
+	Amount   int    `json:"amount"`
+	Currency string `json:"currency"`
+	Reason   string `json:"reason"`
+	GMID     id.UID `json:"gmID"`
+	GMName   string `json:"gmName"`
+}
+
+// Balance is a character's total karma and nuyen.
+type Balance struct {
+	Karma int `json:"karma"`
+	Nuyen int `json:"nuyen"`
+}
+
+// FoldLedger computes the balance from a character's ledger entries.
+func FoldLedger(entries []LedgerEntry) Balance {
+	var balance Balance
+	for _, entry := range entries {
+		switch entry.Currency {
+		case CurrencyKarma:
+			balance.Karma += entry.Amount
+		case CurrencyNuyen:
+			balance.Nuyen += entry.Amount
+		}
+	}
+	return balance
+}
+
+// LedgerKey is the key of a character's ledger list.
+func LedgerKey(charID id.UID) string {
+	return "ledger:" + string(charID)
+}
+
+// GetLedger retrieves all entries in a character's ledger, oldest first.
+func GetLedger(ctx context.Context, client redis.Cmdable, charID id.UID) ([]LedgerEntry, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "character.GetLedger")
+	defer span.End()
+	entryTexts, err := client.LRange(ctx, LedgerKey(charID), 0, -1).Result()
+	if err != nil {
+		return nil, srOtel.WithSetErrorf(span, "getting ledger of %v: %w", charID, err)
+	}
+	entries := make([]LedgerEntry, len(entryTexts))
+	for ix, text := range entryTexts {
+		if err := json.Unmarshal([]byte(text), &entries[ix]); err != nil {
+			return nil, srOtel.WithSetErrorf(span, "parsing ledger entry #%v of %v: %w", ix, charID, err)
+		}
+	}
+	return entries, nil
+}
+
+// csvText escapes text written to a CSV cell so that spreadsheets do not run it
+// as a formula.
+func csvText(text string) string {
+	if text != "" && strings.ContainsRune("=+-@\t\r", rune(text[0])) {
+		return "'" + text
+	}
+	return text
+}
+
+// WriteLedgerCSV writes ledger entries as CSV with a header row. Reasons and
+// GM names are escaped so that they are not run as formulas.
+func WriteLedgerCSV(out io.Writer, entries []LedgerEntry) error {
+	writer := csv.NewWriter(out)
+	if err := writer.Write([]string{"time", "amount", "currency", "reason", "gm"}); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		record := []string{
+			time.UnixMilli(entry.EventID).UTC().Format(time.RFC3339),
+			strconv.Itoa(entry.Amount),
+			entry.Currency,
+			csvText(entry.Reason),
+			csvText(entry.GMName),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
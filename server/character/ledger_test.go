@@ -0,0 +1,46 @@
+package character_test
+
+import (
+	"strings"
+	"testing"
+
+	"sr/character"
+	"sr/test"
+)
+
+func TestLedger(t *testing.T) {
+	entries := []character.LedgerEntry{
+		{EventID: 0, Amount: 5, Currency: character.CurrencyKarma, Reason: "Run", GMName: "gm"},
+		{EventID: 1000, Amount: 12000, Currency: character.CurrencyNuyen, Reason: "Pay, with bonus", GMName: "gm"},
+		{EventID: 2000, Amount: -3, Currency: character.CurrencyKarma, Reason: "Training", GMName: "gm"},
+	}
+
+	test.RunParallel(t, "it folds balances", func(t *testing.T) {
+		test.AssertEqual(t, character.Balance{Karma: 2, Nuyen: 12000}, character.FoldLedger(entries))
+		test.AssertEqual(t, character.Balance{}, character.FoldLedger(nil))
+	})
+	test.RunParallel(t, "it writes CSV", func(t *testing.T) {
+		var out strings.Builder
+		err := character.WriteLedgerCSV(&out, entries)
+		test.AssertSuccess(t, err, "writing CSV")
+		test.AssertEqual(t, `time,amount,currency,reason,gm
+1970-01-01T00:00:00Z,5,karma,Run,gm
+1970-01-01T00:00:01Z,12000,nuyen,"Pay, with bonus",gm
+1970-01-01T00:00:02Z,-3,karma,Training,gm
+`, out.String())
+	})
+	test.RunParallel(t, "it escapes formulas in CSV", func(t *testing.T) {
+		var out strings.Builder
+		err := character.WriteLedgerCSV(&out, []character.LedgerEntry{
+			{EventID: 0, Amount: 1, Currency: character.CurrencyKarma, Reason: "=HYPERLINK(\"x\")", GMName: "@gm"},
+			{EventID: 0, Amount: 1, Currency: character.CurrencyKarma, Reason: "+1", GMName: "-gm"},
+			{EventID: 0, Amount: 1, Currency: character.CurrencyKarma, Reason: "\tTab", GMName: "g=m"},
+		})
+		test.AssertSuccess(t, err, "writing CSV")
+		test.AssertEqual(t, `time,amount,currency,reason,gm
+1970-01-01T00:00:00Z,1,karma,"'=HYPERLINK(""x"")",'@gm
+1970-01-01T00:00:00Z,1,karma,'+1,'-gm
+1970-01-01T00:00:00Z,1,karma,'`+"\t"+`Tab,g=m
+`, out.String())
+	})
+}
@@ -0,0 +1,169 @@
+package character
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"sr/errs"
+	"sr/id"
+	srOtel "sr/otel"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// ErrNilCharacter is returned when an empty ID is passed to get methods.
+var ErrNilCharacter = errors.New("nil CharacterID requested")
+
+// Create adds the given character to the database and to its game.
+// Returns ErrBadRequest if a character with that ID already exists.
+func Create(ctx context.Context, client redis.Cmdable, char *Character) error {
+	ctx, span := srOtel.Tracer.Start(ctx, "character.Create")
+	defer span.End()
+	if char == nil {
+		return srOtel.WithSetErrorf(span, "%w: nil character passed to character.Create", ErrNilCharacter)
+	}
+	var created *redis.BoolCmd
+	_, err := client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		var err error
+		created, err = CreateCommands(ctx, pipe, char)
+		return err
+	})
+	if err != nil {
+		return srOtel.WithSetErrorf(span, "running pipeline: %w", err)
+	}
+	if !created.Val() {
+		return errs.BadRequestf("character %v already exists", char.ID)
+	}
+	return nil
+}
+
+// CreateCommands sends the commands to add the character to the database and
+// to its game, as part of a larger transaction. Returns the command creating
+// the character, which is false if it already existed.
+func CreateCommands(ctx context.Context, pipe redis.Pipeliner, char *Character) (*redis.BoolCmd, error) {
+	charBytes, err := json.Marshal(char)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling character %v: %w", char, err)
+	}
+	created := pipe.SetNX(ctx, char.RedisKey(), charBytes, 0)
+	pipe.SAdd(ctx, "chars:"+char.GameID, string(char.ID))
+	return created, nil
+}
+
+// GetByID retrieves a character from Redis.
+// Returns ErrNotFound if the character is not found.
+func GetByID(ctx context.Context, client redis.Cmdable, charID id.UID) (*Character, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "character.GetByID")
+	defer span.End()
+	if charID == "" {
+		return nil, srOtel.WithSetErrorf(span,
+			"%w: empty CharacterID passed to character.GetByID", ErrNilCharacter,
+		)
+	}
+	charText, err := client.Get(ctx, "char:"+string(charID)).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, errs.NotFoundf("character %v", charID)
+	} else if err != nil {
+		return nil, srOtel.WithSetErrorf(span, "redis error retrieving %v: %w", charID, err)
+	}
+	var char Character
+	if err := json.Unmarshal([]byte(charText), &char); err != nil {
+		return nil, srOtel.WithSetErrorf(span, "parsing character %v: %w", charID, err)
+	}
+	return &char, nil
+}
+
+// GetInGame retrieves all of the characters in a game, sorted by name.
+func GetInGame(ctx context.Context, client redis.Cmdable, gameID string) ([]Character, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "character.GetInGame")
+	defer span.End()
+	charIDs, err := client.SMembers(ctx, "chars:"+gameID).Result()
+	if err != nil {
+		return nil, srOtel.WithSetErrorf(span, "getting chars:gameID: %w", err)
+	}
+	chars := make([]Character, 0, len(charIDs))
+	if len(charIDs) == 0 {
+		return chars, nil
+	}
+	keys := make([]string, len(charIDs))
+	for ix, charID := range charIDs {
+		keys[ix] = "char:" + charID
+	}
+	charTexts, err := client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, srOtel.WithSetErrorf(span, "getting characters: %w", err)
+	}
+	for ix, charText := range charTexts {
+		text, ok := charText.(string)
+		if !ok { // Deleted since SMEMBERS
+			continue
+		}
+		var char Character
+		if err := json.Unmarshal([]byte(text), &char); err != nil {
+			return nil, srOtel.WithSetErrorf(span, "parsing character %v: %w", charIDs[ix], err)
+		}
+		chars = append(chars, char)
+	}
+	sort.Slice(chars, func(i, j int) bool {
+		return chars[i].Name < chars[j].Name
+	})
+	return chars, nil
+}
+
+// GetOfPlayer retrieves the characters of a player in a game, sorted by name.
+func GetOfPlayer(ctx context.Context, client redis.Cmdable, gameID string, playerID id.UID) ([]Character, error) {
+	chars, err := GetInGame(ctx, client, gameID)
+	if err != nil {
+		return nil, err
+	}
+	owned := make([]Character, 0, len(chars))
+	for _, char := range chars {
+		if char.PlayerID == playerID {
+			owned = append(owned, char)
+		}
+	}
+	return owned, nil
+}
+
+// Update replaces an existing character in the database.
+// Returns ErrNotFound if the character does not exist.
+func Update(ctx context.Context, client redis.Cmdable, char *Character) error {
+	ctx, span := srOtel.Tracer.Start(ctx, "character.Update")
+	defer span.End()
+	charBytes, err := json.Marshal(char)
+	if err != nil {
+		return srOtel.WithSetErrorf(span, "marshaling character %v: %w", char, err)
+	}
+	updated, err := client.SetXX(ctx, char.RedisKey(), charBytes, 0).Result()
+	if err != nil {
+		return srOtel.WithSetErrorf(span, "redis error updating %v: %w", char, err)
+	}
+	if !updated {
+		return errs.NotFoundf("character %v", char.ID)
+	}
+	return nil
+}
+
+// Delete removes a character and its ledger from the database and its game.
+func Delete(ctx context.Context, client redis.Cmdable, char *Character) error {
+	ctx, span := srOtel.Tracer.Start(ctx, "character.Delete")
+	defer span.End()
+	_, err := client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		DeleteCommands(ctx, pipe, char)
+		return nil
+	})
+	if err != nil {
+		return srOtel.WithSetErrorf(span, "running pipeline: %w", err)
+	}
+	return nil
+}
+
+// DeleteCommands sends the commands to remove a character and its ledger, as
+// part of a larger transaction.
+func DeleteCommands(ctx context.Context, pipe redis.Pipeliner, char *Character) {
+	pipe.Del(ctx, char.RedisKey(), LedgerKey(char.ID))
+	pipe.SRem(ctx, "chars:"+char.GameID, string(char.ID))
+}
@@ -0,0 +1,102 @@
+package character
+
+import (
+	"strings"
+
+	"sr/errs"
+	"sr/event"
+)
+
+// MaxPoolParts is the largest number of attributes and skills in one pool.
+const MaxPoolParts = 3
+
+// Breakdown computes a dice pool from the character's attributes and skills,
+// along with its wound modifier, the given situational modifier, and the
+// given limit ("physical", "mental", "social", or "" for no limit).
+//
+// Returns ErrBadRequest if a name is not one of the character's attributes or
+// skills, the limit is not known, or the pool is empty.
+func (c *Character) Breakdown(names []string, limit string, modifier int) (*event.Breakdown, error) {
+	if len(names) == 0 || len(names) > MaxPoolParts {
+		return nil, errs.BadRequestf("pool: expected 1-%v attributes or skills", MaxPoolParts)
+	}
+	breakdown := &event.Breakdown{
+		CharID:   c.ID,
+		CharName: c.Name,
+		Parts:    make([]event.PoolPart, 0, len(names)+2),
+	}
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if attribute, found := AttributeName(name); found {
+			rating, _ := c.Attributes.Get(attribute)
+			breakdown.Parts = append(breakdown.Parts, event.PoolPart{Name: attribute, Dice: rating})
+		} else if skill, rating, found := c.Skill(name); found {
+			breakdown.Parts = append(breakdown.Parts, event.PoolPart{Name: skill, Dice: rating})
+		} else {
+			return nil, errs.BadRequestf("pool: %q: not an attribute or skill of %v", name, c.Name)
+		}
+	}
+	if wounds := c.WoundModifier(); wounds != 0 {
+		breakdown.Parts = append(breakdown.Parts, event.PoolPart{Name: "Wounds", Dice: wounds})
+	}
+	if modifier != 0 {
+		breakdown.Parts = append(breakdown.Parts, event.PoolPart{Name: "Modifier", Dice: modifier})
+	}
+
+	limits := c.Limits()
+	switch strings.ToLower(limit) {
+	case "":
+	case "physical":
+		breakdown.Limit, breakdown.LimitName = limits.Physical, "Physical"
+	case "mental":
+		breakdown.Limit, breakdown.LimitName = limits.Mental, "Mental"
+	case "social":
+		breakdown.Limit, breakdown.LimitName = limits.Social, "Social"
+	default:
+		return nil, errs.BadRequestf("limit: invalid")
+	}
+
+	if breakdown.Pool() < 1 {
+		return nil, errs.BadRequestf("pool: no dice to roll")
+	}
+	return breakdown, nil
+}
+
+// InitiativeBreakdown computes the base of the character's initiative rolls.
+func (c *Character) InitiativeBreakdown() *event.Breakdown {
+	breakdown := &event.Breakdown{
+		CharID:   c.ID,
+		CharName: c.Name,
+		Parts: []event.PoolPart{
+			{Name: "Reaction", Dice: c.Attributes.Reaction},
+			{Name: "Intuition", Dice: c.Attributes.Intuition},
+		},
+	}
+	if c.InitiativeMod != 0 {
+		breakdown.Parts = append(breakdown.Parts, event.PoolPart{Name: "Initiative", Dice: c.InitiativeMod})
+	}
+	if wounds := c.WoundModifier(); wounds != 0 {
+		breakdown.Parts = append(breakdown.Parts, event.PoolPart{Name: "Wounds", Dice: wounds})
+	}
+	return breakdown
+}
+
+// SoakBreakdown computes the character's pool for soaking damage with the
+// given armor penetration, which cannot reduce their armor below zero.
+func (c *Character) SoakBreakdown(ap int) *event.Breakdown {
+	breakdown := &event.Breakdown{
+		CharID:   c.ID,
+		CharName: c.Name,
+		Parts: []event.PoolPart{
+			{Name: "Body", Dice: c.Attributes.Body},
+			{Name: "Armor", Dice: c.Armor},
+		},
+	}
+	if ap < -c.Armor {
+		ap = -c.Armor
+	}
+	if ap != 0 {
+		breakdown.Parts = append(breakdown.Parts, event.PoolPart{Name: "AP", Dice: ap})
+	}
+	return breakdown
+}
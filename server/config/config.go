@@ -189,9 +189,13 @@ var (
 	// MaxHeaderBytes is the maximum number of header bytes which can be read by
 	// the Go server.
 	MaxHeaderBytes = readInt("MAX_HEADER_BYTES", 1<<20)
-	// MaxRequestsPer10Secs is a per-address rate limit for all endpoints.
-	// For details, see `middleware.go`.
-	MaxRequestsPer10Secs = readInt("MAX_REQUESTS_PER_10SECS", 5)
+	// RateLimitBudget is the most requests a player or address may make at
+	// once, for routes which are not given their own limit.
+	// For details, see `ratelimit.go`.
+	RateLimitBudget = readInt("RATE_LIMIT_BUDGET", 60)
+	// RateLimitRefillPerMin is how many requests a player or address may make
+	// per minute once they have used their budget.
+	RateLimitRefillPerMin = readInt("RATE_LIMIT_REFILL_PER_MIN", 60)
 
 	// TempSessionTTLSecs is the amount of time a temporary session is stored
 	// in redis after the subscription disconnects.
@@ -199,6 +203,19 @@ var (
 	// PersistSessionTTLDays is the amount of time persistent sessions last.
 	PersistSessionTTLDays = readInt("PERSIST_SESSION_TTL_DAYS", 30)
 
+	// OpenID Connect options
+	// Players may log in with an OpenID Connect provider when OIDCIssuer is set.
+	// Register sr-server with the provider as a confidential client, with the
+	// redirect URI <BACKEND_ORIGIN>/auth/oidc/callback (or /api/auth/oidc/callback
+	// with HOST_FRONTEND=subroute).
+
+	// OIDCIssuer is the issuer URL of the provider, i.e. https://id.example.com
+	OIDCIssuer = readString("OIDC_ISSUER", "")
+	// OIDCClientID is the client ID sr-server is registered with.
+	OIDCClientID = readString("OIDC_CLIENT_ID", "")
+	// OIDCClientSecret is the client secret sr-server is registered with.
+	OIDCClientSecret = readString("OIDC_CLIENT_SECRET", "")
+
 	// HTTP options
 
 	// ClientIPHeader sets a header to use for client IP addresses instead
@@ -255,6 +272,16 @@ var (
 	RollBufferSize = readInt("ROLL_BUFFER_SIZE", 200)
 	// MaxSingleRoll is the largest roll request the server will handle at once.
 	MaxSingleRoll = readInt("MAX_SINGLE_ROLL", 100)
+	// MaxGroupRollActors is the largest number of actors which can be rolled for at once.
+	MaxGroupRollActors = readInt("MAX_GROUP_ROLL_ACTORS", 20)
+	// MaxMessageLength is the longest chat message, in characters, the server will accept.
+	MaxMessageLength = readInt("MAX_MESSAGE_LENGTH", 2000)
+	// MaxEventReactions is the largest number of different reactions on one event.
+	MaxEventReactions = readInt("MAX_EVENT_REACTIONS", 12)
+	// MaxReactionLength is the longest reaction, in characters, the server will accept.
+	MaxReactionLength = readInt("MAX_REACTION_LENGTH", 32)
+	// MaxImportSize is the largest character file, in bytes, the server will import.
+	MaxImportSize = readInt("MAX_IMPORT_SIZE", 8*1024*1024)
 	// MaxEventRange is the largest range of events the server will provide at once.
 	MaxEventRange = readInt("MAX_EVENT_RANGE", 50)
 )
@@ -403,12 +430,19 @@ func VerifyConfig() {
 		panic("Must set one of TLSAutocertDir and TLSCertFiles!")
 	}
 
+	if RateLimitBudget < 1 || RateLimitRefillPerMin < 1 {
+		panic("RATE_LIMIT_BUDGET and RATE_LIMIT_REFILL_PER_MIN must be positive")
+	}
+
 	if HostFrontend != "" && HostFrontend != "by-domain" && HostFrontend != "redirect" && HostFrontend != "subroute" {
 		panic("Invalid value for HostFrontend; expected unset, redirect, subroute, or by-domain!")
 	}
 	if HostFrontend == "by-domain" && (FrontendOrigin.Host == BackendOrigin.Host) {
 		panic("Must have differing FRONTEND_DOMAIN and BACKEND_DOMAIN hosts for HOST_FRONTEND=by-domain")
 	}
+	if OIDCIssuer != "" && OIDCClientID == "" {
+		panic("Must set OIDC_CLIENT_ID if using OIDC_ISSUER!")
+	}
 	if HostFrontend != "" && HostFrontend != "redirect" && FrontendBasePath == "" {
 		panic("Frontend is hosted, FRONTEND_BASE_PATH must be set!")
 	}
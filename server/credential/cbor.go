@@ -0,0 +1,141 @@
+package credential
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// WebAuthn encodes attestations and public keys in CBOR (RFC 8949). We only
+// need to read the small, definite-length subset authenticators produce.
+
+var errCBORTruncated = errors.New("cbor: unexpected end of data")
+
+// maxCBORDepth limits nesting of arrays and maps, so that malicious input
+// cannot exhaust the stack.
+const maxCBORDepth = 8
+
+// decodeCBOR decodes one CBOR item from the start of data, returning it and
+// the bytes after it.
+//
+// Unsigned and negative integers decode to int64, byte strings to []byte,
+// text strings to string, arrays to []interface{}, and maps to
+// map[interface{}]interface{} with int64 or string keys.
+func decodeCBOR(data []byte) (interface{}, []byte, error) {
+	return decodeCBORItem(data, 0)
+}
+
+func decodeCBORHead(data []byte) (byte, uint64, []byte, error) {
+	if len(data) == 0 {
+		return 0, 0, nil, errCBORTruncated
+	}
+	major := data[0] >> 5
+	info := data[0] & 0x1f
+	data = data[1:]
+	switch {
+	case info < 24:
+		return major, uint64(info), data, nil
+	case info == 24:
+		if len(data) < 1 {
+			return 0, 0, nil, errCBORTruncated
+		}
+		return major, uint64(data[0]), data[1:], nil
+	case info == 25:
+		if len(data) < 2 {
+			return 0, 0, nil, errCBORTruncated
+		}
+		return major, uint64(binary.BigEndian.Uint16(data)), data[2:], nil
+	case info == 26:
+		if len(data) < 4 {
+			return 0, 0, nil, errCBORTruncated
+		}
+		return major, uint64(binary.BigEndian.Uint32(data)), data[4:], nil
+	case info == 27:
+		if len(data) < 8 {
+			return 0, 0, nil, errCBORTruncated
+		}
+		return major, binary.BigEndian.Uint64(data), data[8:], nil
+	default:
+		return 0, 0, nil, fmt.Errorf("cbor: unsupported additional info %v", info)
+	}
+}
+
+func decodeCBORItem(data []byte, depth int) (interface{}, []byte, error) {
+	if depth > maxCBORDepth {
+		return nil, nil, errors.New("cbor: nested too deeply")
+	}
+	major, arg, rest, err := decodeCBORHead(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch major {
+	case 0: // unsigned int
+		if arg > 1<<63-1 {
+			return nil, nil, errors.New("cbor: integer out of range")
+		}
+		return int64(arg), rest, nil
+	case 1: // negative int
+		if arg > 1<<63-1 {
+			return nil, nil, errors.New("cbor: integer out of range")
+		}
+		return -1 - int64(arg), rest, nil
+	case 2, 3: // byte string, text string
+		if arg > uint64(len(rest)) {
+			return nil, nil, errCBORTruncated
+		}
+		value := rest[:arg]
+		if major == 3 {
+			return string(value), rest[arg:], nil
+		}
+		return append([]byte(nil), value...), rest[arg:], nil
+	case 4: // array
+		if arg > uint64(len(rest)) {
+			return nil, nil, errCBORTruncated
+		}
+		items := make([]interface{}, 0, arg)
+		for i := uint64(0); i < arg; i++ {
+			var item interface{}
+			item, rest, err = decodeCBORItem(rest, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			items = append(items, item)
+		}
+		return items, rest, nil
+	case 5: // map
+		if arg > uint64(len(rest)) {
+			return nil, nil, errCBORTruncated
+		}
+		items := make(map[interface{}]interface{}, arg)
+		for i := uint64(0); i < arg; i++ {
+			var key, value interface{}
+			key, rest, err = decodeCBORItem(rest, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			switch key.(type) {
+			case int64, string:
+			default:
+				return nil, nil, fmt.Errorf("cbor: unsupported map key %T", key)
+			}
+			value, rest, err = decodeCBORItem(rest, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			items[key] = value
+		}
+		return items, rest, nil
+	case 7: // simple values
+		switch arg {
+		case 20:
+			return false, rest, nil
+		case 21:
+			return true, rest, nil
+		case 22:
+			return nil, rest, nil
+		}
+		return nil, nil, fmt.Errorf("cbor: unsupported simple value %v", arg)
+	default:
+		return nil, nil, fmt.Errorf("cbor: unsupported major type %v", major)
+	}
+}
@@ -0,0 +1,174 @@
+package credential
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"sr/id"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Players may optionally log in with a password, a passkey, or both. Players
+// who have set up either must use it to log in, and games may require all of
+// their players to have done so.
+
+// MinPasswordLength is the shortest password players may choose.
+const MinPasswordLength = 8
+
+// MaxPasswordLength is the longest password players may choose.
+const MaxPasswordLength = 128
+
+// MaxPasskeys is the most passkeys a player may register.
+const MaxPasskeys = 10
+
+// ChallengeTTL is how long players have to answer a passkey challenge.
+const ChallengeTTL = 5 * time.Minute
+
+// argon2id parameters, from the OWASP recommendations.
+const (
+	argonTime    = 2
+	argonMemory  = 19 * 1024
+	argonThreads = 1
+	argonKeyLen  = 32
+	argonSaltLen = 16
+)
+
+// ValidPassword determines if a new password is acceptable.
+func ValidPassword(password string) bool {
+	return len(password) >= MinPasswordLength && len(password) <= MaxPasswordLength
+}
+
+// HashPassword hashes a password with argon2id and a random salt, encoding the
+// hash in the PHC string format.
+func HashPassword(password string) (string, error) {
+	salt := make([]byte, argonSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("generating salt: %w", err)
+	}
+	key := argon2.IDKey([]byte(password), salt, argonTime, argonMemory, argonThreads, argonKeyLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, argonMemory, argonTime, argonThreads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// CheckPassword determines if the password matches the encoded hash. Hashes
+// are checked with the parameters they were made with.
+func CheckPassword(hash string, password string) bool {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return false
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false
+	}
+	var memory, passes uint32
+	var threads uint8
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &passes, &threads); err != nil {
+		return false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false
+	}
+	expected, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(expected) == 0 {
+		return false
+	}
+	key := argon2.IDKey([]byte(password), salt, passes, memory, threads, uint32(len(expected)))
+	return subtle.ConstantTimeCompare(key, expected) == 1
+}
+
+// Bytes is binary data sent to and from WebAuthn clients, which is encoded in
+// JSON as unpadded base64url.
+type Bytes []byte
+
+// MarshalJSON encodes the bytes as unpadded base64url.
+func (b Bytes) MarshalJSON() ([]byte, error) {
+	return json.Marshal(base64.RawURLEncoding.EncodeToString(b))
+}
+
+// UnmarshalJSON decodes base64url, with or without padding.
+func (b *Bytes) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(text, "="))
+	if err != nil {
+		return err
+	}
+	*b = decoded
+	return nil
+}
+
+// Assertion is a passkey's answer to a login challenge.
+type Assertion struct {
+	ChallengeID       id.UID `json:"challengeID"`
+	PasskeyID         string `json:"id"`
+	ClientDataJSON    Bytes  `json:"clientDataJSON"`
+	AuthenticatorData Bytes  `json:"authenticatorData"`
+	Signature         Bytes  `json:"signature"`
+}
+
+// Passkey is a WebAuthn public key credential a player has registered.
+type Passkey struct {
+	ID        string `json:"id"` // base64url credential ID
+	Name      string `json:"name"`
+	PublicKey []byte `json:"publicKey"` // COSE-encoded
+	SignCount uint32 `json:"signCount"`
+	Created   int64  `json:"created"`
+	LastUsed  int64  `json:"lastUsed"`
+}
+
+// ValidPasskeyName determines if a passkey name is valid.
+func ValidPasskeyName(name string) bool {
+	return len(name) > 0 && len(name) <= 64 && !strings.ContainsAny(name, "\r\n")
+}
+
+// PasskeysKey is the key of the hash of a player's passkeys.
+func PasskeysKey(playerID id.UID) string {
+	return "passkeys:" + string(playerID)
+}
+
+// Challenge purposes
+const (
+	PurposeRegister = "register"
+	PurposeLogin    = "login"
+)
+
+// Challenge is a random value sent to the client for a passkey to sign, which
+// proves the passkey's response is fresh.
+type Challenge struct {
+	ID        id.UID `json:"id"`
+	Challenge []byte `json:"challenge"`
+	PlayerID  id.UID `json:"playerID"`
+	Purpose   string `json:"purpose"`
+}
+
+// MakeChallenge constructs a new random challenge for the player.
+func MakeChallenge(playerID id.UID, purpose string) (Challenge, error) {
+	challenge := make([]byte, 32)
+	if _, err := rand.Read(challenge); err != nil {
+		return Challenge{}, fmt.Errorf("generating challenge: %w", err)
+	}
+	return Challenge{
+		ID:        id.GenSessionID(),
+		Challenge: challenge,
+		PlayerID:  playerID,
+		Purpose:   purpose,
+	}, nil
+}
+
+// ChallengeKey is the key of the challenge with the given ID.
+func ChallengeKey(challengeID id.UID) string {
+	return "passkey-challenge:" + string(challengeID)
+}
@@ -0,0 +1,290 @@
+package credential_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	genPlayer "sr/gen/player"
+
+	"sr/credential"
+	"sr/errs"
+	"sr/id"
+	"sr/player"
+	"sr/test"
+)
+
+var rp = &credential.RelyingParty{ID: "localhost", Origin: "http://localhost:3000"}
+
+func TestPassword(t *testing.T) {
+	hash, err := credential.HashPassword("hunter22")
+	test.AssertSuccess(t, err, "hashing password")
+	test.AssertEqual(t, true, credential.CheckPassword(hash, "hunter22"))
+	test.AssertEqual(t, false, credential.CheckPassword(hash, "hunter23"))
+	test.AssertEqual(t, false, credential.CheckPassword("", ""))
+	test.AssertEqual(t, false, credential.CheckPassword("$argon2id$v=19$m=1,t=1,p=1$$", ""))
+
+	again, err := credential.HashPassword("hunter22")
+	test.AssertSuccess(t, err, "hashing password again")
+	test.AssertCheck(t, again, again != hash, "salted hashes differ")
+
+	test.AssertEqual(t, false, credential.ValidPassword("short"))
+	test.AssertEqual(t, true, credential.ValidPassword("long enough"))
+}
+
+// cborHead encodes a CBOR item header.
+func cborHead(major byte, n int) []byte {
+	switch {
+	case n < 24:
+		return []byte{major<<5 | byte(n)}
+	case n < 256:
+		return []byte{major<<5 | 24, byte(n)}
+	default:
+		return []byte{major<<5 | 25, byte(n >> 8), byte(n)}
+	}
+}
+
+func cborInt(n int) []byte {
+	if n < 0 {
+		return cborHead(1, -1-n)
+	}
+	return cborHead(0, n)
+}
+
+func cborBytes(b []byte) []byte {
+	return append(cborHead(2, len(b)), b...)
+}
+
+func cborText(s string) []byte {
+	return append(cborHead(3, len(s)), s...)
+}
+
+// cborMap encodes alternating keys and values which are already encoded.
+func cborMap(items ...[]byte) []byte {
+	result := cborHead(5, len(items)/2)
+	for _, item := range items {
+		result = append(result, item...)
+	}
+	return result
+}
+
+// authenticator is a software passkey for testing.
+type authenticator struct {
+	credentialID []byte
+	cose         []byte
+	sign         func(data []byte) []byte
+	signCount    uint32
+}
+
+func newES256Authenticator(t *testing.T) *authenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertSuccess(t, err, "generating key")
+	x := key.X.FillBytes(make([]byte, 32))
+	y := key.Y.FillBytes(make([]byte, 32))
+	return &authenticator{
+		credentialID: []byte("es256-" + id.GenUID()),
+		cose: cborMap(
+			cborInt(1), cborInt(2),
+			cborInt(3), cborInt(-7),
+			cborInt(-1), cborInt(1),
+			cborInt(-2), cborBytes(x),
+			cborInt(-3), cborBytes(y),
+		),
+		sign: func(data []byte) []byte {
+			digest := sha256.Sum256(data)
+			sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+			test.AssertSuccess(t, err, "signing")
+			return sig
+		},
+	}
+}
+
+func newEdDSAAuthenticator(t *testing.T) *authenticator {
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	test.AssertSuccess(t, err, "generating key")
+	return &authenticator{
+		credentialID: []byte("eddsa-" + id.GenUID()),
+		cose: cborMap(
+			cborInt(1), cborInt(1),
+			cborInt(3), cborInt(-8),
+			cborInt(-1), cborInt(6),
+			cborInt(-2), cborBytes(pub),
+		),
+		sign: func(data []byte) []byte {
+			return ed25519.Sign(key, data)
+		},
+	}
+}
+
+func clientDataFor(ceremony string, challenge []byte, origin string) []byte {
+	data, _ := json.Marshal(map[string]interface{}{
+		"type":      ceremony,
+		"challenge": base64.RawURLEncoding.EncodeToString(challenge),
+		"origin":    origin,
+	})
+	return data
+}
+
+func (a *authenticator) authData(rpID string, attested bool) []byte {
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	data := append([]byte(nil), rpIDHash[:]...)
+	flags := byte(0x01)
+	if attested {
+		flags |= 0x40
+	}
+	data = append(data, flags)
+	data = append(data, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(data[len(data)-4:], a.signCount)
+	if attested {
+		data = append(data, make([]byte, 16)...) // AAGUID
+		data = append(data, byte(len(a.credentialID)>>8), byte(len(a.credentialID)))
+		data = append(data, a.credentialID...)
+		data = append(data, a.cose...)
+	}
+	return data
+}
+
+func (a *authenticator) register(challenge []byte) (clientDataJSON []byte, attestationObject []byte) {
+	clientDataJSON = clientDataFor("webauthn.create", challenge, rp.Origin)
+	attestationObject = cborMap(
+		cborText("fmt"), cborText("none"),
+		cborText("attStmt"), cborMap(),
+		cborText("authData"), cborBytes(a.authData(rp.ID, true)),
+	)
+	return clientDataJSON, attestationObject
+}
+
+func (a *authenticator) assert(challenge []byte) (clientDataJSON []byte, authData []byte, signature []byte) {
+	a.signCount++
+	clientDataJSON = clientDataFor("webauthn.get", challenge, rp.Origin)
+	authData = a.authData(rp.ID, false)
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signature = a.sign(append(append([]byte(nil), authData...), clientDataHash[:]...))
+	return clientDataJSON, authData, signature
+}
+
+func TestPasskeys(t *testing.T) {
+	for name, makeAuthenticator := range map[string]func(*testing.T) *authenticator{
+		"ES256": newES256Authenticator,
+		"EdDSA": newEdDSAAuthenticator,
+	} {
+		makeAuthenticator := makeAuthenticator
+		test.RunParallel(t, name, func(t *testing.T) {
+			auth := makeAuthenticator(t)
+			challenge, err := credential.MakeChallenge("player", credential.PurposeRegister)
+			test.AssertSuccess(t, err, "making challenge")
+
+			clientData, attestation := auth.register(challenge.Challenge)
+			passkey, err := rp.VerifyRegistration(challenge.Challenge, clientData, attestation)
+			test.AssertSuccess(t, err, "registering passkey")
+			test.AssertEqual(t, base64.RawURLEncoding.EncodeToString(auth.credentialID), passkey.ID)
+
+			other, err := credential.MakeChallenge("player", credential.PurposeRegister)
+			test.AssertSuccess(t, err, "making challenge")
+			_, err = rp.VerifyRegistration(other.Challenge, clientData, attestation)
+			test.AssertErrorIs(t, err, errs.ErrBadRequest)
+
+			login, err := credential.MakeChallenge("player", credential.PurposeLogin)
+			test.AssertSuccess(t, err, "making challenge")
+			clientData, authData, signature := auth.assert(login.Challenge)
+			signCount, err := rp.VerifyAssertion(passkey, login.Challenge, clientData, authData, signature)
+			test.AssertSuccess(t, err, "verifying assertion")
+			test.AssertEqual(t, uint32(1), signCount)
+
+			passkey.SignCount = signCount
+			_, err = rp.VerifyAssertion(passkey, login.Challenge, clientData, authData, signature)
+			test.AssertErrorIs(t, err, errs.ErrNoAccess)
+
+			clientData, authData, signature = auth.assert(login.Challenge)
+			signature[len(signature)-1] ^= 0xff
+			_, err = rp.VerifyAssertion(passkey, login.Challenge, clientData, authData, signature)
+			test.AssertErrorIs(t, err, errs.ErrNoAccess)
+
+			clientData, authData, signature = auth.assert(login.Challenge)
+			otherSite := &credential.RelyingParty{ID: "example.com", Origin: "https://example.com"}
+			_, err = otherSite.VerifyAssertion(passkey, login.Challenge, clientData, authData, signature)
+			test.AssertErrorIs(t, err, errs.ErrNoAccess)
+		})
+	}
+}
+
+func TestVerifyRegistration_Malformed(t *testing.T) {
+	challenge := []byte("challenge")
+	clientData := clientDataFor("webauthn.create", challenge, rp.Origin)
+	for name, attestation := range map[string][]byte{
+		"empty":     {},
+		"truncated": cborHead(2, 100),
+		"not a map": cborText("authData"),
+		"no data":   cborMap(cborText("fmt"), cborText("none")),
+		"short":     cborMap(cborText("authData"), cborBytes([]byte{1, 2, 3})),
+		"nested":    {0x81, 0x81, 0x81, 0x81, 0x81, 0x81, 0x81, 0x81, 0x81, 0x81, 0x00},
+	} {
+		_, err := rp.VerifyRegistration(challenge, clientData, attestation)
+		test.AssertCheck(t, name, err != nil, "malformed attestation is rejected")
+		test.AssertErrorIs(t, err, errs.ErrBadRequest)
+	}
+}
+
+func TestPasskeyStorage(t *testing.T) {
+	ctx := context.Background()
+	_, client := test.GetRedis(t)
+	plr := genPlayer.Player(test.RNG())
+	test.Must(t, player.Create(ctx, client, plr))
+
+	statuses, err := credential.GetStatuses(ctx, client, []id.UID{plr.ID})
+	test.AssertSuccess(t, err, "getting statuses")
+	test.AssertEqual(t, map[id.UID]bool{plr.ID: false}, statuses)
+
+	for i := 0; i < credential.MaxPasskeys; i++ {
+		passkey := credential.Passkey{ID: string(id.GenUID()), Name: "Key", Created: int64(i)}
+		test.Must(t, credential.AddPasskey(ctx, client, plr.ID, &passkey))
+	}
+	passkeys, err := credential.GetPasskeys(ctx, client, plr.ID)
+	test.AssertSuccess(t, err, "getting passkeys")
+	test.AssertEqual(t, credential.MaxPasskeys, len(passkeys))
+	test.AssertEqual(t, int64(0), passkeys[0].Created)
+
+	err = credential.AddPasskey(ctx, client, plr.ID, &credential.Passkey{ID: "extra"})
+	test.AssertErrorIs(t, err, errs.ErrBadRequest)
+	test.Must(t, credential.RemovePasskey(ctx, client, plr.ID, passkeys[0].ID))
+	err = credential.RemovePasskey(ctx, client, plr.ID, passkeys[0].ID)
+	test.AssertErrorIs(t, err, errs.ErrNotFound)
+	err = credential.AddPasskey(ctx, client, plr.ID, &passkeys[1])
+	test.AssertErrorIs(t, err, errs.ErrBadRequest)
+
+	statuses, err = credential.GetStatuses(ctx, client, []id.UID{plr.ID})
+	test.AssertSuccess(t, err, "getting statuses")
+	test.AssertEqual(t, map[id.UID]bool{plr.ID: true}, statuses)
+
+	test.Must(t, credential.Clear(ctx, client, plr.ID))
+	found, err := credential.HasCredentials(ctx, client, plr)
+	test.AssertSuccess(t, err, "checking credentials")
+	test.AssertEqual(t, false, found)
+}
+
+func TestTakeChallenge(t *testing.T) {
+	ctx := context.Background()
+	_, client := test.GetRedis(t)
+
+	challenge, err := credential.MakeChallenge("player", credential.PurposeLogin)
+	test.AssertSuccess(t, err, "making challenge")
+	test.Must(t, credential.CreateChallenge(ctx, client, &challenge))
+
+	_, err = credential.TakeChallenge(ctx, client, challenge.ID, credential.PurposeRegister)
+	test.AssertErrorIs(t, err, errs.ErrNotFound)
+
+	test.Must(t, credential.CreateChallenge(ctx, client, &challenge))
+	taken, err := credential.TakeChallenge(ctx, client, challenge.ID, credential.PurposeLogin)
+	test.AssertSuccess(t, err, "taking challenge")
+	test.AssertEqual(t, &challenge, taken)
+
+	_, err = credential.TakeChallenge(ctx, client, challenge.ID, credential.PurposeLogin)
+	test.AssertErrorIs(t, err, errs.ErrNotFound)
+}
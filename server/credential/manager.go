@@ -0,0 +1,257 @@
+package credential
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+
+	"sr/errs"
+	"sr/id"
+	"sr/oidc"
+	srOtel "sr/otel"
+	"sr/player"
+	redisUtil "sr/redis"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// SetPassword sets or changes the player's password.
+// Returns ErrBadRequest if the password is not valid.
+func SetPassword(ctx context.Context, client redis.Cmdable, playerID id.UID, password string) error {
+	ctx, span := srOtel.Tracer.Start(ctx, "credential.SetPassword")
+	defer span.End()
+	if !ValidPassword(password) {
+		return errs.BadRequestf("password: must be %v to %v characters",
+			MinPasswordLength, MaxPasswordLength)
+	}
+	hash, err := HashPassword(password)
+	if err != nil {
+		return srOtel.WithSetErrorf(span, "hashing password: %w", err)
+	}
+	if err := client.HSet(ctx, "player:"+string(playerID), "password", hash).Err(); err != nil {
+		return srOtel.WithSetErrorf(span, "setting password: %w", err)
+	}
+	return nil
+}
+
+// HasCredentials determines if the player has set a password, registered a
+// passkey, or linked an OpenID Connect provider's account.
+func HasCredentials(ctx context.Context, client redis.Cmdable, plr *player.Player) (bool, error) {
+	if plr.HasPassword() {
+		return true, nil
+	}
+	hasPasskeys, err := HasPasskeys(ctx, client, plr.ID)
+	if err != nil || hasPasskeys {
+		return hasPasskeys, err
+	}
+	return oidc.HasLinks(ctx, client, plr.ID)
+}
+
+// HasPasskeys determines if the player has registered a passkey.
+func HasPasskeys(ctx context.Context, client redis.Cmdable, playerID id.UID) (bool, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "credential.HasPasskeys")
+	defer span.End()
+	count, err := client.HLen(ctx, PasskeysKey(playerID)).Result()
+	if err != nil {
+		return false, srOtel.WithSetErrorf(span, "counting passkeys: %w", err)
+	}
+	return count > 0, nil
+}
+
+// GetStatuses determines which of the given players have set a password,
+// registered a passkey, or linked a provider's account.
+func GetStatuses(ctx context.Context, client redis.Cmdable, playerIDs []id.UID) (map[id.UID]bool, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "credential.GetStatuses")
+	defer span.End()
+	passwords := make([]*redis.StringCmd, len(playerIDs))
+	passkeys := make([]*redis.IntCmd, len(playerIDs))
+	links := make([]*redis.IntCmd, len(playerIDs))
+	_, err := client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for ix, playerID := range playerIDs {
+			passwords[ix] = pipe.HGet(ctx, "player:"+string(playerID), "password")
+			passkeys[ix] = pipe.HLen(ctx, PasskeysKey(playerID))
+			links[ix] = pipe.SCard(ctx, oidc.LinksKey(playerID))
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, srOtel.WithSetErrorf(span, "checking credentials: %w", err)
+	}
+	statuses := make(map[id.UID]bool, len(playerIDs))
+	for ix, playerID := range playerIDs {
+		statuses[playerID] = passwords[ix].Val() != "" ||
+			passkeys[ix].Val() > 0 || links[ix].Val() > 0
+	}
+	return statuses, nil
+}
+
+// Clear removes the player's password and passkeys and unlinks their
+// providers' accounts, so that they may log in with their username again.
+func Clear(ctx context.Context, client redis.Cmdable, playerID id.UID) error {
+	ctx, span := srOtel.Tracer.Start(ctx, "credential.Clear")
+	defer span.End()
+	_, err := client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HDel(ctx, "player:"+string(playerID), "password")
+		pipe.Del(ctx, PasskeysKey(playerID))
+		return nil
+	})
+	if err != nil {
+		return srOtel.WithSetErrorf(span, "clearing credentials: %w", err)
+	}
+	if _, err := oidc.UnlinkAll(ctx, client, playerID); err != nil {
+		return srOtel.WithSetErrorf(span, "unlinking providers: %w", err)
+	}
+	return nil
+}
+
+// GetPasskeys retrieves the player's passkeys, sorted by when they were added.
+func GetPasskeys(ctx context.Context, client redis.Cmdable, playerID id.UID) ([]Passkey, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "credential.GetPasskeys")
+	defer span.End()
+	texts, err := client.HVals(ctx, PasskeysKey(playerID)).Result()
+	if err != nil {
+		return nil, srOtel.WithSetErrorf(span, "getting passkeys: %w", err)
+	}
+	passkeys := make([]Passkey, len(texts))
+	for ix, text := range texts {
+		if err := json.Unmarshal([]byte(text), &passkeys[ix]); err != nil {
+			return nil, srOtel.WithSetErrorf(span, "parsing passkey: %w", err)
+		}
+	}
+	sort.Slice(passkeys, func(i, j int) bool {
+		return passkeys[i].Created < passkeys[j].Created
+	})
+	return passkeys, nil
+}
+
+// GetPasskey retrieves one of the player's passkeys.
+// Returns ErrNotFound if the player does not have the passkey.
+func GetPasskey(ctx context.Context, client redis.Cmdable, playerID id.UID, passkeyID string) (*Passkey, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "credential.GetPasskey")
+	defer span.End()
+	text, err := client.HGet(ctx, PasskeysKey(playerID), passkeyID).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, errs.NotFoundf("passkey %v", passkeyID)
+	} else if err != nil {
+		return nil, srOtel.WithSetErrorf(span, "getting passkey: %w", err)
+	}
+	var passkey Passkey
+	if err := json.Unmarshal([]byte(text), &passkey); err != nil {
+		return nil, srOtel.WithSetErrorf(span, "parsing passkey: %w", err)
+	}
+	return &passkey, nil
+}
+
+// AddPasskey registers a new passkey for the player.
+// Returns ErrBadRequest if the passkey is already registered or the player
+// has MaxPasskeys.
+func AddPasskey(ctx context.Context, client *redis.Client, playerID id.UID, passkey *Passkey) error {
+	ctx, span := srOtel.Tracer.Start(ctx, "credential.AddPasskey")
+	defer span.End()
+	passkeyBytes, err := json.Marshal(passkey)
+	if err != nil {
+		return srOtel.WithSetErrorf(span, "marshaling passkey: %w", err)
+	}
+	key := PasskeysKey(playerID)
+
+	watched := func(tx *redis.Tx) error {
+		count, err := tx.HLen(ctx, key).Result()
+		if err != nil {
+			return srOtel.WithSetErrorf(span, "counting passkeys: %w", err)
+		}
+		if count >= MaxPasskeys {
+			return errs.BadRequestf("player already has %v passkeys", MaxPasskeys)
+		}
+		exists, err := tx.HExists(ctx, key, passkey.ID).Result()
+		if err != nil {
+			return srOtel.WithSetErrorf(span, "checking passkey: %w", err)
+		}
+		if exists {
+			return errs.BadRequestf("passkey %v is already registered", passkey.ID)
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.HSet(ctx, key, passkey.ID, passkeyBytes)
+			return nil
+		})
+		return err
+	}
+	err = redisUtil.RetryWatchTxn(ctx, client, watched, key)
+	if errors.Is(err, errs.ErrBadRequest) {
+		return err
+	} else if err != nil {
+		return srOtel.WithSetErrorf(span, "running transaction: %w", err)
+	}
+	return nil
+}
+
+// UpdatePasskey saves the passkey's sign count and last use.
+func UpdatePasskey(ctx context.Context, client redis.Cmdable, playerID id.UID, passkey *Passkey) error {
+	ctx, span := srOtel.Tracer.Start(ctx, "credential.UpdatePasskey")
+	defer span.End()
+	passkeyBytes, err := json.Marshal(passkey)
+	if err != nil {
+		return srOtel.WithSetErrorf(span, "marshaling passkey: %w", err)
+	}
+	if err := client.HSet(ctx, PasskeysKey(playerID), passkey.ID, passkeyBytes).Err(); err != nil {
+		return srOtel.WithSetErrorf(span, "saving passkey: %w", err)
+	}
+	return nil
+}
+
+// RemovePasskey removes one of the player's passkeys.
+// Returns ErrNotFound if the player does not have the passkey.
+func RemovePasskey(ctx context.Context, client redis.Cmdable, playerID id.UID, passkeyID string) error {
+	ctx, span := srOtel.Tracer.Start(ctx, "credential.RemovePasskey")
+	defer span.End()
+	removed, err := client.HDel(ctx, PasskeysKey(playerID), passkeyID).Result()
+	if err != nil {
+		return srOtel.WithSetErrorf(span, "removing passkey: %w", err)
+	}
+	if removed != 1 {
+		return errs.NotFoundf("passkey %v", passkeyID)
+	}
+	return nil
+}
+
+// CreateChallenge saves a challenge for ChallengeTTL.
+func CreateChallenge(ctx context.Context, client redis.Cmdable, challenge *Challenge) error {
+	ctx, span := srOtel.Tracer.Start(ctx, "credential.CreateChallenge")
+	defer span.End()
+	challengeBytes, err := json.Marshal(challenge)
+	if err != nil {
+		return srOtel.WithSetErrorf(span, "marshaling challenge: %w", err)
+	}
+	if err := client.Set(ctx, ChallengeKey(challenge.ID), challengeBytes, ChallengeTTL).Err(); err != nil {
+		return srOtel.WithSetErrorf(span, "saving challenge: %w", err)
+	}
+	return nil
+}
+
+// TakeChallenge retrieves and removes a challenge, so that it can only be
+// answered once.
+// Returns ErrNotFound if the challenge does not exist, has expired, or is not
+// for the given purpose.
+func TakeChallenge(ctx context.Context, client redis.Cmdable, challengeID id.UID, purpose string) (*Challenge, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "credential.TakeChallenge")
+	defer span.End()
+	var get *redis.StringCmd
+	_, err := client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		get = pipe.Get(ctx, ChallengeKey(challengeID))
+		pipe.Del(ctx, ChallengeKey(challengeID))
+		return nil
+	})
+	if errors.Is(err, redis.Nil) {
+		return nil, errs.NotFoundf("challenge %v", challengeID)
+	} else if err != nil {
+		return nil, srOtel.WithSetErrorf(span, "taking challenge: %w", err)
+	}
+	var challenge Challenge
+	if err := json.Unmarshal([]byte(get.Val()), &challenge); err != nil {
+		return nil, srOtel.WithSetErrorf(span, "parsing challenge: %w", err)
+	}
+	if challenge.Purpose != purpose {
+		return nil, errs.NotFoundf("challenge %v", challengeID)
+	}
+	return &challenge, nil
+}
@@ -0,0 +1,273 @@
+package credential
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"sr/errs"
+)
+
+// Passkeys are verified locally, following the WebAuthn Level 2 relying party
+// steps. Attestation statements are not checked: we ask for "none"
+// attestation, and trust the authenticator the player registers.
+
+// COSE algorithms supported for passkeys
+const (
+	algES256 = -7
+	algEdDSA = -8
+	algRS256 = -257
+)
+
+// Algorithms lists the COSE algorithms of passkeys we support, in order of
+// preference.
+var Algorithms = []int{algES256, algEdDSA, algRS256}
+
+// Authenticator data flags
+const (
+	flagUserPresent = 0x01
+	flagAttested    = 0x40
+)
+
+// RelyingParty identifies the site passkeys are registered with.
+type RelyingParty struct {
+	ID     string // Domain of the site, i.e. "shadowroller.net"
+	Origin string // Origin of the frontend, i.e. "https://shadowroller.net"
+}
+
+type clientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+type authenticatorData struct {
+	RPIDHash     []byte
+	Flags        byte
+	SignCount    uint32
+	CredentialID []byte
+	PublicKey    []byte
+}
+
+// checkClientData checks the client data of a WebAuthn ceremony.
+func (rp *RelyingParty) checkClientData(clientDataJSON []byte, ceremony string, challenge []byte) error {
+	var data clientData
+	if err := json.Unmarshal(clientDataJSON, &data); err != nil {
+		return fmt.Errorf("parsing client data: %w", err)
+	}
+	if data.Type != ceremony {
+		return fmt.Errorf("client data type %v, expected %v", data.Type, ceremony)
+	}
+	given, err := base64.RawURLEncoding.DecodeString(data.Challenge)
+	if err != nil || !bytes.Equal(given, challenge) {
+		return fmt.Errorf("client data has the wrong challenge")
+	}
+	if data.Origin != rp.Origin {
+		return fmt.Errorf("client data origin %v, expected %v", data.Origin, rp.Origin)
+	}
+	return nil
+}
+
+// parseAuthenticatorData parses authenticator data and checks it was made for
+// this relying party with the user present.
+func (rp *RelyingParty) parseAuthenticatorData(data []byte) (*authenticatorData, error) {
+	if len(data) < 37 {
+		return nil, fmt.Errorf("authenticator data too short")
+	}
+	parsed := &authenticatorData{
+		RPIDHash:  data[:32],
+		Flags:     data[32],
+		SignCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+	rpIDHash := sha256.Sum256([]byte(rp.ID))
+	if !bytes.Equal(parsed.RPIDHash, rpIDHash[:]) {
+		return nil, fmt.Errorf("authenticator data is for another relying party")
+	}
+	if parsed.Flags&flagUserPresent == 0 {
+		return nil, fmt.Errorf("user was not present")
+	}
+	if parsed.Flags&flagAttested == 0 {
+		return parsed, nil
+	}
+	// Attested credential data: AAGUID, credential ID length and ID, public key
+	rest := data[37:]
+	if len(rest) < 18 {
+		return nil, fmt.Errorf("attested credential data too short")
+	}
+	idLen := int(binary.BigEndian.Uint16(rest[16:18]))
+	rest = rest[18:]
+	if len(rest) < idLen {
+		return nil, fmt.Errorf("credential ID too short")
+	}
+	parsed.CredentialID = rest[:idLen]
+	rest = rest[idLen:]
+	_, after, err := decodeCBOR(rest)
+	if err != nil {
+		return nil, fmt.Errorf("parsing credential public key: %w", err)
+	}
+	parsed.PublicKey = rest[:len(rest)-len(after)]
+	return parsed, nil
+}
+
+// VerifyRegistration checks a passkey's response to a registration challenge,
+// returning the new passkey without a name.
+// Returns ErrBadRequest if the response is not valid.
+func (rp *RelyingParty) VerifyRegistration(challenge []byte, clientDataJSON []byte, attestationObject []byte) (*Passkey, error) {
+	if err := rp.checkClientData(clientDataJSON, "webauthn.create", challenge); err != nil {
+		return nil, errs.BadRequest(err)
+	}
+	decoded, _, err := decodeCBOR(attestationObject)
+	if err != nil {
+		return nil, errs.BadRequestf("parsing attestation: %v", err)
+	}
+	attestation, ok := decoded.(map[interface{}]interface{})
+	if !ok {
+		return nil, errs.BadRequestf("attestation is not a map")
+	}
+	authData, ok := attestation["authData"].([]byte)
+	if !ok {
+		return nil, errs.BadRequestf("attestation has no authenticator data")
+	}
+	data, err := rp.parseAuthenticatorData(authData)
+	if err != nil {
+		return nil, errs.BadRequest(err)
+	}
+	if data.CredentialID == nil {
+		return nil, errs.BadRequestf("attestation has no credential")
+	}
+	if _, err := parsePublicKey(data.PublicKey); err != nil {
+		return nil, errs.BadRequest(err)
+	}
+	return &Passkey{
+		ID:        base64.RawURLEncoding.EncodeToString(data.CredentialID),
+		PublicKey: data.PublicKey,
+		SignCount: data.SignCount,
+	}, nil
+}
+
+// VerifyAssertion checks a passkey's response to a login challenge, returning
+// the passkey's new signature count.
+// Returns ErrNoAccess if the response is not valid.
+func (rp *RelyingParty) VerifyAssertion(passkey *Passkey, challenge []byte, clientDataJSON []byte, authData []byte, signature []byte) (uint32, error) {
+	if err := rp.checkClientData(clientDataJSON, "webauthn.get", challenge); err != nil {
+		return 0, errs.NoAccess(err)
+	}
+	data, err := rp.parseAuthenticatorData(authData)
+	if err != nil {
+		return 0, errs.NoAccess(err)
+	}
+	key, err := parsePublicKey(passkey.PublicKey)
+	if err != nil {
+		return 0, fmt.Errorf("passkey %v: %w", passkey.ID, err)
+	}
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signed := append(append([]byte(nil), authData...), clientDataHash[:]...)
+	if !key.verify(signed, signature) {
+		return 0, errs.NoAccessf("invalid passkey signature")
+	}
+	// Authenticators which count signatures must always count up, or the
+	// passkey may have been cloned.
+	if (data.SignCount != 0 || passkey.SignCount != 0) && data.SignCount <= passkey.SignCount {
+		return 0, errs.NoAccessf("passkey %v signature count went backwards", passkey.ID)
+	}
+	return data.SignCount, nil
+}
+
+type publicKey struct {
+	alg int64
+	key crypto.PublicKey
+}
+
+func (k *publicKey) verify(signed []byte, signature []byte) bool {
+	switch k.alg {
+	case algES256:
+		digest := sha256.Sum256(signed)
+		return ecdsa.VerifyASN1(k.key.(*ecdsa.PublicKey), digest[:], signature)
+	case algEdDSA:
+		return ed25519.Verify(k.key.(ed25519.PublicKey), signed, signature)
+	case algRS256:
+		digest := sha256.Sum256(signed)
+		return rsa.VerifyPKCS1v15(k.key.(*rsa.PublicKey), crypto.SHA256, digest[:], signature) == nil
+	default:
+		return false
+	}
+}
+
+// COSE key parameters
+const (
+	coseKty    = 1
+	coseAlg    = 3
+	coseCrv    = -1 // EC2, OKP; n for RSA
+	coseX      = -2 // EC2, OKP; e for RSA
+	coseY      = -3 // EC2
+	ktyOKP     = 1
+	ktyEC2     = 2
+	ktyRSA     = 3
+	crvP256    = 1
+	crvEd25519 = 6
+)
+
+// parsePublicKey parses a COSE-encoded public key of a supported algorithm.
+func parsePublicKey(cose []byte) (*publicKey, error) {
+	decoded, _, err := decodeCBOR(cose)
+	if err != nil {
+		return nil, fmt.Errorf("parsing public key: %w", err)
+	}
+	params, ok := decoded.(map[interface{}]interface{})
+	if !ok {
+		return nil, fmt.Errorf("public key is not a map")
+	}
+	intParam := func(label int64) int64 {
+		value, _ := params[label].(int64)
+		return value
+	}
+	bytesParam := func(label int64) []byte {
+		value, _ := params[label].([]byte)
+		return value
+	}
+	kty, alg := intParam(coseKty), intParam(coseAlg)
+	switch {
+	case kty == ktyEC2 && alg == algES256:
+		x, y := bytesParam(coseX), bytesParam(coseY)
+		if intParam(coseCrv) != crvP256 || len(x) != 32 || len(y) != 32 {
+			return nil, fmt.Errorf("invalid ES256 public key")
+		}
+		key := &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+		if !key.Curve.IsOnCurve(key.X, key.Y) {
+			return nil, fmt.Errorf("invalid ES256 public key")
+		}
+		return &publicKey{alg: alg, key: key}, nil
+	case kty == ktyOKP && alg == algEdDSA:
+		x := bytesParam(coseX)
+		if intParam(coseCrv) != crvEd25519 || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid EdDSA public key")
+		}
+		return &publicKey{alg: alg, key: ed25519.PublicKey(x)}, nil
+	case kty == ktyRSA && alg == algRS256:
+		n, e := bytesParam(coseCrv), bytesParam(coseX)
+		if len(n) < 256 || len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("invalid RS256 public key")
+		}
+		exponent := 0
+		for _, b := range e {
+			exponent = exponent<<8 | int(b)
+		}
+		key := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: exponent}
+		return &publicKey{alg: alg, key: key}, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key algorithm %v (kty %v)", alg, kty)
+	}
+}
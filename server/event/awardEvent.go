@@ -0,0 +1,41 @@
+package event
+
+import (
+	"fmt"
+
+	"sr/id"
+	"sr/player"
+)
+
+// EventTypeAward is the type of `Award` events.
+const EventTypeAward = "award"
+
+// Award is triggered when a GM awards karma or nuyen to a character.
+type Award struct {
+	core
+	CharID   id.UID `json:"charID"`
+	CharName string `json:"charName"`
+	Amount   int    `json:"amount"`
+	Currency string `json:"currency"`
+	Reason   string `json:"reason"`
+}
+
+// ForAward makes an Award event.
+func ForAward(
+	player *player.Player, charID id.UID, charName string,
+	amount int, currency string, reason string,
+) Award {
+	return Award{
+		core:     makeCore(EventTypeAward, player, ShareInGame),
+		CharID:   charID,
+		CharName: charName,
+		Amount:   amount,
+		Currency: currency,
+		Reason:   reason,
+	}
+}
+
+// Summary describes the award, i.e. "Chrome received 5 karma".
+func (a *Award) Summary() string {
+	return fmt.Sprintf("%v received %v %v", a.CharName, a.Amount, a.Currency)
+}
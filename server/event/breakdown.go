@@ -0,0 +1,34 @@
+package event
+
+import (
+	"sr/id"
+)
+
+// PoolPart is one source of dice in a roll's pool, such as an attribute,
+// skill, or modifier.
+type PoolPart struct {
+	Name string `json:"name"`
+	Dice int    `json:"dice"`
+}
+
+// Breakdown records how a roll's pool was computed from a character's sheet,
+// so that the table can audit where each die came from.
+type Breakdown struct {
+	CharID    id.UID     `json:"charID"`
+	CharName  string     `json:"charName"`
+	Parts     []PoolPart `json:"parts"`
+	Limit     int        `json:"limit,omitempty"`
+	LimitName string     `json:"limitName,omitempty"`
+}
+
+// Pool is the total of the breakdown's parts, which is never negative.
+func (b *Breakdown) Pool() int {
+	total := 0
+	for _, part := range b.Parts {
+		total += part.Dice
+	}
+	if total < 0 {
+		return 0
+	}
+	return total
+}
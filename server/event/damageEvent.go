@@ -0,0 +1,85 @@
+package event
+
+import (
+	"fmt"
+
+	"sr/errs"
+	"sr/id"
+	"sr/player"
+)
+
+// EventTypeDamage is the type of `Damage` events.
+const EventTypeDamage = "damage"
+
+// Damage is triggered when a GM deals damage to a character.
+//
+// The character's owner answers it with a soak roll, after which the net
+// damage is applied to the character's condition monitor.
+type Damage struct {
+	core
+	CharID   id.UID `json:"charID"`
+	CharName string `json:"charName"`
+	Target   id.UID `json:"target"` // Owner of the character, who soaks
+	Value    int    `json:"value"`  // Damage value
+	Stun     bool   `json:"stun"`
+	AP       int    `json:"ap,omitempty"` // Armor penetration
+
+	Resolved bool       `json:"resolved"`
+	Soak     *Breakdown `json:"soak,omitempty"`
+	SoakDice []int      `json:"soakDice,omitempty"`
+	Soaked   int        `json:"soaked"`
+	Taken    int        `json:"taken"`
+}
+
+// ForDamage makes a Damage event.
+func ForDamage(
+	player *player.Player, charID id.UID, charName string, target id.UID,
+	value int, stun bool, ap int,
+) Damage {
+	return Damage{
+		core:     makeCore(EventTypeDamage, player, ShareInGame),
+		CharID:   charID,
+		CharName: charName,
+		Target:   target,
+		Value:    value,
+		Stun:     stun,
+		AP:       ap,
+	}
+}
+
+// CheckSoak determines if the given player may soak the damage.
+// Returns ErrNoAccess if the damage was not dealt to their character, and
+// ErrBadRequest if it has already been soaked.
+func (d *Damage) CheckSoak(playerID id.UID) error {
+	if d.Target != playerID {
+		return errs.NoAccessf("player %v may not soak %v", playerID, d.ID)
+	}
+	if d.Resolved {
+		return errs.BadRequestf("damage %v has already been soaked", d.ID)
+	}
+	return nil
+}
+
+// Resolve records the soak roll and the damage taken after it.
+func (d *Damage) Resolve(soak *Breakdown, dice []int, soaked int) {
+	d.Resolved = true
+	d.Soak = soak
+	d.SoakDice = dice
+	d.Soaked = soaked
+	d.Taken = d.Value - soaked
+	if d.Taken < 0 {
+		d.Taken = 0
+	}
+}
+
+// Summary describes the damage, i.e. "Chrome took 4P, soaked 2".
+func (d *Damage) Summary() string {
+	kind := "P"
+	if d.Stun {
+		kind = "S"
+	}
+	if !d.Resolved {
+		return fmt.Sprintf("%v is hit for %v%v", d.CharName, d.Value, kind)
+	}
+	return fmt.Sprintf("%v took %v%v, soaked %v", d.CharName, d.Taken, kind, d.Soaked)
+}
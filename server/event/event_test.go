@@ -2,6 +2,7 @@ package event_test
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	mathRand "math/rand"
 	"testing"
@@ -10,6 +11,7 @@ import (
 	genGame "sr/gen/game"
 	genPlayer "sr/gen/player"
 
+	"sr/errs"
 	"sr/event"
 	"sr/game"
 	"sr/id"
@@ -87,3 +89,102 @@ func TestGetByID(t *testing.T) {
 }
 
 */
+
+func TestGroupRoll_Succeeded(t *testing.T) {
+	plr := &player.Player{ID: id.UID("plr"), Name: "plr", Username: "plr"}
+	rolls := []event.GroupRollEntry{
+		{Name: "Ganger 1", Dice: []int{5, 6, 1}},
+		{Name: "Ganger 2", Dice: []int{1, 2, 3}},
+		{Name: "Ganger 3", Dice: []int{5, 2, 3}},
+	}
+	test.RunParallel(t, "without threshold counts any hits", func(t *testing.T) {
+		groupRoll := event.ForGroupRoll(plr, event.ShareInGame, "", rolls, 0, 0)
+		test.AssertEqual(t, 2, groupRoll.Succeeded())
+	})
+	test.RunParallel(t, "with threshold counts hits meeting it", func(t *testing.T) {
+		groupRoll := event.ForGroupRoll(plr, event.ShareInGame, "", rolls, 0, 2)
+		test.AssertEqual(t, 1, groupRoll.Succeeded())
+	})
+	test.RunParallel(t, "it parses", func(t *testing.T) {
+		groupRoll := event.ForGroupRoll(plr, event.ShareGMs, "perception", rolls, 1, 2)
+		groupRollText, err := json.Marshal(&groupRoll)
+		test.AssertSuccess(t, err, "marshaling group roll")
+		parsed, err := event.Parse(groupRollText)
+		test.AssertSuccess(t, err, "parsing group roll")
+		test.AssertEqual(t, &groupRoll, parsed)
+	})
+}
+
+func TestParseMentions(t *testing.T) {
+	test.RunParallel(t, "it finds mentions", func(t *testing.T) {
+		found := event.ParseMentions("@Decker, cover @street_sam. Not email@example.com")
+		test.AssertEqual(t, []string{"decker", "street_sam"}, found)
+	})
+	test.RunParallel(t, "it skips duplicates", func(t *testing.T) {
+		found := event.ParseMentions("@face @FACE @face!")
+		test.AssertEqual(t, []string{"face"}, found)
+	})
+	test.RunParallel(t, "it finds nothing without mentions", func(t *testing.T) {
+		found := event.ParseMentions("nobody here @ all")
+		test.AssertEqual(t, []string{}, found)
+	})
+}
+
+func TestMessage(t *testing.T) {
+	plr := &player.Player{ID: id.UID("plr"), Name: "plr", Username: "plr"}
+	message := event.ForMessage(plr, event.ShareInGame, "hoi @chummer")
+	message.Mentions = []id.UID{"chummer"}
+	test.RunParallel(t, "it tracks mentions", func(t *testing.T) {
+		test.AssertEqual(t, true, message.IsMentioned("chummer"))
+		test.AssertEqual(t, false, message.IsMentioned("plr"))
+	})
+	test.RunParallel(t, "it parses", func(t *testing.T) {
+		messageText, err := json.Marshal(&message)
+		test.AssertSuccess(t, err, "marshaling message")
+		parsed, err := event.Parse(messageText)
+		test.AssertSuccess(t, err, "parsing message")
+		test.AssertEqual(t, &message, parsed)
+	})
+}
+
+func TestAnsweredRequest(t *testing.T) {
+	plr := genPlayer.Player(test.RNG())
+	roll := event.ForRoll(plr, event.ShareInGame, "Perception", []int{5, 6}, 0)
+	test.AssertEqual(t, int64(0), event.AnsweredRequest(&roll))
+	roll.RequestID = 12
+	test.AssertEqual(t, int64(12), event.AnsweredRequest(&roll))
+	reroll := event.ForReroll(plr, &roll, [][]int{{1}})
+	test.AssertEqual(t, int64(12), event.AnsweredRequest(&reroll))
+	message := event.ForMessage(plr, event.ShareInGame, "Hoi")
+	test.AssertEqual(t, int64(0), event.AnsweredRequest(&message))
+}
+
+func TestToggleReaction(t *testing.T) {
+	plr := &player.Player{ID: id.UID("plr"), Name: "plr", Username: "plr"}
+	evt := event.ForRoll(plr, event.ShareInGame, "", []int{1, 1, 1}, 0)
+
+	test.AssertEqual(t, true, evt.ToggleReaction("facepalm", "a"))
+	test.AssertEqual(t, true, evt.ToggleReaction("facepalm", "b"))
+	test.AssertEqual(t, map[string][]id.UID{"facepalm": {"a", "b"}}, evt.GetReactions())
+
+	test.AssertEqual(t, false, evt.ToggleReaction("facepalm", "a"))
+	test.AssertEqual(t, map[string][]id.UID{"facepalm": {"b"}}, evt.GetReactions())
+
+	test.AssertEqual(t, false, evt.ToggleReaction("facepalm", "b"))
+	test.AssertEqual(t, 0, len(evt.GetReactions()))
+}
+
+func TestDamage(t *testing.T) {
+	gm := &player.Player{ID: id.UID("gm"), Name: "gm", Username: "gm"}
+	damage := event.ForDamage(gm, id.UID("char"), "Chrome", id.UID("plr"), 6, false, -2)
+	test.AssertEqual(t, "Chrome is hit for 6P", damage.Summary())
+	test.AssertErrorIs(t, damage.CheckSoak(gm.ID), errs.ErrNoAccess)
+	test.AssertSuccess(t, damage.CheckSoak("plr"), "target may soak")
+
+	damage.Resolve(nil, []int{5, 6, 1}, 2)
+	test.AssertEqual(t, "Chrome took 4P, soaked 2", damage.Summary())
+	test.AssertErrorIs(t, damage.CheckSoak("plr"), errs.ErrBadRequest)
+
+	damage.Resolve(nil, nil, 8)
+	test.AssertEqual(t, 0, damage.Taken)
+}
@@ -16,6 +16,10 @@ type Event interface {
 	GetPlayerID() id.UID
 	GetShare() Share
 	SetShare(share Share)
+	GetRecipients() []id.UID
+	SetRecipients(recipients []id.UID)
+	GetReactions() map[string][]id.UID
+	ToggleReaction(reaction string, playerID id.UID) bool
 	GetPlayerName() string
 	GetEdit() int64
 	SetEdit(edited int64)
@@ -23,12 +27,15 @@ type Event interface {
 
 // core is the basic values put into events.
 type core struct {
-	ID         int64  `json:"id"`             // ID of the event
-	Type       string `json:"ty"`             // Type of the event
-	Edit       int64  `json:"edit,omitempty"` // Edit time of the event
-	Share      int    `json:"share"`          // share state of the event
-	PlayerID   id.UID `json:"pID"`            // ID of the player who posted the event
-	PlayerName string `json:"pName"`          // Name of the player who posted the event
+	ID         int64    `json:"id"`             // ID of the event
+	Type       string   `json:"ty"`             // Type of the event
+	Edit       int64    `json:"edit,omitempty"` // Edit time of the event
+	Share      int      `json:"share"`          // share state of the event
+	To         []id.UID `json:"to,omitempty"`   // recipients of a whispered event
+	PlayerID   id.UID   `json:"pID"`            // ID of the player who posted the event
+	PlayerName string   `json:"pName"`          // Name of the player who posted the event
+
+	Reactions map[string][]id.UID `json:"reactions,omitempty"` // players who reacted, by reaction
 }
 
 // GetID returns the timestamp ID of the event.
@@ -67,6 +74,63 @@ func (c *core) SetShare(share Share) {
 	c.Share = int(share)
 }
 
+// GetRecipients gets the players a whispered event is shared with
+func (c *core) GetRecipients() []id.UID {
+	return c.To
+}
+
+// SetRecipients sets the players a whispered event is shared with
+func (c *core) SetRecipients(recipients []id.UID) {
+	c.To = recipients
+}
+
+// IsRecipient determines if the given player is one of the event's recipients.
+func IsRecipient(evt Event, playerID id.UID) bool {
+	return id.Contains(evt.GetRecipients(), playerID)
+}
+
+// HasRecipients determines if the event is shared with exactly the given
+// recipients, in any order.
+func HasRecipients(evt Event, recipients []id.UID) bool {
+	current := evt.GetRecipients()
+	if len(current) != len(recipients) {
+		return false
+	}
+	for _, recipient := range recipients {
+		if !id.Contains(current, recipient) {
+			return false
+		}
+	}
+	return true
+}
+
+// GetReactions gets the players who reacted to the event, by reaction
+func (c *core) GetReactions() map[string][]id.UID {
+	return c.Reactions
+}
+
+// ToggleReaction adds the player's reaction to the event, or removes it if
+// they had already reacted with it. Returns whether the reaction was added.
+func (c *core) ToggleReaction(reaction string, playerID id.UID) bool {
+	players := c.Reactions[reaction]
+	for ix, found := range players {
+		if found == playerID {
+			players = append(players[:ix], players[ix+1:]...)
+			if len(players) == 0 {
+				delete(c.Reactions, reaction)
+			} else {
+				c.Reactions[reaction] = players
+			}
+			return false
+		}
+	}
+	if c.Reactions == nil {
+		c.Reactions = make(map[string][]id.UID)
+	}
+	c.Reactions[reaction] = append(players, playerID)
+	return true
+}
+
 // SetEdit updates the event's edit time
 func (c *core) SetEdit(edited int64) {
 	c.Edit = edited
@@ -109,6 +173,46 @@ func Parse(input []byte) (Event, error) {
 		err = json.Unmarshal(input, &initiativeRoll)
 		return &initiativeRoll, err
 
+	case EventTypeGroupRoll:
+		var groupRoll GroupRoll
+		err = json.Unmarshal(input, &groupRoll)
+		return &groupRoll, err
+
+	case EventTypeRollRequest:
+		var rollRequest RollRequest
+		err = json.Unmarshal(input, &rollRequest)
+		return &rollRequest, err
+
+	case EventTypeDamage:
+		var damage Damage
+		err = json.Unmarshal(input, &damage)
+		return &damage, err
+
+	case EventTypeAward:
+		var award Award
+		err = json.Unmarshal(input, &award)
+		return &award, err
+
+	case EventTypeTimer:
+		var timer Timer
+		err = json.Unmarshal(input, &timer)
+		return &timer, err
+
+	case EventTypeOverwatch:
+		var overwatch Overwatch
+		err = json.Unmarshal(input, &overwatch)
+		return &overwatch, err
+
+	case EventTypeConvergence:
+		var convergence Convergence
+		err = json.Unmarshal(input, &convergence)
+		return &convergence, err
+
+	case EventTypeMessage:
+		var message Message
+		err = json.Unmarshal(input, &message)
+		return &message, err
+
 	case EventTypePlayerJoin:
 		var playerJoin PlayerJoin
 		err = json.Unmarshal(input, &playerJoin)
@@ -0,0 +1,62 @@
+package event
+
+import (
+	"sr/player"
+)
+
+// EventTypeGroupRoll is the type of `GroupRoll` events.
+const EventTypeGroupRoll = "groupRoll"
+
+// GroupRollEntry is a single actor's roll within a `GroupRoll`.
+type GroupRollEntry struct {
+	Name string `json:"name"`
+	Dice []int  `json:"dice"`
+}
+
+// Hits counts the number of hits in the entry's dice.
+func (e *GroupRollEntry) Hits() int {
+	hits := 0
+	for _, die := range e.Dice {
+		if die == 5 || die == 6 {
+			hits++
+		}
+	}
+	return hits
+}
+
+// GroupRoll is triggered when a player rolls pools for several actors
+// (usually NPCs) at once.
+type GroupRoll struct {
+	core
+	Title     string           `json:"title"`
+	Rolls     []GroupRollEntry `json:"rolls"`
+	Glitchy   int              `json:"glitchy"`
+	Threshold int              `json:"threshold,omitempty"`
+}
+
+// ForGroupRoll makes a GroupRoll.
+func ForGroupRoll(
+	player *player.Player, share Share, title string,
+	rolls []GroupRollEntry, glitchy int, threshold int,
+) GroupRoll {
+	return GroupRoll{
+		core:      makeCore(EventTypeGroupRoll, player, share),
+		Title:     title,
+		Rolls:     rolls,
+		Glitchy:   glitchy,
+		Threshold: threshold,
+	}
+}
+
+// Succeeded counts the number of actors whose hits met the roll's threshold.
+// If the roll has no threshold, actors with any hits are counted.
+func (g *GroupRoll) Succeeded() int {
+	succeeded := 0
+	for i := range g.Rolls {
+		hits := g.Rolls[i].Hits()
+		if (g.Threshold > 0 && hits >= g.Threshold) || (g.Threshold <= 0 && hits > 0) {
+			succeeded++
+		}
+	}
+	return succeeded
+}
@@ -0,0 +1,28 @@
+package event
+
+// EventTypeHidden is the type of `Hidden` events.
+const EventTypeHidden = "hidden"
+
+// Hidden is a placeholder shown to a player in place of an event they
+// triggered but may not see, such as a blind roll.
+//
+// Hidden events are never stored in a game's history.
+type Hidden struct {
+	core
+	Of string `json:"of"` // Type of the hidden event
+}
+
+// ForHidden makes a placeholder for the given event.
+func ForHidden(evt Event) *Hidden {
+	return &Hidden{
+		core: core{
+			ID:         evt.GetID(),
+			Type:       EventTypeHidden,
+			Edit:       evt.GetEdit(),
+			Share:      int(evt.GetShare()),
+			PlayerID:   evt.GetPlayerID(),
+			PlayerName: evt.GetPlayerName(),
+		},
+		Of: evt.GetType(),
+	}
+}
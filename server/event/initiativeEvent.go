@@ -15,6 +15,8 @@ type InitiativeRoll struct {
 	Dice    []int  `json:"dice"`
 	Seized  bool   `json:"seized"`
 	Blitzed bool   `json:"blitzed"`
+
+	Breakdown *Breakdown `json:"breakdown,omitempty"` // Character sheet source of the base
 }
 
 // ForInitiativeRoll makes an InitiativeRollEvent.
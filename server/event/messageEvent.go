@@ -0,0 +1,61 @@
+package event
+
+import (
+	"regexp"
+	"strings"
+
+	"sr/id"
+	"sr/player"
+)
+
+// EventTypeMessage is the type of `Message` events.
+const EventTypeMessage = "message"
+
+// Message is a chat message sent by a player.
+//
+// Mentions lists the IDs of players mentioned with `@username` who could see
+// the message when it was sent or last edited.
+type Message struct {
+	core
+	Text     string   `json:"text"`
+	Mentions []id.UID `json:"mentions,omitempty"`
+}
+
+// ForMessage makes a Message.
+func ForMessage(player *player.Player, share Share, text string) Message {
+	return Message{
+		core: makeCore(EventTypeMessage, player, share),
+		Text: text,
+	}
+}
+
+var mentionParse = regexp.MustCompile(`(?:^|[^\w@])@([\w.-]+)`)
+
+// ParseMentions finds the usernames mentioned in the given text, in lowercase
+// and without duplicates.
+func ParseMentions(text string) []string {
+	matches := mentionParse.FindAllStringSubmatch(text, -1)
+	usernames := make([]string, 0, len(matches))
+	for _, match := range matches {
+		username := strings.ToLower(strings.TrimRight(match[1], ".-"))
+		if username == "" {
+			continue
+		}
+		found := false
+		for _, seen := range usernames {
+			if seen == username {
+				found = true
+				break
+			}
+		}
+		if !found {
+			usernames = append(usernames, username)
+		}
+	}
+	return usernames
+}
+
+// IsMentioned determines if the given player is mentioned in the message.
+func (m *Message) IsMentioned(playerID id.UID) bool {
+	return id.Contains(m.Mentions, playerID)
+}
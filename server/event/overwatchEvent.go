@@ -0,0 +1,61 @@
+package event
+
+import (
+	"fmt"
+
+	"sr/id"
+	"sr/player"
+)
+
+// EventTypeOverwatch is the type of `Overwatch` events.
+const EventTypeOverwatch = "overwatch"
+
+// Overwatch is triggered when a character's Overwatch Score increases.
+//
+// Increments the GM rolls in secret are shared with GMs only, and their Score
+// includes the secret part of the character's Overwatch Score.
+type Overwatch struct {
+	core
+	CharID   id.UID `json:"charID"`
+	CharName string `json:"charName"`
+	Added    int    `json:"added"`
+	Score    int    `json:"score"`
+}
+
+// ForOverwatch makes an Overwatch event.
+func ForOverwatch(
+	player *player.Player, share Share, charID id.UID, charName string, added int, score int,
+) Overwatch {
+	return Overwatch{
+		core:     makeCore(EventTypeOverwatch, player, share),
+		CharID:   charID,
+		CharName: charName,
+		Added:    added,
+		Score:    score,
+	}
+}
+
+// Summary describes the increment, i.e. "Chrome's Overwatch Score +3 (12)".
+func (o *Overwatch) Summary() string {
+	return fmt.Sprintf("%v's Overwatch Score %+d (%v)", o.CharName, o.Added, o.Score)
+}
+
+// EventTypeConvergence is the type of `Convergence` events.
+const EventTypeConvergence = "convergence"
+
+// Convergence is triggered when a character's Overwatch Score reaches the
+// convergence threshold.
+type Convergence struct {
+	core
+	CharID   id.UID `json:"charID"`
+	CharName string `json:"charName"`
+}
+
+// ForConvergence makes a Convergence event.
+func ForConvergence(player *player.Player, charID id.UID, charName string) Convergence {
+	return Convergence{
+		core:     makeCore(EventTypeConvergence, player, ShareInGame),
+		CharID:   charID,
+		CharName: charName,
+	}
+}
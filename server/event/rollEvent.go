@@ -10,9 +10,12 @@ const EventTypeRoll = "roll"
 // Roll is triggered when a player rolls non-edge dice.
 type Roll struct {
 	core
-	Title   string `json:"title"`
-	Dice    []int  `json:"dice"`
-	Glitchy int    `json:"glitchy"`
+	Title     string     `json:"title"`
+	Dice      []int      `json:"dice"`
+	Glitchy   int        `json:"glitchy"`
+	RequestID int64      `json:"requestID,omitempty"` // ID of the RollRequest this answers
+	Breakdown *Breakdown `json:"breakdown,omitempty"` // Character sheet source of the pool
+	Macro     string     `json:"macro,omitempty"`     // Name of the macro rolled, if any
 }
 
 // ForRoll makes a RollEvent.
@@ -31,9 +34,12 @@ const EventTypeEdgeRoll = "edgeRoll"
 // EdgeRoll is triggered when a player uses edge before a roll.
 type EdgeRoll struct {
 	core
-	Title   string  `json:"title"`
-	Rounds  [][]int `json:"rounds"`
-	Glitchy int     `json:"glitchy"`
+	Title     string     `json:"title"`
+	Rounds    [][]int    `json:"rounds"`
+	Glitchy   int        `json:"glitchy"`
+	RequestID int64      `json:"requestID,omitempty"` // ID of the RollRequest this answers
+	Breakdown *Breakdown `json:"breakdown,omitempty"` // Character sheet source of the pool
+	Macro     string     `json:"macro,omitempty"`     // Name of the macro rolled, if any
 }
 
 // ForEdgeRoll makes an EdgeRollEvent.
@@ -53,19 +59,41 @@ const EventTypeReroll = "rerollFailures"
 // on a roll.
 type Reroll struct {
 	core
-	PrevID  int64   `json:"prevID"`
-	Title   string  `json:"title"`
-	Rounds  [][]int `json:"rounds"`
-	Glitchy int     `json:"glitchy"`
+	PrevID    int64      `json:"prevID"`
+	Title     string     `json:"title"`
+	Rounds    [][]int    `json:"rounds"`
+	Glitchy   int        `json:"glitchy"`
+	RequestID int64      `json:"requestID,omitempty"` // ID of the RollRequest this answers
+	Breakdown *Breakdown `json:"breakdown,omitempty"` // Character sheet source of the pool
+	Macro     string     `json:"macro,omitempty"`     // Name of the macro rolled, if any
 }
 
 // ForReroll constructs a Reroll
 func ForReroll(player *player.Player, previous *Roll, rounds [][]int) Reroll {
+	core := makeCore(EventTypeReroll, player, previous.GetShare())
+	core.To = previous.GetRecipients()
 	return Reroll{
-		core:    makeCore(EventTypeReroll, player, previous.GetShare()),
-		PrevID:  previous.ID,
-		Title:   previous.Title,
-		Rounds:  rounds,
-		Glitchy: previous.Glitchy,
+		core:      core,
+		PrevID:    previous.ID,
+		Title:     previous.Title,
+		Rounds:    rounds,
+		Glitchy:   previous.Glitchy,
+		RequestID: previous.RequestID,
+		Breakdown: previous.Breakdown,
+		Macro:     previous.Macro,
+	}
+}
+
+// AnsweredRequest is the ID of the RollRequest the event answers, or 0 if it
+// does not answer one.
+func AnsweredRequest(evt Event) int64 {
+	switch roll := evt.(type) {
+	case *Roll:
+		return roll.RequestID
+	case *EdgeRoll:
+		return roll.RequestID
+	case *Reroll:
+		return roll.RequestID
 	}
+	return 0
 }
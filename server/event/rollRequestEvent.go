@@ -0,0 +1,71 @@
+package event
+
+import (
+	"sr/errs"
+	"sr/id"
+	"sr/player"
+)
+
+// EventTypeRollRequest is the type of `RollRequest` events.
+const EventTypeRollRequest = "rollRequest"
+
+// RollRequest is triggered when a GM asks players to make a roll.
+//
+// Players answer the request by rolling with its ID as their `requestID`.
+// Answered lists the IDs of players who have answered.
+type RollRequest struct {
+	core
+	Title     string   `json:"title"`
+	Targets   []id.UID `json:"targets"`
+	Threshold int      `json:"threshold,omitempty"`
+	GMsOnly   bool     `json:"gmsOnly"`
+	Answered  []id.UID `json:"answered"`
+}
+
+// ForRollRequest makes a RollRequest.
+func ForRollRequest(
+	player *player.Player, title string, targets []id.UID, threshold int, gmsOnly bool,
+) RollRequest {
+	return RollRequest{
+		core:      makeCore(EventTypeRollRequest, player, ShareInGame),
+		Title:     title,
+		Targets:   targets,
+		Threshold: threshold,
+		GMsOnly:   gmsOnly,
+		Answered:  []id.UID{},
+	}
+}
+
+// IsTarget determines if the given player was asked to roll.
+func (r *RollRequest) IsTarget(playerID id.UID) bool {
+	return id.Contains(r.Targets, playerID)
+}
+
+// HasAnswered determines if the given player has answered the request.
+func (r *RollRequest) HasAnswered(playerID id.UID) bool {
+	return id.Contains(r.Answered, playerID)
+}
+
+// Unanswered lists the targets who have not yet answered the request.
+func (r *RollRequest) Unanswered() []id.UID {
+	result := make([]id.UID, 0, len(r.Targets))
+	for _, target := range r.Targets {
+		if !r.HasAnswered(target) {
+			result = append(result, target)
+		}
+	}
+	return result
+}
+
+// CheckAnswer determines if the given player may answer the request.
+// Returns ErrNoAccess if the player was not asked to roll, and ErrBadRequest
+// if they have already answered.
+func (r *RollRequest) CheckAnswer(playerID id.UID) error {
+	if !r.IsTarget(playerID) {
+		return errs.NoAccessf("player %v was not asked to roll for %v", playerID, r.ID)
+	}
+	if r.HasAnswered(playerID) {
+		return errs.BadRequestf("player %v has already answered %v", playerID, r.ID)
+	}
+	return nil
+}
@@ -14,6 +14,13 @@ const SharePrivate = Share(1)
 // ShareGMs is share to the gamemasters in addition to event originator
 const ShareGMs = Share(2)
 
+// ShareBlind is share only to the gamemasters. The originator of the event
+// only sees a placeholder for it.
+const ShareBlind = Share(3)
+
+// ShareWhisper is share to the originator and an explicit list of recipients
+const ShareWhisper = Share(4)
+
 // String() provides a string-enum version of the Share
 func (a Share) String() string {
 	switch a {
@@ -23,6 +30,10 @@ func (a Share) String() string {
 		return "private"
 	case ShareGMs:
 		return "gms"
+	case ShareBlind:
+		return "blind"
+	case ShareWhisper:
+		return "whisper"
 	default:
 		return "unknown"
 	}
@@ -37,6 +48,10 @@ func ShareFromString(share string) (Share, bool) {
 		return SharePrivate, true
 	case "gms":
 		return ShareGMs, true
+	case "blind":
+		return ShareBlind, true
+	case "whisper":
+		return ShareWhisper, true
 	default:
 		return ShareInGame, false
 	}
@@ -44,5 +59,7 @@ func ShareFromString(share string) (Share, bool) {
 
 // IsShare determines if a number matches an share
 func IsShare(share int) bool {
-	return share == int(ShareInGame) || share == int(SharePrivate) || share == int(ShareGMs)
+	return share == int(ShareInGame) || share == int(SharePrivate) ||
+		share == int(ShareGMs) || share == int(ShareBlind) ||
+		share == int(ShareWhisper)
 }
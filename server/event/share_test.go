@@ -6,7 +6,7 @@ import (
 )
 
 func randomShare(rand *mathRand.Rand) Share {
-	return Share(rand.Intn(3))
+	return Share(rand.Intn(5))
 }
 
 func (s *Share) Generate(rand *mathRand.Rand, size int) reflect.Value {
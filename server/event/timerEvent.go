@@ -0,0 +1,25 @@
+package event
+
+import (
+	"sr/id"
+	"sr/player"
+)
+
+// EventTypeTimer is the type of `Timer` events.
+const EventTypeTimer = "timer"
+
+// Timer is triggered when a game's timer runs out.
+type Timer struct {
+	core
+	TimerID id.UID `json:"timerID"`
+	Title   string `json:"title"`
+}
+
+// ForTimer makes a Timer event, attributed to the GM who started the timer.
+func ForTimer(player *player.Player, timerID id.UID, title string) Timer {
+	return Timer{
+		core:    makeCore(EventTypeTimer, player, ShareInGame),
+		TimerID: timerID,
+		Title:   title,
+	}
+}
@@ -0,0 +1,51 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"sr/character"
+	"sr/event"
+	srOtel "sr/otel"
+	redisUtil "sr/redis"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// PostAward adds an award to a game and appends it to the character's ledger.
+// Returns ErrNotFound if the character no longer exists.
+func PostAward(ctx context.Context, client *redis.Client, gameID string, award *event.Award) error {
+	ctx, span := srOtel.Tracer.Start(ctx, "game.PostAward")
+	defer span.End()
+	entryBytes, err := json.Marshal(&character.LedgerEntry{
+		EventID:  award.GetID(),
+		Amount:   award.Amount,
+		Currency: award.Currency,
+		Reason:   award.Reason,
+		GMID:     award.GetPlayerID(),
+		GMName:   award.GetPlayerName(),
+	})
+	if err != nil {
+		return srOtel.WithSetErrorf(span, "marshaling ledger entry %v: %w", award.GetID(), err)
+	}
+	charKey := "char:" + string(award.CharID)
+
+	watched := func(tx *redis.Tx) error {
+		// Make sure the ledger isn't recreated for a deleted character
+		if _, err := character.GetByID(ctx, tx, award.CharID); err != nil {
+			return err
+		}
+		_, err := tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			if err := pipe.RPush(ctx, character.LedgerKey(award.CharID), entryBytes).Err(); err != nil {
+				return fmt.Errorf("sending ledger append: %w", err)
+			}
+			return postEventCommands(ctx, pipe, gameID, award)
+		})
+		return err
+	}
+	if err := redisUtil.RetryWatchTxn(ctx, client, watched, charKey); err != nil {
+		return srOtel.WithSetErrorf(span, "posting award %v: %w", award.GetID(), err)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package game_test
+
+import (
+	"context"
+	"testing"
+
+	genCharacter "sr/gen/character"
+	genGame "sr/gen/game"
+	genPlayer "sr/gen/player"
+
+	"sr/character"
+	"sr/errs"
+	"sr/event"
+	"sr/game"
+	"sr/test"
+)
+
+func TestPostAward(t *testing.T) {
+	ctx := context.Background()
+	rng := test.RNG()
+	_, client := test.GetRedis(t)
+
+	gameID := genGame.GameID(rng)
+	test.Must(t, game.Create(ctx, client, gameID))
+	gm := genPlayer.Player(rng)
+	char := genCharacter.Character(rng, gameID, genPlayer.Player(rng).ID)
+	test.Must(t, character.Create(ctx, client, char))
+
+	award := event.ForAward(gm, char.ID, char.Name, 5, character.CurrencyKarma, "Run")
+	err := game.PostAward(ctx, client, gameID, &award)
+	test.AssertSuccess(t, err, "posting award")
+
+	found, err := event.GetByID(ctx, client, gameID, award.ID)
+	test.AssertSuccess(t, err, "finding award")
+	parsed, err := event.Parse([]byte(found))
+	test.AssertSuccess(t, err, "parsing award")
+	test.AssertEqual(t, &award, parsed)
+
+	entries, err := character.GetLedger(ctx, client, char.ID)
+	test.AssertSuccess(t, err, "getting ledger")
+	test.AssertEqual(t, []character.LedgerEntry{{
+		EventID: award.ID, Amount: 5, Currency: character.CurrencyKarma,
+		Reason: "Run", GMID: gm.ID, GMName: gm.Name,
+	}}, entries)
+
+	t.Run("awarding a deleted character", func(t *testing.T) {
+		test.Must(t, character.Delete(ctx, client, char))
+		award := event.ForAward(gm, char.ID, char.Name, 100, character.CurrencyNuyen, "Run")
+		err := game.PostAward(ctx, client, gameID, &award)
+		test.AssertErrorIs(t, err, errs.ErrNotFound)
+		entries, err := character.GetLedger(ctx, client, char.ID)
+		test.AssertSuccess(t, err, "getting ledger")
+		test.AssertEqual(t, 0, len(entries))
+	})
+}
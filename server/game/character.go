@@ -0,0 +1,196 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"sr/character"
+	"sr/errs"
+	"sr/id"
+	srOtel "sr/otel"
+	redisUtil "sr/redis"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// UpdateCharacter atomically changes the character's sheet and sends it to
+// its owner and the GMs. update may return an error to cancel the update.
+// Returns ErrNotFound if the character is not in the game.
+func UpdateCharacter(
+	ctx context.Context, client *redis.Client, gameID string, charID id.UID,
+	update func(char *character.Character) error,
+) (*character.Character, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "game.UpdateCharacter")
+	defer span.End()
+	char, err := modifyCharacter(ctx, client, gameID, charID,
+		func(char *character.Character) (map[string]interface{}, error) {
+			if err := update(char); err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{
+				"name":       char.Name,
+				"attributes": char.Attributes,
+				"skills":     char.Skills,
+				"armor":      char.Armor,
+				"edgePoints": char.EdgePoints,
+				"initMod":    char.InitiativeMod,
+				"initDice":   char.InitiativeDice,
+				"condition":  char.Condition,
+			}, nil
+		},
+	)
+	if err != nil {
+		return nil, srOtel.WithSetErrorf(span, "updating %v: %w", charID, err)
+	}
+	return char, nil
+}
+
+// retiredKey is the key of what only GMs may reset of the characters a player
+// deleted from a game, which their next character there starts with.
+func retiredKey(gameID string, playerID id.UID) string {
+	return "retired:" + gameID + ":" + string(playerID)
+}
+
+// retired is what is kept of a player's deleted characters: their spent Edge,
+// damage, and Overwatch Score, which only GMs may reset.
+type retired struct {
+	EdgeSpent       int                 `json:"edgeSpent"`
+	Condition       character.Condition `json:"condition"`
+	Overwatch       int                 `json:"overwatch"`
+	HiddenOverwatch int                 `json:"hiddenOverwatch"`
+}
+
+func maxInt(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// merge keeps the worst of the retired state and the given character's.
+func (r *retired) merge(char *character.Character, hiddenOverwatch int) {
+	r.EdgeSpent = maxInt(r.EdgeSpent, char.Attributes.Edge-char.EdgePoints)
+	r.Condition.Physical = maxInt(r.Condition.Physical, char.Condition.Physical)
+	r.Condition.Stun = maxInt(r.Condition.Stun, char.Condition.Stun)
+	r.Overwatch = maxInt(r.Overwatch, char.Overwatch)
+	r.HiddenOverwatch = maxInt(r.HiddenOverwatch, hiddenOverwatch)
+}
+
+// applyTo gives a new character the retired state, within its limits.
+func (r *retired) applyTo(char *character.Character) {
+	if edge := char.Attributes.Edge - r.EdgeSpent; char.EdgePoints > edge {
+		char.EdgePoints = maxInt(edge, 0)
+	}
+	char.Condition.Physical = maxInt(char.Condition.Physical, r.Condition.Physical)
+	char.Condition.Stun = maxInt(char.Condition.Stun, r.Condition.Stun)
+	if maxPhysical := char.PhysicalBoxes() + char.MaxOverflow(); char.Condition.Physical > maxPhysical {
+		char.Condition.Physical = maxPhysical
+	}
+	if char.Condition.Stun > char.StunBoxes() {
+		char.Condition.Stun = char.StunBoxes()
+	}
+	char.Overwatch = maxInt(char.Overwatch, r.Overwatch)
+}
+
+func getRetired(ctx context.Context, client redis.Cmdable, gameID string, playerID id.UID) (*retired, error) {
+	var state retired
+	text, err := client.Get(ctx, retiredKey(gameID, playerID)).Result()
+	if errors.Is(err, redis.Nil) {
+		return &state, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(text), &state); err != nil {
+		return nil, fmt.Errorf("parsing retired state: %w", err)
+	}
+	return &state, nil
+}
+
+// CreateCharacter adds a new character to its game. The character starts with
+// the spent Edge, damage, and Overwatch Score of any characters its player
+// deleted from the game, so that only GMs may reset them.
+// Returns ErrBadRequest if a character with that ID already exists.
+func CreateCharacter(ctx context.Context, client *redis.Client, char *character.Character) error {
+	ctx, span := srOtel.Tracer.Start(ctx, "game.CreateCharacter")
+	defer span.End()
+	key := retiredKey(char.GameID, char.PlayerID)
+	watched := func(tx *redis.Tx) error {
+		exists, err := tx.Exists(ctx, char.RedisKey()).Result()
+		if err != nil {
+			return err
+		}
+		if exists != 0 {
+			return errs.BadRequestf("character %v already exists", char.ID)
+		}
+		state, err := getRetired(ctx, tx, char.GameID, char.PlayerID)
+		if err != nil {
+			return err
+		}
+		state.applyTo(char)
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			if _, err := character.CreateCommands(ctx, pipe, char); err != nil {
+				return err
+			}
+			if state.HiddenOverwatch != 0 {
+				pipe.HSet(ctx, hiddenOverwatchKey(char.GameID), string(char.ID), state.HiddenOverwatch)
+			}
+			pipe.Del(ctx, key)
+			return nil
+		})
+		return err
+	}
+	err := redisUtil.RetryWatchTxn(ctx, client, watched, key, char.RedisKey())
+	if errors.Is(err, errs.ErrBadRequest) {
+		return err
+	} else if err != nil {
+		return srOtel.WithSetErrorf(span, "creating %v: %w", char.ID, err)
+	}
+	return nil
+}
+
+// DeleteCharacter removes a character from its game. Unless a GM deletes it,
+// its spent Edge, damage, and Overwatch Score are kept for its player's next
+// character in the game.
+// Returns ErrNotFound if the character does not exist.
+func DeleteCharacter(ctx context.Context, client *redis.Client, char *character.Character, byGM bool) error {
+	ctx, span := srOtel.Tracer.Start(ctx, "game.DeleteCharacter")
+	defer span.End()
+	key := retiredKey(char.GameID, char.PlayerID)
+	watched := func(tx *redis.Tx) error {
+		current, err := character.GetByID(ctx, tx, char.ID)
+		if err != nil {
+			return err
+		}
+		hidden, err := getHiddenOverwatch(ctx, tx, char.GameID, char.ID)
+		if err != nil {
+			return err
+		}
+		state, err := getRetired(ctx, tx, char.GameID, char.PlayerID)
+		if err != nil {
+			return err
+		}
+		state.merge(current, hidden)
+		stateBytes, err := json.Marshal(state)
+		if err != nil {
+			return fmt.Errorf("marshaling retired state: %w", err)
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			character.DeleteCommands(ctx, pipe, char)
+			pipe.HDel(ctx, hiddenOverwatchKey(char.GameID), string(char.ID))
+			if !byGM {
+				pipe.Set(ctx, key, stateBytes, 0)
+			}
+			return nil
+		})
+		return err
+	}
+	err := redisUtil.RetryWatchTxn(ctx, client, watched, key, char.RedisKey(), hiddenOverwatchKey(char.GameID))
+	if errors.Is(err, errs.ErrNotFound) {
+		return err
+	} else if err != nil {
+		return srOtel.WithSetErrorf(span, "deleting %v: %w", char.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package game_test
+
+import (
+	"context"
+	"testing"
+
+	genCharacter "sr/gen/character"
+	genGame "sr/gen/game"
+	genPlayer "sr/gen/player"
+
+	"sr/character"
+	"sr/errs"
+	"sr/game"
+	"sr/test"
+)
+
+func TestUpdateCharacter(t *testing.T) {
+	ctx := context.Background()
+	rng := test.RNG()
+	_, client := test.GetRedis(t)
+
+	gameID := genGame.GameID(rng)
+	test.Must(t, game.Create(ctx, client, gameID))
+	plr := genPlayer.Player(rng)
+	char := genCharacter.Character(rng, gameID, plr.ID)
+	char.Attributes.Edge = 3
+	char.EdgePoints = 3
+	test.Must(t, character.Create(ctx, client, char))
+
+	// Edge spent since the character was read is kept
+	_, err := game.SpendEdge(ctx, client, gameID, char.ID)
+	test.AssertSuccess(t, err, "spending edge")
+	updated, err := game.UpdateCharacter(ctx, client, gameID, char.ID,
+		func(found *character.Character) error {
+			found.Name = "Updated"
+			return nil
+		},
+	)
+	test.AssertSuccess(t, err, "updating character")
+	test.AssertEqual(t, "Updated", updated.Name)
+	test.AssertEqual(t, 2, updated.EdgePoints)
+	found, err := character.GetByID(ctx, client, char.ID)
+	test.AssertSuccess(t, err, "getting character")
+	test.AssertEqual(t, updated, found)
+
+	_, err = game.UpdateCharacter(ctx, client, gameID, char.ID,
+		func(found *character.Character) error {
+			found.Name = "Invalid"
+			return errs.BadRequestf("invalid")
+		},
+	)
+	test.AssertErrorIs(t, err, errs.ErrBadRequest)
+	found, err = character.GetByID(ctx, client, char.ID)
+	test.AssertSuccess(t, err, "getting character")
+	test.AssertEqual(t, "Updated", found.Name)
+
+	_, err = game.UpdateCharacter(ctx, client, genGame.GameID(rng), char.ID,
+		func(found *character.Character) error { return nil },
+	)
+	test.AssertErrorIs(t, err, errs.ErrNotFound)
+}
+
+func TestRecreateCharacter(t *testing.T) {
+	ctx := context.Background()
+	rng := test.RNG()
+	_, client := test.GetRedis(t)
+
+	gameID := genGame.GameID(rng)
+	test.Must(t, game.Create(ctx, client, gameID))
+	gm := genPlayer.Player(rng)
+	plr := genPlayer.Player(rng)
+	char := genCharacter.Character(rng, gameID, plr.ID)
+	char.Attributes.Edge = 3
+	char.EdgePoints = 3
+	char.Condition = character.Condition{Physical: 2, Stun: 1}
+	test.Must(t, game.CreateCharacter(ctx, client, char))
+	_, err := game.SpendEdge(ctx, client, gameID, char.ID)
+	test.AssertSuccess(t, err, "spending edge")
+	_, _, err = game.AddOverwatch(ctx, client, gameID, char.ID, gm, 5, true, 0)
+	test.AssertSuccess(t, err, "adding secret overwatch")
+
+	test.Must(t, game.DeleteCharacter(ctx, client, char, false))
+	_, err = character.GetByID(ctx, client, char.ID)
+	test.AssertErrorIs(t, err, errs.ErrNotFound)
+
+	t.Run("a player's new character keeps what only GMs may reset", func(t *testing.T) {
+		recreated := genCharacter.Character(rng, gameID, plr.ID)
+		recreated.Attributes.Edge = 3
+		recreated.EdgePoints = 3
+		recreated.Condition = character.Condition{}
+		recreated.Overwatch = 0
+		test.Must(t, game.CreateCharacter(ctx, client, recreated))
+		found, err := character.GetByID(ctx, client, recreated.ID)
+		test.AssertSuccess(t, err, "getting character")
+		test.AssertEqual(t, 2, found.EdgePoints)
+		test.AssertEqual(t, character.Condition{Physical: 2, Stun: 1}, found.Condition)
+		full, err := game.GetOverwatch(ctx, client, gameID, found)
+		test.AssertSuccess(t, err, "getting full overwatch")
+		test.AssertEqual(t, 5, full)
+
+		test.Must(t, game.DeleteCharacter(ctx, client, found, true))
+	})
+
+	t.Run("characters deleted by GMs are not kept", func(t *testing.T) {
+		fresh := genCharacter.Character(rng, gameID, plr.ID)
+		fresh.Attributes.Edge = 3
+		fresh.EdgePoints = 3
+		fresh.Condition = character.Condition{}
+		fresh.Overwatch = 0
+		test.Must(t, game.CreateCharacter(ctx, client, fresh))
+		found, err := character.GetByID(ctx, client, fresh.ID)
+		test.AssertSuccess(t, err, "getting character")
+		test.AssertEqual(t, 3, found.EdgePoints)
+		test.AssertEqual(t, character.Condition{}, found.Condition)
+	})
+
+	err = game.DeleteCharacter(ctx, client, char, false)
+	test.AssertErrorIs(t, err, errs.ErrNotFound)
+}
@@ -0,0 +1,134 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"sr/character"
+	"sr/errs"
+	"sr/event"
+	"sr/id"
+	srOtel "sr/otel"
+	redisUtil "sr/redis"
+	"sr/update"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// characterPackets sends a character update to its owner and the GMs.
+func characterPackets(gameID string, char *character.Character, ud update.Update) []Packet {
+	return []Packet{
+		{PlayerChannel(gameID, char.PlayerID), []string{}, ud},
+		{GMsChannel(gameID), []string{string(char.PlayerID)}, ud},
+	}
+}
+
+// PostDamage adds damage to a game and prompts its target to soak it.
+func PostDamage(ctx context.Context, client redis.Cmdable, gameID string, damage *event.Damage) error {
+	ctx, span := srOtel.Tracer.Start(ctx, "game.PostDamage")
+	defer span.End()
+	prompt := Packet{PlayerChannel(gameID, damage.Target), []string{}, update.ForSoakPrompt(damage)}
+	_, err := client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		if err := postEventCommands(ctx, pipe, gameID, damage); err != nil {
+			return err
+		}
+		if err := publishPacket(ctx, pipe, &prompt); err != nil {
+			return fmt.Errorf("sending soak prompt: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return srOtel.WithSetErrorf(span, "running pipeline: %w", err)
+	}
+	return nil
+}
+
+// GetDamage retrieves the given damage event from a game.
+// Returns ErrNotFound if the event does not exist, and ErrBadRequest if it is
+// not damage.
+func GetDamage(ctx context.Context, client redis.Cmdable, gameID string, damageID int64) (*event.Damage, error) {
+	eventText, err := event.GetByID(ctx, client, gameID, damageID)
+	if err != nil {
+		return nil, err
+	}
+	evt, err := event.Parse([]byte(eventText))
+	if err != nil {
+		return nil, fmt.Errorf("%w: parsing event %v: %v", errs.ErrParse, damageID, err)
+	}
+	damage, ok := evt.(*event.Damage)
+	if !ok {
+		return nil, errs.BadRequestf("event %v is not damage", damageID)
+	}
+	return damage, nil
+}
+
+// SoakDamage resolves damage with the given soak roll, and applies the damage
+// taken to the character's condition monitor.
+// Returns ErrNoAccess if the player may not soak the damage, ErrBadRequest if
+// it has already been soaked, and ErrNotFound if the character is gone.
+func SoakDamage(
+	ctx context.Context, client *redis.Client, gameID string, damageID int64,
+	playerID id.UID, soak *event.Breakdown, dice []int, soaked int,
+) (*event.Damage, *character.Character, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "game.SoakDamage")
+	defer span.End()
+	damage, err := GetDamage(ctx, client, gameID, damageID)
+	if err != nil {
+		return nil, nil, srOtel.WithSetErrorf(span, "getting damage %v: %w", damageID, err)
+	}
+	charKey := "char:" + string(damage.CharID)
+
+	var char *character.Character
+	watched := func(tx *redis.Tx) error {
+		damage, err = GetDamage(ctx, tx, gameID, damageID)
+		if err != nil {
+			return err
+		}
+		if err := damage.CheckSoak(playerID); err != nil {
+			return err
+		}
+		char, err = character.GetByID(ctx, tx, damage.CharID)
+		if err != nil {
+			return err
+		}
+		damage.Resolve(soak, dice, soaked)
+		char.ApplyDamage(damage.Taken, damage.Stun)
+
+		damageBytes, err := json.Marshal(damage)
+		if err != nil {
+			return fmt.Errorf("marshaling damage %v: %w", damageID, err)
+		}
+		charBytes, err := json.Marshal(char)
+		if err != nil {
+			return fmt.Errorf("marshaling character %v: %w", char, err)
+		}
+		packets := updatePackets(gameID, damage, update.ForDamageSoaked(damage))
+		condition := update.ForCharacterDiff(char.ID, map[string]interface{}{"condition": char.Condition})
+		packets = append(packets, characterPackets(gameID, char, condition)...)
+		damageIDStr := fmt.Sprintf("%v", damageID)
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			if err := pipe.ZRemRangeByScore(ctx, "history:"+gameID, damageIDStr, damageIDStr).Err(); err != nil {
+				return fmt.Errorf("sending damage delete: %w", err)
+			}
+			if err := pipe.ZAddNX(ctx, "history:"+gameID, &redis.Z{Score: float64(damageID), Member: damageBytes}).Err(); err != nil {
+				return fmt.Errorf("sending damage add: %w", err)
+			}
+			if err := pipe.SetXX(ctx, charKey, charBytes, 0).Err(); err != nil {
+				return fmt.Errorf("sending character update: %w", err)
+			}
+			for ix, packet := range packets {
+				if err := publishPacket(ctx, pipe, &packet); err != nil {
+					return fmt.Errorf("sending packet #%v %#v: %w", ix, packet, err)
+				}
+			}
+			return nil
+		})
+		return err
+	}
+	if err := redisUtil.RetryWatchTxn(ctx, client, watched, "history:"+gameID, charKey); err != nil {
+		return nil, nil, srOtel.WithSetErrorf(span, "soaking damage %v: %w", damageID, err)
+	}
+	return damage, char, nil
+}
@@ -0,0 +1,60 @@
+package game_test
+
+import (
+	"context"
+	"testing"
+
+	genCharacter "sr/gen/character"
+	genGame "sr/gen/game"
+	genPlayer "sr/gen/player"
+
+	"sr/character"
+	"sr/errs"
+	"sr/event"
+	"sr/game"
+	"sr/test"
+)
+
+func TestSoakDamage(t *testing.T) {
+	ctx := context.Background()
+	rng := test.RNG()
+	_, client := test.GetRedis(t)
+
+	gameID := genGame.GameID(rng)
+	test.Must(t, game.Create(ctx, client, gameID))
+	gm := genPlayer.Player(rng)
+	plr := genPlayer.Player(rng)
+	char := genCharacter.Character(rng, gameID, plr.ID)
+	char.Condition = character.Condition{}
+	test.Must(t, character.Create(ctx, client, char))
+
+	damage := event.ForDamage(gm, char.ID, char.Name, plr.ID, 6, false, -2)
+	test.Must(t, game.PostDamage(ctx, client, gameID, &damage))
+
+	t.Run("soaking someone else's damage", func(t *testing.T) {
+		_, _, err := game.SoakDamage(ctx, client, gameID, damage.ID, gm.ID, nil, nil, 0)
+		test.AssertErrorIs(t, err, errs.ErrNoAccess)
+	})
+
+	soak := char.SoakBreakdown(damage.AP)
+	soaked, found, err := game.SoakDamage(
+		ctx, client, gameID, damage.ID, plr.ID, soak, []int{5, 2, 6}, 2,
+	)
+	test.AssertSuccess(t, err, "soaking damage")
+	test.AssertEqual(t, 4, soaked.Taken)
+	test.AssertEqual(t, 4, found.Condition.Physical)
+
+	stored, err := character.GetByID(ctx, client, char.ID)
+	test.AssertSuccess(t, err, "getting character")
+	test.AssertEqual(t, found.Condition, stored.Condition)
+
+	resolved, err := game.GetDamage(ctx, client, gameID, damage.ID)
+	test.AssertSuccess(t, err, "getting damage")
+	test.AssertEqual(t, true, resolved.Resolved)
+	test.AssertEqual(t, 2, resolved.Soaked)
+
+	t.Run("soaking twice", func(t *testing.T) {
+		_, _, err := game.SoakDamage(ctx, client, gameID, damage.ID, plr.ID, soak, nil, 0)
+		test.AssertErrorIs(t, err, errs.ErrBadRequest)
+	})
+}
@@ -0,0 +1,121 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"sr/character"
+	"sr/errs"
+	"sr/id"
+	srOtel "sr/otel"
+	redisUtil "sr/redis"
+	"sr/update"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// modifyCharacter atomically changes a character in a game and sends the
+// resulting diff to its owner and the GMs.
+// Returns ErrNotFound if the character is not in the game.
+func modifyCharacter(
+	ctx context.Context, client *redis.Client, gameID string, charID id.UID,
+	modify func(char *character.Character) (map[string]interface{}, error),
+) (*character.Character, error) {
+	charKey := "char:" + string(charID)
+	var char *character.Character
+	watched := func(tx *redis.Tx) error {
+		var err error
+		char, err = character.GetByID(ctx, tx, charID)
+		if err != nil {
+			return err
+		}
+		if char.GameID != gameID {
+			return errs.NotFoundf("character %v in %v", charID, gameID)
+		}
+		diff, err := modify(char)
+		if err != nil {
+			return err
+		}
+		charBytes, err := json.Marshal(char)
+		if err != nil {
+			return fmt.Errorf("marshaling character %v: %w", char, err)
+		}
+		packets := characterPackets(gameID, char, update.ForCharacterDiff(char.ID, diff))
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			if err := pipe.SetXX(ctx, charKey, charBytes, 0).Err(); err != nil {
+				return fmt.Errorf("sending character update: %w", err)
+			}
+			for ix, packet := range packets {
+				if err := publishPacket(ctx, pipe, &packet); err != nil {
+					return fmt.Errorf("sending packet #%v %#v: %w", ix, packet, err)
+				}
+			}
+			return nil
+		})
+		return err
+	}
+	if err := redisUtil.RetryWatchTxn(ctx, client, watched, charKey); err != nil {
+		return nil, err
+	}
+	return char, nil
+}
+
+// SpendEdge spends a point of the character's Edge.
+// Returns ErrNotFound if the character is not in the game, and ErrBadRequest
+// if they have no Edge left.
+func SpendEdge(ctx context.Context, client *redis.Client, gameID string, charID id.UID) (*character.Character, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "game.SpendEdge")
+	defer span.End()
+	char, err := modifyCharacter(ctx, client, gameID, charID,
+		func(char *character.Character) (map[string]interface{}, error) {
+			if char.EdgePoints < 1 {
+				return nil, errs.BadRequestf("%v has no Edge left", char.Name)
+			}
+			char.EdgePoints--
+			return map[string]interface{}{"edgePoints": char.EdgePoints}, nil
+		},
+	)
+	if err != nil {
+		return nil, srOtel.WithSetErrorf(span, "spending edge of %v: %w", charID, err)
+	}
+	return char, nil
+}
+
+// RefundEdge gives back a point of Edge spent on a roll which was not posted,
+// up to the character's Edge attribute.
+// Returns ErrNotFound if the character is not in the game.
+func RefundEdge(ctx context.Context, client *redis.Client, gameID string, charID id.UID) (*character.Character, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "game.RefundEdge")
+	defer span.End()
+	char, err := modifyCharacter(ctx, client, gameID, charID,
+		func(char *character.Character) (map[string]interface{}, error) {
+			if char.EdgePoints < char.Attributes.Edge {
+				char.EdgePoints++
+			}
+			return map[string]interface{}{"edgePoints": char.EdgePoints}, nil
+		},
+	)
+	if err != nil {
+		return nil, srOtel.WithSetErrorf(span, "refunding edge of %v: %w", charID, err)
+	}
+	return char, nil
+}
+
+// RefreshEdge restores the character's Edge to their Edge attribute.
+// Returns ErrNotFound if the character is not in the game.
+func RefreshEdge(ctx context.Context, client *redis.Client, gameID string, charID id.UID) (*character.Character, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "game.RefreshEdge")
+	defer span.End()
+	char, err := modifyCharacter(ctx, client, gameID, charID,
+		func(char *character.Character) (map[string]interface{}, error) {
+			char.EdgePoints = char.Attributes.Edge
+			return map[string]interface{}{"edgePoints": char.EdgePoints}, nil
+		},
+	)
+	if err != nil {
+		return nil, srOtel.WithSetErrorf(span, "refreshing edge of %v: %w", charID, err)
+	}
+	return char, nil
+}
@@ -0,0 +1,59 @@
+package game_test
+
+import (
+	"context"
+	"testing"
+
+	genCharacter "sr/gen/character"
+	genGame "sr/gen/game"
+	genPlayer "sr/gen/player"
+
+	"sr/character"
+	"sr/errs"
+	"sr/game"
+	"sr/test"
+)
+
+func TestSpendEdge(t *testing.T) {
+	ctx := context.Background()
+	rng := test.RNG()
+	_, client := test.GetRedis(t)
+
+	gameID := genGame.GameID(rng)
+	test.Must(t, game.Create(ctx, client, gameID))
+	plr := genPlayer.Player(rng)
+	char := genCharacter.Character(rng, gameID, plr.ID)
+	char.EdgePoints = 1
+	test.Must(t, character.Create(ctx, client, char))
+
+	spent, err := game.SpendEdge(ctx, client, gameID, char.ID)
+	test.AssertSuccess(t, err, "spending edge")
+	test.AssertEqual(t, 0, spent.EdgePoints)
+
+	t.Run("spending without edge", func(t *testing.T) {
+		_, err := game.SpendEdge(ctx, client, gameID, char.ID)
+		test.AssertErrorIs(t, err, errs.ErrBadRequest)
+	})
+
+	t.Run("spending in another game", func(t *testing.T) {
+		_, err := game.SpendEdge(ctx, client, genGame.GameID(rng), char.ID)
+		test.AssertErrorIs(t, err, errs.ErrNotFound)
+	})
+
+	t.Run("refunding edge", func(t *testing.T) {
+		refunded, err := game.RefundEdge(ctx, client, gameID, char.ID)
+		test.AssertSuccess(t, err, "refunding edge")
+		test.AssertEqual(t, 1, refunded.EdgePoints)
+		_, err = game.SpendEdge(ctx, client, gameID, char.ID)
+		test.AssertSuccess(t, err, "spending refunded edge")
+	})
+
+	t.Run("refreshing edge", func(t *testing.T) {
+		refreshed, err := game.RefreshEdge(ctx, client, gameID, char.ID)
+		test.AssertSuccess(t, err, "refreshing edge")
+		test.AssertEqual(t, char.Attributes.Edge, refreshed.EdgePoints)
+		found, err := character.GetByID(ctx, client, char.ID)
+		test.AssertSuccess(t, err, "getting character")
+		test.AssertEqual(t, char.Attributes.Edge, found.EdgePoints)
+	})
+}
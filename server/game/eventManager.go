@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"sr/event"
+	"sr/id"
 	srOtel "sr/otel"
 	"sr/update"
 
@@ -45,6 +46,24 @@ func PostEvent(ctx context.Context, client redis.Cmdable, gameID string, evt eve
 	return nil
 }
 
+// postEventCommands sends the commands to add an event to a game's history and
+// send it to the players who can see it, as part of a larger transaction.
+func postEventCommands(ctx context.Context, pipe redis.Pipeliner, gameID string, evt event.Event) error {
+	eventBytes, err := json.Marshal(evt)
+	if err != nil {
+		return fmt.Errorf("marshaling %v event %v: %w", evt.GetType(), evt.GetID(), err)
+	}
+	if err := pipe.ZAddNX(ctx, "history:"+gameID, &redis.Z{Score: float64(evt.GetID()), Member: eventBytes}).Err(); err != nil {
+		return fmt.Errorf("sending history add: %w", err)
+	}
+	for ix, packet := range createOrDeletePackets(gameID, evt, update.ForNewEvent(evt)) {
+		if err := publishPacket(ctx, pipe, &packet); err != nil {
+			return fmt.Errorf("sending packet #%v %#v: %w", ix, packet, err)
+		}
+	}
+	return nil
+}
+
 // DeleteEvent removes an event from a game and updates the game's connected players.
 func DeleteEvent(ctx context.Context, client redis.Cmdable, gameID string, evt event.Event) error {
 	ctx, span := srOtel.Tracer.Start(ctx, "game.DeleteEvent")
@@ -75,17 +94,19 @@ func DeleteEvent(ctx context.Context, client redis.Cmdable, gameID string, evt e
 	return nil
 }
 
-// UpdateEventShare changes the sharing of an event
-func UpdateEventShare(ctx context.Context, client redis.Cmdable, gameID string, evt event.Event, newShare event.Share) error {
+// UpdateEventShare changes the sharing of an event.
+// newRecipients are the players a whispered event is shared with.
+func UpdateEventShare(ctx context.Context, client redis.Cmdable, gameID string, evt event.Event, newShare event.Share, newRecipients []id.UID) error {
 	ctx, span := srOtel.Tracer.Start(ctx, "game.UpdateEventShare")
 	defer span.End()
 	eventID := evt.GetID()
-	if evt.GetShare() == newShare {
+	if evt.GetShare() == newShare &&
+		(newShare != event.ShareWhisper || event.HasRecipients(evt, newRecipients)) {
 		return srOtel.WithSetErrorf(span, "share matches: event %v share %v matches new share %s", evt.GetID(), evt.GetShare().String(), newShare.String())
 	}
 
 	// Do the logic of figuring out how to send the minimum number of updates
-	packets := sharePacketsModifyingEvent(gameID, evt, newShare)
+	packets := sharePacketsModifyingEvent(gameID, evt, newShare, newRecipients)
 	// Event now has new share, can be updated
 	eventBytes, err := json.Marshal(evt)
 	if err != nil {
@@ -118,7 +139,7 @@ func UpdateEventShare(ctx context.Context, client redis.Cmdable, gameID string,
 	if err != nil {
 		return srOtel.WithSetErrorf(span, "ececing event post: %w", err)
 	}
-	if len(results) < 4 {
+	if len(results) != 2+len(packets) {
 		return srOtel.WithSetErrorf(span, "updating event share, expected [1, 1, **], got %v", results)
 	}
 	return nil
@@ -128,16 +149,12 @@ func UpdateEventShare(ctx context.Context, client redis.Cmdable, gameID string,
 func UpdateEvent(ctx context.Context, client redis.Cmdable, gameID string, newEvent event.Event, update update.Event) error {
 	ctx, span := srOtel.Tracer.Start(ctx, "game.UpdateEvent")
 	defer span.End()
-	channel := UpdateChannel(gameID, newEvent.GetPlayerID(), newEvent.GetShare())
+	packets := updatePackets(gameID, newEvent, update)
 	eventID := newEvent.GetID()
 	eventBytes, err := json.Marshal(newEvent)
 	if err != nil {
 		return srOtel.WithSetErrorf(span, "unable to marshal event to JSON: %w", err)
 	}
-	updateBytes, err := json.Marshal(update)
-	if err != nil {
-		return srOtel.WithSetErrorf(span, "unable to marshal update to JSON: %w", err)
-	}
 	eventIDStr := fmt.Sprintf("%v", eventID)
 
 	results, err := client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
@@ -154,18 +171,19 @@ func UpdateEvent(ctx context.Context, client redis.Cmdable, gameID string, newEv
 		if err != nil {
 			return srOtel.WithSetErrorf(span, "redis error sending event delete: %w", err)
 		}
-		err = pipe.Publish(ctx, channel, updateBytes).Err()
-		if err != nil {
-			return srOtel.WithSetErrorf(span, "redis error sending event publish: %w", err)
+		for _, packet := range packets {
+			if err := publishPacket(ctx, pipe, &packet); err != nil {
+				return srOtel.WithSetErrorf(span, "redis error sending event publish: %w", err)
+			}
 		}
 		return nil
 	})
 
-	// EXEC: [#deleted=1, #added=1, #players]
+	// EXEC: [#deleted=1, #added=1, #players...]
 	if err != nil {
 		return srOtel.WithSetErrorf(span, "redis error EXECing event post: %w", err)
 	}
-	if len(results) != 3 {
+	if len(results) != 2+len(packets) {
 		return srOtel.WithSetErrorf(span, "redis error updating event, expected [1, 1, *], got %v", results)
 	}
 	return nil
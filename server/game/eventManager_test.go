@@ -13,6 +13,7 @@ import (
 
 	"sr/event"
 	"sr/game"
+	"sr/id"
 	"sr/test"
 	"sr/update"
 )
@@ -141,12 +142,44 @@ func TestUpdateEventShare(t *testing.T) {
 
 		wait := test.WaitForMessage(t, sub.Messages(), string(udBytes))
 
-		err = game.UpdateEventShare(ctx, client, gameID, evt, event.ShareInGame)
+		err = game.UpdateEventShare(ctx, client, gameID, evt, event.ShareInGame, nil)
 		test.AssertSuccess(t, err, "event share updated")
 
 		wait.Wait()
 	})
 
+	t.Run("whisper2whisper: added recipient sent event", func(t *testing.T) {
+		time.Sleep(time.Duration(50) * time.Millisecond)
+		first, second := playerGen.Player(rng), playerGen.Player(rng)
+		evt := eventGen.Event(rng, plr)
+		evt.SetShare(event.ShareWhisper)
+		evt.SetRecipients([]id.UID{first.ID})
+		err = game.PostEvent(ctx, client, gameID, evt)
+		test.AssertSuccess(t, err, "event posted")
+
+		sub := db.NewSubscriber()
+		defer sub.Close()
+		secondChannel := fmt.Sprintf("update:%v:%v", gameID, second.ID)
+		sub.Subscribe(secondChannel)
+		defer sub.Unsubscribe(secondChannel)
+
+		recipients := []id.UID{first.ID, second.ID}
+		evt.SetRecipients(recipients)
+		udBytes, err := update.ForNewEvent(evt).MarshalJSON()
+		test.AssertSuccess(t, err, "update marshalled")
+		evt.SetRecipients([]id.UID{first.ID})
+
+		wait := test.WaitForMessage(t, sub.Messages(), string(udBytes))
+
+		err = game.UpdateEventShare(ctx, client, gameID, evt, event.ShareWhisper, recipients)
+		test.AssertSuccess(t, err, "event recipients updated")
+
+		wait.Wait()
+
+		err = game.UpdateEventShare(ctx, client, gameID, evt, event.ShareWhisper, recipients)
+		test.AssertError(t, err, "recipients unchanged")
+	})
+
 	t.Run("event share was updated", func(t *testing.T) {
 		// Offset event ID
 		time.Sleep(time.Duration(50) * time.Millisecond)
@@ -163,7 +196,7 @@ func TestUpdateEventShare(t *testing.T) {
 			}
 		}
 		t.Logf("Event %v share %v -> %v", evt.GetID(), oldShare, newShare)
-		err := game.UpdateEventShare(ctx, client, gameID, evt, newShare)
+		err := game.UpdateEventShare(ctx, client, gameID, evt, newShare, nil)
 		// evt's share has also been updated
 		test.AssertSuccess(t, err, "event share updated")
 
@@ -27,9 +27,21 @@ func PlayerCanSeeEvent(plr *player.Player, isGM bool, evt event.Event) bool {
 	if share == event.ShareGMs {
 		return evt.GetPlayerID() == plr.ID || isGM
 	}
+	if share == event.ShareBlind {
+		return isGM
+	}
+	if share == event.ShareWhisper {
+		return evt.GetPlayerID() == plr.ID || event.IsRecipient(evt, plr.ID)
+	}
 	panic(fmt.Sprintf("unexpected share %v for event %v", share, evt))
 }
 
+// PlayerSeesPlaceholder determines if the given player should see a placeholder
+// for the given event in place of the event itself.
+func PlayerSeesPlaceholder(plr *player.Player, isGM bool, evt event.Event) bool {
+	return evt.GetShare() == event.ShareBlind && !isGM && evt.GetPlayerID() == plr.ID
+}
+
 // GameChannel is the Redis subscription to game-broadcast updates.
 func GameChannel(gameID string) string {
 	return "update:" + gameID
@@ -46,6 +58,7 @@ func GMsChannel(gameID string) string {
 }
 
 // UpdateChannel produces the channel an update should be posted in, and whether it may overlap.
+// Whispered events are sent to each recipient's channel, see `updatePackets`.
 func UpdateChannel(gameID string, playerID id.UID, share event.Share) string {
 	if share == event.ShareInGame {
 		return GameChannel(gameID)
@@ -53,7 +66,7 @@ func UpdateChannel(gameID string, playerID id.UID, share event.Share) string {
 	if share == event.SharePrivate {
 		return PlayerChannel(gameID, playerID)
 	}
-	if share == event.ShareGMs {
+	if share == event.ShareGMs || share == event.ShareBlind {
 		return GMsChannel(gameID)
 	}
 	panic(fmt.Sprintf("unexpected share %v for player %v in %v",
@@ -61,6 +74,37 @@ func UpdateChannel(gameID string, playerID id.UID, share event.Share) string {
 	))
 }
 
+// whisperAudience lists the originator and recipients of a whispered event.
+func whisperAudience(evt event.Event) []id.UID {
+	playerID := evt.GetPlayerID()
+	audience := []id.UID{playerID}
+	for _, recipient := range evt.GetRecipients() {
+		if recipient != playerID {
+			audience = append(audience, recipient)
+		}
+	}
+	return audience
+}
+
+// whisperFilter excludes the originator and recipients of a whispered event.
+func whisperFilter(evt event.Event) []string {
+	audience := whisperAudience(evt)
+	filter := make([]string, len(audience))
+	for ix, playerID := range audience {
+		filter[ix] = string(playerID)
+	}
+	return filter
+}
+
+// playerPackets sends the update to each of the given players' channels.
+func playerPackets(gameID string, playerIDs []id.UID, ud update.Update) []Packet {
+	packets := make([]Packet, len(playerIDs))
+	for ix, playerID := range playerIDs {
+		packets[ix] = Packet{PlayerChannel(gameID, playerID), []string{}, ud}
+	}
+	return packets
+}
+
 func gameOrGMsChannel(gameID string, share event.Share) string {
 	if share == event.ShareInGame {
 		return GameChannel(gameID)
@@ -110,39 +154,123 @@ func publishPacket(ctx context.Context, client redis.Cmdable, packet *Packet) er
 
 // createOrDeletePackets returns the {channel, filter, update} trios for an event create or delete.
 // If an event is created/deleted shared with GMs, two pakcets are needed.
-func createOrDeletePackets(gameID string, evt event.Event, update update.Event) []Packet {
+// If a blind event is created, its originator is sent a placeholder instead.
+// If a whispered event is created/deleted, each recipient is sent a packet.
+func createOrDeletePackets(gameID string, evt event.Event, ud update.Event) []Packet {
 	share := evt.GetShare()
 	playerID := evt.GetPlayerID()
 
 	if share == event.ShareInGame {
-		return []Packet{{GameChannel(gameID), []string{}, update}}
+		return []Packet{{GameChannel(gameID), []string{}, ud}}
 	} else if share == event.SharePrivate {
-		return []Packet{{PlayerChannel(gameID, playerID), []string{}, update}}
+		return []Packet{{PlayerChannel(gameID, playerID), []string{}, ud}}
 	} else if share == event.ShareGMs {
 		return []Packet{
-			{GMsChannel(gameID), []string{string(playerID)}, update},
-			{PlayerChannel(gameID, playerID), []string{}, update},
+			{GMsChannel(gameID), []string{string(playerID)}, ud},
+			{PlayerChannel(gameID, playerID), []string{}, ud},
+		}
+	} else if share == event.ShareBlind {
+		playerUpdate := ud
+		if ud.Type() == update.TypeEventNew {
+			playerUpdate = update.ForNewEvent(event.ForHidden(evt))
+		}
+		// GM originators see the event itself via the GMs channel.
+		return []Packet{
+			{GMsChannel(gameID), []string{}, ud},
+			{PlayerChannel(gameID, playerID), []string{"gms"}, playerUpdate},
 		}
+	} else if share == event.ShareWhisper {
+		return playerPackets(gameID, whisperAudience(evt), ud)
 	} else {
 		panic(fmt.Sprintf("unexpected update share %v for %v update %v in %v",
-			share, evt, update, gameID,
+			share, evt, ud, gameID,
 		))
 	}
 }
 
+// updatePackets returns the {channel, filter, update} trios for an update
+// modifying an existing event.
+// Updates to blind events are only sent to GMs, as the originator only has a
+// placeholder for the event.
+func updatePackets(gameID string, evt event.Event, ud update.Event) []Packet {
+	share := evt.GetShare()
+	playerID := evt.GetPlayerID()
+	if share == event.ShareWhisper {
+		return playerPackets(gameID, whisperAudience(evt), ud)
+	} else if share == event.ShareGMs {
+		return []Packet{
+			{GMsChannel(gameID), []string{string(playerID)}, ud},
+			{PlayerChannel(gameID, playerID), []string{}, ud},
+		}
+	}
+	return []Packet{{UpdateChannel(gameID, playerID, share), []string{}, ud}}
+}
+
 // GetSharePacketsModifyingEvent returns the {channel, filter, update} trios
 // which should be sent to a game when an event share is changed.
-// It calls event.SetShare on the given evt.
-func sharePacketsModifyingEvent(gameID string, evt event.Event, newShare event.Share) []Packet {
+// It calls event.SetShare and event.SetRecipients on the given evt.
+func sharePacketsModifyingEvent(gameID string, evt event.Event, newShare event.Share, newRecipients []id.UID) []Packet {
 	oldShare := evt.GetShare()
+	oldRecipients := whisperAudience(evt)[1:]
+	if oldShare != event.ShareWhisper {
+		oldRecipients = nil
+	}
 	evt.SetShare(newShare)
+	if newShare == event.ShareWhisper {
+		evt.SetRecipients(newRecipients)
+	} else {
+		evt.SetRecipients(nil)
+	}
+	if oldShare == event.ShareWhisper || newShare == event.ShareWhisper {
+		return whisperPacketsModifyingEvent(gameID, evt, oldShare, oldRecipients)
+	}
 
 	playerID := evt.GetPlayerID()
 	modify := update.ForEventShare(evt, newShare)
 	create := update.ForNewEvent(evt)
 	delete := update.ForEventDelete(evt.GetID())
 
-	if /* oldShare in GMs, Game && */ newShare == event.SharePrivate {
+	if oldShare == event.ShareBlind {
+		// blind -> {game/gms/private}:
+		// = player delete placeholder; player create; gms-player {modify/delete}
+		packets := []Packet{
+			// 1. player delete placeholder
+			{PlayerChannel(gameID, playerID), []string{}, delete},
+			// 2. player create
+			{PlayerChannel(gameID, playerID), []string{}, create},
+		}
+		if newShare == event.SharePrivate {
+			// 3. gms-player delete
+			return append(packets, Packet{GMsChannel(gameID), []string{string(playerID)}, delete})
+		}
+		// 3. gms-player modify
+		packets = append(packets, Packet{GMsChannel(gameID), []string{string(playerID)}, modify})
+		if newShare == event.ShareInGame {
+			// 4. game-player-gms create
+			packets = append(packets, Packet{GameChannel(gameID), []string{string(playerID), "gms"}, create})
+		}
+		return packets
+	} else if newShare == event.ShareBlind {
+		// {game/gms/private} -> blind:
+		// = player-gms delete; player-gms create placeholder; gms modify
+		// (GM originators are sent the modify via the GMs channel.)
+		placeholder := update.ForNewEvent(event.ForHidden(evt))
+		packets := []Packet{
+			// 1. player-gms delete
+			{PlayerChannel(gameID, playerID), []string{"gms"}, delete},
+			// 2. player-gms create placeholder
+			{PlayerChannel(gameID, playerID), []string{"gms"}, placeholder},
+		}
+		if oldShare == event.ShareInGame {
+			// 3. game-player-gms delete
+			packets = append(packets, Packet{GameChannel(gameID), []string{string(playerID), "gms"}, delete})
+		} else if oldShare == event.SharePrivate {
+			// 3. gms-player create
+			packets = append(packets, Packet{GMsChannel(gameID), []string{string(playerID)}, create})
+		}
+		// 4. gms modify
+		return append(packets, Packet{GMsChannel(gameID), []string{}, modify})
+	} else if /* oldShare in GMs, Game && */ newShare == event.SharePrivate {
 		// {game/gms} -> private:
 		// = {game/gms}-player delete; player modify
 		return []Packet{
@@ -185,3 +313,107 @@ func sharePacketsModifyingEvent(gameID string, evt event.Event, newShare event.S
 	}
 	panic(fmt.Sprintf("unexpected new share %v for event %v", newShare, evt))
 }
+
+// whisperPacketsModifyingEvent returns the {channel, filter, update} trios
+// for a share change to or from a whisper. The given evt has already been
+// given its new share and recipients.
+func whisperPacketsModifyingEvent(gameID string, evt event.Event, oldShare event.Share, oldRecipients []id.UID) []Packet {
+	newShare := evt.GetShare()
+	playerID := evt.GetPlayerID()
+	modify := update.ForEventShare(evt, newShare)
+	create := update.ForNewEvent(evt)
+	delete := update.ForEventDelete(evt.GetID())
+
+	if oldShare == event.ShareWhisper && newShare == event.ShareWhisper {
+		// whisper -> whisper:
+		// = player modify; kept recipients modify; added recipients create;
+		// removed recipients delete
+		packets := []Packet{{PlayerChannel(gameID, playerID), []string{}, modify}}
+		for _, recipient := range oldRecipients {
+			if event.IsRecipient(evt, recipient) {
+				packets = append(packets, Packet{PlayerChannel(gameID, recipient), []string{}, modify})
+			} else {
+				packets = append(packets, Packet{PlayerChannel(gameID, recipient), []string{}, delete})
+			}
+		}
+		for _, recipient := range whisperAudience(evt)[1:] {
+			if !id.Contains(oldRecipients, recipient) {
+				packets = append(packets, Packet{PlayerChannel(gameID, recipient), []string{}, create})
+			}
+		}
+		return packets
+	} else if oldShare == event.ShareWhisper {
+		switch newShare {
+		case event.SharePrivate:
+			// whisper -> private:
+			// = player modify; recipients delete
+			return append(
+				[]Packet{{PlayerChannel(gameID, playerID), []string{}, modify}},
+				playerPackets(gameID, oldRecipients, delete)...,
+			)
+		case event.ShareInGame:
+			// whisper -> game:
+			// = player modify; recipients modify; game-player-recipients create
+			packets := playerPackets(gameID, append([]id.UID{playerID}, oldRecipients...), modify)
+			filter := []string{string(playerID)}
+			for _, recipient := range oldRecipients {
+				filter = append(filter, string(recipient))
+			}
+			return append(packets, Packet{GameChannel(gameID), filter, create})
+		case event.ShareGMs:
+			// whisper -> gms:
+			// = player modify; recipients delete; gms-player create
+			// (GM recipients are sent the event again via the GMs channel.)
+			packets := []Packet{{PlayerChannel(gameID, playerID), []string{}, modify}}
+			packets = append(packets, playerPackets(gameID, oldRecipients, delete)...)
+			return append(packets, Packet{GMsChannel(gameID), []string{string(playerID)}, create})
+		case event.ShareBlind:
+			// whisper -> blind:
+			// = recipients delete; player-gms delete; player-gms create placeholder;
+			// gms-player create; gms modify
+			packets := playerPackets(gameID, oldRecipients, delete)
+			return append(packets,
+				Packet{PlayerChannel(gameID, playerID), []string{"gms"}, delete},
+				Packet{PlayerChannel(gameID, playerID), []string{"gms"}, update.ForNewEvent(event.ForHidden(evt))},
+				Packet{GMsChannel(gameID), []string{string(playerID)}, create},
+				Packet{GMsChannel(gameID), []string{}, modify},
+			)
+		}
+	} else {
+		recipients := whisperAudience(evt)[1:]
+		switch oldShare {
+		case event.SharePrivate:
+			// private -> whisper:
+			// = player modify; recipients create
+			return append(
+				[]Packet{{PlayerChannel(gameID, playerID), []string{}, modify}},
+				playerPackets(gameID, recipients, create)...,
+			)
+		case event.ShareInGame:
+			// game -> whisper:
+			// = game-player-recipients delete; player modify; recipients modify
+			packets := []Packet{{GameChannel(gameID), whisperFilter(evt), delete}}
+			return append(packets, playerPackets(gameID, whisperAudience(evt), modify)...)
+		case event.ShareGMs:
+			// gms -> whisper:
+			// = gms-player delete; player modify; recipients create
+			// (GM recipients are sent the event again via their own channel.)
+			packets := []Packet{
+				{GMsChannel(gameID), []string{string(playerID)}, delete},
+				{PlayerChannel(gameID, playerID), []string{}, modify},
+			}
+			return append(packets, playerPackets(gameID, recipients, create)...)
+		case event.ShareBlind:
+			// blind -> whisper:
+			// = player delete placeholder; player create; gms-player delete;
+			// recipients create
+			packets := []Packet{
+				{PlayerChannel(gameID, playerID), []string{}, delete},
+				{PlayerChannel(gameID, playerID), []string{}, create},
+				{GMsChannel(gameID), []string{string(playerID)}, delete},
+			}
+			return append(packets, playerPackets(gameID, recipients, create)...)
+		}
+	}
+	panic(fmt.Sprintf("unexpected share change %v -> %v for event %v", oldShare, newShare, evt))
+}
@@ -7,6 +7,7 @@ import (
 	"sr/id"
 	"sr/player"
 	"sr/test"
+	"sr/update"
 )
 
 func TestPlayerCanSeeEvent(t *testing.T) {
@@ -49,6 +50,156 @@ func TestPlayerCanSeeEvent(t *testing.T) {
 		test.AssertEqual(t, PlayerCanSeeEvent(plr2, false, evt), false)
 		test.AssertEqual(t, PlayerCanSeeEvent(plr2, true, evt), true)
 	})
+	test.RunParallel(t, "share blind visible only to GMs", func(t *testing.T) {
+		evt := event.ForPlayerJoin(plr)
+		evt.SetShare(event.ShareBlind)
+		test.AssertEqual(t, PlayerCanSeeEvent(plr, false, evt), false)
+		test.AssertEqual(t, PlayerCanSeeEvent(plr, true, evt), true)
+		test.AssertEqual(t, PlayerCanSeeEvent(plr2, false, evt), false)
+		test.AssertEqual(t, PlayerCanSeeEvent(plr2, true, evt), true)
+	})
+	test.RunParallel(t, "share whisper visible only to player and recipients", func(t *testing.T) {
+		plr3 := &player.Player{ID: id.UID("plr3"), Name: "plebby the third", Username: "pleb16"}
+		evt := event.ForPlayerJoin(plr)
+		evt.SetShare(event.ShareWhisper)
+		evt.SetRecipients([]id.UID{plr2.ID})
+		test.AssertEqual(t, PlayerCanSeeEvent(plr, false, evt), true)
+		test.AssertEqual(t, PlayerCanSeeEvent(plr2, false, evt), true)
+		test.AssertEqual(t, PlayerCanSeeEvent(plr3, false, evt), false)
+		test.AssertEqual(t, PlayerCanSeeEvent(plr3, true, evt), false)
+	})
+}
+
+func TestPlayerSeesPlaceholder(t *testing.T) {
+	plr := &player.Player{ID: id.UID("plr"), Name: "plebby", Username: "pleb14"}
+	plr2 := &player.Player{ID: id.UID("plr2"), Name: "plebby the second", Username: "pleb15"}
+
+	test.RunParallel(t, "originator of blind event sees placeholder", func(t *testing.T) {
+		evt := event.ForPlayerJoin(plr)
+		evt.SetShare(event.ShareBlind)
+		test.AssertEqual(t, PlayerSeesPlaceholder(plr, false, evt), true)
+		test.AssertEqual(t, PlayerSeesPlaceholder(plr, true, evt), false)
+		test.AssertEqual(t, PlayerSeesPlaceholder(plr2, false, evt), false)
+	})
+	test.RunParallel(t, "other shares have no placeholder", func(t *testing.T) {
+		evt := event.ForPlayerJoin(plr)
+		for _, share := range []event.Share{event.ShareInGame, event.SharePrivate, event.ShareGMs} {
+			evt.SetShare(share)
+			test.AssertEqual(t, PlayerSeesPlaceholder(plr, false, evt), false)
+		}
+	})
+}
+
+func TestCreateOrDeletePackets(t *testing.T) {
+	plr := &player.Player{ID: id.UID("p"), Name: "plebby", Username: "pleb14"}
+
+	test.RunParallel(t, "blind create sends placeholder to originator", func(t *testing.T) {
+		evt := event.ForPlayerJoin(plr)
+		evt.SetShare(event.ShareBlind)
+		create := update.ForNewEvent(evt)
+		packets := createOrDeletePackets("g", evt, create)
+		test.AssertEqual(t, 2, len(packets))
+		test.AssertEqual(t, Packet{"update:g:gms", []string{}, create}, packets[0])
+		test.AssertEqual(t, "update:g:p", packets[1].Channel)
+		test.AssertEqual(t, []string{"gms"}, packets[1].Filter)
+		test.AssertEqual(t, update.ForNewEvent(event.ForHidden(evt)), packets[1].Update)
+	})
+	test.RunParallel(t, "blind delete sends delete to originator", func(t *testing.T) {
+		evt := event.ForPlayerJoin(plr)
+		evt.SetShare(event.ShareBlind)
+		delete := update.ForEventDelete(evt.GetID())
+		packets := createOrDeletePackets("g", evt, delete)
+		test.AssertEqual(t, []Packet{
+			{"update:g:gms", []string{}, delete},
+			{"update:g:p", []string{"gms"}, delete},
+		}, packets)
+	})
+	test.RunParallel(t, "whisper create sends event to each recipient", func(t *testing.T) {
+		evt := event.ForPlayerJoin(plr)
+		evt.SetShare(event.ShareWhisper)
+		evt.SetRecipients([]id.UID{"r1", "p", "r2"})
+		create := update.ForNewEvent(evt)
+		packets := createOrDeletePackets("g", evt, create)
+		test.AssertEqual(t, []Packet{
+			{"update:g:p", []string{}, create},
+			{"update:g:r1", []string{}, create},
+			{"update:g:r2", []string{}, create},
+		}, packets)
+	})
+}
+
+func TestUpdatePackets(t *testing.T) {
+	plr := &player.Player{ID: id.UID("p"), Name: "plebby", Username: "pleb14"}
+
+	test.RunParallel(t, "gms updates are sent to originator and GMs", func(t *testing.T) {
+		evt := event.ForPlayerJoin(plr)
+		evt.SetShare(event.ShareGMs)
+		diff := update.ForEventDiff(evt, map[string]interface{}{"title": "t"})
+		test.AssertEqual(t, []Packet{
+			{"update:g:gms", []string{"p"}, diff},
+			{"update:g:p", []string{}, diff},
+		}, updatePackets("g", evt, diff))
+	})
+	test.RunParallel(t, "blind updates are only sent to GMs", func(t *testing.T) {
+		evt := event.ForPlayerJoin(plr)
+		evt.SetShare(event.ShareBlind)
+		diff := update.ForEventDiff(evt, map[string]interface{}{"title": "t"})
+		test.AssertEqual(t, []Packet{
+			{"update:g:gms", []string{}, diff},
+		}, updatePackets("g", evt, diff))
+	})
+	test.RunParallel(t, "whisper updates are sent to recipients", func(t *testing.T) {
+		evt := event.ForPlayerJoin(plr)
+		evt.SetShare(event.ShareWhisper)
+		evt.SetRecipients([]id.UID{"r"})
+		diff := update.ForEventDiff(evt, map[string]interface{}{"title": "t"})
+		test.AssertEqual(t, []Packet{
+			{"update:g:p", []string{}, diff},
+			{"update:g:r", []string{}, diff},
+		}, updatePackets("g", evt, diff))
+	})
+}
+
+func TestSharePacketsModifyingEvent(t *testing.T) {
+	plr := &player.Player{ID: id.UID("p"), Name: "plebby", Username: "pleb14"}
+
+	test.RunParallel(t, "game to whisper deletes for everyone else", func(t *testing.T) {
+		evt := event.ForPlayerJoin(plr)
+		evt.SetShare(event.ShareInGame)
+		packets := sharePacketsModifyingEvent("g", evt, event.ShareWhisper, []id.UID{"r1"})
+		test.AssertEqual(t, event.ShareWhisper, evt.GetShare())
+		test.AssertEqual(t, []id.UID{"r1"}, evt.GetRecipients())
+		modify := update.ForEventShare(evt, event.ShareWhisper)
+		test.AssertEqual(t, []Packet{
+			{"update:g", []string{"p", "r1"}, update.ForEventDelete(evt.GetID())},
+			{"update:g:p", []string{}, modify},
+			{"update:g:r1", []string{}, modify},
+		}, packets)
+	})
+	test.RunParallel(t, "whisper to whisper only updates changed recipients", func(t *testing.T) {
+		evt := event.ForPlayerJoin(plr)
+		evt.SetShare(event.ShareWhisper)
+		evt.SetRecipients([]id.UID{"kept", "removed"})
+		packets := sharePacketsModifyingEvent("g", evt, event.ShareWhisper, []id.UID{"kept", "added"})
+		modify := update.ForEventShare(evt, event.ShareWhisper)
+		test.AssertEqual(t, []Packet{
+			{"update:g:p", []string{}, modify},
+			{"update:g:kept", []string{}, modify},
+			{"update:g:removed", []string{}, update.ForEventDelete(evt.GetID())},
+			{"update:g:added", []string{}, update.ForNewEvent(evt)},
+		}, packets)
+	})
+	test.RunParallel(t, "whisper to private deletes for recipients", func(t *testing.T) {
+		evt := event.ForPlayerJoin(plr)
+		evt.SetShare(event.ShareWhisper)
+		evt.SetRecipients([]id.UID{"r1"})
+		packets := sharePacketsModifyingEvent("g", evt, event.SharePrivate, nil)
+		test.AssertEqual(t, 0, len(evt.GetRecipients()))
+		test.AssertEqual(t, []Packet{
+			{"update:g:p", []string{}, update.ForEventShare(evt, event.SharePrivate)},
+			{"update:g:r1", []string{}, update.ForEventDelete(evt.GetID())},
+		}, packets)
+	})
 }
 
 func TestUpdateChannel(t *testing.T) {
@@ -63,4 +214,7 @@ func TestUpdateChannel(t *testing.T) {
 	test.RunParallel(t, "it produces the in-game channel", func(t *testing.T) {
 		test.AssertEqual(t, UpdateChannel(gID, pID, event.ShareGMs), "update:g:gms")
 	})
+	test.RunParallel(t, "it produces the GMs channel for blind events", func(t *testing.T) {
+		test.AssertEqual(t, UpdateChannel(gID, pID, event.ShareBlind), "update:g:gms")
+	})
 }
@@ -3,12 +3,19 @@ package game
 import (
 	"context"
 	"errors"
+	"sort"
 
+	"sr/apitoken"
+	"sr/character"
 	"sr/errs"
 	"sr/id"
+	"sr/invite"
+	"sr/macro"
 	srOtel "sr/otel"
+	"sr/overlay"
 	"sr/player"
 	redisUtil "sr/redis"
+	"sr/timer"
 
 	"github.com/go-redis/redis/v8"
 )
@@ -31,21 +38,44 @@ func Exists(ctx context.Context, client redis.Cmdable, gameID string) (bool, err
 func HasGM(ctx context.Context, client redis.Cmdable, gameID string, playerID id.UID) (bool, error) {
 	ctx, span := srOtel.Tracer.Start(ctx, "game.HasGM")
 	defer span.End()
-	has, err := client.SIsMember(ctx, "gm:"+gameID, string(playerID)).Result()
+	has, err := client.SIsMember(ctx, "gms:"+gameID, string(playerID)).Result()
 	if err != nil {
 		return false, srOtel.WithSetErrorf(span, "checking if player is GM: %w", err)
 	}
 	return has, nil
 }
 
-// GetGMs returns the list of GMs from a game.
+// HasPlayer determines if the given player is in the given game.
+func HasPlayer(ctx context.Context, client redis.Cmdable, gameID string, playerID id.UID) (bool, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "game.HasPlayer")
+	defer span.End()
+	has, err := client.SIsMember(ctx, "players:"+gameID, string(playerID)).Result()
+	if err != nil {
+		return false, srOtel.WithSetErrorf(span, "checking if player is in game: %w", err)
+	}
+	return has, nil
+}
+
+// HasSpectator determines if the given player is spectating the given game.
+func HasSpectator(ctx context.Context, client redis.Cmdable, gameID string, playerID id.UID) (bool, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "game.HasSpectator")
+	defer span.End()
+	has, err := client.SIsMember(ctx, "spectators:"+gameID, string(playerID)).Result()
+	if err != nil {
+		return false, srOtel.WithSetErrorf(span, "checking if player is spectating: %w", err)
+	}
+	return has, nil
+}
+
+// GetGMs returns the sorted list of GMs from a game.
 func GetGMs(ctx context.Context, client redis.Cmdable, gameID string) ([]string, error) {
-	ctx, span := srOtel.Tracer.Start(ctx, "game.HasGM")
+	ctx, span := srOtel.Tracer.Start(ctx, "game.GetGMs")
 	defer span.End()
 	gms, err := client.SMembers(ctx, "gms:"+gameID).Result()
 	if err != nil {
 		return nil, srOtel.WithSetErrorf(span, "getting gms for game: %w", err)
 	}
+	sort.Strings(gms)
 	return gms, nil
 }
 
@@ -78,9 +108,183 @@ func Create(ctx context.Context, client redis.Cmdable, gameID string) error {
 	return nil
 }
 
+// ValidID determines if a game ID is valid for a new game.
+// It checks for 3-32 lowercase letters, digits, dashes, or underscores.
+func ValidID(gameID string) bool {
+	if len(gameID) < 3 || len(gameID) > 32 {
+		return false
+	}
+	for _, c := range gameID {
+		if !(c >= 'a' && c <= 'z') && !(c >= '0' && c <= '9') && c != '-' && c != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// CreateWithGM creates a new game with the given ID, with the given player as
+// its first player and GM.
+// Returns BadRequest if the game already exists.
+func CreateWithGM(ctx context.Context, client *redis.Client, gameID string, plr *player.Player) error {
+	ctx, span := srOtel.Tracer.Start(ctx, "game.CreateWithGM")
+	defer span.End()
+	watched := func(tx *redis.Tx) error {
+		exists, err := Exists(ctx, tx, gameID)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return errs.BadRequestf("creating game: game %v already exists", gameID)
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.HSet(ctx, "game:"+gameID,
+				"event_id", "0",
+				"created_at", id.TimestampNow(),
+				"created_by", plr.ID.String(),
+			)
+			addMemberCommands(ctx, pipe, gameID, plr.ID, false)
+			pipe.SAdd(ctx, "gms:"+gameID, plr.ID.String())
+			return nil
+		})
+		return err
+	}
+	err := redisUtil.RetryWatchTxn(ctx, client, watched, "game:"+gameID)
+	if errors.Is(err, errs.ErrBadRequest) {
+		return err
+	} else if err != nil {
+		return srOtel.WithSetErrorf(span, "creating game with %v: %w", plr.ID, err)
+	}
+	return nil
+}
+
+// Delete removes a game along with its players, spectators, GMs, invites,
+// overlay tokens, history, characters, timers, and its members' macros, API
+// tokens, and retired characters, and removes it from its members'
+// PlayerGamesKey.
+// Returns ErrNotFound if the game does not exist.
+func Delete(ctx context.Context, client *redis.Client, gameID string) error {
+	ctx, span := srOtel.Tracer.Start(ctx, "game.Delete")
+	defer span.End()
+	keys := []string{
+		"game:" + gameID, "players:" + gameID, "spectators:" + gameID,
+		"gms:" + gameID, invite.GameKey(gameID), overlay.GameKey(gameID),
+		"chars:" + gameID, timer.RedisKey(gameID),
+	}
+	watched := func(tx *redis.Tx) error {
+		exists, err := Exists(ctx, tx, gameID)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return errs.NotFoundf("game %v", gameID)
+		}
+		playerIDs, err := tx.SMembers(ctx, "players:"+gameID).Result()
+		if err != nil {
+			return srOtel.WithSetErrorf(span, "getting players: %w", err)
+		}
+		spectatorIDs, err := tx.SMembers(ctx, "spectators:"+gameID).Result()
+		if err != nil {
+			return srOtel.WithSetErrorf(span, "getting spectators: %w", err)
+		}
+		codes, err := tx.SMembers(ctx, invite.GameKey(gameID)).Result()
+		if err != nil {
+			return srOtel.WithSetErrorf(span, "getting invites: %w", err)
+		}
+		tokens, err := tx.SMembers(ctx, overlay.GameKey(gameID)).Result()
+		if err != nil {
+			return srOtel.WithSetErrorf(span, "getting overlay tokens: %w", err)
+		}
+		charIDs, err := tx.SMembers(ctx, "chars:"+gameID).Result()
+		if err != nil {
+			return srOtel.WithSetErrorf(span, "getting characters: %w", err)
+		}
+		timerIDs, err := tx.HKeys(ctx, timer.RedisKey(gameID)).Result()
+		if err != nil {
+			return srOtel.WithSetErrorf(span, "getting timers: %w", err)
+		}
+		memberIDs := append(playerIDs, spectatorIDs...)
+		apiTokens := make(map[string]map[string]string, len(memberIDs))
+		for _, memberID := range memberIDs {
+			hashes, err := apitoken.GetHashesIn(ctx, tx, gameID, id.UID(memberID))
+			if err != nil {
+				return srOtel.WithSetErrorf(span, "getting API tokens of %v: %w", memberID, err)
+			}
+			apiTokens[memberID] = hashes
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Del(ctx, keys...)
+			pipe.Del(ctx, "history:"+gameID, hiddenOverwatchKey(gameID))
+			for _, memberID := range memberIDs {
+				pipe.SRem(ctx, PlayerGamesKey(id.UID(memberID)), gameID)
+				pipe.Del(ctx, macro.RedisKey(gameID, id.UID(memberID)), retiredKey(gameID, id.UID(memberID)))
+				apitoken.RevokeCommands(ctx, pipe, id.UID(memberID), apiTokens[memberID])
+			}
+			for _, code := range codes {
+				pipe.Del(ctx, invite.RedisKey(id.UID(code)))
+			}
+			for _, token := range tokens {
+				pipe.Del(ctx, overlay.RedisKey(id.UID(token)))
+			}
+			for _, charID := range charIDs {
+				char := character.Character{ID: id.UID(charID)}
+				pipe.Del(ctx, char.RedisKey(), character.LedgerKey(char.ID))
+			}
+			for _, timerID := range timerIDs {
+				pipe.ZRem(ctx, timer.DueKey, timer.DueMember(gameID, id.UID(timerID)))
+			}
+			return nil
+		})
+		return err
+	}
+	err := redisUtil.RetryWatchTxn(ctx, client, watched, keys...)
+	if errors.Is(err, errs.ErrNotFound) {
+		return err
+	} else if err != nil {
+		return srOtel.WithSetErrorf(span, "deleting game: %w", err)
+	}
+	return nil
+}
+
+// RequiresCredentials determines if players must log in to the game with a
+// password or passkey.
+func RequiresCredentials(ctx context.Context, client redis.Cmdable, gameID string) (bool, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "game.RequiresCredentials")
+	defer span.End()
+	required, err := client.HExists(ctx, "game:"+gameID, "require_credentials").Result()
+	if err != nil {
+		return false, srOtel.WithSetErrorf(span, "checking game setting: %w", err)
+	}
+	return required, nil
+}
+
+// SetRequireCredentials sets whether players must log in to the game with a
+// password or passkey. Existing sessions made without credentials lose access
+// to the game while it is set.
+// Returns ErrNotFound if the game does not exist.
+func SetRequireCredentials(ctx context.Context, client redis.Cmdable, gameID string, require bool) error {
+	ctx, span := srOtel.Tracer.Start(ctx, "game.SetRequireCredentials")
+	defer span.End()
+	exists, err := Exists(ctx, client, gameID)
+	if err != nil {
+		return srOtel.WithSetErrorf(span, "checking game: %w", err)
+	}
+	if !exists {
+		return errs.NotFoundf("game %v", gameID)
+	}
+	if require {
+		err = client.HSet(ctx, "game:"+gameID, "require_credentials", "1").Err()
+	} else {
+		err = client.HDel(ctx, "game:"+gameID, "require_credentials").Err()
+	}
+	if err != nil {
+		return srOtel.WithSetErrorf(span, "setting game setting: %w", err)
+	}
+	return nil
+}
+
 // AddGM adds a gm to the given game idempotently.
 func AddGM(ctx context.Context, client redis.Cmdable, gameID string, playerID id.UID) error {
-	ctx, span := srOtel.Tracer.Start(ctx, "game.Create")
+	ctx, span := srOtel.Tracer.Start(ctx, "game.AddGM")
 	defer span.End()
 	_, err := client.SAdd(ctx, "gms:"+gameID, playerID.String()).Result()
 	if err != nil {
@@ -126,9 +330,12 @@ func GetPlayers(ctx context.Context, client *redis.Client, gameID string) ([]pla
 // Info represents basic info about a game that the frontend would want
 // by default, all at once.
 type Info struct {
-	ID      string                 `json:"id"`
-	Players map[string]player.Info `json:"players"`
-	GMs     []string               `json:"gms"`
+	ID         string                 `json:"id"`
+	Players    map[string]player.Info `json:"players"`
+	GMs        []string               `json:"gms"`
+	Spectators map[string]player.Info `json:"spectators,omitempty"`
+
+	RequireCredentials bool `json:"requireCredentials"`
 }
 
 // GetInfo retrieves `Info` for the given ID.
@@ -163,5 +370,28 @@ func GetInfo(ctx context.Context, client *redis.Client, gameID string) (*Info, e
 	for _, player := range players {
 		info[string(player.ID)] = player.Info()
 	}
-	return &Info{ID: gameID, Players: info, GMs: gms}, nil
+	spectators, err := GetSpectators(ctx, client, gameID)
+	if err != nil {
+		return nil, srOtel.WithSetErrorf(span,
+			"getting spectators in game %v: %w", gameID, err)
+	}
+	var spectatorInfo map[string]player.Info
+	if len(spectators) > 0 {
+		spectatorInfo = make(map[string]player.Info, len(spectators))
+		for _, spectator := range spectators {
+			spectatorInfo[string(spectator.ID)] = spectator.Info()
+		}
+	}
+	requireCredentials, err := RequiresCredentials(ctx, client, gameID)
+	if err != nil {
+		return nil, srOtel.WithSetErrorf(span,
+			"getting settings of game %v: %w", gameID, err)
+	}
+	return &Info{
+		ID:                 gameID,
+		Players:            info,
+		GMs:                gms,
+		Spectators:         spectatorInfo,
+		RequireCredentials: requireCredentials,
+	}, nil
 }
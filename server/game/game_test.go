@@ -7,12 +7,22 @@ import (
 	"testing"
 
 	"sr/errs"
+	genCharacter "sr/gen/character"
+	genEvent "sr/gen/event"
 	genGame "sr/gen/game"
 	genPlayer "sr/gen/player"
 
+	"sr/apitoken"
+	"sr/character"
 	"sr/game"
+	"sr/id"
+	"sr/invite"
+	"sr/macro"
 	"sr/player"
 	"sr/test"
+	"sr/timer"
+
+	"github.com/go-redis/redis/v8"
 )
 
 func TestExists(t *testing.T) {
@@ -121,6 +131,32 @@ func (p Players) Swap(i int, j int) {
 	plrs[i], plrs[j] = plrs[j], plrs[i]
 }
 
+func TestHasPlayer(t *testing.T) {
+	ctx := context.Background()
+	rng := test.RNG()
+	_, client := test.GetRedis(t)
+
+	gameID := genGame.GameID(rng)
+	err := game.Create(ctx, client, gameID)
+	test.AssertSuccess(t, err, "game created")
+	plr := genPlayer.Player(rng)
+	err = game.AddPlayer(ctx, client, gameID, plr)
+	test.AssertSuccess(t, err, "player added")
+
+	test.RunParallel(t, "player in game", func(t *testing.T) {
+		found, err := game.HasPlayer(ctx, client, gameID, plr.ID)
+		test.AssertSuccess(t, err, "checking player")
+		test.AssertEqual(t, true, found)
+	})
+
+	test.RunParallel(t, "player not in game", func(t *testing.T) {
+		other := genPlayer.Player(rng)
+		found, err := game.HasPlayer(ctx, client, gameID, other.ID)
+		test.AssertSuccess(t, err, "checking player")
+		test.AssertEqual(t, false, found)
+	})
+}
+
 func TestGetPlayers(t *testing.T) {
 	ctx := context.Background()
 	rng := test.RNG()
@@ -192,6 +228,7 @@ func TestGetInfo(t *testing.T) {
 			test.Must(t, game.AddGM(ctx, client, gameID, gmID))
 			gms[i] = gmID.String()
 		}
+		sort.Strings(gms)
 		expected := &game.Info{
 			ID:      gameID,
 			Players: playerInfo,
@@ -202,3 +239,217 @@ func TestGetInfo(t *testing.T) {
 		test.AssertEqual(t, expected, found)
 	})
 }
+
+func TestCreateWithGM(t *testing.T) {
+	ctx := context.Background()
+	rng := test.RNG()
+	_, client := test.GetRedis(t)
+
+	gameID := genGame.GameID(rng)
+	plr := genPlayer.Player(rng)
+	test.Must(t, player.Create(ctx, client, plr))
+
+	err := game.CreateWithGM(ctx, client, gameID, plr)
+	test.AssertSuccess(t, err, "game created")
+
+	info, err := game.GetInfo(ctx, client, gameID)
+	test.AssertSuccess(t, err, "getting info")
+	test.AssertEqual(t, []string{plr.ID.String()}, info.GMs)
+	_, found := info.Players[plr.ID.String()]
+	test.AssertEqual(t, true, found)
+
+	err = game.CreateWithGM(ctx, client, gameID, plr)
+	test.AssertErrorIs(t, err, errs.ErrBadRequest)
+}
+
+func TestValidID(t *testing.T) {
+	test.AssertEqual(t, true, game.ValidID("seattle-2080"))
+	test.AssertEqual(t, false, game.ValidID("ab"))
+	test.AssertEqual(t, false, game.ValidID("Seattle"))
+	test.AssertEqual(t, false, game.ValidID("game:1"))
+}
+
+func TestSetGM(t *testing.T) {
+	ctx := context.Background()
+	rng := test.RNG()
+	_, client := test.GetRedis(t)
+
+	gameID := genGame.GameID(rng)
+	gm := genPlayer.Player(rng)
+	plr := genPlayer.Player(rng)
+	test.Must(t, player.Create(ctx, client, gm))
+	test.Must(t, player.Create(ctx, client, plr))
+	test.Must(t, game.CreateWithGM(ctx, client, gameID, gm))
+
+	_, err := game.SetGM(ctx, client, gameID, plr.ID, true)
+	test.AssertErrorIs(t, err, errs.ErrNotFound)
+	test.Must(t, game.AddPlayer(ctx, client, gameID, plr))
+
+	gms, err := game.SetGM(ctx, client, gameID, plr.ID, true)
+	test.AssertSuccess(t, err, "promoting player")
+	expected := []string{gm.ID.String(), plr.ID.String()}
+	sort.Strings(expected)
+	test.AssertEqual(t, expected, gms)
+	isGM, err := game.HasGM(ctx, client, gameID, plr.ID)
+	test.AssertSuccess(t, err, "checking GM")
+	test.AssertEqual(t, true, isGM)
+
+	_, err = game.SetGM(ctx, client, gameID, plr.ID, true)
+	test.AssertErrorIs(t, err, errs.ErrBadRequest)
+
+	gms, err = game.SetGM(ctx, client, gameID, gm.ID, false)
+	test.AssertSuccess(t, err, "demoting GM")
+	test.AssertEqual(t, []string{plr.ID.String()}, gms)
+
+	_, err = game.SetGM(ctx, client, gameID, plr.ID, false)
+	test.AssertErrorIs(t, err, errs.ErrBadRequest)
+}
+
+func TestKickPlayer(t *testing.T) {
+	ctx := context.Background()
+	rng := test.RNG()
+	_, client := test.GetRedis(t)
+
+	gameID := genGame.GameID(rng)
+	gm := genPlayer.Player(rng)
+	plr := genPlayer.Player(rng)
+	test.Must(t, player.Create(ctx, client, gm))
+	test.Must(t, player.Create(ctx, client, plr))
+	test.Must(t, game.CreateWithGM(ctx, client, gameID, gm))
+	test.Must(t, game.AddPlayer(ctx, client, gameID, plr))
+	now := id.TimestampNow()
+	token, secret := apitoken.Make(gameID, plr.ID, "Bot", []apitoken.Scope{apitoken.ScopeAdmin}, false, now)
+	test.Must(t, apitoken.Create(ctx, client, &token, secret))
+
+	test.AssertErrorIs(t, game.KickPlayer(ctx, client, gameID, gm.ID), errs.ErrBadRequest)
+	test.Must(t, game.KickPlayer(ctx, client, gameID, plr.ID))
+	_, err := apitoken.Check(ctx, client, secret)
+	test.AssertErrorIs(t, err, errs.ErrNoAccess)
+
+	inGame, err := game.HasPlayer(ctx, client, gameID, plr.ID)
+	test.AssertSuccess(t, err, "checking player")
+	test.AssertEqual(t, false, inGame)
+	test.AssertErrorIs(t, game.KickPlayer(ctx, client, gameID, plr.ID), errs.ErrNotFound)
+}
+
+func TestSpectators(t *testing.T) {
+	ctx := context.Background()
+	rng := test.RNG()
+	_, client := test.GetRedis(t)
+
+	gameID := genGame.GameID(rng)
+	gm := genPlayer.Player(rng)
+	spectator := genPlayer.Player(rng)
+	test.Must(t, player.Create(ctx, client, gm))
+	test.Must(t, player.Create(ctx, client, spectator))
+	test.Must(t, game.CreateWithGM(ctx, client, gameID, gm))
+
+	test.AssertErrorIs(t, game.AddSpectator(ctx, client, gameID, gm), errs.ErrBadRequest)
+	test.Must(t, game.AddSpectator(ctx, client, gameID, spectator))
+
+	info, err := game.GetInfo(ctx, client, gameID)
+	test.AssertSuccess(t, err, "getting info")
+	_, found := info.Players[spectator.ID.String()]
+	test.AssertEqual(t, false, found)
+	test.AssertEqual(t, map[string]player.Info{spectator.ID.String(): spectator.Info()}, info.Spectators)
+
+	test.Must(t, game.KickPlayer(ctx, client, gameID, spectator.ID))
+	spectating, err := game.HasSpectator(ctx, client, gameID, spectator.ID)
+	test.AssertSuccess(t, err, "checking spectator")
+	test.AssertEqual(t, false, spectating)
+}
+
+func TestGetPlayerGames(t *testing.T) {
+	ctx := context.Background()
+	rng := test.RNG()
+	_, client := test.GetRedis(t)
+
+	plr := genPlayer.Player(rng)
+	test.Must(t, player.Create(ctx, client, plr))
+	ownGame := genGame.GameID(rng)
+	test.Must(t, game.CreateWithGM(ctx, client, ownGame, plr))
+
+	gm := genPlayer.Player(rng)
+	test.Must(t, player.Create(ctx, client, gm))
+	watchedGame := genGame.GameID(rng)
+	test.Must(t, game.CreateWithGM(ctx, client, watchedGame, gm))
+	test.Must(t, game.AddSpectator(ctx, client, watchedGame, plr))
+
+	expected := []game.Membership{
+		{GameID: ownGame, GM: true},
+		{GameID: watchedGame, Spectator: true},
+	}
+	sort.Slice(expected, func(i, j int) bool {
+		return expected[i].GameID < expected[j].GameID
+	})
+	games, err := game.GetPlayerGames(ctx, client, plr.ID)
+	test.AssertSuccess(t, err, "getting games")
+	test.AssertEqual(t, expected, games)
+
+	test.Must(t, game.KickPlayer(ctx, client, watchedGame, plr.ID))
+	games, err = game.GetPlayerGames(ctx, client, plr.ID)
+	test.AssertSuccess(t, err, "getting games after kick")
+	test.AssertEqual(t, []game.Membership{{GameID: ownGame, GM: true}}, games)
+}
+
+func TestDelete(t *testing.T) {
+	ctx := context.Background()
+	rng := test.RNG()
+	_, client := test.GetRedis(t)
+
+	gameID := genGame.GameID(rng)
+	gm := genPlayer.Player(rng)
+	spectator := genPlayer.Player(rng)
+	test.Must(t,
+		player.Create(ctx, client, gm),
+		player.Create(ctx, client, spectator),
+		game.CreateWithGM(ctx, client, gameID, gm),
+		game.AddSpectator(ctx, client, gameID, spectator),
+	)
+	now := id.TimestampNow()
+	inv := invite.Make(gameID, gm, 60000, 1, false, now)
+	test.Must(t, invite.Create(ctx, client, &inv, now))
+	char := genCharacter.Character(rng, gameID, gm.ID)
+	roll := genEvent.Roll(rng, gm)
+	tmr := timer.Make(gm, "Security", timer.KindRealTime, 60000, now)
+	token, secret := apitoken.Make(gameID, gm.ID, "Bot", []apitoken.Scope{apitoken.ScopeRoll}, false, now)
+	otherToken, otherSecret := apitoken.Make(genGame.GameID(rng), gm.ID, "Other", []apitoken.Scope{apitoken.ScopeRoll}, false, now)
+	test.Must(t,
+		character.Create(ctx, client, char),
+		game.PostEvent(ctx, client, gameID, &roll),
+		game.CreateTimer(ctx, client, gameID, &tmr),
+		macro.Save(ctx, client, gameID, gm.ID, true, macro.Make("Shoot")),
+		apitoken.Create(ctx, client, &token, secret),
+		apitoken.Create(ctx, client, &otherToken, otherSecret),
+	)
+
+	test.Must(t, game.Delete(ctx, client, gameID))
+	exists, err := game.Exists(ctx, client, gameID)
+	test.AssertSuccess(t, err, "checking game")
+	test.AssertEqual(t, false, exists)
+	spectating, err := game.HasSpectator(ctx, client, gameID, spectator.ID)
+	test.AssertSuccess(t, err, "checking spectator")
+	test.AssertEqual(t, false, spectating)
+	for _, plr := range []*player.Player{gm, spectator} {
+		games, err := client.SCard(ctx, game.PlayerGamesKey(plr.ID)).Result()
+		test.AssertSuccess(t, err, "getting games of player")
+		test.AssertEqual(t, int64(0), games)
+	}
+	_, err = invite.GetByCode(ctx, client, inv.Code)
+	test.AssertErrorIs(t, err, errs.ErrNotFound)
+	leftover, err := client.Exists(ctx,
+		"history:"+gameID, "chars:"+gameID, "char:"+string(char.ID),
+		timer.RedisKey(gameID), macro.RedisKey(gameID, gm.ID),
+	).Result()
+	test.AssertSuccess(t, err, "checking game keys")
+	test.AssertEqual(t, int64(0), leftover)
+	_, err = client.ZScore(ctx, timer.DueKey, timer.DueMember(gameID, tmr.ID)).Result()
+	test.AssertErrorIs(t, err, redis.Nil)
+	_, err = apitoken.Check(ctx, client, secret)
+	test.AssertErrorIs(t, err, errs.ErrNoAccess)
+	tokens, err := apitoken.GetAll(ctx, client, gm.ID)
+	test.AssertSuccess(t, err, "getting API tokens")
+	test.AssertEqual(t, []apitoken.Token{otherToken}, tokens)
+
+	test.AssertErrorIs(t, game.Delete(ctx, client, gameID), errs.ErrNotFound)
+}
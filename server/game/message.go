@@ -0,0 +1,60 @@
+package game
+
+import (
+	"context"
+	"fmt"
+
+	"sr/event"
+	"sr/id"
+	srOtel "sr/otel"
+	"sr/update"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// mentionPackets notifies each of the given players that they were mentioned.
+func mentionPackets(gameID string, message *event.Message, playerIDs []id.UID) []Packet {
+	return playerPackets(gameID, playerIDs, update.ForMention(message))
+}
+
+// PostMessage adds a message to a game and notifies the players it mentions.
+func PostMessage(ctx context.Context, client redis.Cmdable, gameID string, message *event.Message) error {
+	ctx, span := srOtel.Tracer.Start(ctx, "game.PostMessage")
+	defer span.End()
+	packets := mentionPackets(gameID, message, message.Mentions)
+	_, err := client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		if err := postEventCommands(ctx, pipe, gameID, message); err != nil {
+			return err
+		}
+		for ix, packet := range packets {
+			if err := publishPacket(ctx, pipe, &packet); err != nil {
+				return fmt.Errorf("sending mention #%v %#v: %w", ix, packet, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return srOtel.WithSetErrorf(span, "running pipeline: %w", err)
+	}
+	return nil
+}
+
+// NotifyMentions notifies the given players that they were mentioned in a
+// message, such as when they are added to it in an edit.
+func NotifyMentions(ctx context.Context, client redis.Cmdable, gameID string, message *event.Message, playerIDs []id.UID) error {
+	ctx, span := srOtel.Tracer.Start(ctx, "game.NotifyMentions")
+	defer span.End()
+	packets := mentionPackets(gameID, message, playerIDs)
+	_, err := client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for ix, packet := range packets {
+			if err := publishPacket(ctx, pipe, &packet); err != nil {
+				return srOtel.WithSetErrorf(span, "sending packet #%v %#v: %w", ix, packet, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return srOtel.WithSetErrorf(span, "running pipeline: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package game_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	genGame "sr/gen/game"
+	genPlayer "sr/gen/player"
+
+	"sr/event"
+	"sr/game"
+	"sr/id"
+	"sr/test"
+	"sr/update"
+)
+
+func TestPostMessage(t *testing.T) {
+	ctx := context.Background()
+	rng := test.RNG()
+	db, client := test.GetRedis(t)
+
+	gameID := genGame.GameID(rng)
+	test.Must(t, game.Create(ctx, client, gameID))
+	plr := genPlayer.Player(rng)
+	mentioned := genPlayer.Player(rng)
+
+	message := event.ForMessage(plr, event.ShareInGame, "hoi @"+mentioned.Username)
+	message.Mentions = []id.UID{mentioned.ID}
+
+	sub := db.NewSubscriber()
+	defer sub.Close()
+	mentionedChannel := fmt.Sprintf("update:%v:%v", gameID, mentioned.ID)
+	sub.Subscribe(mentionedChannel)
+	defer sub.Unsubscribe(mentionedChannel)
+
+	udBytes, err := update.ForMention(&message).MarshalJSON()
+	test.AssertSuccess(t, err, "update marshalled")
+	wait := test.WaitForMessage(t, sub.Messages(), string(udBytes))
+
+	err = game.PostMessage(ctx, client, gameID, &message)
+	test.AssertSuccess(t, err, "posting message")
+	wait.Wait()
+
+	found, err := event.GetByID(ctx, client, gameID, message.ID)
+	test.AssertSuccess(t, err, "finding message")
+	parsed, err := event.Parse([]byte(found))
+	test.AssertSuccess(t, err, "parsing message")
+	test.AssertEqual(t, &message, parsed)
+}
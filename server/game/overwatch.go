@@ -0,0 +1,243 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"sr/character"
+	"sr/errs"
+	"sr/event"
+	"sr/id"
+	srOtel "sr/otel"
+	"sr/player"
+	redisUtil "sr/redis"
+	"sr/update"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// hiddenOverwatchKey is the hash of the secret parts of the Overwatch Scores
+// of characters in a game, which only GMs may see.
+func hiddenOverwatchKey(gameID string) string {
+	return "overwatch:" + gameID
+}
+
+// getHiddenOverwatch retrieves the secret part of a character's Overwatch Score.
+func getHiddenOverwatch(ctx context.Context, client redis.Cmdable, gameID string, charID id.UID) (int, error) {
+	hidden, err := client.HGet(ctx, hiddenOverwatchKey(gameID), string(charID)).Int()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return hidden, err
+}
+
+// GetOverwatch retrieves a character's full Overwatch Score, including the
+// secret part only GMs may see.
+func GetOverwatch(ctx context.Context, client redis.Cmdable, gameID string, char *character.Character) (int, error) {
+	hidden, err := getHiddenOverwatch(ctx, client, gameID, char.ID)
+	if err != nil {
+		return 0, fmt.Errorf("getting hidden overwatch of %v: %w", char, err)
+	}
+	return char.Overwatch + hidden, nil
+}
+
+// AddOverwatch adds to a character's Overwatch Score, posting an event shared
+// with GMs if the increment is secret and with the game otherwise, and a
+// convergence event if the score reaches the threshold.
+// Events are given IDs after afterID, such as the roll causing the increment.
+// Returns the character and their full Overwatch Score.
+// Returns ErrNotFound if the character is not in the game.
+func AddOverwatch(
+	ctx context.Context, client *redis.Client, gameID string, charID id.UID,
+	plr *player.Player, amount int, secret bool, afterID int64,
+) (*character.Character, int, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "game.AddOverwatch")
+	defer span.End()
+	var char *character.Character
+	var score int
+	watched := func(tx *redis.Tx) error {
+		var commands func(redis.Pipeliner) error
+		var err error
+		char, score, commands, err = overwatchCommands(ctx, tx, gameID, charID, plr, amount, secret, afterID)
+		if err != nil {
+			return err
+		}
+		_, err = tx.TxPipelined(ctx, commands)
+		return err
+	}
+	err := redisUtil.RetryWatchTxn(ctx, client, watched, "char:"+string(charID), hiddenOverwatchKey(gameID))
+	if err != nil {
+		return nil, 0, srOtel.WithSetErrorf(span, "adding overwatch to %v: %w", charID, err)
+	}
+	return char, score, nil
+}
+
+// PostIllegalRoll posts a roll for an illegal Matrix action, answering the
+// roll request if requestID is set, and adds the opposing hits to the
+// character's Overwatch Score in the same transaction.
+// Returns the character and their full Overwatch Score.
+// Returns ErrNotFound if the character is not in the game, and the errors of
+// AnswerRollRequest when answering a request.
+func PostIllegalRoll(
+	ctx context.Context, client *redis.Client, gameID string, roll event.Event, requestID int64,
+	charID id.UID, plr *player.Player, opposingHits int,
+) (*character.Character, int, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "game.PostIllegalRoll")
+	defer span.End()
+	var char *character.Character
+	var score int
+	watched := func(tx *redis.Tx) error {
+		postRoll := func(pipe redis.Pipeliner) error {
+			return postEventCommands(ctx, pipe, gameID, roll)
+		}
+		if requestID != 0 {
+			var err error
+			postRoll, err = answerCommands(ctx, tx, gameID, requestID, roll)
+			if err != nil {
+				return err
+			}
+		}
+		var addOverwatch func(redis.Pipeliner) error
+		var err error
+		char, score, addOverwatch, err = overwatchCommands(ctx, tx, gameID, charID, plr, opposingHits, false, roll.GetID())
+		if err != nil {
+			return err
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			if err := postRoll(pipe); err != nil {
+				return err
+			}
+			return addOverwatch(pipe)
+		})
+		return err
+	}
+	err := redisUtil.RetryWatchTxn(ctx, client, watched,
+		"history:"+gameID, "char:"+string(charID), hiddenOverwatchKey(gameID),
+	)
+	if err != nil {
+		return nil, 0, srOtel.WithSetErrorf(span, "posting illegal roll %v: %w", roll.GetID(), err)
+	}
+	return char, score, nil
+}
+
+// overwatchCommands computes a character's new Overwatch Score, and returns
+// the commands to save it and post its events as part of a transaction
+// watching the character and hiddenOverwatchKey.
+func overwatchCommands(
+	ctx context.Context, tx redis.Cmdable, gameID string, charID id.UID,
+	plr *player.Player, amount int, secret bool, afterID int64,
+) (*character.Character, int, func(redis.Pipeliner) error, error) {
+	char, err := character.GetByID(ctx, tx, charID)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	if char.GameID != gameID {
+		return nil, 0, nil, errs.NotFoundf("character %v in %v", charID, gameID)
+	}
+	hidden, err := getHiddenOverwatch(ctx, tx, gameID, charID)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	before := char.Overwatch + hidden
+	if secret {
+		hidden += amount
+		if hidden < 0 {
+			hidden = 0
+		}
+	} else {
+		char.Overwatch += amount
+		if char.Overwatch < 0 {
+			char.Overwatch = 0
+		}
+	}
+	score := char.Overwatch + hidden
+
+	var evt event.Overwatch
+	if secret {
+		evt = event.ForOverwatch(plr, event.ShareGMs, char.ID, char.Name, amount, score)
+	} else {
+		evt = event.ForOverwatch(plr, event.ShareInGame, char.ID, char.Name, amount, char.Overwatch)
+	}
+	if evt.ID <= afterID {
+		evt.ID = afterID + 1
+	}
+	charBytes, err := json.Marshal(char)
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("marshaling character %v: %w", char, err)
+	}
+	diff := update.ForCharacterDiff(char.ID, map[string]interface{}{"overwatch": char.Overwatch})
+
+	return char, score, func(pipe redis.Pipeliner) error {
+		if secret {
+			if err := pipe.HSet(ctx, hiddenOverwatchKey(gameID), string(charID), hidden).Err(); err != nil {
+				return fmt.Errorf("sending hidden overwatch set: %w", err)
+			}
+		} else {
+			if err := pipe.SetXX(ctx, char.RedisKey(), charBytes, 0).Err(); err != nil {
+				return fmt.Errorf("sending character update: %w", err)
+			}
+			for ix, packet := range characterPackets(gameID, char, diff) {
+				if err := publishPacket(ctx, pipe, &packet); err != nil {
+					return fmt.Errorf("sending packet #%v %#v: %w", ix, packet, err)
+				}
+			}
+		}
+		if err := postEventCommands(ctx, pipe, gameID, &evt); err != nil {
+			return err
+		}
+		if before < character.ConvergenceScore && score >= character.ConvergenceScore {
+			convergence := event.ForConvergence(plr, char.ID, char.Name)
+			convergence.ID = evt.ID + 1
+			return postEventCommands(ctx, pipe, gameID, &convergence)
+		}
+		return nil
+	}, nil
+}
+
+// ResetOverwatch clears a character's Overwatch Score, including its secret part.
+// Returns ErrNotFound if the character is not in the game.
+func ResetOverwatch(ctx context.Context, client *redis.Client, gameID string, charID id.UID) (*character.Character, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "game.ResetOverwatch")
+	defer span.End()
+	charKey := "char:" + string(charID)
+	var char *character.Character
+	watched := func(tx *redis.Tx) error {
+		var err error
+		char, err = character.GetByID(ctx, tx, charID)
+		if err != nil {
+			return err
+		}
+		if char.GameID != gameID {
+			return errs.NotFoundf("character %v in %v", charID, gameID)
+		}
+		char.Overwatch = 0
+		charBytes, err := json.Marshal(char)
+		if err != nil {
+			return fmt.Errorf("marshaling character %v: %w", char, err)
+		}
+		diff := update.ForCharacterDiff(char.ID, map[string]interface{}{"overwatch": 0})
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			if err := pipe.SetXX(ctx, charKey, charBytes, 0).Err(); err != nil {
+				return fmt.Errorf("sending character update: %w", err)
+			}
+			if err := pipe.HDel(ctx, hiddenOverwatchKey(gameID), string(charID)).Err(); err != nil {
+				return fmt.Errorf("sending hidden overwatch clear: %w", err)
+			}
+			for ix, packet := range characterPackets(gameID, char, diff) {
+				if err := publishPacket(ctx, pipe, &packet); err != nil {
+					return fmt.Errorf("sending packet #%v %#v: %w", ix, packet, err)
+				}
+			}
+			return nil
+		})
+		return err
+	}
+	err := redisUtil.RetryWatchTxn(ctx, client, watched, charKey, hiddenOverwatchKey(gameID))
+	if err != nil {
+		return nil, srOtel.WithSetErrorf(span, "resetting overwatch of %v: %w", charID, err)
+	}
+	return char, nil
+}
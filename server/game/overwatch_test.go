@@ -0,0 +1,114 @@
+package game_test
+
+import (
+	"context"
+	"testing"
+
+	genCharacter "sr/gen/character"
+	genEvent "sr/gen/event"
+	genGame "sr/gen/game"
+	genPlayer "sr/gen/player"
+
+	"sr/character"
+	"sr/errs"
+	"sr/event"
+	"sr/game"
+	"sr/id"
+	"sr/test"
+)
+
+func TestAddOverwatch(t *testing.T) {
+	ctx := context.Background()
+	rng := test.RNG()
+	_, client := test.GetRedis(t)
+
+	gameID := genGame.GameID(rng)
+	test.Must(t, game.Create(ctx, client, gameID))
+	gm := genPlayer.Player(rng)
+	plr := genPlayer.Player(rng)
+	char := genCharacter.Character(rng, gameID, plr.ID)
+	test.Must(t, character.Create(ctx, client, char))
+
+	found, score, err := game.AddOverwatch(ctx, client, gameID, char.ID, plr, 12, false, 0)
+	test.AssertSuccess(t, err, "adding overwatch")
+	test.AssertEqual(t, 12, found.Overwatch)
+	test.AssertEqual(t, 12, score)
+
+	t.Run("secret increments are hidden from the character", func(t *testing.T) {
+		found, score, err := game.AddOverwatch(ctx, client, gameID, char.ID, gm, 20, true, 0)
+		test.AssertSuccess(t, err, "adding secret overwatch")
+		test.AssertEqual(t, 12, found.Overwatch)
+		test.AssertEqual(t, 32, score)
+
+		stored, err := character.GetByID(ctx, client, char.ID)
+		test.AssertSuccess(t, err, "getting character")
+		test.AssertEqual(t, 12, stored.Overwatch)
+		full, err := game.GetOverwatch(ctx, client, gameID, stored)
+		test.AssertSuccess(t, err, "getting full overwatch")
+		test.AssertEqual(t, 32, full)
+	})
+
+	t.Run("crossing the threshold converges", func(t *testing.T) {
+		const rollID = int64(1)
+		_, score, err := game.AddOverwatch(ctx, client, gameID, char.ID, plr, 8, false, rollID)
+		test.AssertSuccess(t, err, "adding overwatch")
+		test.AssertEqual(t, character.ConvergenceScore, score)
+
+		latest, err := event.GetLatest(ctx, client, gameID, 1)
+		test.AssertSuccess(t, err, "getting latest event")
+		test.AssertEqual(t, event.EventTypeConvergence, event.ParseTy(latest[0]))
+	})
+
+	t.Run("resetting clears secret increments", func(t *testing.T) {
+		found, err := game.ResetOverwatch(ctx, client, gameID, char.ID)
+		test.AssertSuccess(t, err, "resetting overwatch")
+		test.AssertEqual(t, 0, found.Overwatch)
+		full, err := game.GetOverwatch(ctx, client, gameID, found)
+		test.AssertSuccess(t, err, "getting full overwatch")
+		test.AssertEqual(t, 0, full)
+	})
+}
+
+func TestPostIllegalRoll(t *testing.T) {
+	ctx := context.Background()
+	rng := test.RNG()
+	_, client := test.GetRedis(t)
+
+	gameID := genGame.GameID(rng)
+	test.Must(t, game.Create(ctx, client, gameID))
+	plr := genPlayer.Player(rng)
+	char := genCharacter.Character(rng, gameID, plr.ID)
+	test.Must(t, character.Create(ctx, client, char))
+
+	roll := genEvent.Roll(rng, plr)
+	found, score, err := game.PostIllegalRoll(ctx, client, gameID, &roll, 0, char.ID, plr, 3)
+	test.AssertSuccess(t, err, "posting illegal roll")
+	test.AssertEqual(t, 3, found.Overwatch)
+	test.AssertEqual(t, 3, score)
+	_, err = event.GetByID(ctx, client, gameID, roll.ID)
+	test.AssertSuccess(t, err, "finding roll")
+
+	t.Run("answering a request", func(t *testing.T) {
+		request := event.ForRollRequest(genPlayer.Player(rng), "Hack", []id.UID{plr.ID}, 0, false)
+		test.Must(t, game.PostRollRequest(ctx, client, gameID, &request))
+		answer := genEvent.Roll(rng, plr)
+		answer.ID = request.ID + 1
+		_, score, err := game.PostIllegalRoll(ctx, client, gameID, &answer, request.ID, char.ID, plr, 2)
+		test.AssertSuccess(t, err, "answering with illegal roll")
+		test.AssertEqual(t, 5, score)
+		answered, err := game.GetRollRequest(ctx, client, gameID, request.ID)
+		test.AssertSuccess(t, err, "getting request")
+		test.AssertEqual(t, []id.UID{plr.ID}, answered.Answered)
+	})
+
+	t.Run("rolls for other games' characters are not posted", func(t *testing.T) {
+		other := genCharacter.Character(rng, genGame.GameID(rng), plr.ID)
+		test.Must(t, character.Create(ctx, client, other))
+		roll := genEvent.Roll(rng, plr)
+		roll.ID = -1 // An ID no earlier event in the game has
+		_, _, err := game.PostIllegalRoll(ctx, client, gameID, &roll, 0, other.ID, plr, 2)
+		test.AssertErrorIs(t, err, errs.ErrNotFound)
+		_, err = event.GetByID(ctx, client, gameID, roll.ID)
+		test.AssertErrorIs(t, err, errs.ErrNotFound)
+	})
+}
@@ -3,15 +3,34 @@ package game
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
 
+	"sr/apitoken"
+	"sr/errs"
 	"sr/id"
 	srOtel "sr/otel"
 	"sr/player"
+	redisUtil "sr/redis"
 	"sr/update"
 
 	"github.com/go-redis/redis/v8"
 )
 
+// addMemberCommands sends the commands to add a player to a game's players, or
+// its spectators, as part of a larger transaction. Returns the command adding
+// them to the game.
+func addMemberCommands(ctx context.Context, pipe redis.Pipeliner, gameID string, playerID id.UID, spectator bool) *redis.IntCmd {
+	membersKey := "players:" + gameID
+	if spectator {
+		membersKey = "spectators:" + gameID
+	}
+	added := pipe.SAdd(ctx, membersKey, playerID.String())
+	pipe.SAdd(ctx, PlayerGamesKey(playerID), gameID)
+	return added
+}
+
 // AddPlayer adds a player to a given game.
 func AddPlayer(ctx context.Context, client redis.Cmdable, gameID string, player *player.Player) error {
 	ctx, span := srOtel.Tracer.Start(ctx, "game.AddPlayer")
@@ -24,7 +43,7 @@ func AddPlayer(ctx context.Context, client redis.Cmdable, gameID string, player
 	var added *redis.IntCmd
 	var published *redis.IntCmd
 	_, err = client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
-		added = pipe.SAdd(ctx, "players:"+gameID, player.ID)
+		added = addMemberCommands(ctx, pipe, gameID, player.ID, false)
 		published = pipe.Publish(ctx, "update:"+gameID, updateBytes)
 		return nil
 	})
@@ -41,6 +60,49 @@ func AddPlayer(ctx context.Context, client redis.Cmdable, gameID string, player
 	return nil
 }
 
+// AddSpectator adds a player to a game as a spectator, who may follow the
+// game without taking part in it. Spectators are not sent updates about them.
+// Returns ErrBadRequest if the player is already in the game.
+func AddSpectator(ctx context.Context, client redis.Cmdable, gameID string, plr *player.Player) error {
+	ctx, span := srOtel.Tracer.Start(ctx, "game.AddSpectator")
+	defer span.End()
+	inGame, err := HasPlayer(ctx, client, gameID, plr.ID)
+	if err != nil {
+		return srOtel.WithSetErrorf(span, "checking player in game: %w", err)
+	}
+	if inGame {
+		return errs.BadRequestf("player %v is already in %v", plr.ID, gameID)
+	}
+	_, err = client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		addMemberCommands(ctx, pipe, gameID, plr.ID, true)
+		return nil
+	})
+	if err != nil {
+		return srOtel.WithSetErrorf(span, "adding spectator: %w", err)
+	}
+	return nil
+}
+
+// GetSpectators gets the spectators of a game.
+func GetSpectators(ctx context.Context, client redis.Cmdable, gameID string) ([]player.Player, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "game.GetSpectators")
+	defer span.End()
+	spectatorIDs, err := client.SMembers(ctx, "spectators:"+gameID).Result()
+	if err != nil {
+		return nil, srOtel.WithSetErrorf(span, "getting spectators:gameID: %w", err)
+	}
+	spectators := make([]player.Player, 0, len(spectatorIDs))
+	for _, spectatorID := range spectatorIDs {
+		plr, err := player.GetByID(ctx, client, spectatorID)
+		if err != nil {
+			return nil, srOtel.WithSetErrorf(span,
+				"getting info on spectator %v: %w", spectatorID, err)
+		}
+		spectators = append(spectators, *plr)
+	}
+	return spectators, nil
+}
+
 // UpdatePlayerConnections tracks a player's online status, and updates the game accordingly.
 func UpdatePlayerConnections(ctx context.Context, client redis.Cmdable, gameID string, playerID id.UID, mod int) (int64, error) {
 	ctx, span := srOtel.Tracer.Start(ctx, "game.UpdatePlayerConnections")
@@ -78,8 +140,8 @@ func UpdatePlayerConnections(ctx context.Context, client redis.Cmdable, gameID s
 }
 
 // UpdatePlayer updates a player in the database.
-// It does not allow for username updates. It only publishes the update to the given game.
-func UpdatePlayer(ctx context.Context, client redis.Cmdable, gameID string, playerID id.UID, externalUpdate update.Player, internalUpdate update.Player) error {
+// It does not allow for username updates. It only publishes the update to the given games.
+func UpdatePlayer(ctx context.Context, client redis.Cmdable, gameIDs []string, playerID id.UID, externalUpdate update.Player, internalUpdate update.Player) error {
 	ctx, span := srOtel.Tracer.Start(ctx, "game.UpdatePlayer")
 	defer span.End()
 	if internalUpdate.IsEmpty() && externalUpdate.IsEmpty() {
@@ -100,7 +162,9 @@ func UpdatePlayer(ctx context.Context, client redis.Cmdable, gameID string, play
 			if err != nil {
 				return srOtel.WithSetErrorf(span, "unable to marshal update to JSON: %w", err)
 			}
-			_ = pipe.Publish(ctx, "update:"+gameID, updateBytes)
+			for _, gameID := range gameIDs {
+				_ = pipe.Publish(ctx, "update:"+gameID, updateBytes)
+			}
 		}
 		return nil
 	})
@@ -109,3 +173,224 @@ func UpdatePlayer(ctx context.Context, client redis.Cmdable, gameID string, play
 	}
 	return nil
 }
+
+// SetGM promotes a player in a game to GM, or demotes them to a regular
+// player, and returns the new list of GMs.
+// Returns ErrNotFound if the player is not in the game, and ErrBadRequest if
+// they already have the role or are the game's last GM.
+func SetGM(ctx context.Context, client *redis.Client, gameID string, playerID id.UID, isGM bool) ([]string, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "game.SetGM")
+	defer span.End()
+	var newGMs []string
+
+	watched := func(tx *redis.Tx) error {
+		inGame, err := HasPlayer(ctx, tx, gameID, playerID)
+		if err != nil {
+			return err
+		}
+		if !inGame {
+			return errs.NotFoundf("player %v in %v", playerID, gameID)
+		}
+		gms, err := GetGMs(ctx, tx, gameID)
+		if err != nil {
+			return err
+		}
+		if IsGM(gms, playerID) == isGM {
+			return errs.BadRequestf("player %v already has that role", playerID)
+		}
+		newGMs = make([]string, 0, len(gms)+1)
+		if isGM {
+			newGMs = append(newGMs, gms...)
+			newGMs = append(newGMs, playerID.String())
+			sort.Strings(newGMs)
+		} else {
+			if len(gms) == 1 {
+				return errs.BadRequestf("cannot demote the last GM")
+			}
+			for _, gmID := range gms {
+				if gmID != playerID.String() {
+					newGMs = append(newGMs, gmID)
+				}
+			}
+		}
+		updateBytes, err := json.Marshal(update.ForGMs(newGMs))
+		if err != nil {
+			return srOtel.WithSetErrorf(span, "marshal update to JSON: %w", err)
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			if isGM {
+				pipe.SAdd(ctx, "gms:"+gameID, playerID.String())
+			} else {
+				pipe.SRem(ctx, "gms:"+gameID, playerID.String())
+			}
+			pipe.Publish(ctx, GameChannel(gameID), updateBytes)
+			return nil
+		})
+		return err
+	}
+	err := redisUtil.RetryWatchTxn(ctx, client, watched, "players:"+gameID, "gms:"+gameID)
+	if errors.Is(err, errs.ErrNotFound) || errors.Is(err, errs.ErrBadRequest) {
+		return nil, err
+	} else if err != nil {
+		return nil, srOtel.WithSetErrorf(span, "running transaction: %w", err)
+	}
+	return newGMs, nil
+}
+
+// KickPlayer removes a player or spectator from a game, along with their GM
+// role and API tokens for the game, and notifies the game's players. Their
+// sessions are not affected.
+// Returns ErrNotFound if the player is not in the game, and ErrBadRequest if
+// they are the game's last GM.
+func KickPlayer(ctx context.Context, client *redis.Client, gameID string, playerID id.UID) error {
+	ctx, span := srOtel.Tracer.Start(ctx, "game.KickPlayer")
+	defer span.End()
+	delBytes, err := json.Marshal(update.ForPlayerDel(playerID))
+	if err != nil {
+		return srOtel.WithSetErrorf(span, "marshal update to JSON: %w", err)
+	}
+
+	watched := func(tx *redis.Tx) error {
+		inGame, err := HasPlayer(ctx, tx, gameID, playerID)
+		if err != nil {
+			return err
+		}
+		tokens, err := apitoken.GetHashesIn(ctx, tx, gameID, playerID)
+		if err != nil {
+			return err
+		}
+		if !inGame {
+			spectating, err := HasSpectator(ctx, tx, gameID, playerID)
+			if err != nil {
+				return err
+			}
+			if !spectating {
+				return errs.NotFoundf("player %v in %v", playerID, gameID)
+			}
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				pipe.SRem(ctx, "spectators:"+gameID, playerID.String())
+				pipe.SRem(ctx, PlayerGamesKey(playerID), gameID)
+				apitoken.RevokeCommands(ctx, pipe, playerID, tokens)
+				pipe.Publish(ctx, GameChannel(gameID), delBytes)
+				return nil
+			})
+			return err
+		}
+		gms, err := GetGMs(ctx, tx, gameID)
+		if err != nil {
+			return err
+		}
+		wasGM := IsGM(gms, playerID)
+		if wasGM && len(gms) == 1 {
+			return errs.BadRequestf("cannot remove the last GM")
+		}
+		newGMs := make([]string, 0, len(gms))
+		for _, gmID := range gms {
+			if gmID != playerID.String() {
+				newGMs = append(newGMs, gmID)
+			}
+		}
+		gmsBytes, err := json.Marshal(update.ForGMs(newGMs))
+		if err != nil {
+			return srOtel.WithSetErrorf(span, "marshal update to JSON: %w", err)
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.SRem(ctx, "players:"+gameID, playerID.String())
+			pipe.SRem(ctx, PlayerGamesKey(playerID), gameID)
+			apitoken.RevokeCommands(ctx, pipe, playerID, tokens)
+			if wasGM {
+				pipe.SRem(ctx, "gms:"+gameID, playerID.String())
+				pipe.Publish(ctx, GameChannel(gameID), gmsBytes)
+			}
+			pipe.Publish(ctx, GameChannel(gameID), delBytes)
+			return nil
+		})
+		return err
+	}
+	err = redisUtil.RetryWatchTxn(ctx, client, watched,
+		"players:"+gameID, "gms:"+gameID, "spectators:"+gameID,
+		apitoken.PlayerKey(playerID),
+	)
+	if errors.Is(err, errs.ErrNotFound) || errors.Is(err, errs.ErrBadRequest) {
+		return err
+	} else if err != nil {
+		return srOtel.WithSetErrorf(span, "running transaction: %w", err)
+	}
+	return nil
+}
+
+// PlayerGamesKey is the key of the set of games a player is in or spectating.
+func PlayerGamesKey(playerID id.UID) string {
+	return "games:" + string(playerID)
+}
+
+// Membership is a game a player is in, and their role in it.
+type Membership struct {
+	GameID    string `json:"id"`
+	GM        bool   `json:"gm"`
+	Spectator bool   `json:"spectator"`
+}
+
+// GetPlayerGames gets the games a player is in or spectating, sorted by ID.
+func GetPlayerGames(ctx context.Context, client redis.Cmdable, playerID id.UID) ([]Membership, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "game.GetPlayerGames")
+	defer span.End()
+	gameIDs, err := client.SMembers(ctx, PlayerGamesKey(playerID)).Result()
+	if err != nil {
+		return nil, srOtel.WithSetErrorf(span, "getting games of player: %w", err)
+	}
+	sort.Strings(gameIDs)
+	games := make([]Membership, 0, len(gameIDs))
+	for _, gameID := range gameIDs {
+		inGame, err := HasPlayer(ctx, client, gameID, playerID)
+		if err != nil {
+			return nil, srOtel.WithSetErrorf(span, "checking %v: %w", gameID, err)
+		}
+		if inGame {
+			isGM, err := HasGM(ctx, client, gameID, playerID)
+			if err != nil {
+				return nil, srOtel.WithSetErrorf(span, "checking GM of %v: %w", gameID, err)
+			}
+			games = append(games, Membership{GameID: gameID, GM: isGM})
+			continue
+		}
+		spectating, err := HasSpectator(ctx, client, gameID, playerID)
+		if err != nil {
+			return nil, srOtel.WithSetErrorf(span, "checking spectator of %v: %w", gameID, err)
+		}
+		if spectating {
+			games = append(games, Membership{GameID: gameID, Spectator: true})
+		}
+	}
+	return games, nil
+}
+
+// IndexPlayerGames adds every game's players and spectators to their
+// PlayerGamesKey, for games they joined before it was kept. Returns the number
+// of games indexed.
+func IndexPlayerGames(ctx context.Context, client redis.Cmdable) (int, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "game.IndexPlayerGames")
+	defer span.End()
+	indexed := 0
+	for _, pattern := range []string{"players:*", "spectators:*"} {
+		iter := client.Scan(ctx, 0, pattern, 100).Iterator()
+		for iter.Next(ctx) {
+			key := iter.Val()
+			gameID := key[strings.Index(key, ":")+1:]
+			memberIDs, err := client.SMembers(ctx, key).Result()
+			if err != nil {
+				return indexed, srOtel.WithSetErrorf(span, "getting %v: %w", key, err)
+			}
+			for _, memberID := range memberIDs {
+				if err := client.SAdd(ctx, PlayerGamesKey(id.UID(memberID)), gameID).Err(); err != nil {
+					return indexed, srOtel.WithSetErrorf(span, "indexing %v: %w", memberID, err)
+				}
+			}
+			indexed++
+		}
+		if err := iter.Err(); err != nil {
+			return indexed, srOtel.WithSetErrorf(span, "scanning %v: %w", pattern, err)
+		}
+	}
+	return indexed, nil
+}
@@ -0,0 +1,80 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"sr/config"
+	"sr/errs"
+	"sr/event"
+	"sr/id"
+	srOtel "sr/otel"
+	"sr/player"
+	redisUtil "sr/redis"
+	"sr/update"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// ToggleReaction adds or removes a player's reaction on an event, and sends
+// the event's new reactions to the players who can see it.
+// Returns whether the reaction was added.
+// Returns ErrNotFound if the event does not exist, ErrNoAccess if the player
+// cannot see the event, and ErrBadRequest if the event has too many reactions.
+func ToggleReaction(
+	ctx context.Context, client *redis.Client, gameID string, eventID int64,
+	reaction string, plr *player.Player, isGM bool,
+) (bool, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "game.ToggleReaction")
+	defer span.End()
+	var added bool
+	watched := func(tx *redis.Tx) error {
+		eventText, err := event.GetByID(ctx, tx, gameID, eventID)
+		if err != nil {
+			return err
+		}
+		evt, err := event.Parse([]byte(eventText))
+		if err != nil {
+			return fmt.Errorf("%w: parsing event %v: %v", errs.ErrParse, eventID, err)
+		}
+		if !PlayerCanSeeEvent(plr, isGM, evt) {
+			return errs.NoAccessf("player %v cannot see event %v", plr.ID, eventID)
+		}
+		added = evt.ToggleReaction(reaction, plr.ID)
+		if len(evt.GetReactions()) > config.MaxEventReactions {
+			return errs.BadRequestf("event %v has too many reactions", eventID)
+		}
+		eventBytes, err := json.Marshal(evt)
+		if err != nil {
+			return fmt.Errorf("marshaling event %v: %w", eventID, err)
+		}
+		reactions := evt.GetReactions()
+		if reactions == nil {
+			reactions = map[string][]id.UID{}
+		}
+		diff := update.ForEventDiff(evt, map[string]interface{}{"reactions": reactions})
+		packets := updatePackets(gameID, evt, diff)
+		eventIDStr := fmt.Sprintf("%v", eventID)
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			if err := pipe.ZRemRangeByScore(ctx, "history:"+gameID, eventIDStr, eventIDStr).Err(); err != nil {
+				return fmt.Errorf("sending event delete: %w", err)
+			}
+			if err := pipe.ZAddNX(ctx, "history:"+gameID, &redis.Z{Score: float64(eventID), Member: eventBytes}).Err(); err != nil {
+				return fmt.Errorf("sending event add: %w", err)
+			}
+			for ix, packet := range packets {
+				if err := publishPacket(ctx, pipe, &packet); err != nil {
+					return fmt.Errorf("sending packet #%v %#v: %w", ix, packet, err)
+				}
+			}
+			return nil
+		})
+		return err
+	}
+	if err := redisUtil.RetryWatchTxn(ctx, client, watched, "history:"+gameID); err != nil {
+		return false, srOtel.WithSetErrorf(span, "toggling reaction on %v: %w", eventID, err)
+	}
+	return added, nil
+}
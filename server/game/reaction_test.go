@@ -0,0 +1,72 @@
+package game_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	genEvent "sr/gen/event"
+	genGame "sr/gen/game"
+	genPlayer "sr/gen/player"
+
+	"sr/config"
+	"sr/errs"
+	"sr/event"
+	"sr/game"
+	"sr/id"
+	"sr/test"
+)
+
+func TestToggleReaction(t *testing.T) {
+	ctx := context.Background()
+	rng := test.RNG()
+	_, client := test.GetRedis(t)
+
+	gameID := genGame.GameID(rng)
+	test.Must(t, game.Create(ctx, client, gameID))
+	plr := genPlayer.Player(rng)
+	other := genPlayer.Player(rng)
+
+	roll := genEvent.Roll(rng, plr)
+	roll.SetShare(event.ShareInGame)
+	test.Must(t, game.PostEvent(ctx, client, gameID, &roll))
+
+	t.Run("toggles a reaction", func(t *testing.T) {
+		added, err := game.ToggleReaction(ctx, client, gameID, roll.ID, "nice", other, false)
+		test.AssertSuccess(t, err, "adding reaction")
+		test.AssertEqual(t, true, added)
+
+		found, err := event.GetByID(ctx, client, gameID, roll.ID)
+		test.AssertSuccess(t, err, "finding event")
+		parsed, err := event.Parse([]byte(found))
+		test.AssertSuccess(t, err, "parsing event")
+		test.AssertEqual(t, map[string][]id.UID{"nice": {other.ID}}, parsed.GetReactions())
+
+		added, err = game.ToggleReaction(ctx, client, gameID, roll.ID, "nice", other, false)
+		test.AssertSuccess(t, err, "removing reaction")
+		test.AssertEqual(t, false, added)
+	})
+
+	t.Run("hidden events cannot be reacted to", func(t *testing.T) {
+		private := genEvent.Roll(rng, plr)
+		private.ID = roll.ID + 1
+		private.SetShare(event.SharePrivate)
+		test.Must(t, game.PostEvent(ctx, client, gameID, &private))
+		_, err := game.ToggleReaction(ctx, client, gameID, private.ID, "nice", other, true)
+		test.AssertErrorIs(t, err, errs.ErrNoAccess)
+	})
+
+	t.Run("missing events cannot be reacted to", func(t *testing.T) {
+		_, err := game.ToggleReaction(ctx, client, gameID, roll.ID+2, "nice", other, false)
+		test.AssertErrorIs(t, err, errs.ErrNotFound)
+	})
+
+	t.Run("reactions are limited", func(t *testing.T) {
+		for i := 0; i < config.MaxEventReactions; i++ {
+			_, err := game.ToggleReaction(ctx, client, gameID, roll.ID, fmt.Sprintf("r%v", i), other, false)
+			test.AssertSuccess(t, err, "adding reaction")
+		}
+		_, err := game.ToggleReaction(ctx, client, gameID, roll.ID, "one too many", other, false)
+		test.AssertErrorIs(t, err, errs.ErrBadRequest)
+	})
+}
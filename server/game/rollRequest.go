@@ -0,0 +1,118 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"sr/errs"
+	"sr/event"
+	srOtel "sr/otel"
+	redisUtil "sr/redis"
+	"sr/update"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// PostRollRequest adds a roll request to a game and prompts its targets to roll.
+func PostRollRequest(ctx context.Context, client redis.Cmdable, gameID string, request *event.RollRequest) error {
+	ctx, span := srOtel.Tracer.Start(ctx, "game.PostRollRequest")
+	defer span.End()
+	prompt := update.ForRollPrompt(request)
+	_, err := client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		if err := postEventCommands(ctx, pipe, gameID, request); err != nil {
+			return err
+		}
+		for _, target := range request.Targets {
+			packet := Packet{PlayerChannel(gameID, target), []string{}, prompt}
+			if err := publishPacket(ctx, pipe, &packet); err != nil {
+				return fmt.Errorf("sending prompt to %v: %w", target, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return srOtel.WithSetErrorf(span, "running pipeline: %w", err)
+	}
+	return nil
+}
+
+// GetRollRequest retrieves the given roll request from a game.
+// Returns ErrNotFound if the event does not exist, and ErrBadRequest if it is
+// not a roll request.
+func GetRollRequest(ctx context.Context, client redis.Cmdable, gameID string, requestID int64) (*event.RollRequest, error) {
+	eventText, err := event.GetByID(ctx, client, gameID, requestID)
+	if err != nil {
+		return nil, err
+	}
+	evt, err := event.Parse([]byte(eventText))
+	if err != nil {
+		return nil, fmt.Errorf("%w: parsing event %v: %v", errs.ErrParse, requestID, err)
+	}
+	request, ok := evt.(*event.RollRequest)
+	if !ok {
+		return nil, errs.BadRequestf("event %v is not a roll request", requestID)
+	}
+	return request, nil
+}
+
+// AnswerRollRequest posts the given answer to a roll request, and marks the
+// answering player as having answered the request.
+// Returns ErrNoAccess if the player was not asked to roll, and ErrBadRequest if
+// they have already answered.
+func AnswerRollRequest(ctx context.Context, client *redis.Client, gameID string, requestID int64, answer event.Event) error {
+	ctx, span := srOtel.Tracer.Start(ctx, "game.AnswerRollRequest")
+	defer span.End()
+	watched := func(tx *redis.Tx) error {
+		commands, err := answerCommands(ctx, tx, gameID, requestID, answer)
+		if err != nil {
+			return err
+		}
+		_, err = tx.TxPipelined(ctx, commands)
+		return err
+	}
+	if err := redisUtil.RetryWatchTxn(ctx, client, watched, "history:"+gameID); err != nil {
+		return srOtel.WithSetErrorf(span, "answering roll request %v: %w", requestID, err)
+	}
+	return nil
+}
+
+// answerCommands checks that the answer may be posted to the roll request,
+// and returns the commands to post it and mark the request answered as part
+// of a transaction watching the game's history.
+func answerCommands(
+	ctx context.Context, tx redis.Cmdable, gameID string, requestID int64, answer event.Event,
+) (func(redis.Pipeliner) error, error) {
+	request, err := GetRollRequest(ctx, tx, gameID, requestID)
+	if err != nil {
+		return nil, err
+	}
+	if err := request.CheckAnswer(answer.GetPlayerID()); err != nil {
+		return nil, err
+	}
+	request.Answered = append(request.Answered, answer.GetPlayerID())
+	requestBytes, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling roll request %v: %w", requestID, err)
+	}
+	answeredPackets := updatePackets(gameID, request, update.ForRollRequestAnswered(request))
+	requestIDStr := fmt.Sprintf("%v", requestID)
+
+	return func(pipe redis.Pipeliner) error {
+		if err := postEventCommands(ctx, pipe, gameID, answer); err != nil {
+			return err
+		}
+		if err := pipe.ZRemRangeByScore(ctx, "history:"+gameID, requestIDStr, requestIDStr).Err(); err != nil {
+			return fmt.Errorf("sending request delete: %w", err)
+		}
+		if err := pipe.ZAddNX(ctx, "history:"+gameID, &redis.Z{Score: float64(requestID), Member: requestBytes}).Err(); err != nil {
+			return fmt.Errorf("sending request add: %w", err)
+		}
+		for ix, packet := range answeredPackets {
+			if err := publishPacket(ctx, pipe, &packet); err != nil {
+				return fmt.Errorf("sending answered packet #%v %#v: %w", ix, packet, err)
+			}
+		}
+		return nil
+	}, nil
+}
@@ -0,0 +1,75 @@
+package game_test
+
+import (
+	"context"
+	"testing"
+
+	genEvent "sr/gen/event"
+	genGame "sr/gen/game"
+	genPlayer "sr/gen/player"
+
+	"sr/errs"
+	"sr/event"
+	"sr/game"
+	"sr/id"
+	"sr/test"
+)
+
+func TestAnswerRollRequest(t *testing.T) {
+	ctx := context.Background()
+	rng := test.RNG()
+	_, client := test.GetRedis(t)
+
+	gameID := genGame.GameID(rng)
+	test.Must(t, game.Create(ctx, client, gameID))
+	gm := genPlayer.Player(rng)
+	plr := genPlayer.Player(rng)
+	other := genPlayer.Player(rng)
+
+	request := event.ForRollRequest(gm, "Perception", []id.UID{plr.ID}, 2, false)
+	test.Must(t, game.PostRollRequest(ctx, client, gameID, &request))
+
+	answer := genEvent.Roll(rng, plr)
+	answer.ID = request.ID + 1
+	err := game.AnswerRollRequest(ctx, client, gameID, request.ID, &answer)
+	test.AssertSuccess(t, err, "answering roll request")
+
+	found, err := game.GetRollRequest(ctx, client, gameID, request.ID)
+	test.AssertSuccess(t, err, "finding roll request")
+	test.AssertEqual(t, []id.UID{plr.ID}, found.Answered)
+	test.AssertEqual(t, []id.UID{}, found.Unanswered())
+
+	t.Run("answering twice", func(t *testing.T) {
+		again := genEvent.Roll(rng, plr)
+		again.ID = request.ID + 2
+		err := game.AnswerRollRequest(ctx, client, gameID, request.ID, &again)
+		test.AssertErrorIs(t, err, errs.ErrBadRequest)
+	})
+
+	t.Run("answering when not asked", func(t *testing.T) {
+		notAsked := genEvent.Roll(rng, other)
+		notAsked.ID = request.ID + 3
+		err := game.AnswerRollRequest(ctx, client, gameID, request.ID, &notAsked)
+		test.AssertErrorIs(t, err, errs.ErrNoAccess)
+	})
+
+	t.Run("answering a whispered request", func(t *testing.T) {
+		whispered := event.ForRollRequest(gm, "Stealth", []id.UID{plr.ID}, 0, false)
+		whispered.ID = request.ID + 10
+		test.Must(t,
+			game.PostRollRequest(ctx, client, gameID, &whispered),
+			game.UpdateEventShare(ctx, client, gameID, &whispered, event.ShareWhisper, []id.UID{plr.ID}),
+		)
+		whisperAnswer := genEvent.Roll(rng, plr)
+		whisperAnswer.ID = whispered.ID + 1
+		err := game.AnswerRollRequest(ctx, client, gameID, whispered.ID, &whisperAnswer)
+		test.AssertSuccess(t, err, "answering whispered request")
+	})
+
+	t.Run("answering a non-request", func(t *testing.T) {
+		notAsked := genEvent.Roll(rng, other)
+		notAsked.ID = request.ID + 4
+		err := game.AnswerRollRequest(ctx, client, gameID, answer.ID, &notAsked)
+		test.AssertErrorIs(t, err, errs.ErrBadRequest)
+	})
+}
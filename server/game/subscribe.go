@@ -17,7 +17,9 @@ import (
 // and errors to the error channel. Both channels will be closed upon completion.
 // ctx is used to cancel the remote task and must also have been initialized with a redis connection.
 // As noted in redis client.Subscribe(), the subscription is not immediately active.
-func Subscribe(ctx context.Context, client *redis.Client, gameID string, playerID id.UID, isGM bool) (<-chan *redis.Message, <-chan error, func()) {
+// Messages on sessionChannel, which are not game updates, are also received if
+// it is not empty.
+func Subscribe(ctx context.Context, client *redis.Client, gameID string, playerID id.UID, isGM bool, sessionChannel string) (<-chan *redis.Message, <-chan error, func()) {
 	ctx, span := srOtel.Tracer.Start(ctx, "game.Subscribe")
 	defer span.End()
 	channels := []string{
@@ -26,7 +28,21 @@ func Subscribe(ctx context.Context, client *redis.Client, gameID string, playerI
 	if isGM {
 		channels = append(channels, GMsChannel(gameID))
 	}
+	return subscribe(ctx, client, gameID, playerID, channels, sessionChannel)
+}
 
+// SubscribeSpectator is Subscribe for spectators, who only receive updates
+// broadcast to the whole game.
+func SubscribeSpectator(ctx context.Context, client *redis.Client, gameID string, playerID id.UID, sessionChannel string) (<-chan *redis.Message, <-chan error, func()) {
+	ctx, span := srOtel.Tracer.Start(ctx, "game.SubscribeSpectator")
+	defer span.End()
+	return subscribe(ctx, client, gameID, playerID, []string{GameChannel(gameID)}, sessionChannel)
+}
+
+func subscribe(ctx context.Context, client *redis.Client, gameID string, playerID id.UID, channels []string, sessionChannel string) (<-chan *redis.Message, <-chan error, func()) {
+	if sessionChannel != "" {
+		channels = append(channels, sessionChannel)
+	}
 	sub := client.Subscribe(ctx, channels...)
 	updates := sub.Channel(
 		redis.WithChannelHealthCheckInterval(time.Duration(config.RedisHealthcheckSecs) * time.Second),
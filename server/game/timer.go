@@ -0,0 +1,272 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"sr/errs"
+	"sr/event"
+	"sr/id"
+	"sr/log"
+	srOtel "sr/otel"
+	"sr/player"
+	redisUtil "sr/redis"
+	"sr/timer"
+	"sr/update"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// TimerPollInterval is how often running timers are checked for expiry.
+const TimerPollInterval = time.Second
+
+// saveTimer sends the commands to store a timer and schedule its expiry.
+func saveTimer(ctx context.Context, pipe redis.Pipeliner, gameID string, t *timer.Timer, ud update.Update) error {
+	timerBytes, err := json.Marshal(t)
+	if err != nil {
+		return fmt.Errorf("marshaling timer %v: %w", t, err)
+	}
+	if err := pipe.HSet(ctx, timer.RedisKey(gameID), string(t.ID), timerBytes).Err(); err != nil {
+		return fmt.Errorf("sending timer set: %w", err)
+	}
+	member := timer.DueMember(gameID, t.ID)
+	if t.IsRunning() {
+		err = pipe.ZAdd(ctx, timer.DueKey, &redis.Z{Score: float64(t.Ends), Member: member}).Err()
+	} else {
+		err = pipe.ZRem(ctx, timer.DueKey, member).Err()
+	}
+	if err != nil {
+		return fmt.Errorf("sending timer schedule: %w", err)
+	}
+	return publishPacket(ctx, pipe, &Packet{GameChannel(gameID), []string{}, ud})
+}
+
+// removeTimer sends the commands to remove a timer.
+func removeTimer(ctx context.Context, pipe redis.Pipeliner, gameID string, timerID id.UID) error {
+	if err := pipe.HDel(ctx, timer.RedisKey(gameID), string(timerID)).Err(); err != nil {
+		return fmt.Errorf("sending timer delete: %w", err)
+	}
+	if err := pipe.ZRem(ctx, timer.DueKey, timer.DueMember(gameID, timerID)).Err(); err != nil {
+		return fmt.Errorf("sending timer unschedule: %w", err)
+	}
+	return publishPacket(ctx, pipe, &Packet{GameChannel(gameID), []string{}, update.ForTimerDelete(timerID)})
+}
+
+// expireTimer sends the commands to remove a timer and post the event for it
+// running out with the given ID.
+func expireTimer(ctx context.Context, pipe redis.Pipeliner, gameID string, t *timer.Timer, eventID int64) error {
+	if err := removeTimer(ctx, pipe, gameID, t.ID); err != nil {
+		return err
+	}
+	evt := event.ForTimer(&player.Player{ID: t.PlayerID, Name: t.PlayerName}, t.ID, t.Title)
+	evt.ID = eventID
+	return postEventCommands(ctx, pipe, gameID, &evt)
+}
+
+// CreateTimer starts a timer in a game.
+// Returns ErrBadRequest if the game has too many timers.
+func CreateTimer(ctx context.Context, client *redis.Client, gameID string, t *timer.Timer) error {
+	ctx, span := srOtel.Tracer.Start(ctx, "game.CreateTimer")
+	defer span.End()
+	key := timer.RedisKey(gameID)
+	watched := func(tx *redis.Tx) error {
+		count, err := tx.HLen(ctx, key).Result()
+		if err != nil {
+			return err
+		}
+		if count >= timer.MaxTimers {
+			return errs.BadRequestf("cannot have more than %v timers", timer.MaxTimers)
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			return saveTimer(ctx, pipe, gameID, t, update.ForTimerNew(t))
+		})
+		return err
+	}
+	if err := redisUtil.RetryWatchTxn(ctx, client, watched, key); err != nil {
+		return srOtel.WithSetErrorf(span, "creating timer %v: %w", t, err)
+	}
+	return nil
+}
+
+// ModifyTimer atomically changes a timer in a game, expiring it if it has
+// run out.
+// Returns ErrNotFound if the timer does not exist, or the error from modify.
+func ModifyTimer(
+	ctx context.Context, client *redis.Client, gameID string, timerID id.UID,
+	modify func(t *timer.Timer, now int64) error,
+) (*timer.Timer, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "game.ModifyTimer")
+	defer span.End()
+	var modified *timer.Timer
+	watched := func(tx *redis.Tx) error {
+		t, err := timer.GetByID(ctx, tx, gameID, timerID)
+		if err != nil {
+			return err
+		}
+		now := id.TimestampNow()
+		if err := modify(t, now); err != nil {
+			return err
+		}
+		modified = t
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			if t.IsExpired(now) {
+				return expireTimer(ctx, pipe, gameID, t, now)
+			}
+			return saveTimer(ctx, pipe, gameID, t, update.ForTimerMod(t))
+		})
+		return err
+	}
+	if err := redisUtil.RetryWatchTxn(ctx, client, watched, timer.RedisKey(gameID)); err != nil {
+		return nil, srOtel.WithSetErrorf(span, "modifying timer %v: %w", timerID, err)
+	}
+	return modified, nil
+}
+
+// DeleteTimer removes a timer from a game without it running out.
+// Returns ErrNotFound if the timer does not exist.
+func DeleteTimer(ctx context.Context, client *redis.Client, gameID string, timerID id.UID) error {
+	ctx, span := srOtel.Tracer.Start(ctx, "game.DeleteTimer")
+	defer span.End()
+	watched := func(tx *redis.Tx) error {
+		if _, err := timer.GetByID(ctx, tx, gameID, timerID); err != nil {
+			return err
+		}
+		_, err := tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			return removeTimer(ctx, pipe, gameID, timerID)
+		})
+		return err
+	}
+	if err := redisUtil.RetryWatchTxn(ctx, client, watched, timer.RedisKey(gameID)); err != nil {
+		return srOtel.WithSetErrorf(span, "deleting timer %v: %w", timerID, err)
+	}
+	return nil
+}
+
+// AdvanceTurns counts down the game's running turn-based timers by the given
+// number of turns, expiring those which run out.
+// Returns the number of timers which expired.
+func AdvanceTurns(ctx context.Context, client *redis.Client, gameID string, turns int64) (int, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "game.AdvanceTurns")
+	defer span.End()
+	var expired int
+	watched := func(tx *redis.Tx) error {
+		timers, err := timer.GetAll(ctx, tx, gameID)
+		if err != nil {
+			return err
+		}
+		now := id.TimestampNow()
+		expired = 0
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			for ix := range timers {
+				t := &timers[ix]
+				if t.Kind != timer.KindTurns || t.Paused {
+					continue
+				}
+				t.Adjust(-turns, now)
+				if t.IsExpired(now) {
+					// Offset event IDs of timers expiring together
+					if err := expireTimer(ctx, pipe, gameID, t, now+int64(expired)); err != nil {
+						return err
+					}
+					expired++
+				} else if err := saveTimer(ctx, pipe, gameID, t, update.ForTimerMod(t)); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		return err
+	}
+	if err := redisUtil.RetryWatchTxn(ctx, client, watched, timer.RedisKey(gameID)); err != nil {
+		return 0, srOtel.WithSetErrorf(span, "advancing %v turns: %w", turns, err)
+	}
+	return expired, nil
+}
+
+// expireIfDue expires a real-time timer if it has run out, posting its event
+// with the given ID rather than at its end, which may be older than events
+// posted since, or unschedules it if it, or its game, no longer exists or
+// it is paused.
+func expireIfDue(ctx context.Context, client *redis.Client, member string, now int64, eventID int64) (bool, error) {
+	gameID, timerID, ok := timer.ParseDueMember(member)
+	if !ok {
+		return false, client.ZRem(ctx, timer.DueKey, member).Err()
+	}
+	expired := false
+	watched := func(tx *redis.Tx) error {
+		exists, err := Exists(ctx, tx, gameID)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return tx.ZRem(ctx, timer.DueKey, member).Err()
+		}
+		t, err := timer.GetByID(ctx, tx, gameID, timerID)
+		if errors.Is(err, errs.ErrNotFound) {
+			return tx.ZRem(ctx, timer.DueKey, member).Err()
+		} else if err != nil {
+			return err
+		}
+		if !t.IsRunning() {
+			return tx.ZRem(ctx, timer.DueKey, member).Err()
+		}
+		if !t.IsExpired(now) {
+			return nil
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			return expireTimer(ctx, pipe, gameID, t, eventID)
+		})
+		expired = err == nil
+		return err
+	}
+	err := redisUtil.RetryWatchTxn(ctx, client, watched, "game:"+gameID, timer.RedisKey(gameID))
+	return expired, err
+}
+
+// ExpireDueTimers expires all real-time timers which have run out by the
+// given time, across all games.
+// Returns the number of timers which expired.
+func ExpireDueTimers(ctx context.Context, client *redis.Client, now int64) (int, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "game.ExpireDueTimers")
+	defer span.End()
+	due, err := timer.GetDue(ctx, client, now)
+	if err != nil {
+		return 0, srOtel.WithSetErrorf(span, "getting due timers: %w", err)
+	}
+	expired := 0
+	for _, member := range due {
+		// Offset event IDs of timers expiring together
+		didExpire, err := expireIfDue(ctx, client, member, now, now+int64(expired))
+		if err != nil {
+			return expired, srOtel.WithSetErrorf(span, "expiring %v: %w", member, err)
+		}
+		if didExpire {
+			expired++
+		}
+	}
+	return expired, nil
+}
+
+// RunTimers expires timers as they run out until the context is cancelled.
+// Timers are scheduled in redis, so those which ran out while the server was
+// down are expired when it starts.
+func RunTimers(ctx context.Context, client *redis.Client) {
+	ticker := time.NewTicker(TimerPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			expired, err := ExpireDueTimers(ctx, client, id.TimestampNow())
+			if err != nil {
+				log.Printf(ctx, "Error expiring timers: %v", err)
+			} else if expired > 0 {
+				log.Printf(ctx, "Expired %v timers", expired)
+			}
+		}
+	}
+}
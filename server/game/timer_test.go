@@ -0,0 +1,97 @@
+package game_test
+
+import (
+	"context"
+	"testing"
+
+	genGame "sr/gen/game"
+	genPlayer "sr/gen/player"
+
+	"sr/errs"
+	"sr/event"
+	"sr/game"
+	"sr/id"
+	"sr/test"
+	"sr/timer"
+
+	"github.com/go-redis/redis/v8"
+)
+
+func TestTimers(t *testing.T) {
+	ctx := context.Background()
+	rng := test.RNG()
+	_, client := test.GetRedis(t)
+
+	gameID := genGame.GameID(rng)
+	test.Must(t, game.Create(ctx, client, gameID))
+	gm := genPlayer.Player(rng)
+	now := id.TimestampNow()
+
+	t.Run("real-time timers expire when due", func(t *testing.T) {
+		tmr := timer.Make(gm, "Security", timer.KindRealTime, 5000, now)
+		test.Must(t, game.CreateTimer(ctx, client, gameID, &tmr))
+
+		expired, err := game.ExpireDueTimers(ctx, client, now+4000)
+		test.AssertSuccess(t, err, "expiring timers early")
+		test.AssertEqual(t, 0, expired)
+
+		expired, err = game.ExpireDueTimers(ctx, client, now+5000)
+		test.AssertSuccess(t, err, "expiring due timers")
+		test.AssertEqual(t, 1, expired)
+
+		_, err = timer.GetByID(ctx, client, gameID, tmr.ID)
+		test.AssertErrorIs(t, err, errs.ErrNotFound)
+		found, err := event.GetByID(ctx, client, gameID, now+5000)
+		test.AssertSuccess(t, err, "finding timer event")
+		test.AssertEqual(t, event.EventTypeTimer, event.ParseTy(found))
+	})
+
+	t.Run("paused timers do not expire", func(t *testing.T) {
+		tmr := timer.Make(gm, "Paused", timer.KindRealTime, 5000, now)
+		test.Must(t, game.CreateTimer(ctx, client, gameID, &tmr))
+		_, err := game.ModifyTimer(ctx, client, gameID, tmr.ID, func(t *timer.Timer, now int64) error {
+			return t.Pause(now)
+		})
+		test.AssertSuccess(t, err, "pausing timer")
+
+		expired, err := game.ExpireDueTimers(ctx, client, now+10000)
+		test.AssertSuccess(t, err, "expiring timers")
+		test.AssertEqual(t, 0, expired)
+		test.Must(t, game.DeleteTimer(ctx, client, gameID, tmr.ID))
+	})
+
+	t.Run("timers of deleted games do not expire", func(t *testing.T) {
+		deletedID := genGame.GameID(rng)
+		test.Must(t, game.Create(ctx, client, deletedID))
+		tmr := timer.Make(gm, "Deleted", timer.KindRealTime, 5000, now)
+		test.Must(t,
+			game.CreateTimer(ctx, client, deletedID, &tmr),
+			client.Del(ctx, "game:"+deletedID).Err(),
+		)
+
+		expired, err := game.ExpireDueTimers(ctx, client, now+10000)
+		test.AssertSuccess(t, err, "expiring timers")
+		test.AssertEqual(t, 0, expired)
+		_, err = client.ZScore(ctx, timer.DueKey, timer.DueMember(deletedID, tmr.ID)).Result()
+		test.AssertErrorIs(t, err, redis.Nil)
+		events, err := client.Exists(ctx, "history:"+deletedID).Result()
+		test.AssertSuccess(t, err, "checking history")
+		test.AssertEqual(t, int64(0), events)
+	})
+
+	t.Run("turn timers expire as turns pass", func(t *testing.T) {
+		short := timer.Make(gm, "Short", timer.KindTurns, 1, now)
+		long := timer.Make(gm, "Long", timer.KindTurns, 3, now)
+		test.Must(t, game.CreateTimer(ctx, client, gameID, &short))
+		test.Must(t, game.CreateTimer(ctx, client, gameID, &long))
+
+		expired, err := game.AdvanceTurns(ctx, client, gameID, 1)
+		test.AssertSuccess(t, err, "advancing turns")
+		test.AssertEqual(t, 1, expired)
+
+		timers, err := timer.GetAll(ctx, client, gameID)
+		test.AssertSuccess(t, err, "getting timers")
+		test.AssertEqual(t, 1, len(timers))
+		test.AssertEqual(t, int64(2), timers[0].Remaining)
+	})
+}
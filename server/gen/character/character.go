@@ -0,0 +1,58 @@
+package character
+
+import (
+	mathRand "math/rand"
+
+	"sr/gen"
+
+	"sr/character"
+	"sr/id"
+)
+
+func Attributes(rand *mathRand.Rand) character.Attributes {
+	rating := func() int { return 1 + rand.Intn(6) }
+	return character.Attributes{
+		Body:      rating(),
+		Agility:   rating(),
+		Reaction:  rating(),
+		Strength:  rating(),
+		Willpower: rating(),
+		Logic:     rating(),
+		Intuition: rating(),
+		Charisma:  rating(),
+		Edge:      rating(),
+		Magic:     rand.Intn(7),
+		Resonance: 0,
+		Essence:   float64(1+rand.Intn(50)) / 10,
+	}
+}
+
+func Skills(rand *mathRand.Rand) map[string]int {
+	numSkills := rand.Intn(8)
+	skills := make(map[string]int, numSkills)
+	for i := 0; i < numSkills; i++ {
+		skills[gen.ASCIILower(rand)] = 1 + rand.Intn(7)
+	}
+	return skills
+}
+
+// Character generates a valid character of the given player.
+func Character(rand *mathRand.Rand, gameID string, playerID id.UID) *character.Character {
+	attributes := Attributes(rand)
+	return &character.Character{
+		ID:             id.GenUIDWith(rand),
+		GameID:         gameID,
+		PlayerID:       playerID,
+		Name:           gen.Alphanumeric(rand),
+		Attributes:     attributes,
+		Skills:         Skills(rand),
+		EdgePoints:     rand.Intn(attributes.Edge + 1),
+		InitiativeMod:  rand.Intn(3),
+		InitiativeDice: 1 + rand.Intn(3),
+		Armor:          rand.Intn(13),
+		Condition: character.Condition{
+			Physical: rand.Intn(4),
+			Stun:     rand.Intn(4),
+		},
+	}
+}
@@ -23,7 +23,7 @@ func Glitchy(rand *mathRand.Rand) int {
 }
 
 func Share(rand *mathRand.Rand) event.Share {
-	return event.Share(rand.Intn(3))
+	return event.Share(rand.Intn(5))
 }
 
 func Roll(rand *mathRand.Rand, plr *player.Player) event.Roll {
@@ -79,13 +79,35 @@ func InitiativeRoll(rand *mathRand.Rand, plr *player.Player) event.InitiativeRol
 	)
 }
 
+func GroupRoll(rand *mathRand.Rand, plr *player.Player) event.GroupRoll {
+	actors := 1 + rand.Intn(6)
+	pool := 1 + rand.Intn(12)
+	rolls := make([]event.GroupRollEntry, actors)
+	for i := range rolls {
+		dice, _ := roll.MakeMathRoller(rand).Roll(pool)
+		rolls[i] = event.GroupRollEntry{Name: gen.String(rand), Dice: dice}
+	}
+	return event.ForGroupRoll(
+		plr,
+		Share(rand),
+		gen.String(rand),
+		rolls,
+		Glitchy(rand),
+		rand.Intn(4),
+	)
+}
+
+func Message(rand *mathRand.Rand, plr *player.Player) event.Message {
+	return event.ForMessage(plr, Share(rand), gen.String(rand))
+}
+
 func PlayerJoin(rand *mathRand.Rand, plr *player.Player) event.Event {
 	return event.ForPlayerJoin(plr)
 }
 
 // Generate implements quick.Generator for Event.
 func Event(rand *mathRand.Rand, plr *player.Player) event.Event {
-	ty := rand.Intn(5)
+	ty := rand.Intn(7)
 	switch ty {
 	case 0: // initiativeRoll
 		evt := InitiativeRoll(rand, plr)
@@ -101,6 +123,12 @@ func Event(rand *mathRand.Rand, plr *player.Player) event.Event {
 	case 4: // rerollFailures
 		evt := Reroll(rand, plr)
 		return &evt
+	case 5: // groupRoll
+		evt := GroupRoll(rand, plr)
+		return &evt
+	case 6: // message
+		evt := Message(rand, plr)
+		return &evt
 	default:
 		panic("Invalid choice when generating an event!")
 	}
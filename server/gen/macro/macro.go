@@ -0,0 +1,26 @@
+package macro
+
+import (
+	mathRand "math/rand"
+
+	"sr/gen"
+
+	"sr/event"
+	"sr/id"
+	"sr/macro"
+)
+
+// Macro generates a valid macro.
+func Macro(rand *mathRand.Rand) macro.Macro {
+	pool := 1 + rand.Intn(20)
+	return macro.Macro{
+		ID:      id.GenUIDWith(rand),
+		Name:    gen.Alphanumeric(rand),
+		Title:   gen.String(rand),
+		Pool:    pool,
+		Edge:    rand.Intn(2) == 0,
+		Glitchy: rand.Intn(pool + 1),
+		Limit:   rand.Intn(8),
+		Share:   int(event.ShareInGame) + rand.Intn(int(event.ShareBlind)+1),
+	}
+}
@@ -6,11 +6,14 @@ import (
 	netHTTP "net/http"
 	"strings"
 
+	"sr/apitoken"
 	"sr/config"
+	"sr/errs"
 	"sr/log"
 	redisUtil "sr/redis"
 	"sr/session"
 
+	attr "go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 
 	"github.com/go-redis/redis/v8"
@@ -27,6 +30,7 @@ type Args struct {
 	Client   *redis.Client
 	Method   string
 	Path     string
+	Scope    apitoken.Scope // Scope API tokens need for the route
 	Span     trace.Span
 }
 
@@ -37,8 +41,56 @@ func (a *Args) Get() (context.Context, Response, Request, *redis.Client, trace.S
 	return a.Ctx, a.Response, a.Request, a.Client, a.Span
 }
 
+// MustSession halts unless the request has a valid session for a game the
+// player is in (see RequestGame). Spectators may only make GET requests.
 func (a *Args) MustSession() (context.Context, Response, Request, *redis.Client, *session.Session) {
+	ctx, response, request, client, sess := a.MustAnySession()
+	if sess.Spectator && request.Method != netHTTP.MethodGet {
+		Halt(ctx, errs.NoAccessf("Spectators may not change the game"))
+	}
+	return ctx, response, request, client, sess
+}
+
+// MustAnySession halts unless the request has a valid session for a game the
+// player is in or spectating, for any request.
+//
+// Requests may be made with an API token whose scopes allow the route.
+func (a *Args) MustAnySession() (context.Context, Response, Request, *redis.Client, *session.Session) {
+	ctx, response, request, client, _ := a.Get()
+	var sess *session.Session
+	if RequestHasToken(request) {
+		sess = a.mustTokenSession()
+	} else {
+		var err error
+		sess, err = RequestSession(request, client)
+		Halt(ctx, err)
+	}
+	Halt(ctx, RequestGame(request, client, sess))
+	return ctx, response, request, client, sess
+}
+
+// mustTokenSession halts unless the request has a valid API token whose scopes
+// allow the route.
+func (a *Args) mustTokenSession() *session.Session {
+	sess, token, err := RequestTokenSession(a.Request, a.Client)
+	Halt(a.Ctx, err)
+	if a.Span.IsRecording() {
+		a.Span.SetAttributes(attr.String("sr.token.routeScope", string(a.Scope)))
+	}
+	if !token.Allows(a.Scope) {
+		Halt(a.Ctx, errs.NoAccessf("API token may not be used for %v %v", a.Method, a.Path))
+	}
+	return sess
+}
+
+// MustPlayerSession halts unless the request has a valid session, without
+// checking the player's games. sess.GameID is empty for player sessions.
+// API tokens may not be used.
+func (a *Args) MustPlayerSession() (context.Context, Response, Request, *redis.Client, *session.Session) {
 	ctx, response, request, client, _ := a.Get()
+	if RequestHasToken(request) {
+		Halt(ctx, errs.NoAccessf("API tokens may not be used for %v %v", a.Method, a.Path))
+	}
 	sess, err := RequestSession(request, client)
 	Halt(ctx, err)
 	return ctx, response, request, client, sess
@@ -53,22 +105,29 @@ type HandlerFunc func(response Response, request Request, client *redis.Client)
 func Handle(router *mux.Router, pathAndMethod string, handler Handler) *mux.Route {
 	file, line := log.FileAndLine(1)
 	line += 2
-	return HandleWith(router, pathAndMethod, handler, file, line, redisUtil.Client)
+	return HandleWith(router, pathAndMethod, handler, file, line, redisUtil.Client, "")
+}
+
+// HandleScoped is Handle for routes which API tokens with the scope may use.
+func HandleScoped(router *mux.Router, pathAndMethod string, scope apitoken.Scope, handler Handler) *mux.Route {
+	file, line := log.FileAndLine(1)
+	line += 2
+	return HandleWith(router, pathAndMethod, handler, file, line, redisUtil.Client, scope)
 }
 
 func HandleGet(router *mux.Router, path string, handler Handler) *mux.Route {
 	file, line := log.FileAndLine(1)
 	line += 2
-	return HandleWith(router, "GET "+path, handler, file, line, redisUtil.Client)
+	return HandleWith(router, "GET "+path, handler, file, line, redisUtil.Client, "")
 }
 
 func HandlePost(router *mux.Router, path string, handler Handler) *mux.Route {
 	file, line := log.FileAndLine(1)
 	line += 2
-	return HandleWith(router, "POST "+path, handler, file, line, redisUtil.Client)
+	return HandleWith(router, "POST "+path, handler, file, line, redisUtil.Client, "")
 }
 
-func HandleWith(router *mux.Router, pathAndMethod string, handler Handler, file string, line int, client *redis.Client) *mux.Route {
+func HandleWith(router *mux.Router, pathAndMethod string, handler Handler, file string, line int, client *redis.Client, scope apitoken.Scope) *mux.Route {
 	split := strings.SplitN(pathAndMethod, " ", 2)
 	if len(split) != 2 {
 		panic(fmt.Errorf("srHTTP.Handle: invalid path %v", pathAndMethod))
@@ -90,6 +149,7 @@ func HandleWith(router *mux.Router, pathAndMethod string, handler Handler, file
 			Span:     span,
 			Method:   method,
 			Path:     path,
+			Scope:    scope,
 		}
 		logRequest(request, fullPath, file, line)
 		handler(args)
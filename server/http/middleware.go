@@ -4,16 +4,12 @@ import (
 	"fmt"
 	netHTTP "net/http"
 	"runtime/debug"
-	"time"
 
-	"sr/config"
 	"sr/errs"
 	"sr/log"
 	srOtel "sr/otel"
-	redisUtil "sr/redis"
 	"sr/taskCtx"
 
-	"github.com/go-redis/redis/v8"
 	"go.opentelemetry.io/otel/trace"
 )
 
@@ -92,44 +88,3 @@ func RecoveryMiddleware(wrapped netHTTP.Handler) netHTTP.Handler {
 		wrapped.ServeHTTP(response, request)
 	})
 }
-
-func RateLimitedMiddleware(wrapped netHTTP.Handler) netHTTP.Handler {
-	return RateLimitedHandler(wrapped, redisUtil.Client)
-}
-
-func RateLimitedHandler(wrapped netHTTP.Handler, client redis.Cmdable) netHTTP.Handler {
-	return netHTTP.HandlerFunc(func(response Response, request Request) {
-		// Taken from https://redis.io/commands/incr#pattern-rate-limiter-1
-		remoteAddr := RequestRemoteIP(request)
-		ts := time.Now().Unix()
-		rateLimitKey := fmt.Sprintf("ratelimit:%v:%v", remoteAddr, ts-ts%10)
-		ctx := request.Context()
-
-		current, err := client.Get(ctx, rateLimitKey).Int()
-		if err == redis.Nil {
-			current = 0
-		} else if err != nil {
-			log.Printf(ctx, "Unable to get rate limit key: %v", err)
-			HaltInternal(ctx, err)
-		}
-
-		limited := false
-		if current > config.MaxRequestsPer10Secs {
-			log.Printf(ctx, "Rate limit for %v hit", remoteAddr)
-			netHTTP.Error(response, "Rate limited", netHTTP.StatusTooManyRequests)
-			limited = true
-		}
-
-		_, err = client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
-			const expiration = 15 * time.Second
-			pipe.Incr(ctx, rateLimitKey)
-			pipe.Expire(ctx, rateLimitKey, expiration)
-			return nil
-		})
-		HaltInternal(ctx, err)
-
-		if !limited {
-			wrapped.ServeHTTP(response, request)
-		}
-	})
-}
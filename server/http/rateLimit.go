@@ -0,0 +1,133 @@
+package http
+
+import (
+	"fmt"
+	netHTTP "net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"sr/apitoken"
+	"sr/config"
+	"sr/log"
+	"sr/ratelimit"
+	redisUtil "sr/redis"
+	"sr/session"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/gorilla/mux"
+	attr "go.opentelemetry.io/otel/attribute"
+)
+
+// RateLimits configures the limits of routes by their method and path, such as
+// "POST /game/roll".
+type RateLimits map[string]ratelimit.Limit
+
+// DefaultRateLimit is the limit of API routes without their own, shared by all
+// of them.
+func DefaultRateLimit() ratelimit.Limit {
+	return ratelimit.Limit{
+		Bucket: "api",
+		Budget: config.RateLimitBudget,
+		Refill: config.RateLimitRefillPerMin,
+		Cost:   1,
+	}
+}
+
+// RateLimitMiddleware limits requests to the routes of a router, charging them
+// to the player making them or to their address. Routes which are not in
+// limits use fallback. pathPrefix is removed from route paths before finding
+// them in limits.
+//
+// Responses have RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset
+// headers for the route's bucket, and throttled requests get a 429 with a
+// Retry-After header.
+func RateLimitMiddleware(limits RateLimits, fallback ratelimit.Limit, pathPrefix string) mux.MiddlewareFunc {
+	if !fallback.Valid() {
+		panic(fmt.Sprintf("Invalid fallback rate limit: %v", fallback.String()))
+	}
+	for route, limit := range limits {
+		if !limit.Valid() {
+			panic(fmt.Sprintf("Invalid rate limit for %v: %v", route, limit.String()))
+		}
+	}
+	return func(wrapped netHTTP.Handler) netHTTP.Handler {
+		return netHTTP.HandlerFunc(func(response Response, request Request) {
+			limit, found := limits[routeName(request, pathPrefix)]
+			if !found {
+				limit = fallback
+			}
+			if limit.Exempt {
+				wrapped.ServeHTTP(response, request)
+				return
+			}
+			if rateLimit(response, request, redisUtil.Client, &limit) {
+				wrapped.ServeHTTP(response, request)
+			}
+		})
+	}
+}
+
+// routeName is the method and path template of the route matching a request.
+func routeName(request Request, pathPrefix string) string {
+	route := mux.CurrentRoute(request)
+	if route == nil {
+		return ""
+	}
+	path, err := route.GetPathTemplate()
+	if err != nil {
+		return ""
+	}
+	return request.Method + " " + strings.TrimPrefix(path, pathPrefix)
+}
+
+// rateLimitSubject finds who a request is charged to: the player of its
+// session or API token, or else its address.
+func rateLimitSubject(request Request, client redis.Cmdable) string {
+	ctx := request.Context()
+	if bearer, err := SessionFromHeader(request); err == nil {
+		if apitoken.IsSecret(bearer) {
+			if token, err := apitoken.Check(ctx, client, bearer); err == nil {
+				return "player:" + string(token.PlayerID)
+			}
+		} else if sess, err := session.GetByID(ctx, client, bearer); err == nil {
+			return "player:" + string(sess.PlayerID)
+		}
+	}
+	return "ip:" + RequestRemoteIP(request)
+}
+
+func durationSecs(dur time.Duration) string {
+	return strconv.Itoa(int((dur + time.Second - 1) / time.Second))
+}
+
+// rateLimit takes the cost of a request from its subject's bucket, returning
+// whether it may continue. Throttled requests are responded to.
+func rateLimit(response Response, request Request, client redis.Cmdable, limit *ratelimit.Limit) bool {
+	ctx := request.Context()
+	subject := rateLimitSubject(request, client)
+	result, err := ratelimit.Take(ctx, client, limit, subject, time.Now())
+	if err != nil {
+		log.Printf(ctx, "Unable to take from rate limit bucket: %v", err)
+		HaltInternal(ctx, err)
+	}
+
+	headers := response.Header()
+	headers.Set("RateLimit-Limit", strconv.Itoa(limit.Budget))
+	headers.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	headers.Set("RateLimit-Reset", durationSecs(result.Reset))
+	if result.Allowed {
+		return true
+	}
+
+	headers.Set("Retry-After", durationSecs(result.RetryAfter))
+	log.Event(ctx, "Rate limited",
+		attr.String("sr.ratelimit.subject", subject),
+		attr.String("sr.ratelimit.bucket", limit.Bucket),
+		attr.Int("sr.ratelimit.cost", limit.Cost),
+		attr.Int("sr.ratelimit.remaining", result.Remaining),
+		attr.Int64("sr.ratelimit.retryAfterMs", result.RetryAfter.Milliseconds()),
+	)
+	netHTTP.Error(response, "Rate limited", netHTTP.StatusTooManyRequests)
+	return false
+}
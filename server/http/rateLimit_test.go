@@ -0,0 +1,73 @@
+package http_test
+
+import (
+	netHTTP "net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	srHTTP "sr/http"
+	"sr/id"
+	"sr/ratelimit"
+	redisUtil "sr/redis"
+	"sr/test"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	_, client := test.GetRedis(t)
+	redisUtil.Client = client
+
+	limits := srHTTP.RateLimits{
+		"POST /game/roll":        {Bucket: "roll-" + string(id.GenUID()), Budget: 4, Refill: 1, Cost: 2},
+		"GET /game/subscription": {Exempt: true},
+	}
+	fallback := ratelimit.Limit{Bucket: "api-" + string(id.GenUID()), Budget: 1, Refill: 1, Cost: 1}
+	router := mux.NewRouter()
+	router.Use(srHTTP.RateLimitMiddleware(limits, fallback, ""))
+	ok := func(response netHTTP.ResponseWriter, request *netHTTP.Request) {}
+	router.HandleFunc("/game/roll", ok).Methods("POST")
+	router.HandleFunc("/game/subscription", ok).Methods("GET")
+	router.HandleFunc("/game/info", ok).Methods("GET")
+
+	serveFrom := func(addr string, method string, path string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(method, path, nil)
+		request.RemoteAddr = addr
+		response := httptest.NewRecorder()
+		router.ServeHTTP(response, request)
+		return response
+	}
+	addr := string(id.GenUID()) + ":1234"
+
+	for ix := 0; ix < 2; ix++ {
+		response := serveFrom(addr, "POST", "/game/roll")
+		test.AssertEqual(t, netHTTP.StatusOK, response.Code)
+		test.AssertEqual(t, "4", response.Header().Get("RateLimit-Limit"))
+		test.AssertEqual(t, strconv.Itoa(2-2*ix), response.Header().Get("RateLimit-Remaining"))
+		test.AssertEqual(t, "", response.Header().Get("Retry-After"))
+	}
+
+	throttled := serveFrom(addr, "POST", "/game/roll")
+	test.AssertEqual(t, netHTTP.StatusTooManyRequests, throttled.Code)
+	test.AssertEqual(t, "4", throttled.Header().Get("RateLimit-Limit"))
+	test.AssertEqual(t, "0", throttled.Header().Get("RateLimit-Remaining"))
+	test.AssertEqual(t, "120", throttled.Header().Get("Retry-After"))
+	test.AssertEqual(t, "240", throttled.Header().Get("RateLimit-Reset"))
+
+	// Other routes use their own buckets
+	info := serveFrom(addr, "GET", "/game/info")
+	test.AssertEqual(t, netHTTP.StatusOK, info.Code)
+	test.AssertEqual(t, "1", info.Header().Get("RateLimit-Limit"))
+	test.AssertEqual(t, netHTTP.StatusTooManyRequests, serveFrom(addr, "GET", "/game/info").Code)
+
+	for ix := 0; ix < 3; ix++ {
+		subscription := serveFrom(addr, "GET", "/game/subscription")
+		test.AssertEqual(t, netHTTP.StatusOK, subscription.Code)
+		test.AssertEqual(t, "", subscription.Header().Get("RateLimit-Limit"))
+	}
+
+	// Requests from other addresses are charged separately
+	other := string(id.GenUID()) + ":1234"
+	test.AssertEqual(t, netHTTP.StatusOK, serveFrom(other, "POST", "/game/roll").Code)
+}
@@ -78,6 +78,11 @@ func RunServer(ctx context.Context, name string, server *netHTTP.Server, tls boo
 	}
 }
 
+// rateLimitHeaders are the headers of responses from RateLimitMiddleware.
+var rateLimitHeaders = []string{
+	"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After",
+}
+
 func makeCORSConfig() *cors.Cors {
 	var c *cors.Cors
 	if config.IsProduction || !config.DisableCORS {
@@ -86,7 +91,8 @@ func makeCORSConfig() *cors.Cors {
 				config.FrontendOrigin.String(),
 				config.BackendOrigin.String(),
 			},
-			AllowedHeaders:   []string{"Authentication", "Content-Type"},
+			AllowedHeaders:   []string{"Authentication", "Content-Type", "Game"},
+			ExposedHeaders:   rateLimitHeaders,
 			AllowCredentials: true,
 			Debug:            config.CORSDebug,
 		})
@@ -98,7 +104,8 @@ func makeCORSConfig() *cors.Cors {
 				}
 				return true
 			},
-			AllowedHeaders:   []string{"Authentication", "Content-Type"},
+			AllowedHeaders:   []string{"Authentication", "Content-Type", "Game"},
+			ExposedHeaders:   rateLimitHeaders,
 			AllowCredentials: true,
 			Debug:            config.CORSDebug,
 		})
@@ -4,10 +4,13 @@ import (
 	"context"
 	"strings"
 
+	"sr/apitoken"
 	"sr/errs"
+	"sr/game"
 	"sr/log"
 	"sr/session"
 
+	attr "go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/semconv/v1.4.0"
 	"go.opentelemetry.io/otel/trace"
 
@@ -16,6 +19,7 @@ import (
 
 var errNoAuthBearer = errs.BadRequestf("no auth bearer header")
 var errNoSessionParam = errs.BadRequestf("no session query param")
+var errNoGame = errs.BadRequestf("no game requested")
 
 func SessionFromHeader(request Request) (string, error) {
 	auth := request.Header.Get("Authentication")
@@ -25,6 +29,13 @@ func SessionFromHeader(request Request) (string, error) {
 	return auth[7:], nil
 }
 
+// RequestHasToken determines if the request was made with an API token rather
+// than a session.
+func RequestHasToken(request Request) bool {
+	bearer, err := SessionFromHeader(request)
+	return err == nil && apitoken.IsSecret(bearer)
+}
+
 func SessionFromParams(request Request) (string, error) {
 	session := request.URL.Query().Get("session")
 	if session == "" || session == "null" || session == "undefined" {
@@ -33,12 +44,71 @@ func SessionFromParams(request Request) (string, error) {
 	return session, nil
 }
 
+// GameFromRequest finds the game requested with the `Game` header or the
+// `game` query param, for player sessions which are not bound to one game.
+func GameFromRequest(request Request) string {
+	if gameID := request.Header.Get("Game"); gameID != "" {
+		return gameID
+	}
+	return request.URL.Query().Get("game")
+}
+
+// RequestGame sets the game of a player session from the request, and checks
+// that the session's player is still in its game. Sessions of spectators of
+// the game are marked as such.
+//
+// Returns ErrBadRequest if no game was requested, and ErrNoAccess if the game
+// does not exist, the player is not in the game, or the game requires
+// credentials and the session was not made with them.
+func RequestGame(request Request, client redis.Cmdable, sess *session.Session) error {
+	ctx := request.Context()
+	if sess.GameID == "" {
+		sess.GameID = GameFromRequest(request)
+		if sess.GameID == "" {
+			return errNoGame
+		}
+	}
+	exists, err := game.Exists(ctx, client, sess.GameID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errs.NoAccessf("game %v does not exist", sess.GameID)
+	}
+	inGame, err := game.HasPlayer(ctx, client, sess.GameID, sess.PlayerID)
+	if err != nil {
+		return err
+	}
+	sess.Spectator = false
+	if !inGame {
+		spectating, err := game.HasSpectator(ctx, client, sess.GameID, sess.PlayerID)
+		if err != nil {
+			return err
+		}
+		if !spectating {
+			return errs.NoAccessf("player %v is not in %v", sess.PlayerID, sess.GameID)
+		}
+		sess.Spectator = true
+	}
+	if !sess.Verified {
+		required, err := game.RequiresCredentials(ctx, client, sess.GameID)
+		if err != nil {
+			return err
+		}
+		if required {
+			return errs.NoAccessf("%v requires a password or passkey", sess.GameID)
+		}
+	}
+	return nil
+}
+
 func RecordRequestSession(ctx context.Context, sess *session.Session) {
 	span := trace.SpanFromContext(ctx)
 	if span.IsRecording() {
 		span.AddEvent("Authorized")
 		span.SetAttributes(
 			semconv.EnduserIDKey.String(sess.PlayerID.String()),
+			attr.Bool("sr.session.spectator", sess.Spectator),
 			//attr.String("enduser.game", sess.GameID),
 		)
 	}
@@ -56,6 +126,9 @@ func RequestSession(request Request, client redis.Cmdable) (*session.Session, er
 		log.Printf(ctx, "Didn't get %s by id", sessionID)
 		return nil, err
 	}
+	if err := session.Touch(ctx, client, sess); err != nil {
+		log.Printf(ctx, "Unable to update last seen of %v: %v", sess.ID, err)
+	}
 	RecordRequestSession(ctx, sess)
 	return sess, nil
 }
@@ -72,6 +145,42 @@ func RequestParamSession(request Request, client redis.Cmdable) (*session.Sessio
 		log.Printf(ctx, "Didn't get %v by ID", sessionID)
 		return nil, err
 	}
+	if err := session.Touch(ctx, client, sess); err != nil {
+		log.Printf(ctx, "Unable to update last seen of %v: %v", sess.ID, err)
+	}
 	RecordRequestSession(ctx, sess)
 	return sess, nil
 }
+
+// RequestTokenSession authenticates a request made with an API token in the
+// Authentication header, returning a session for the token's player in its
+// game along with the token.
+//
+// Returns ErrNoAccess if the token does not exist.
+func RequestTokenSession(request Request, client redis.Cmdable) (*session.Session, *apitoken.Token, error) {
+	ctx := request.Context()
+	secret, err := SessionFromHeader(request)
+	if err != nil {
+		return nil, nil, err
+	}
+	token, err := apitoken.Check(ctx, client, secret)
+	if err != nil {
+		log.Printf(ctx, "Didn't find API token")
+		return nil, nil, err
+	}
+	sess := &session.Session{
+		GameID:   token.GameID,
+		PlayerID: token.PlayerID,
+		Verified: token.Verified,
+		TokenID:  token.ID,
+	}
+	RecordRequestSession(ctx, sess)
+	span := trace.SpanFromContext(ctx)
+	if span.IsRecording() {
+		span.SetAttributes(
+			attr.String("sr.token.id", token.ID.String()),
+			attr.StringSlice("sr.token.scopes", token.ScopeNames()),
+		)
+	}
+	return sess, token, nil
+}
@@ -29,6 +29,16 @@ func (uid UID) MarshalBinary() ([]byte, error) {
 	return []byte(string(uid)), nil
 }
 
+// Contains determines if target is one of the given IDs.
+func Contains(ids []UID, target UID) bool {
+	for _, found := range ids {
+		if found == target {
+			return true
+		}
+	}
+	return false
+}
+
 // PlayerID is the random ID of players.
 type PlayerID UID
 
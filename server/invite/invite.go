@@ -0,0 +1,78 @@
+package invite
+
+import (
+	"fmt"
+
+	"sr/errs"
+	"sr/id"
+	"sr/player"
+)
+
+// MaxTTL is the longest an invite may last, in milliseconds.
+const MaxTTL = 30 * 24 * 60 * 60 * 1000
+
+// MaxUses is the most players who may join a game with one invite.
+const MaxUses = 100
+
+// MaxInvites is the largest number of active invites in a game.
+const MaxInvites = 20
+
+// Invite is a code GMs hand out to let new players join their game.
+//
+// Invites expire at Expires, and are removed once they have been used MaxUses
+// times or revoked by a GM.
+type Invite struct {
+	Code       id.UID `json:"code"`
+	GameID     string `json:"gameID"`
+	Expires    int64  `json:"expires"` // Timestamp the invite stops working
+	MaxUses    int    `json:"maxUses"`
+	Uses       int    `json:"uses"`
+	Spectate   bool   `json:"spectate"` // Joins players as read-only spectators
+	PlayerID   id.UID `json:"playerID"` // GM who created the invite
+	PlayerName string `json:"playerName"`
+}
+
+// Make constructs a new Invite which lasts for ttl milliseconds from now,
+// giving it a random code.
+func Make(gameID string, plr *player.Player, ttl int64, maxUses int, spectate bool, now int64) Invite {
+	return Invite{
+		Code:       id.GenUID(),
+		GameID:     gameID,
+		Expires:    now + ttl,
+		MaxUses:    maxUses,
+		Spectate:   spectate,
+		PlayerID:   plr.ID,
+		PlayerName: plr.Name,
+	}
+}
+
+// RedisKey is the key of the invite with the given code.
+func RedisKey(code id.UID) string {
+	return "invite:" + string(code)
+}
+
+// GameKey is the key of the set of invite codes in a game.
+func GameKey(gameID string) string {
+	return "invites:" + gameID
+}
+
+// IsUsable determines if the invite may still be redeemed at the given time.
+func (i *Invite) IsUsable(now int64) bool {
+	return now < i.Expires && i.Uses < i.MaxUses
+}
+
+// Validate checks that a new invite's values are in range.
+// Returns ErrBadRequest describing the first invalid value.
+func (i *Invite) Validate(now int64) error {
+	if i.Expires <= now || i.Expires-now > MaxTTL {
+		return errs.BadRequestf("ttl: invalid")
+	}
+	if i.MaxUses < 1 || i.MaxUses > MaxUses {
+		return errs.BadRequestf("maxUses: invalid")
+	}
+	return nil
+}
+
+func (i *Invite) String() string {
+	return fmt.Sprintf("%v (%v/%v uses in %v)", i.Code, i.Uses, i.MaxUses, i.GameID)
+}
@@ -0,0 +1,71 @@
+package invite_test
+
+import (
+	"context"
+	"testing"
+
+	genGame "sr/gen/game"
+	genPlayer "sr/gen/player"
+
+	"sr/errs"
+	"sr/id"
+	"sr/invite"
+	"sr/test"
+)
+
+func TestInvite_Validate(t *testing.T) {
+	gm := genPlayer.Player(test.RNG())
+	inv := invite.Make("game", gm, 60000, 2, false, 1000)
+	test.AssertSuccess(t, inv.Validate(1000), "validating invite")
+	test.AssertEqual(t, true, inv.IsUsable(60999))
+	test.AssertEqual(t, false, inv.IsUsable(61000))
+
+	inv.MaxUses = invite.MaxUses + 1
+	test.AssertErrorIs(t, inv.Validate(1000), errs.ErrBadRequest)
+	inv = invite.Make("game", gm, invite.MaxTTL+1, 2, false, 1000)
+	test.AssertErrorIs(t, inv.Validate(1000), errs.ErrBadRequest)
+}
+
+func TestRedeem(t *testing.T) {
+	ctx := context.Background()
+	rng := test.RNG()
+	_, client := test.GetRedis(t)
+
+	gameID := genGame.GameID(rng)
+	gm := genPlayer.Player(rng)
+	now := id.TimestampNow()
+
+	t.Run("invites are removed when used up", func(t *testing.T) {
+		inv := invite.Make(gameID, gm, 60000, 2, false, now)
+		test.Must(t, invite.Create(ctx, client, &inv, now))
+
+		redeemed, err := invite.Redeem(ctx, client, inv.Code, now)
+		test.AssertSuccess(t, err, "redeeming invite")
+		test.AssertEqual(t, 1, redeemed.Uses)
+
+		_, err = invite.Redeem(ctx, client, inv.Code, now)
+		test.AssertSuccess(t, err, "redeeming invite again")
+
+		_, err = invite.Redeem(ctx, client, inv.Code, now)
+		test.AssertErrorIs(t, err, errs.ErrNotFound)
+		invites, err := invite.GetAll(ctx, client, gameID)
+		test.AssertSuccess(t, err, "getting invites")
+		test.AssertEqual(t, 0, len(invites))
+	})
+
+	t.Run("expired invites cannot be redeemed", func(t *testing.T) {
+		inv := invite.Make(gameID, gm, 60000, 5, false, now)
+		test.Must(t, invite.Create(ctx, client, &inv, now))
+		_, err := invite.Redeem(ctx, client, inv.Code, now+60000)
+		test.AssertErrorIs(t, err, errs.ErrNotFound)
+	})
+
+	t.Run("revoked invites cannot be redeemed", func(t *testing.T) {
+		inv := invite.Make(gameID, gm, 60000, 5, false, now)
+		test.Must(t, invite.Create(ctx, client, &inv, now))
+		test.AssertErrorIs(t, invite.Revoke(ctx, client, "other-game", inv.Code), errs.ErrNotFound)
+		test.Must(t, invite.Revoke(ctx, client, gameID, inv.Code))
+		_, err := invite.Redeem(ctx, client, inv.Code, now)
+		test.AssertErrorIs(t, err, errs.ErrNotFound)
+	})
+}
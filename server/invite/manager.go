@@ -0,0 +1,183 @@
+package invite
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"sr/errs"
+	"sr/id"
+	srOtel "sr/otel"
+	redisUtil "sr/redis"
+
+	"github.com/go-redis/redis/v8"
+)
+
+func ttlAt(inv *Invite, now int64) time.Duration {
+	return time.Duration(inv.Expires-now) * time.Millisecond
+}
+
+// GetAll retrieves the active invites in a game, sorted by when they expire.
+func GetAll(ctx context.Context, client redis.Cmdable, gameID string) ([]Invite, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "invite.GetAll")
+	defer span.End()
+	codes, err := client.SMembers(ctx, GameKey(gameID)).Result()
+	if err != nil {
+		return nil, srOtel.WithSetErrorf(span, "getting invite codes: %w", err)
+	}
+	invites := make([]Invite, 0, len(codes))
+	if len(codes) == 0 {
+		return invites, nil
+	}
+	keys := make([]string, len(codes))
+	for ix, code := range codes {
+		keys[ix] = RedisKey(id.UID(code))
+	}
+	texts, err := client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, srOtel.WithSetErrorf(span, "getting invites: %w", err)
+	}
+	for ix, text := range texts {
+		textStr, ok := text.(string)
+		if !ok { // Expired since it was added
+			continue
+		}
+		var inv Invite
+		if err := json.Unmarshal([]byte(textStr), &inv); err != nil {
+			return nil, srOtel.WithSetErrorf(span, "parsing invite %v: %w", codes[ix], err)
+		}
+		invites = append(invites, inv)
+	}
+	sort.Slice(invites, func(i, j int) bool {
+		return invites[i].Expires < invites[j].Expires
+	})
+	return invites, nil
+}
+
+// GetByCode retrieves the invite with the given code.
+// Returns ErrNotFound if the invite does not exist or has expired.
+func GetByCode(ctx context.Context, client redis.Cmdable, code id.UID) (*Invite, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "invite.GetByCode")
+	defer span.End()
+	text, err := client.Get(ctx, RedisKey(code)).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, errs.NotFoundf("invite %v", code)
+	} else if err != nil {
+		return nil, srOtel.WithSetErrorf(span, "redis error retrieving %v: %w", code, err)
+	}
+	var inv Invite
+	if err := json.Unmarshal([]byte(text), &inv); err != nil {
+		return nil, srOtel.WithSetErrorf(span, "parsing invite %v: %w", code, err)
+	}
+	return &inv, nil
+}
+
+// Create adds a new invite to its game. Codes of expired invites in the game
+// are cleaned up along the way.
+// Returns ErrBadRequest if the game would have more than MaxInvites.
+func Create(ctx context.Context, client *redis.Client, inv *Invite, now int64) error {
+	ctx, span := srOtel.Tracer.Start(ctx, "invite.Create")
+	defer span.End()
+	inviteBytes, err := json.Marshal(inv)
+	if err != nil {
+		return srOtel.WithSetErrorf(span, "marshaling invite %v: %w", inv, err)
+	}
+	gameKey := GameKey(inv.GameID)
+
+	watched := func(tx *redis.Tx) error {
+		codes, err := tx.SMembers(ctx, gameKey).Result()
+		if err != nil {
+			return srOtel.WithSetErrorf(span, "getting invite codes: %w", err)
+		}
+		var expired []interface{}
+		for _, code := range codes {
+			found, err := tx.Exists(ctx, RedisKey(id.UID(code))).Result()
+			if err != nil {
+				return srOtel.WithSetErrorf(span, "checking invite %v: %w", code, err)
+			}
+			if found == 0 {
+				expired = append(expired, code)
+			}
+		}
+		if len(codes)-len(expired) >= MaxInvites {
+			return errs.BadRequestf("game already has %v invites", MaxInvites)
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			if len(expired) > 0 {
+				pipe.SRem(ctx, gameKey, expired...)
+			}
+			pipe.Set(ctx, RedisKey(inv.Code), inviteBytes, ttlAt(inv, now))
+			pipe.SAdd(ctx, gameKey, string(inv.Code))
+			return nil
+		})
+		return err
+	}
+	err = redisUtil.RetryWatchTxn(ctx, client, watched, gameKey)
+	if errors.Is(err, errs.ErrBadRequest) {
+		return err
+	} else if err != nil {
+		return srOtel.WithSetErrorf(span, "running transaction: %w", err)
+	}
+	return nil
+}
+
+// Redeem uses up one of an invite's uses, removing it if it has none left.
+// Returns ErrNotFound if the invite does not exist or cannot be used.
+func Redeem(ctx context.Context, client *redis.Client, code id.UID, now int64) (*Invite, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "invite.Redeem")
+	defer span.End()
+	var redeemed *Invite
+
+	watched := func(tx *redis.Tx) error {
+		inv, err := GetByCode(ctx, tx, code)
+		if err != nil {
+			return err
+		}
+		if !inv.IsUsable(now) {
+			return errs.NotFoundf("invite %v", code)
+		}
+		inv.Uses++
+		inviteBytes, err := json.Marshal(inv)
+		if err != nil {
+			return srOtel.WithSetErrorf(span, "marshaling invite %v: %w", inv, err)
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			if inv.Uses >= inv.MaxUses {
+				pipe.Del(ctx, RedisKey(code))
+				pipe.SRem(ctx, GameKey(inv.GameID), string(code))
+			} else {
+				pipe.Set(ctx, RedisKey(code), inviteBytes, ttlAt(inv, now))
+			}
+			return nil
+		})
+		redeemed = inv
+		return err
+	}
+	err := redisUtil.RetryWatchTxn(ctx, client, watched, RedisKey(code))
+	if errors.Is(err, errs.ErrNotFound) {
+		return nil, err
+	} else if err != nil {
+		return nil, srOtel.WithSetErrorf(span, "running transaction: %w", err)
+	}
+	return redeemed, nil
+}
+
+// Revoke removes an invite from a game.
+// Returns ErrNotFound if the game does not have the invite.
+func Revoke(ctx context.Context, client redis.Cmdable, gameID string, code id.UID) error {
+	ctx, span := srOtel.Tracer.Start(ctx, "invite.Revoke")
+	defer span.End()
+	removed, err := client.SRem(ctx, GameKey(gameID), string(code)).Result()
+	if err != nil {
+		return srOtel.WithSetErrorf(span, "removing invite code: %w", err)
+	}
+	if removed != 1 {
+		return errs.NotFoundf("invite %v", code)
+	}
+	if err := client.Del(ctx, RedisKey(code)).Err(); err != nil {
+		return srOtel.WithSetErrorf(span, "deleting invite: %w", err)
+	}
+	return nil
+}
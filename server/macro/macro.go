@@ -0,0 +1,76 @@
+package macro
+
+import (
+	"fmt"
+	"strings"
+
+	"sr/config"
+	"sr/errs"
+	"sr/event"
+	"sr/id"
+)
+
+// MaxMacros is the largest number of macros a player may have in a game.
+const MaxMacros = 50
+
+// Macro is a saved roll a player may repeat.
+type Macro struct {
+	ID      id.UID `json:"id"`
+	Name    string `json:"name"`
+	Title   string `json:"title"`
+	Pool    int    `json:"pool"`
+	Edge    bool   `json:"edge"`
+	Glitchy int    `json:"glitchy"`
+	Limit   int    `json:"limit,omitempty"` // Maximum hits, if any
+	Share   int    `json:"share"`
+}
+
+// Make constructs a new Macro object, giving it a UID.
+func Make(name string) Macro {
+	return Macro{ID: id.GenUID(), Name: name}
+}
+
+// RedisKey is the key of the hash of a player's macros in a game.
+func RedisKey(gameID string, playerID id.UID) string {
+	return "macros:" + gameID + ":" + string(playerID)
+}
+
+// Validate checks that the macro's values are in range.
+// Returns ErrBadRequest describing the first invalid value.
+func (m *Macro) Validate() error {
+	if len(m.Name) == 0 || len(m.Name) > 32 || strings.ContainsAny(m.Name, "\r\n") {
+		return errs.BadRequestf("name: invalid")
+	}
+	if len(m.Title) > 128 {
+		return errs.BadRequestf("title: too long")
+	}
+	if m.Pool < 1 || m.Pool > config.MaxSingleRoll {
+		return errs.BadRequestf("pool: invalid")
+	}
+	if m.Glitchy < -m.Pool || m.Glitchy > m.Pool {
+		return errs.BadRequestf("glitchy: invalid")
+	}
+	if m.Limit < 0 || m.Limit > config.MaxSingleRoll {
+		return errs.BadRequestf("limit: invalid")
+	}
+	if !event.IsShare(m.Share) || event.Share(m.Share) == event.ShareWhisper {
+		return errs.BadRequestf("share: invalid")
+	}
+	return nil
+}
+
+// Breakdown describes the macro's pool and limit on its rolls.
+func (m *Macro) Breakdown() *event.Breakdown {
+	breakdown := &event.Breakdown{
+		Parts: []event.PoolPart{{Name: m.Name, Dice: m.Pool}},
+	}
+	if m.Limit > 0 {
+		breakdown.Limit = m.Limit
+		breakdown.LimitName = "Limit"
+	}
+	return breakdown
+}
+
+func (m *Macro) String() string {
+	return fmt.Sprintf("%v (%v)", m.ID, m.Name)
+}
@@ -0,0 +1,100 @@
+package macro_test
+
+import (
+	"context"
+	"testing"
+
+	genGame "sr/gen/game"
+	genMacro "sr/gen/macro"
+	genPlayer "sr/gen/player"
+
+	"sr/errs"
+	"sr/event"
+	"sr/macro"
+	"sr/test"
+)
+
+func TestMacro_Validate(t *testing.T) {
+	rng := test.RNG()
+
+	test.RunParallel(t, "generated macros are valid", func(t *testing.T) {
+		for i := 0; i < 20; i++ {
+			generated := genMacro.Macro(rng)
+			test.AssertSuccess(t, generated.Validate(), "validating macro")
+		}
+	})
+	test.RunParallel(t, "invalid values are rejected", func(t *testing.T) {
+		invalid := []func(*macro.Macro){
+			func(m *macro.Macro) { m.Name = "" },
+			func(m *macro.Macro) { m.Name = "line\nbreak" },
+			func(m *macro.Macro) { m.Pool = 0 },
+			func(m *macro.Macro) { m.Limit = -1 },
+			func(m *macro.Macro) { m.Share = int(event.ShareWhisper) },
+		}
+		for ix, makeInvalid := range invalid {
+			generated := genMacro.Macro(rng)
+			makeInvalid(&generated)
+			err := generated.Validate()
+			if err == nil {
+				t.Errorf("case %v: expected macro %v to be invalid", ix, &generated)
+			}
+			test.AssertErrorIs(t, err, errs.ErrBadRequest)
+		}
+	})
+}
+
+func TestCRUD(t *testing.T) {
+	ctx := context.Background()
+	rng := test.RNG()
+	_, client := test.GetRedis(t)
+
+	gameID := genGame.GameID(rng)
+	plr := genPlayer.Player(rng)
+	first, second := genMacro.Macro(rng), genMacro.Macro(rng)
+	first.Name, second.Name = "a", "b"
+
+	err := macro.Save(ctx, client, gameID, plr.ID, true, second, first)
+	test.AssertSuccess(t, err, "creating macros")
+
+	t.Run("it finds created macros", func(t *testing.T) {
+		found, err := macro.GetAll(ctx, client, gameID, plr.ID)
+		test.AssertSuccess(t, err, "getting macros")
+		test.AssertEqual(t, []macro.Macro{first, second}, found)
+
+		other, err := macro.GetAll(ctx, client, gameID, genPlayer.Player(rng).ID)
+		test.AssertSuccess(t, err, "getting other player's macros")
+		test.AssertEqual(t, 0, len(other))
+	})
+	t.Run("it does not create twice", func(t *testing.T) {
+		err := macro.Save(ctx, client, gameID, plr.ID, true, first)
+		test.AssertErrorIs(t, err, errs.ErrBadRequest)
+	})
+	t.Run("it updates macros", func(t *testing.T) {
+		first.Pool++
+		err := macro.Save(ctx, client, gameID, plr.ID, false, first)
+		test.AssertSuccess(t, err, "updating macro")
+		found, err := macro.GetByID(ctx, client, gameID, plr.ID, first.ID)
+		test.AssertSuccess(t, err, "getting macro")
+		test.AssertEqual(t, first, *found)
+
+		missing := genMacro.Macro(rng)
+		err = macro.Save(ctx, client, gameID, plr.ID, false, missing)
+		test.AssertErrorIs(t, err, errs.ErrNotFound)
+	})
+	t.Run("it deletes macros", func(t *testing.T) {
+		err := macro.Delete(ctx, client, gameID, plr.ID, second.ID)
+		test.AssertSuccess(t, err, "deleting macro")
+		_, err = macro.GetByID(ctx, client, gameID, plr.ID, second.ID)
+		test.AssertErrorIs(t, err, errs.ErrNotFound)
+		err = macro.Delete(ctx, client, gameID, plr.ID, second.ID)
+		test.AssertErrorIs(t, err, errs.ErrNotFound)
+	})
+	t.Run("it limits macro count", func(t *testing.T) {
+		many := make([]macro.Macro, macro.MaxMacros)
+		for ix := range many {
+			many[ix] = genMacro.Macro(rng)
+		}
+		err := macro.Save(ctx, client, gameID, plr.ID, true, many...)
+		test.AssertErrorIs(t, err, errs.ErrBadRequest)
+	})
+}
@@ -0,0 +1,123 @@
+package macro
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+
+	"sr/errs"
+	"sr/id"
+	srOtel "sr/otel"
+	redisUtil "sr/redis"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// GetAll retrieves a player's macros in a game, sorted by name.
+func GetAll(ctx context.Context, client redis.Cmdable, gameID string, playerID id.UID) ([]Macro, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "macro.GetAll")
+	defer span.End()
+	macroTexts, err := client.HGetAll(ctx, RedisKey(gameID, playerID)).Result()
+	if err != nil {
+		return nil, srOtel.WithSetErrorf(span, "getting macros: %w", err)
+	}
+	macros := make([]Macro, 0, len(macroTexts))
+	for macroID, text := range macroTexts {
+		var macro Macro
+		if err := json.Unmarshal([]byte(text), &macro); err != nil {
+			return nil, srOtel.WithSetErrorf(span, "parsing macro %v: %w", macroID, err)
+		}
+		macros = append(macros, macro)
+	}
+	sort.Slice(macros, func(i, j int) bool {
+		return macros[i].Name < macros[j].Name
+	})
+	return macros, nil
+}
+
+// GetByID retrieves one of a player's macros in a game.
+// Returns ErrNotFound if the macro does not exist.
+func GetByID(ctx context.Context, client redis.Cmdable, gameID string, playerID id.UID, macroID id.UID) (*Macro, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "macro.GetByID")
+	defer span.End()
+	text, err := client.HGet(ctx, RedisKey(gameID, playerID), string(macroID)).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, errs.NotFoundf("macro %v", macroID)
+	} else if err != nil {
+		return nil, srOtel.WithSetErrorf(span, "redis error retrieving %v: %w", macroID, err)
+	}
+	var macro Macro
+	if err := json.Unmarshal([]byte(text), &macro); err != nil {
+		return nil, srOtel.WithSetErrorf(span, "parsing macro %v: %w", macroID, err)
+	}
+	return &macro, nil
+}
+
+// Save adds or replaces macros of a player in a game. If create is set, the
+// macros must not already exist, otherwise they must.
+// Returns ErrBadRequest if the player would have more than MaxMacros, or if
+// a macro to create exists, and ErrNotFound if a macro to replace does not.
+func Save(
+	ctx context.Context, client *redis.Client, gameID string, playerID id.UID,
+	create bool, macros ...Macro,
+) error {
+	ctx, span := srOtel.Tracer.Start(ctx, "macro.Save")
+	defer span.End()
+	if len(macros) == 0 {
+		return nil
+	}
+	key := RedisKey(gameID, playerID)
+	fields := make([]interface{}, 0, 2*len(macros))
+	for ix := range macros {
+		macroBytes, err := json.Marshal(&macros[ix])
+		if err != nil {
+			return srOtel.WithSetErrorf(span, "marshaling macro %v: %w", &macros[ix], err)
+		}
+		fields = append(fields, string(macros[ix].ID), macroBytes)
+	}
+
+	watched := func(tx *redis.Tx) error {
+		count, err := tx.HLen(ctx, key).Result()
+		if err != nil {
+			return err
+		}
+		for ix := range macros {
+			exists, err := tx.HExists(ctx, key, string(macros[ix].ID)).Result()
+			if err != nil {
+				return err
+			}
+			if create && exists {
+				return errs.BadRequestf("macro %v already exists", macros[ix].ID)
+			} else if !create && !exists {
+				return errs.NotFoundf("macro %v", macros[ix].ID)
+			}
+		}
+		if create && int(count)+len(macros) > MaxMacros {
+			return errs.BadRequestf("cannot have more than %v macros", MaxMacros)
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			return pipe.HSet(ctx, key, fields...).Err()
+		})
+		return err
+	}
+	if err := redisUtil.RetryWatchTxn(ctx, client, watched, key); err != nil {
+		return srOtel.WithSetErrorf(span, "saving %v macros: %w", len(macros), err)
+	}
+	return nil
+}
+
+// Delete removes one of a player's macros in a game.
+// Returns ErrNotFound if the macro does not exist.
+func Delete(ctx context.Context, client redis.Cmdable, gameID string, playerID id.UID, macroID id.UID) error {
+	ctx, span := srOtel.Tracer.Start(ctx, "macro.Delete")
+	defer span.End()
+	deleted, err := client.HDel(ctx, RedisKey(gameID, playerID), string(macroID)).Result()
+	if err != nil {
+		return srOtel.WithSetErrorf(span, "redis error deleting %v: %w", macroID, err)
+	}
+	if deleted == 0 {
+		return errs.NotFoundf("macro %v", macroID)
+	}
+	return nil
+}
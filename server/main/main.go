@@ -7,6 +7,7 @@ import (
 
 	"sr"
 	"sr/config"
+	"sr/game"
 	srHTTP "sr/http"
 	"sr/log"
 	srOtel "sr/otel"
@@ -49,6 +50,14 @@ func main() {
 	sr.SeedRand(ctx)
 	roll.Init(ctx)
 
+	{
+		ctx, release := shutdown.Register(ctx, "timers")
+		go func() {
+			defer release()
+			game.RunTimers(ctx, redisUtil.Client)
+		}()
+	}
+
 	{
 		ctx, release := shutdown.Register(ctx, "setup")
 		setup.CheckGamesAndPlayers(ctx, redisUtil.Client)
@@ -0,0 +1,373 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"sr/errs"
+	srOtel "sr/otel"
+)
+
+// maxResponseBytes limits how much we read of provider responses.
+const maxResponseBytes = 1 << 20
+
+// keysRefreshInterval is the least time between fetching the provider's keys,
+// which are fetched again when a token is signed with an unknown key.
+const keysRefreshInterval = time.Minute
+
+// clockSkew is how far the provider's clock may be from ours.
+const clockSkew = time.Minute
+
+// Provider is the configuration of an OpenID Connect provider, from its
+// discovery document.
+type Provider struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Client is a relying party of an OpenID Connect provider. The provider is
+// discovered on first use.
+type Client struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	HTTP         *http.Client
+
+	mutex       sync.Mutex
+	provider    *Provider
+	keys        map[string]crypto.PublicKey
+	keysFetched time.Time
+}
+
+// NewClient constructs a client of the provider at the given issuer URL.
+func NewClient(issuer string, clientID string, clientSecret string, redirectURL string) *Client {
+	return &Client{
+		Issuer:       strings.TrimSuffix(issuer, "/"),
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  redirectURL,
+		HTTP:         &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (c *Client) getJSON(ctx context.Context, url string, value interface{}) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Accept", "application/json")
+	response, err := c.HTTP.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %v: %v", url, response.Status)
+	}
+	return json.NewDecoder(io.LimitReader(response.Body, maxResponseBytes)).Decode(value)
+}
+
+// Provider retrieves the provider's discovery document.
+func (c *Client) Provider(ctx context.Context) (*Provider, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.provider != nil {
+		return c.provider, nil
+	}
+	ctx, span := srOtel.Tracer.Start(ctx, "oidc.Provider")
+	defer span.End()
+	var provider Provider
+	if err := c.getJSON(ctx, c.Issuer+"/.well-known/openid-configuration", &provider); err != nil {
+		return nil, srOtel.WithSetErrorf(span, "discovering provider: %w", err)
+	}
+	if provider.Issuer != c.Issuer {
+		return nil, srOtel.WithSetErrorf(span, "provider issuer %v, expected %v", provider.Issuer, c.Issuer)
+	}
+	if provider.AuthorizationEndpoint == "" || provider.TokenEndpoint == "" || provider.JWKSURI == "" {
+		return nil, srOtel.WithSetErrorf(span, "provider is missing endpoints")
+	}
+	c.provider = &provider
+	return c.provider, nil
+}
+
+// AuthURL is the URL to send the player to in order to log in with the
+// provider.
+func (c *Client) AuthURL(ctx context.Context, state *State) (string, error) {
+	provider, err := c.Provider(ctx)
+	if err != nil {
+		return "", err
+	}
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {c.ClientID},
+		"redirect_uri":          {c.RedirectURL},
+		"scope":                 {"openid profile"},
+		"state":                 {string(state.ID)},
+		"nonce":                 {state.Nonce},
+		"code_challenge":        {state.Challenge()},
+		"code_challenge_method": {"S256"},
+	}
+	separator := "?"
+	if strings.Contains(provider.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return provider.AuthorizationEndpoint + separator + params.Encode(), nil
+}
+
+type tokenResponse struct {
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// Exchange exchanges an authorization code for an ID token, which should be
+// checked with VerifyIDToken.
+// Returns ErrNoAccess if the provider rejects the code.
+func (c *Client) Exchange(ctx context.Context, code string, verifier string) (string, error) {
+	provider, err := c.Provider(ctx)
+	if err != nil {
+		return "", err
+	}
+	ctx, span := srOtel.Tracer.Start(ctx, "oidc.Exchange")
+	defer span.End()
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {c.RedirectURL},
+		"client_id":     {c.ClientID},
+		"code_verifier": {verifier},
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		provider.TokenEndpoint, strings.NewReader(form.Encode()),
+	)
+	if err != nil {
+		return "", srOtel.WithSetErrorf(span, "making token request: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+	if c.ClientSecret != "" {
+		request.SetBasicAuth(url.QueryEscape(c.ClientID), url.QueryEscape(c.ClientSecret))
+	}
+	response, err := c.HTTP.Do(request)
+	if err != nil {
+		return "", srOtel.WithSetErrorf(span, "requesting token: %w", err)
+	}
+	defer response.Body.Close()
+	var tokens tokenResponse
+	if err := json.NewDecoder(io.LimitReader(response.Body, maxResponseBytes)).Decode(&tokens); err != nil {
+		return "", srOtel.WithSetErrorf(span, "parsing token response (%v): %w", response.Status, err)
+	}
+	if response.StatusCode != http.StatusOK {
+		if response.StatusCode >= 500 {
+			return "", srOtel.WithSetErrorf(span, "token request: %v", response.Status)
+		}
+		return "", errs.NoAccessf("token request: %v %v", tokens.Error, tokens.ErrorDescription)
+	}
+	if tokens.IDToken == "" {
+		return "", errs.NoAccessf("token response has no ID token")
+	}
+	return tokens.IDToken, nil
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k *jwk) publicKey() (crypto.PublicKey, error) {
+	decode := base64.RawURLEncoding.DecodeString
+	switch k.Kty {
+	case "RSA":
+		n, err := decode(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode(k.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("invalid RSA exponent")
+		}
+		exponent := 0
+		for _, b := range e {
+			exponent = exponent<<8 | int(b)
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: exponent}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %v", k.Crv)
+		}
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		key := &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+		if !key.Curve.IsOnCurve(key.X, key.Y) {
+			return nil, fmt.Errorf("invalid EC key")
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %v", k.Kty)
+	}
+}
+
+// key finds the provider's signing key with the given ID, fetching the
+// provider's keys again if it is not known.
+func (c *Client) key(ctx context.Context, provider *Provider, keyID string) (crypto.PublicKey, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if key, ok := c.keys[keyID]; ok {
+		return key, nil
+	}
+	if time.Since(c.keysFetched) < keysRefreshInterval {
+		return nil, errs.NoAccessf("unknown signing key %v", keyID)
+	}
+	ctx, span := srOtel.Tracer.Start(ctx, "oidc.key")
+	defer span.End()
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := c.getJSON(ctx, provider.JWKSURI, &set); err != nil {
+		return nil, srOtel.WithSetErrorf(span, "fetching keys: %w", err)
+	}
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, found := range set.Keys {
+		if found.Use != "" && found.Use != "sig" {
+			continue
+		}
+		key, err := found.publicKey()
+		if err != nil {
+			continue // Keys we can't use
+		}
+		keys[found.Kid] = key
+	}
+	c.keys = keys
+	c.keysFetched = time.Now()
+	if key, ok := keys[keyID]; ok {
+		return key, nil
+	}
+	return nil, errs.NoAccessf("unknown signing key %v", keyID)
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// verifySignature checks a JWT's RS256 or ES256 signature.
+func verifySignature(key crypto.PublicKey, alg string, signed []byte, signature []byte) bool {
+	digest := sha256.Sum256(signed)
+	switch alg {
+	case "RS256":
+		rsaKey, ok := key.(*rsa.PublicKey)
+		return ok && rsa.VerifyPKCS1v15(rsaKey, crypto.SHA256, digest[:], signature) == nil
+	case "ES256":
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok || len(signature) != 64 {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		return ecdsa.Verify(ecKey, digest[:], r, s)
+	default:
+		return false
+	}
+}
+
+var errMalformedToken = errs.NoAccessf("malformed ID token")
+
+// VerifyIDToken checks an ID token's signature and claims, returning its
+// claims.
+// Returns ErrNoAccess if the token is not valid for this client and login.
+func (c *Client) VerifyIDToken(ctx context.Context, rawToken string, nonce string, now time.Time) (*Claims, error) {
+	provider, err := c.Provider(ctx)
+	if err != nil {
+		return nil, err
+	}
+	parts := strings.Split(rawToken, ".")
+	if len(parts) != 3 {
+		return nil, errMalformedToken
+	}
+	headerBytes, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, errMalformedToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, errMalformedToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errMalformedToken
+	}
+	var header jwtHeader
+	if err := json.Unmarshal(headerBytes, &header); err != nil {
+		return nil, errMalformedToken
+	}
+	if header.Alg != "RS256" && header.Alg != "ES256" {
+		return nil, errs.NoAccessf("unsupported ID token algorithm %v", header.Alg)
+	}
+	key, err := c.key(ctx, provider, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if !verifySignature(key, header.Alg, []byte(parts[0]+"."+parts[1]), signature) {
+		return nil, errs.NoAccessf("invalid ID token signature")
+	}
+
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, errMalformedToken
+	}
+	if claims.Issuer != provider.Issuer {
+		return nil, errs.NoAccessf("ID token issuer %v", claims.Issuer)
+	}
+	if !claims.Audience.contains(c.ClientID) {
+		return nil, errs.NoAccessf("ID token is for another client")
+	}
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != c.ClientID {
+		return nil, errs.NoAccessf("ID token is for another client")
+	}
+	if now.After(time.Unix(claims.Expires, 0).Add(clockSkew)) {
+		return nil, errs.NoAccessf("ID token expired")
+	}
+	if time.Unix(claims.IssuedAt, 0).After(now.Add(clockSkew)) {
+		return nil, errs.NoAccessf("ID token issued in the future")
+	}
+	if claims.Nonce != nonce {
+		return nil, errs.NoAccessf("ID token has the wrong nonce")
+	}
+	if claims.Subject == "" {
+		return nil, errs.NoAccessf("ID token has no subject")
+	}
+	return &claims, nil
+}
@@ -0,0 +1,161 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"sr/errs"
+	"sr/id"
+	srOtel "sr/otel"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// CreateState saves the state of a new login for StateTTL.
+func CreateState(ctx context.Context, client redis.Cmdable, state *State) error {
+	ctx, span := srOtel.Tracer.Start(ctx, "oidc.CreateState")
+	defer span.End()
+	stateBytes, err := json.Marshal(state)
+	if err != nil {
+		return srOtel.WithSetErrorf(span, "marshaling state: %w", err)
+	}
+	if err := client.Set(ctx, StateKey(state.ID), stateBytes, StateTTL).Err(); err != nil {
+		return srOtel.WithSetErrorf(span, "saving state: %w", err)
+	}
+	return nil
+}
+
+// TakeState retrieves and removes the state of a login, so that the
+// provider's response can only be used once.
+// Returns ErrNotFound if the state does not exist or has expired.
+func TakeState(ctx context.Context, client redis.Cmdable, stateID id.UID) (*State, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "oidc.TakeState")
+	defer span.End()
+	var get *redis.StringCmd
+	_, err := client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		get = pipe.Get(ctx, StateKey(stateID))
+		pipe.Del(ctx, StateKey(stateID))
+		return nil
+	})
+	if errors.Is(err, redis.Nil) {
+		return nil, errs.NotFoundf("login state %v", stateID)
+	} else if err != nil {
+		return nil, srOtel.WithSetErrorf(span, "taking state: %w", err)
+	}
+	var state State
+	if err := json.Unmarshal([]byte(get.Val()), &state); err != nil {
+		return nil, srOtel.WithSetErrorf(span, "parsing state: %w", err)
+	}
+	return &state, nil
+}
+
+// CreateCode saves a one-time code which can be exchanged for the session for
+// CodeTTL, so that the session itself is not put in a URL.
+func CreateCode(ctx context.Context, client redis.Cmdable, sessionID id.UID) (id.UID, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "oidc.CreateCode")
+	defer span.End()
+	code := id.GenSessionID()
+	if err := client.Set(ctx, CodeKey(code), string(sessionID), CodeTTL).Err(); err != nil {
+		return "", srOtel.WithSetErrorf(span, "saving code: %w", err)
+	}
+	return code, nil
+}
+
+// TakeCode retrieves and removes the session of a login's code.
+// Returns ErrNotFound if the code does not exist, was already used, or has
+// expired.
+func TakeCode(ctx context.Context, client redis.Cmdable, code id.UID) (id.UID, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "oidc.TakeCode")
+	defer span.End()
+	var get *redis.StringCmd
+	_, err := client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		get = pipe.Get(ctx, CodeKey(code))
+		pipe.Del(ctx, CodeKey(code))
+		return nil
+	})
+	if errors.Is(err, redis.Nil) {
+		return "", errs.NotFoundf("login code %v", code)
+	} else if err != nil {
+		return "", srOtel.WithSetErrorf(span, "taking code: %w", err)
+	}
+	return id.UID(get.Val()), nil
+}
+
+// GetLinkedPlayer finds the player linked to the issuer's subject.
+// Returns ErrNotFound if the subject is not linked to a player.
+func GetLinkedPlayer(ctx context.Context, client redis.Cmdable, issuer string, subject string) (id.UID, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "oidc.GetLinkedPlayer")
+	defer span.End()
+	playerID, err := client.HGet(ctx, SubjectsKey, Subject(issuer, subject)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", errs.NotFoundf("subject %v", subject)
+	} else if err != nil {
+		return "", srOtel.WithSetErrorf(span, "getting linked player: %w", err)
+	}
+	return id.UID(playerID), nil
+}
+
+// linkScript links a subject to a player if it is not linked yet, and adds it
+// to the player's links.
+var linkScript = redis.NewScript(`
+if redis.call("HSETNX", KEYS[1], ARGV[1], ARGV[2]) == 0 then
+	return 0
+end
+redis.call("SADD", KEYS[2], ARGV[1])
+return 1
+`)
+
+// Link links the issuer's subject to the player.
+// Returns ErrBadRequest if the subject is already linked to a player.
+func Link(ctx context.Context, client redis.Cmdable, issuer string, subject string, playerID id.UID) error {
+	ctx, span := srOtel.Tracer.Start(ctx, "oidc.Link")
+	defer span.End()
+	linked, err := linkScript.Run(ctx, client,
+		[]string{SubjectsKey, LinksKey(playerID)},
+		Subject(issuer, subject), string(playerID),
+	).Int()
+	if err != nil {
+		return srOtel.WithSetErrorf(span, "linking subject: %w", err)
+	}
+	if linked == 0 {
+		return errs.BadRequestf("subject %v is already linked to a player", subject)
+	}
+	return nil
+}
+
+// HasLinks determines if any subjects are linked to the player, so that they
+// log in with a provider.
+func HasLinks(ctx context.Context, client redis.Cmdable, playerID id.UID) (bool, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "oidc.HasLinks")
+	defer span.End()
+	count, err := client.SCard(ctx, LinksKey(playerID)).Result()
+	if err != nil {
+		return false, srOtel.WithSetErrorf(span, "counting links: %w", err)
+	}
+	return count > 0, nil
+}
+
+// unlinkAllScript removes all of a player's subjects from SubjectsKey.
+var unlinkAllScript = redis.NewScript(`
+local subjects = redis.call("SMEMBERS", KEYS[2])
+for _, subject in ipairs(subjects) do
+	redis.call("HDEL", KEYS[1], subject)
+end
+redis.call("DEL", KEYS[2])
+return #subjects
+`)
+
+// UnlinkAll unlinks all subjects linked to the player, returning the number
+// of subjects unlinked.
+func UnlinkAll(ctx context.Context, client redis.Cmdable, playerID id.UID) (int, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "oidc.UnlinkAll")
+	defer span.End()
+	unlinked, err := unlinkAllScript.Run(ctx, client,
+		[]string{SubjectsKey, LinksKey(playerID)},
+	).Int()
+	if err != nil {
+		return 0, srOtel.WithSetErrorf(span, "unlinking subjects: %w", err)
+	}
+	return unlinked, nil
+}
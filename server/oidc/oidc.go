@@ -0,0 +1,159 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"sr/id"
+)
+
+// Players may log in with an OpenID Connect provider, using the authorization
+// code flow with PKCE. Provider subjects are linked to players the first time
+// they log in, creating a new player if needed.
+
+// StateTTL is how long players have to log in with the provider.
+const StateTTL = 10 * time.Minute
+
+// CodeTTL is how long the frontend has to exchange a login's code for its
+// session.
+const CodeTTL = time.Minute
+
+// State is what we remember of a login while the player is with the provider.
+// Its ID is the `state` param of the flow.
+type State struct {
+	ID       id.UID `json:"id"`
+	Verifier string `json:"verifier"` // PKCE code verifier
+	Nonce    string `json:"nonce"`
+	GameID   string `json:"gameID"`
+	Persist  bool   `json:"persist"`
+	PlayerID id.UID `json:"playerID,omitempty"` // Player linking their account
+	Browser  string `json:"browser"`            // Hash of the browser's secret
+}
+
+func randomString() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+// MakeState constructs the state of a new login, with a random PKCE verifier
+// and nonce. playerID is set when a logged in player links their account.
+func MakeState(gameID string, persist bool, playerID id.UID) (State, error) {
+	verifier, err := randomString()
+	if err != nil {
+		return State{}, fmt.Errorf("generating verifier: %w", err)
+	}
+	nonce, err := randomString()
+	if err != nil {
+		return State{}, fmt.Errorf("generating nonce: %w", err)
+	}
+	return State{
+		ID:       id.GenSessionID(),
+		Verifier: verifier,
+		Nonce:    nonce,
+		GameID:   gameID,
+		Persist:  persist,
+		PlayerID: playerID,
+	}, nil
+}
+
+func hashString(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// Challenge is the PKCE S256 code challenge of the state's verifier.
+func (s *State) Challenge() string {
+	return hashString(s.Verifier)
+}
+
+// MakeBrowserSecret generates a secret for the browser starting a login, which
+// it keeps in a cookie so that only it can finish the login.
+func MakeBrowserSecret() (string, error) {
+	return randomString()
+}
+
+// BindBrowser ties the state to the browser with the given secret. Only the
+// hash of the secret is saved with the state.
+func (s *State) BindBrowser(secret string) {
+	s.Browser = hashString(secret)
+}
+
+// FromBrowser determines if the login was started by the browser with the
+// given secret.
+func (s *State) FromBrowser(secret string) bool {
+	if s.Browser == "" || secret == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(s.Browser), []byte(hashString(secret))) == 1
+}
+
+// StateKey is the key of the login state with the given ID.
+func StateKey(stateID id.UID) string {
+	return "oidc-state:" + string(stateID)
+}
+
+// CodeKey is the key of the session a login's one-time code is exchanged for.
+func CodeKey(code id.UID) string {
+	return "oidc-code:" + string(code)
+}
+
+// SubjectsKey is the key of the hash of provider subjects to playerIDs.
+const SubjectsKey = "oidc_player_ids"
+
+// LinksKey is the key of the set of subjects linked to a player, as in
+// SubjectsKey.
+func LinksKey(playerID id.UID) string {
+	return "oidc_subjects:" + string(playerID)
+}
+
+// Subject is the field of an issuer's subject in SubjectsKey.
+func Subject(issuer string, subject string) string {
+	return issuer + " " + subject
+}
+
+// Claims are the claims of an ID token we use.
+type Claims struct {
+	Issuer            string   `json:"iss"`
+	Subject           string   `json:"sub"`
+	Audience          audience `json:"aud"`
+	AuthorizedParty   string   `json:"azp"`
+	Expires           int64    `json:"exp"`
+	IssuedAt          int64    `json:"iat"`
+	Nonce             string   `json:"nonce"`
+	Name              string   `json:"name"`
+	PreferredUsername string   `json:"preferred_username"`
+}
+
+// audience is the `aud` claim, which may be a string or an array.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+	*a = multiple
+	return nil
+}
+
+func (a audience) contains(clientID string) bool {
+	for _, aud := range a {
+		if aud == clientID {
+			return true
+		}
+	}
+	return false
+}
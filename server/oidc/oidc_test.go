@@ -0,0 +1,330 @@
+package oidc_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"sr/errs"
+	"sr/id"
+	"sr/oidc"
+	"sr/test"
+)
+
+const clientID = "shadowroller"
+const clientSecret = "secret"
+const redirectURL = "http://localhost:3001/auth/oidc/callback"
+
+type grant struct {
+	challenge string
+	nonce     string
+	claims    map[string]interface{}
+}
+
+// testProvider is an in-process OpenID Connect provider.
+type testProvider struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	mutex  sync.Mutex
+	grants map[string]grant
+}
+
+func newTestProvider(t *testing.T) *testProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	test.AssertSuccess(t, err, "generating key")
+	provider := &testProvider{key: key, grants: make(map[string]grant)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", provider.handleDiscovery)
+	mux.HandleFunc("/jwks", provider.handleKeys)
+	mux.HandleFunc("/token", provider.handleToken)
+	provider.server = httptest.NewServer(mux)
+	t.Cleanup(provider.server.Close)
+	return provider
+}
+
+func (p *testProvider) client() *oidc.Client {
+	client := oidc.NewClient(p.server.URL, clientID, clientSecret, redirectURL)
+	client.HTTP = p.server.Client()
+	return client
+}
+
+func (p *testProvider) handleDiscovery(response http.ResponseWriter, request *http.Request) {
+	json.NewEncoder(response).Encode(map[string]string{
+		"issuer":                 p.server.URL,
+		"authorization_endpoint": p.server.URL + "/authorize",
+		"token_endpoint":         p.server.URL + "/token",
+		"jwks_uri":               p.server.URL + "/jwks",
+	})
+}
+
+func (p *testProvider) handleKeys(response http.ResponseWriter, request *http.Request) {
+	encode := base64.RawURLEncoding.EncodeToString
+	json.NewEncoder(response).Encode(map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "test",
+			"use": "sig",
+			"n":   encode(p.key.N.Bytes()),
+			"e":   encode(big.NewInt(int64(p.key.E)).Bytes()),
+		}},
+	})
+}
+
+func tokenError(response http.ResponseWriter, code string) {
+	response.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(response).Encode(map[string]string{"error": code})
+}
+
+func (p *testProvider) handleToken(response http.ResponseWriter, request *http.Request) {
+	user, pass, ok := request.BasicAuth()
+	if request.Method != http.MethodPost || !ok || user != clientID || pass != clientSecret {
+		tokenError(response, "invalid_client")
+		return
+	}
+	if request.PostFormValue("grant_type") != "authorization_code" ||
+		request.PostFormValue("redirect_uri") != redirectURL {
+		tokenError(response, "invalid_request")
+		return
+	}
+	p.mutex.Lock()
+	code := request.PostFormValue("code")
+	granted, found := p.grants[code]
+	delete(p.grants, code)
+	p.mutex.Unlock()
+	verifier := sha256.Sum256([]byte(request.PostFormValue("code_verifier")))
+	if !found || base64.RawURLEncoding.EncodeToString(verifier[:]) != granted.challenge {
+		tokenError(response, "invalid_grant")
+		return
+	}
+	json.NewEncoder(response).Encode(map[string]string{
+		"token_type": "Bearer",
+		"id_token":   p.sign("test", granted.claims),
+	})
+}
+
+func (p *testProvider) sign(keyID string, claims map[string]interface{}) string {
+	encode := base64.RawURLEncoding.EncodeToString
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": keyID})
+	payload, _ := json.Marshal(claims)
+	signed := encode(header) + "." + encode(payload)
+	digest := sha256.Sum256([]byte(signed))
+	signature, _ := rsa.SignPKCS1v15(rand.Reader, p.key, crypto.SHA256, digest[:])
+	return signed + "." + encode(signature)
+}
+
+func (p *testProvider) claims(nonce string) map[string]interface{} {
+	now := time.Now().Unix()
+	return map[string]interface{}{
+		"iss":                p.server.URL,
+		"sub":                "user-1",
+		"aud":                clientID,
+		"exp":                now + 300,
+		"iat":                now,
+		"nonce":              nonce,
+		"name":               "Test User",
+		"preferred_username": "test-user",
+	}
+}
+
+// authorize plays the part of the player logging in at the provider, returning
+// the code and state it would redirect back with.
+func (p *testProvider) authorize(t *testing.T, authURL string, edit func(map[string]interface{})) (string, id.UID) {
+	parsed, err := url.Parse(authURL)
+	test.AssertSuccess(t, err, "parsing auth URL")
+	test.AssertEqual(t, p.server.URL+"/authorize", parsed.Scheme+"://"+parsed.Host+parsed.Path)
+	params := parsed.Query()
+	test.AssertEqual(t, "code", params.Get("response_type"))
+	test.AssertEqual(t, clientID, params.Get("client_id"))
+	test.AssertEqual(t, redirectURL, params.Get("redirect_uri"))
+	test.AssertEqual(t, "S256", params.Get("code_challenge_method"))
+	test.AssertCheck(t, params.Get("scope"), strings.Contains(params.Get("scope"), "openid"), "openid scope")
+
+	claims := p.claims(params.Get("nonce"))
+	if edit != nil {
+		edit(claims)
+	}
+	code := string(id.GenSessionID())
+	p.mutex.Lock()
+	p.grants[code] = grant{challenge: params.Get("code_challenge"), claims: claims}
+	p.mutex.Unlock()
+	return code, id.UID(params.Get("state"))
+}
+
+func TestLogin(t *testing.T) {
+	ctx := context.Background()
+	provider := newTestProvider(t)
+	client := provider.client()
+
+	state, err := oidc.MakeState("game", true, "")
+	test.AssertSuccess(t, err, "making state")
+	authURL, err := client.AuthURL(ctx, &state)
+	test.AssertSuccess(t, err, "getting auth URL")
+	code, stateID := provider.authorize(t, authURL, nil)
+	test.AssertEqual(t, state.ID, stateID)
+
+	idToken, err := client.Exchange(ctx, code, state.Verifier)
+	test.AssertSuccess(t, err, "exchanging code")
+	claims, err := client.VerifyIDToken(ctx, idToken, state.Nonce, time.Now())
+	test.AssertSuccess(t, err, "verifying ID token")
+	test.AssertEqual(t, provider.server.URL, claims.Issuer)
+	test.AssertEqual(t, "user-1", claims.Subject)
+	test.AssertEqual(t, "test-user", claims.PreferredUsername)
+
+	_, err = client.Exchange(ctx, code, state.Verifier)
+	test.AssertErrorIs(t, err, errs.ErrNoAccess)
+}
+
+func TestExchange_WrongVerifier(t *testing.T) {
+	ctx := context.Background()
+	provider := newTestProvider(t)
+	client := provider.client()
+
+	state, err := oidc.MakeState("", false, "")
+	test.AssertSuccess(t, err, "making state")
+	other, err := oidc.MakeState("", false, "")
+	test.AssertSuccess(t, err, "making state")
+	authURL, err := client.AuthURL(ctx, &state)
+	test.AssertSuccess(t, err, "getting auth URL")
+	code, _ := provider.authorize(t, authURL, nil)
+
+	_, err = client.Exchange(ctx, code, other.Verifier)
+	test.AssertErrorIs(t, err, errs.ErrNoAccess)
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	ctx := context.Background()
+	provider := newTestProvider(t)
+	client := provider.client()
+	const nonce = "nonce"
+
+	valid := provider.sign("test", provider.claims(nonce))
+	_, err := client.VerifyIDToken(ctx, valid, nonce, time.Now())
+	test.AssertSuccess(t, err, "verifying valid token")
+
+	for name, edit := range map[string]func(map[string]interface{}){
+		"issuer":    func(c map[string]interface{}) { c["iss"] = "https://example.com" },
+		"audience":  func(c map[string]interface{}) { c["aud"] = "someone-else" },
+		"azp":       func(c map[string]interface{}) { c["aud"] = []string{clientID, "someone-else"} },
+		"expired":   func(c map[string]interface{}) { c["exp"] = time.Now().Add(-time.Hour).Unix() },
+		"future":    func(c map[string]interface{}) { c["iat"] = time.Now().Add(time.Hour).Unix() },
+		"nonce":     func(c map[string]interface{}) { c["nonce"] = "replayed" },
+		"subject":   func(c map[string]interface{}) { delete(c, "sub") },
+		"malformed": func(c map[string]interface{}) { c["exp"] = "soon" },
+	} {
+		claims := provider.claims(nonce)
+		edit(claims)
+		_, err := client.VerifyIDToken(ctx, provider.sign("test", claims), nonce, time.Now())
+		test.AssertCheck(t, name, err != nil, "invalid claims are rejected")
+		test.AssertErrorIs(t, err, errs.ErrNoAccess)
+	}
+
+	claims := provider.claims(nonce)
+	claims["aud"] = []string{clientID, "someone-else"}
+	claims["azp"] = clientID
+	_, err = client.VerifyIDToken(ctx, provider.sign("test", claims), nonce, time.Now())
+	test.AssertSuccess(t, err, "verifying token for multiple audiences")
+
+	parts := strings.Split(valid, ".")
+	tampered, _ := json.Marshal(provider.claims(nonce))
+	tampered = []byte(strings.Replace(string(tampered), "user-1", "user-2", 1))
+	forged := parts[0] + "." + base64.RawURLEncoding.EncodeToString(tampered) + "." + parts[2]
+	_, err = client.VerifyIDToken(ctx, forged, nonce, time.Now())
+	test.AssertErrorIs(t, err, errs.ErrNoAccess)
+
+	none, _ := json.Marshal(map[string]string{"alg": "none"})
+	unsigned := base64.RawURLEncoding.EncodeToString(none) + "." + parts[1] + "."
+	_, err = client.VerifyIDToken(ctx, unsigned, nonce, time.Now())
+	test.AssertErrorIs(t, err, errs.ErrNoAccess)
+
+	_, err = client.VerifyIDToken(ctx, provider.sign("unknown", provider.claims(nonce)), nonce, time.Now())
+	test.AssertErrorIs(t, err, errs.ErrNoAccess)
+	_, err = client.VerifyIDToken(ctx, "not.a-token", nonce, time.Now())
+	test.AssertErrorIs(t, err, errs.ErrNoAccess)
+}
+
+func TestTakeState(t *testing.T) {
+	ctx := context.Background()
+	_, client := test.GetRedis(t)
+
+	state, err := oidc.MakeState("game", true, "player")
+	test.AssertSuccess(t, err, "making state")
+	test.Must(t, oidc.CreateState(ctx, client, &state))
+
+	taken, err := oidc.TakeState(ctx, client, state.ID)
+	test.AssertSuccess(t, err, "taking state")
+	test.AssertEqual(t, &state, taken)
+	_, err = oidc.TakeState(ctx, client, state.ID)
+	test.AssertErrorIs(t, err, errs.ErrNotFound)
+}
+
+func TestFromBrowser(t *testing.T) {
+	state, err := oidc.MakeState("game", false, "")
+	test.AssertSuccess(t, err, "making state")
+	test.AssertEqual(t, false, state.FromBrowser(""))
+
+	secret, err := oidc.MakeBrowserSecret()
+	test.AssertSuccess(t, err, "making secret")
+	state.BindBrowser(secret)
+	test.AssertEqual(t, true, state.FromBrowser(secret))
+	test.AssertEqual(t, false, state.FromBrowser(secret+"x"))
+	test.AssertEqual(t, false, state.FromBrowser(""))
+}
+
+func TestTakeCode(t *testing.T) {
+	ctx := context.Background()
+	_, client := test.GetRedis(t)
+
+	code, err := oidc.CreateCode(ctx, client, "session")
+	test.AssertSuccess(t, err, "creating code")
+	sessionID, err := oidc.TakeCode(ctx, client, code)
+	test.AssertSuccess(t, err, "taking code")
+	test.AssertEqual(t, id.UID("session"), sessionID)
+	_, err = oidc.TakeCode(ctx, client, code)
+	test.AssertErrorIs(t, err, errs.ErrNotFound)
+}
+
+func TestLink(t *testing.T) {
+	ctx := context.Background()
+	_, client := test.GetRedis(t)
+	issuer := "https://id.example.com"
+
+	_, err := oidc.GetLinkedPlayer(ctx, client, issuer, "user-1")
+	test.AssertErrorIs(t, err, errs.ErrNotFound)
+	test.Must(t, oidc.Link(ctx, client, issuer, "user-1", "player-1"))
+	playerID, err := oidc.GetLinkedPlayer(ctx, client, issuer, "user-1")
+	test.AssertSuccess(t, err, "getting linked player")
+	test.AssertEqual(t, id.UID("player-1"), playerID)
+
+	err = oidc.Link(ctx, client, issuer, "user-1", "player-2")
+	test.AssertErrorIs(t, err, errs.ErrBadRequest)
+	_, err = oidc.GetLinkedPlayer(ctx, client, "https://other.example.com", "user-1")
+	test.AssertErrorIs(t, err, errs.ErrNotFound)
+
+	hasLinks, err := oidc.HasLinks(ctx, client, "player-1")
+	test.AssertSuccess(t, err, "checking links")
+	test.AssertEqual(t, true, hasLinks)
+	hasLinks, err = oidc.HasLinks(ctx, client, "player-2")
+	test.AssertSuccess(t, err, "checking links")
+	test.AssertEqual(t, false, hasLinks)
+
+	unlinked, err := oidc.UnlinkAll(ctx, client, "player-1")
+	test.AssertSuccess(t, err, "unlinking")
+	test.AssertEqual(t, 1, unlinked)
+	_, err = oidc.GetLinkedPlayer(ctx, client, issuer, "user-1")
+	test.AssertErrorIs(t, err, errs.ErrNotFound)
+	hasLinks, err = oidc.HasLinks(ctx, client, "player-1")
+	test.AssertSuccess(t, err, "checking links")
+	test.AssertEqual(t, false, hasLinks)
+}
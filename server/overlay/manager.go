@@ -0,0 +1,148 @@
+package overlay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+
+	"sr/errs"
+	"sr/event"
+	"sr/id"
+	srOtel "sr/otel"
+	redisUtil "sr/redis"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// GetAll retrieves the overlay tokens of a game, oldest first.
+func GetAll(ctx context.Context, client redis.Cmdable, gameID string) ([]Token, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "overlay.GetAll")
+	defer span.End()
+	values, err := client.SMembers(ctx, GameKey(gameID)).Result()
+	if err != nil {
+		return nil, srOtel.WithSetErrorf(span, "getting overlay tokens: %w", err)
+	}
+	tokens := make([]Token, 0, len(values))
+	for _, value := range values {
+		token, err := GetByToken(ctx, client, id.UID(value))
+		if errors.Is(err, errs.ErrNotFound) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, *token)
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i].Created < tokens[j].Created
+	})
+	return tokens, nil
+}
+
+// GetByToken retrieves an overlay token by its value.
+// Returns ErrNotFound if the token does not exist.
+func GetByToken(ctx context.Context, client redis.Cmdable, value id.UID) (*Token, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "overlay.GetByToken")
+	defer span.End()
+	text, err := client.Get(ctx, RedisKey(value)).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, errs.NotFoundf("overlay token")
+	} else if err != nil {
+		return nil, srOtel.WithSetErrorf(span, "redis error retrieving token: %w", err)
+	}
+	var token Token
+	if err := json.Unmarshal([]byte(text), &token); err != nil {
+		return nil, srOtel.WithSetErrorf(span, "parsing overlay token: %w", err)
+	}
+	return &token, nil
+}
+
+// Check ensures an overlay token exists and is for the given game.
+// Returns ErrNoAccess otherwise.
+func Check(ctx context.Context, client redis.Cmdable, gameID string, value id.UID) (*Token, error) {
+	if value == "" {
+		return nil, errs.NoAccessf("overlay token")
+	}
+	token, err := GetByToken(ctx, client, value)
+	if errors.Is(err, errs.ErrNotFound) {
+		return nil, errs.NoAccessf("overlay token")
+	} else if err != nil {
+		return nil, err
+	}
+	if token.GameID != gameID {
+		return nil, errs.NoAccessf("overlay token")
+	}
+	return token, nil
+}
+
+// Create adds a new overlay token to its game.
+// Returns ErrBadRequest if the game would have more than MaxTokens.
+func Create(ctx context.Context, client *redis.Client, token *Token) error {
+	ctx, span := srOtel.Tracer.Start(ctx, "overlay.Create")
+	defer span.End()
+	tokenBytes, err := json.Marshal(token)
+	if err != nil {
+		return srOtel.WithSetErrorf(span, "marshaling %v: %w", token, err)
+	}
+	gameKey := GameKey(token.GameID)
+
+	watched := func(tx *redis.Tx) error {
+		count, err := tx.SCard(ctx, gameKey).Result()
+		if err != nil {
+			return srOtel.WithSetErrorf(span, "counting overlay tokens: %w", err)
+		}
+		if count >= MaxTokens {
+			return errs.BadRequestf("game already has %v overlay tokens", MaxTokens)
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, RedisKey(token.Token), tokenBytes, 0)
+			pipe.SAdd(ctx, gameKey, string(token.Token))
+			return nil
+		})
+		return err
+	}
+	err = redisUtil.RetryWatchTxn(ctx, client, watched, gameKey)
+	if errors.Is(err, errs.ErrBadRequest) {
+		return err
+	} else if err != nil {
+		return srOtel.WithSetErrorf(span, "running transaction: %w", err)
+	}
+	return nil
+}
+
+// Revoke removes an overlay token from a game.
+// Returns ErrNotFound if the game does not have the token.
+func Revoke(ctx context.Context, client redis.Cmdable, gameID string, value id.UID) error {
+	ctx, span := srOtel.Tracer.Start(ctx, "overlay.Revoke")
+	defer span.End()
+	removed, err := client.SRem(ctx, GameKey(gameID), string(value)).Result()
+	if err != nil {
+		return srOtel.WithSetErrorf(span, "removing overlay token: %w", err)
+	}
+	if removed != 1 {
+		return errs.NotFoundf("overlay token")
+	}
+	if err := client.Del(ctx, RedisKey(value)).Err(); err != nil {
+		return srOtel.WithSetErrorf(span, "deleting overlay token: %w", err)
+	}
+	return nil
+}
+
+// GetFeed builds the Feed of a game from its recent history.
+func GetFeed(ctx context.Context, client redis.Cmdable, gameID string, rolls int) (*Feed, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "overlay.GetFeed")
+	defer span.End()
+	eventTexts, err := event.GetLatest(ctx, client, gameID, historyWindow)
+	if err != nil {
+		return nil, srOtel.WithSetErrorf(span, "getting events: %w", err)
+	}
+	events := make([]event.Event, 0, len(eventTexts))
+	for _, text := range eventTexts {
+		evt, err := event.Parse([]byte(text))
+		if err != nil {
+			return nil, srOtel.WithSetErrorf(span, "parsing event: %w", err)
+		}
+		events = append(events, evt)
+	}
+	return BuildFeed(gameID, events, rolls), nil
+}
@@ -0,0 +1,137 @@
+package overlay
+
+import (
+	"fmt"
+	"sort"
+
+	"sr/event"
+	"sr/id"
+	"sr/player"
+	"sr/roll"
+)
+
+// MaxTokens is the largest number of overlay tokens in a game.
+const MaxTokens = 10
+
+// MaxRolls is the most rolls an overlay may show.
+const MaxRolls = 20
+
+// DefaultRolls is the number of rolls an overlay shows if not specified.
+const DefaultRolls = 5
+
+// historyWindow is the number of recent events an overlay's feed is built from.
+const historyWindow = 100
+
+// Token allows a stream overlay to show a game's public rolls without a
+// session. Tokens are scoped to one game and are revoked by its GMs.
+type Token struct {
+	Token      id.UID `json:"token"`
+	GameID     string `json:"gameID"`
+	Title      string `json:"title"`
+	Created    int64  `json:"created"`
+	PlayerID   id.UID `json:"playerID"` // GM who created the token
+	PlayerName string `json:"playerName"`
+}
+
+// Make constructs a new Token, giving it a random value as long as a session ID.
+func Make(gameID string, plr *player.Player, title string, now int64) Token {
+	return Token{
+		Token:      id.GenSessionID(),
+		GameID:     gameID,
+		Title:      title,
+		Created:    now,
+		PlayerID:   plr.ID,
+		PlayerName: plr.Name,
+	}
+}
+
+// RedisKey is the key of the overlay token with the given value.
+func RedisKey(token id.UID) string {
+	return "overlay:" + string(token)
+}
+
+// GameKey is the key of the set of overlay tokens in a game.
+func GameKey(gameID string) string {
+	return "overlays:" + gameID
+}
+
+func (t *Token) String() string {
+	return fmt.Sprintf("overlay %v in %v", t.Title, t.GameID)
+}
+
+// InitiativeEntry is one place in a game's initiative order.
+type InitiativeEntry struct {
+	EventID int64  `json:"id"`
+	Name    string `json:"name"`
+	Title   string `json:"title"`
+	Total   int    `json:"total"`
+	Seized  bool   `json:"seized"`
+	Blitzed bool   `json:"blitzed"`
+}
+
+// Feed is what an overlay displays: a game's latest public rolls, and its
+// initiative order from public initiative rolls.
+type Feed struct {
+	GameID     string            `json:"gameID"`
+	Rolls      []event.Event     `json:"rolls"`
+	Initiative []InitiativeEntry `json:"initiative"`
+}
+
+// isRoll determines if an event is a roll an overlay shows.
+func isRoll(evt event.Event) bool {
+	switch evt.GetType() {
+	case event.EventTypeRoll, event.EventTypeEdgeRoll,
+		event.EventTypeReroll, event.EventTypeGroupRoll:
+		return true
+	default:
+		return false
+	}
+}
+
+// BuildFeed creates a Feed from a game's events, newest first, including only
+// the given number of rolls.
+//
+// Only events shared in game are ever included. The initiative order has the
+// latest initiative roll of each player with each title, sorted by those who
+// seized the initiative and then by total.
+func BuildFeed(gameID string, events []event.Event, rolls int) *Feed {
+	feed := &Feed{
+		GameID:     gameID,
+		Rolls:      make([]event.Event, 0, rolls),
+		Initiative: make([]InitiativeEntry, 0),
+	}
+	seenInits := make(map[string]bool)
+	for _, evt := range events {
+		if evt.GetShare() != event.ShareInGame {
+			continue
+		}
+		if isRoll(evt) && len(feed.Rolls) < rolls {
+			feed.Rolls = append(feed.Rolls, evt)
+		}
+		initiative, ok := evt.(*event.InitiativeRoll)
+		if !ok {
+			continue
+		}
+		initKey := string(initiative.GetPlayerID()) + ":" + initiative.Title
+		if seenInits[initKey] {
+			continue
+		}
+		seenInits[initKey] = true
+		feed.Initiative = append(feed.Initiative, InitiativeEntry{
+			EventID: initiative.GetID(),
+			Name:    initiative.GetPlayerName(),
+			Title:   initiative.Title,
+			Total:   initiative.Base + roll.SumDice(initiative.Dice),
+			Seized:  initiative.Seized,
+			Blitzed: initiative.Blitzed,
+		})
+	}
+	sort.SliceStable(feed.Initiative, func(i, j int) bool {
+		left, right := &feed.Initiative[i], &feed.Initiative[j]
+		if left.Seized != right.Seized {
+			return left.Seized
+		}
+		return left.Total > right.Total
+	})
+	return feed
+}
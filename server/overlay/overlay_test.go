@@ -0,0 +1,59 @@
+package overlay_test
+
+import (
+	"context"
+	"testing"
+
+	genGame "sr/gen/game"
+	genPlayer "sr/gen/player"
+
+	"sr/errs"
+	"sr/event"
+	"sr/id"
+	"sr/overlay"
+	"sr/player"
+	"sr/test"
+)
+
+func TestBuildFeed(t *testing.T) {
+	plr := &player.Player{ID: id.UID("plr"), Name: "Chrome"}
+	gm := &player.Player{ID: id.UID("gm"), Name: "GM"}
+
+	public := event.ForRoll(plr, event.ShareInGame, "Shoot", []int{5, 6, 1}, 0)
+	secret := event.ForRoll(gm, event.ShareGMs, "Perception", []int{5, 5}, 0)
+	oldInit := event.ForInitiativeRoll(plr, event.ShareInGame, "", 10, []int{1}, false, false)
+	init := event.ForInitiativeRoll(plr, event.ShareInGame, "", 10, []int{6}, false, false)
+	seized := event.ForInitiativeRoll(gm, event.ShareInGame, "Ganger", 5, []int{1}, true, false)
+	secretInit := event.ForInitiativeRoll(gm, event.ShareGMs, "Sniper", 20, []int{6}, false, false)
+	events := []event.Event{&secret, &public, &secretInit, &seized, &init, &oldInit}
+
+	feed := overlay.BuildFeed("game", events, 5)
+	test.AssertEqual(t, []event.Event{&public}, feed.Rolls)
+	test.AssertEqual(t, []overlay.InitiativeEntry{
+		{EventID: seized.GetID(), Name: "GM", Title: "Ganger", Total: 6, Seized: true},
+		{EventID: init.GetID(), Name: "Chrome", Total: 16},
+	}, feed.Initiative)
+
+	feed = overlay.BuildFeed("game", events, 0)
+	test.AssertEqual(t, 0, len(feed.Rolls))
+}
+
+func TestCheck(t *testing.T) {
+	ctx := context.Background()
+	rng := test.RNG()
+	_, client := test.GetRedis(t)
+
+	gameID := genGame.GameID(rng)
+	gm := genPlayer.Player(rng)
+	token := overlay.Make(gameID, gm, "Stream", id.TimestampNow())
+	test.Must(t, overlay.Create(ctx, client, &token))
+
+	_, err := overlay.Check(ctx, client, gameID, token.Token)
+	test.AssertSuccess(t, err, "checking token")
+	_, err = overlay.Check(ctx, client, "other-game", token.Token)
+	test.AssertErrorIs(t, err, errs.ErrNoAccess)
+
+	test.Must(t, overlay.Revoke(ctx, client, gameID, token.Token))
+	_, err = overlay.Check(ctx, client, gameID, token.Token)
+	test.AssertErrorIs(t, err, errs.ErrNoAccess)
+}
@@ -32,7 +32,7 @@ var OnlineModeOffline OnlineMode = 2
 // Player is a user of Shadowroller.
 //
 // Players may be registered for a number of games.
-// Within those games, they may have a number of chars.
+// Within those games, they may have a number of characters (see `character`).
 type Player struct {
 	ID   id.UID `redis:"-"`
 	Name string `redis:"name"`
@@ -41,6 +41,10 @@ type Player struct {
 	Username    string     `redis:"uname"`
 	Connections int        `redis:"connections"`
 	OnlineMode  OnlineMode `redis:"onlineMode"`
+
+	// PasswordHash is the encoded hash of the player's password, if they have
+	// set one. See `credential`.
+	PasswordHash string `redis:"password"`
 }
 
 // Info is data other players can see about a player.
@@ -94,6 +98,11 @@ func (p *Player) IsOnline() bool {
 	}
 }
 
+// HasPassword determines if the player has set a password to log in with.
+func (p *Player) HasPassword() bool {
+	return p.PasswordHash != ""
+}
+
 // RedisKey is the key for acccessing player info from redis
 func (p *Player) RedisKey() string {
 	if p == nil || p.ID == "" {
@@ -232,63 +241,6 @@ func ValidName(name string) bool {
 	return len(name) > 0 && len(name) < 32 && !strings.ContainsAny(name, "\r\n")
 }
 
-/*
-// GetPlayerCharIDs returns the IDs of all the chars of a player
-func GetPlayerCharIDs(playerID UID) ([]UID, error) {
-	ids, err := redis.Strings(conn.Do("SGETALL", "chars:"+string(playerID)))
-	if err != nil {
-		return nil, err
-	}
-	uids := make([]UID, len(ids))
-	for ix, id := range ids {
-		uids[ix] = UID(id)
-	}
-	return uids, nil
-}
-
-// GetPlayerChars returns the chars of a given player
-func GetPlayerChars(playerID UID) ([]Char, error) {
-	ids, err := GetPlayerCharIDs(playerID, conn)
-	if err != nil {
-		return nil, srOtel.WithSetErrorf(span, "error from GetPlayerCharIDs: %w", err)
-	}
-	err = conn.Send("MULTI")
-	if err != nil {
-		return nil, srOtel.WithSetErrorf(span, "redis error sending `MULTI`: %w", err)
-	}
-	found := make([]Char, len(ids))
-	for _, charID := range ids {
-		err = conn.Send("HGETALL", "char:"+string(charID))
-		if err != nil {
-			return nil, srOtel.WithSetErrorf(span,
-				"redis error sending `HGETALL` for %v: %w", charID, err,
-			)
-		}
-	}
-	charsData, err := redis.Values(conn.Do("EXEC"))
-	if err != nil {
-		return nil, srOtel.WithSetErrorf(span, "redis error sending `EXEC`: %w", err)
-	}
-
-	for ix, charData := range charsData {
-		var char Char
-		err = redis.ScanStruct(charData.([]interface{}), &char)
-		if err != nil {
-			return nil, srOtel.WithSetErrorf(span,
-				"redis error parsing char #%v %v: %w", ix, ids[ix], err,
-			)
-		}
-		if char.Name == "" {
-			return nil, srOtel.WithSetErrorf(span,
-				"no data for char #%v %v after redis parse", ix, ids[ix],
-			)
-		}
-		found[ix] = char
-	}
-	return found, nil
-}
-*/
-
 // Create adds the given player to the database.
 // Returns ErrBadRequest if a player with that username already exists
 func Create(ctx context.Context, client redis.Cmdable, player *Player) error {
@@ -0,0 +1,110 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	srOtel "sr/otel"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Requests are rate limited with token buckets. Each bucket holds up to its
+// Budget of tokens and is refilled at a steady rate; requests take their Cost
+// from it, and are refused while it does not have enough.
+//
+// Buckets are kept in Redis and updated atomically with a Lua script, so
+// limits are shared by every server.
+
+// Limit configures the bucket requests to a route are charged to.
+type Limit struct {
+	Bucket string // Name of the bucket; routes with the same bucket share it
+	Budget int    // Most tokens the bucket holds
+	Refill int    // Tokens added to the bucket per minute
+	Cost   int    // Tokens each request takes
+	Exempt bool   // Requests are not limited
+}
+
+func (l *Limit) String() string {
+	if l.Exempt {
+		return "exempt"
+	}
+	return fmt.Sprintf("%v: %v of %v, %v/min", l.Bucket, l.Cost, l.Budget, l.Refill)
+}
+
+// Valid determines if requests could ever be allowed by the limit.
+func (l *Limit) Valid() bool {
+	return l.Exempt || (l.Bucket != "" && l.Refill > 0 && l.Cost > 0 && l.Cost <= l.Budget)
+}
+
+// Result is the state of a bucket after a request.
+type Result struct {
+	Allowed    bool
+	Remaining  int           // Tokens left in the bucket
+	Reset      time.Duration // Until the bucket is full
+	RetryAfter time.Duration // Until the request would be allowed, if it was not
+}
+
+// BucketKey is the key of the bucket of the given subject, a player or IP.
+func BucketKey(bucket string, subject string) string {
+	return "ratelimit:" + bucket + ":" + subject
+}
+
+// takeScript takes tokens from a bucket, after refilling it for the time since
+// it was last updated. Buckets expire once they would be full.
+//
+// KEYS: bucket
+// ARGV: budget, refill per minute, cost, now in milliseconds
+// Returns: allowed (0 or 1), remaining tokens, ms until full, ms until allowed
+var takeScript = redis.NewScript(`
+local budget = tonumber(ARGV[1])
+local refill = tonumber(ARGV[2]) / 60000
+local cost = tonumber(ARGV[3])
+local now = tonumber(ARGV[4])
+
+local bucket = redis.call("HMGET", KEYS[1], "tokens", "updated")
+local tokens = tonumber(bucket[1])
+local updated = tonumber(bucket[2])
+if tokens == nil or updated == nil then
+	tokens = budget
+	updated = now
+end
+tokens = math.min(budget, tokens + math.max(0, now - updated) * refill)
+
+local allowed = 0
+local wait = 0
+if tokens >= cost then
+	tokens = tokens - cost
+	allowed = 1
+else
+	wait = math.ceil((cost - tokens) / refill)
+end
+local reset = math.ceil((budget - tokens) / refill)
+
+redis.call("HMSET", KEYS[1], "tokens", tostring(tokens), "updated", now)
+redis.call("PEXPIRE", KEYS[1], reset + 1000)
+return {allowed, math.floor(tokens), reset, wait}
+`)
+
+// Take takes the cost of a request from the subject's bucket.
+func Take(ctx context.Context, client redis.Cmdable, limit *Limit, subject string, now time.Time) (*Result, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "ratelimit.Take")
+	defer span.End()
+	values, err := takeScript.Run(ctx, client,
+		[]string{BucketKey(limit.Bucket, subject)},
+		limit.Budget, limit.Refill, limit.Cost, now.UnixNano()/int64(time.Millisecond),
+	).Int64Slice()
+	if err != nil {
+		return nil, srOtel.WithSetErrorf(span, "running take script: %w", err)
+	}
+	if len(values) != 4 {
+		return nil, srOtel.WithSetErrorf(span, "expected 4 results, got %v", values)
+	}
+	return &Result{
+		Allowed:    values[0] == 1,
+		Remaining:  int(values[1]),
+		Reset:      time.Duration(values[2]) * time.Millisecond,
+		RetryAfter: time.Duration(values[3]) * time.Millisecond,
+	}, nil
+}
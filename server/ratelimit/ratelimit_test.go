@@ -0,0 +1,52 @@
+package ratelimit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"sr/id"
+	"sr/ratelimit"
+	"sr/test"
+)
+
+func TestTake(t *testing.T) {
+	ctx := context.Background()
+	_, client := test.GetRedis(t)
+	limit := &ratelimit.Limit{Bucket: "test", Budget: 10, Refill: 60, Cost: 4}
+	subject := string(id.GenUID())
+	now := time.Now()
+
+	for _, remaining := range []int{6, 2} {
+		result, err := ratelimit.Take(ctx, client, limit, subject, now)
+		test.AssertSuccess(t, err, "taking tokens")
+		test.AssertEqual(t, true, result.Allowed)
+		test.AssertEqual(t, remaining, result.Remaining)
+	}
+	result, err := ratelimit.Take(ctx, client, limit, subject, now)
+	test.AssertSuccess(t, err, "taking tokens")
+	test.AssertEqual(t, false, result.Allowed)
+	test.AssertEqual(t, 2, result.Remaining)
+	test.AssertEqual(t, 2*time.Second, result.RetryAfter)
+	test.AssertEqual(t, 8*time.Second, result.Reset)
+
+	other, err := ratelimit.Take(ctx, client, limit, string(id.GenUID()), now)
+	test.AssertSuccess(t, err, "taking tokens of another subject")
+	test.AssertEqual(t, true, other.Allowed)
+
+	result, err = ratelimit.Take(ctx, client, limit, subject, now.Add(2*time.Second))
+	test.AssertSuccess(t, err, "taking tokens after refill")
+	test.AssertEqual(t, true, result.Allowed)
+	test.AssertEqual(t, 0, result.Remaining)
+
+	result, err = ratelimit.Take(ctx, client, limit, subject, now.Add(time.Hour))
+	test.AssertSuccess(t, err, "taking tokens after full refill")
+	test.AssertEqual(t, 6, result.Remaining)
+}
+
+func TestValid(t *testing.T) {
+	test.AssertEqual(t, true, (&ratelimit.Limit{Exempt: true}).Valid())
+	test.AssertEqual(t, true, (&ratelimit.Limit{Bucket: "api", Budget: 5, Refill: 1, Cost: 5}).Valid())
+	test.AssertEqual(t, false, (&ratelimit.Limit{Bucket: "api", Budget: 5, Refill: 1, Cost: 6}).Valid())
+	test.AssertEqual(t, false, (&ratelimit.Limit{Bucket: "api", Budget: 5, Cost: 1}).Valid())
+}
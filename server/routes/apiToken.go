@@ -0,0 +1,118 @@
+package routes
+
+import (
+	"context"
+	"errors"
+
+	"sr/apitoken"
+	"sr/errs"
+	srHTTP "sr/http"
+	"sr/id"
+	"sr/log"
+	"sr/player"
+	"sr/session"
+
+	attr "go.opentelemetry.io/otel/attribute"
+)
+
+// mustNotUseToken halts if the request was made with an API token, for routes
+// which manage the player's or the game's access.
+func mustNotUseToken(ctx context.Context, sess *session.Session, action string) {
+	if sess.TokenID != "" {
+		srHTTP.Halt(ctx, errs.NoAccessf("API tokens may not %v", action))
+	}
+}
+
+// $ GET /tokens
+var _ = srHTTP.Handle(gameRouter, "GET /tokens", handleGetTokens)
+
+func handleGetTokens(args *srHTTP.Args) {
+	ctx, response, _, client, sess := args.MustSession()
+	mustNotUseToken(ctx, sess, "view API tokens")
+
+	tokens, err := apitoken.GetAll(ctx, client, sess.PlayerID)
+	srHTTP.HaltInternal(ctx, err)
+	inGame := make([]apitoken.Token, 0, len(tokens))
+	for _, token := range tokens {
+		if token.GameID == sess.GameID {
+			inGame = append(inGame, token)
+		}
+	}
+	srHTTP.MustWriteBodyJSON(ctx, response, inGame)
+	srHTTP.LogSuccessf(ctx, "%v API tokens", len(inGame))
+}
+
+type createTokenRequest struct {
+	Name   string           `json:"name"`
+	Scopes []apitoken.Scope `json:"scopes"`
+}
+
+type createTokenResponse struct {
+	Token  apitoken.Token `json:"token"`
+	Secret string         `json:"secret"` // Only shown here
+}
+
+// $ POST /token/create name scopes -> token secret
+var _ = srHTTP.Handle(gameRouter, "POST /token/create", handleCreateToken)
+
+func handleCreateToken(args *srHTTP.Args) {
+	ctx, response, request, client, sess := args.MustSession()
+	mustNotUseToken(ctx, sess, "create API tokens")
+
+	var tokenRequest createTokenRequest
+	srHTTP.MustReadBodyJSON(request, &tokenRequest)
+	if !player.ValidName(tokenRequest.Name) {
+		srHTTP.Halt(ctx, errs.BadRequestf("name: invalid"))
+	}
+	if len(tokenRequest.Scopes) == 0 {
+		srHTTP.Halt(ctx, errs.BadRequestf("scopes: none given"))
+	}
+	for _, scope := range tokenRequest.Scopes {
+		if !apitoken.ValidScope(scope) {
+			srHTTP.Halt(ctx, errs.BadRequestf("scopes: invalid scope %v", scope))
+		}
+	}
+
+	token, secret := apitoken.Make(
+		sess.GameID, sess.PlayerID, tokenRequest.Name, tokenRequest.Scopes,
+		sess.Verified, id.TimestampNow(),
+	)
+	err := apitoken.Create(ctx, client, &token, secret)
+	if errors.Is(err, errs.ErrBadRequest) {
+		srHTTP.Halt(ctx, err)
+	}
+	srHTTP.HaltInternal(ctx, err)
+	srHTTP.MustWriteBodyJSON(ctx, response, createTokenResponse{Token: token, Secret: secret})
+
+	log.Event(ctx, "API token created",
+		attr.String("sr.token.id", token.ID.String()),
+		attr.StringSlice("sr.token.scopes", token.ScopeNames()),
+	)
+	srHTTP.LogSuccessf(ctx, "Created %v", token.String())
+}
+
+type revokeTokenRequest struct {
+	ID id.UID `json:"id"`
+}
+
+// $ POST /token/revoke id
+var _ = srHTTP.Handle(gameRouter, "POST /token/revoke", handleRevokeToken)
+
+func handleRevokeToken(args *srHTTP.Args) {
+	ctx, _, request, client, sess := args.MustSession()
+	mustNotUseToken(ctx, sess, "revoke API tokens")
+
+	var revokeRequest revokeTokenRequest
+	srHTTP.MustReadBodyJSON(request, &revokeRequest)
+
+	err := apitoken.Revoke(ctx, client, sess.PlayerID, revokeRequest.ID)
+	if errors.Is(err, errs.ErrNotFound) {
+		srHTTP.Halt(ctx, err)
+	}
+	srHTTP.HaltInternal(ctx, err)
+
+	log.Event(ctx, "API token revoked",
+		attr.String("sr.token.id", revokeRequest.ID.String()),
+	)
+	srHTTP.LogSuccessf(ctx, "Revoked API token %v", revokeRequest.ID)
+}
@@ -7,6 +7,7 @@ import (
 	"sr/errs"
 	"sr/game"
 	srHTTP "sr/http"
+	"sr/id"
 	"sr/log"
 	"sr/player"
 	"sr/session"
@@ -20,16 +21,19 @@ var authRouter = RESTRouter.PathPrefix("/auth").Subrouter()
 type loginRequest struct {
 	GameID   string `json:"gameID"`
 	Username string `json:"username"`
+	Password string `json:"password"` // for players who have set one
 	Persist  bool   `json:"persist"`
 }
 
 type loginResponse struct {
 	Player   *player.Player `json:"player"`
-	GameInfo *game.Info     `json:"game"`
+	GameInfo *game.Info     `json:"game"` // null for player sessions
 	Session  string         `json:"session"`
 }
 
-// POST /auth/login { gameID, playerName } -> auth token, session token
+// POST /auth/login { gameID, username, password, persist } -> auth token, session token
+// Without a gameID, the session is a player session usable in all the
+// player's games, which requires the player's password.
 var _ = srHTTP.Handle(authRouter, "POST /login", handleLogin)
 
 func handleLogin(args *srHTTP.Args) {
@@ -46,7 +50,7 @@ func handleLogin(args *srHTTP.Args) {
 		attr.String("sr.login.requestType", status),
 	)
 
-	gameInfo, plr, err := auth.LogPlayerIn(ctx, client, login.GameID, login.Username)
+	gameInfo, plr, err := auth.LogPlayerIn(ctx, client, login.GameID, login.Username, login.Password)
 	if err != nil {
 		log.Printf(ctx, "Login result: %v", err)
 	}
@@ -59,6 +63,11 @@ func handleLogin(args *srHTTP.Args) {
 
 	log.Printf(ctx, "Creating session %s for %v", status, plr.ID)
 	sess := session.New(plr, login.GameID, login.Persist)
+	if gameInfo != nil {
+		_, sess.Spectator = gameInfo.Spectators[string(plr.ID)]
+	}
+	sess.Verified = plr.HasPassword()
+	sess.UserAgent = request.UserAgent()
 	err = session.Create(ctx, client, sess)
 	srHTTP.HaltInternal(ctx, err)
 
@@ -80,6 +89,64 @@ func handleLogin(args *srHTTP.Args) {
 	)
 }
 
+type joinRequest struct {
+	Code     id.UID `json:"code"`
+	Username string `json:"username"`
+	Name     string `json:"name"`
+	Password string `json:"password"`
+	Persist  bool   `json:"persist"`
+}
+
+// POST /auth/join { code, username, name, password, persist } -> { login response }
+var _ = srHTTP.Handle(authRouter, "POST /join", handleJoin)
+
+func handleJoin(args *srHTTP.Args) {
+	ctx, response, request, client, _ := args.Get()
+	var join joinRequest
+	srHTTP.MustReadBodyJSON(request, &join)
+
+	// Existing players without credentials may join from a session of theirs
+	var loggedIn *session.Session
+	if _, err := srHTTP.SessionFromHeader(request); err == nil {
+		loggedIn, err = srHTTP.RequestSession(request, client)
+		srHTTP.Halt(ctx, errs.NoAccess(err))
+	}
+
+	gameInfo, plr, err := auth.JoinWithInvite(ctx, client, loggedIn, join.Code, join.Username, join.Name, join.Password)
+	if err != nil {
+		log.Printf(ctx, "Join result: %v", err)
+	}
+	if errors.Is(err, errs.ErrNoAccess) || errors.Is(err, errs.ErrBadRequest) {
+		srHTTP.Halt(ctx, err)
+	}
+	srHTTP.HaltInternal(ctx, err)
+	log.Printf(ctx, "%v joined %v", plr.ID, gameInfo.ID)
+
+	sess := session.New(plr, gameInfo.ID, join.Persist)
+	_, sess.Spectator = gameInfo.Spectators[string(plr.ID)]
+	sess.Verified = plr.HasPassword() ||
+		(loggedIn != nil && loggedIn.PlayerID == plr.ID && loggedIn.Verified)
+	sess.UserAgent = request.UserAgent()
+	err = session.Create(ctx, client, sess)
+	srHTTP.HaltInternal(ctx, err)
+
+	srHTTP.MustWriteBodyJSON(ctx, response, loginResponse{
+		Player:   plr,
+		GameInfo: gameInfo,
+		Session:  string(sess.ID),
+	})
+
+	log.Event(ctx, "Player join",
+		semconv.EnduserIDKey.String(sess.PlayerID.String()),
+		attr.String("sr.login.sessionID", sess.ID.String()),
+		attr.String("sr.login.sessionType", sess.Type()),
+	)
+	srHTTP.LogSuccessf(ctx, "%v %v for %v in %v",
+		sess.Type(), sess.ID,
+		sess.PlayerID, gameInfo.ID,
+	)
+}
+
 type reauthRequest struct {
 	Session string `json:"session"`
 }
@@ -100,36 +167,45 @@ func handleReauth(args *srHTTP.Args) {
 	srHTTP.Halt(ctx, errs.NoAccess(err))
 	log.Printf(ctx, "Found session %v", sess.String())
 
-	// Get the session's game info
-	gameInfo, err := game.GetInfo(ctx, client, sess.GameID)
-	if errors.Is(err, errs.ErrNotFound) {
-		log.Printf(ctx, "Game %v does not exist", sess.GameID)
-		err = session.Remove(ctx, client, sess)
-		srHTTP.HaltInternal(ctx, err)
-		log.Printf(ctx,
-			"Removed session %v for deleted game %v", sess.ID, sess.GameID,
-		)
-		srHTTP.Halt(ctx, errs.NoAccessf("Your session is invalid"))
-	} else if err != nil {
-		srHTTP.HaltInternal(ctx, err)
-	}
-	log.Printf(ctx, "Confirmed game %v exists", sess.GameID)
+	// Get the session's game info, for sessions bound to a game
+	var gameInfo *game.Info
+	if sess.GameID != "" {
+		gameInfo, err = game.GetInfo(ctx, client, sess.GameID)
+		if errors.Is(err, errs.ErrNotFound) {
+			log.Printf(ctx, "Game %v does not exist", sess.GameID)
+			err = session.Remove(ctx, client, sess)
+			srHTTP.HaltInternal(ctx, err)
+			log.Printf(ctx,
+				"Removed session %v for deleted game %v", sess.ID, sess.GameID,
+			)
+			srHTTP.Halt(ctx, errs.NoAccessf("Your session is invalid"))
+		} else if err != nil {
+			srHTTP.HaltInternal(ctx, err)
+		}
+		log.Printf(ctx, "Confirmed game %v exists", sess.GameID)
 
-	// Check if game still has the player
-	found := false
-	for id, _ := range gameInfo.Players {
-		if id == string(sess.PlayerID) {
+		// Check if game still has the player
+		_, found := gameInfo.Players[string(sess.PlayerID)]
+		if _, spectating := gameInfo.Spectators[string(sess.PlayerID)]; spectating {
 			found = true
-			break
 		}
-	}
-	if !found {
-		log.Printf(ctx, "Player is no longer part of game %v", sess.GameID)
-		err = session.Remove(ctx, client, sess)
-		srHTTP.HaltInternal(ctx, err)
-		log.Printf(ctx,
-			"Removed session %v for no longer being in game %v", sess.ID, sess.GameID,
-		)
+		if !found {
+			log.Printf(ctx, "Player is no longer part of game %v", sess.GameID)
+			err = session.Remove(ctx, client, sess)
+			srHTTP.HaltInternal(ctx, err)
+			log.Printf(ctx,
+				"Removed session %v for no longer being in game %v", sess.ID, sess.GameID,
+			)
+			srHTTP.Halt(ctx, errs.NoAccessf("Your session is invalid"))
+		}
+
+		// Check if the game now requires credentials the session was not made with
+		if gameInfo.RequireCredentials && !sess.Verified {
+			log.Printf(ctx, "Game %v requires credentials", sess.GameID)
+			err = session.Remove(ctx, client, sess)
+			srHTTP.HaltInternal(ctx, err)
+			srHTTP.Halt(ctx, errs.NoAccessf("%v requires a password or passkey", sess.GameID))
+		}
 	}
 
 	// Get the session's player info
@@ -147,6 +223,10 @@ func handleReauth(args *srHTTP.Args) {
 	}
 	log.Printf(ctx, "Confirmed player %s exists", plr.ID)
 
+	if err := session.Touch(ctx, client, sess); err != nil {
+		log.Printf(ctx, "Unable to update last seen of %v: %v", sess.ID, err)
+	}
+
 	srHTTP.MustWriteBodyJSON(ctx, response, loginResponse{
 		Player:   plr,
 		GameInfo: gameInfo,
@@ -169,7 +249,7 @@ func handleReauth(args *srHTTP.Args) {
 var _ = srHTTP.Handle(authRouter, "POST /logout", handleLogout)
 
 func handleLogout(args *srHTTP.Args) {
-	ctx, _, _, client, sess := args.MustSession()
+	ctx, _, _, client, sess := args.MustPlayerSession()
 
 	err := session.Remove(ctx, client, sess)
 	srHTTP.HaltInternal(ctx, err)
@@ -181,3 +261,66 @@ func handleLogout(args *srHTTP.Args) {
 
 	srHTTP.LogSuccessf(ctx, "Logged out %v", sess.PlayerID)
 }
+
+type sessionInfo struct {
+	session.Info
+	Current bool `json:"current"`
+}
+
+// GET /auth/sessions -> [{ id, gameID, type, verified, created, lastSeen, userAgent, current }]
+var _ = srHTTP.Handle(authRouter, "GET /sessions", handleGetSessions)
+
+func handleGetSessions(args *srHTTP.Args) {
+	ctx, response, _, client, sess := args.MustPlayerSession()
+
+	sessions, err := session.ListFor(ctx, client, sess.PlayerID)
+	srHTTP.HaltInternal(ctx, err)
+
+	infos := make([]sessionInfo, len(sessions))
+	for ix := range sessions {
+		infos[ix] = sessionInfo{
+			Info:    sessions[ix].Info(),
+			Current: sessions[ix].ID == sess.ID,
+		}
+	}
+	srHTTP.MustWriteBodyJSON(ctx, response, infos)
+	srHTTP.LogSuccessf(ctx, "%v sessions of %v", len(infos), sess.PlayerID)
+}
+
+type revokeRequest struct {
+	ID  string `json:"id"`
+	All bool   `json:"all"`
+}
+
+// POST /auth/revoke { id } or { all: true } -> OK
+// Revoking all sessions logs the player out everywhere, including the session
+// making the request.
+var _ = srHTTP.Handle(authRouter, "POST /revoke", handleRevoke)
+
+func handleRevoke(args *srHTTP.Args) {
+	ctx, _, request, client, sess := args.MustPlayerSession()
+	var revoke revokeRequest
+	srHTTP.MustReadBodyJSON(request, &revoke)
+
+	if revoke.All {
+		removed, err := session.RemoveAllOf(ctx, client, sess.PlayerID)
+		srHTTP.HaltInternal(ctx, err)
+		log.Event(ctx, "Player logout everywhere",
+			semconv.EnduserIDKey.String(sess.PlayerID.String()),
+			attr.Int("sr.login.revokedCount", removed),
+		)
+		srHTTP.LogSuccessf(ctx, "Revoked %v sessions of %v", removed, sess.PlayerID)
+		return
+	}
+
+	revoked, err := session.GetByPublicID(ctx, client, sess.PlayerID, revoke.ID)
+	srHTTP.Halt(ctx, err)
+	err = session.Remove(ctx, client, revoked)
+	srHTTP.HaltInternal(ctx, err)
+
+	log.Event(ctx, "Player revoke session",
+		semconv.EnduserIDKey.String(sess.PlayerID.String()),
+		attr.String("sr.login.sessionID", revoked.ID.String()),
+	)
+	srHTTP.LogSuccessf(ctx, "Revoked %v of %v", revoked.ID, sess.PlayerID)
+}
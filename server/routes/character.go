@@ -0,0 +1,372 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	netHTTP "net/http"
+
+	"sr/character"
+	"sr/config"
+	"sr/errs"
+	"sr/game"
+	srHTTP "sr/http"
+	"sr/id"
+	"sr/log"
+	"sr/session"
+
+	"github.com/go-redis/redis/v8"
+	attr "go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// characterResponse is a character along with its derived values.
+type characterResponse struct {
+	*character.Character
+	Limits         character.Limits `json:"limits"`
+	PhysicalBoxes  int              `json:"physicalBoxes"`
+	StunBoxes      int              `json:"stunBoxes"`
+	InitiativeBase int              `json:"initBase"`
+	MaxEdge        int              `json:"maxEdge"`
+}
+
+func makeCharacterResponse(char *character.Character) characterResponse {
+	return characterResponse{
+		Character:      char,
+		Limits:         char.Limits(),
+		PhysicalBoxes:  char.PhysicalBoxes(),
+		StunBoxes:      char.StunBoxes(),
+		InitiativeBase: char.InitiativeBase(),
+		MaxEdge:        char.Attributes.Edge,
+	}
+}
+
+// mustGetCharacter retrieves a character in the session's game, halting
+// unless it is owned by the session's player or the player is a GM.
+func mustGetCharacter(
+	ctx context.Context, client redis.Cmdable, sess *session.Session, charID id.UID,
+) *character.Character {
+	if charID == "" {
+		srHTTP.Halt(ctx, errs.BadRequestf("id: expected a character ID"))
+	}
+	char, err := character.GetByID(ctx, client, charID)
+	if errors.Is(err, errs.ErrNotFound) {
+		srHTTP.Halt(ctx, err)
+	}
+	srHTTP.HaltInternal(ctx, err)
+	if char.GameID != sess.GameID {
+		srHTTP.Halt(ctx, errs.NotFoundf("character %v in %v", charID, sess.GameID))
+	}
+	if char.PlayerID != sess.PlayerID {
+		gms, err := game.GetGMs(ctx, client, sess.GameID)
+		srHTTP.HaltInternal(ctx, err)
+		if !game.IsGM(gms, sess.PlayerID) {
+			srHTTP.Halt(ctx, errs.NoAccessf("You may not access this character"))
+		}
+	}
+	return char
+}
+
+// edgeSpend is a point of Edge spent on a roll, which is refunded unless the
+// roll is posted.
+type edgeSpend struct {
+	ctx    context.Context
+	client *redis.Client
+	gameID string
+	charID id.UID
+	posted bool
+}
+
+// mustSpendEdge spends a point of Edge from a character in the session's game,
+// halting if they have none left. The refund of the spend should be deferred,
+// and the spend marked posted once the roll using it has been posted.
+func mustSpendEdge(ctx context.Context, client *redis.Client, sess *session.Session, charID id.UID) *edgeSpend {
+	char, err := game.SpendEdge(ctx, client, sess.GameID, charID)
+	if errors.Is(err, errs.ErrNotFound) || errors.Is(err, errs.ErrBadRequest) {
+		srHTTP.Halt(ctx, err)
+	}
+	srHTTP.HaltInternal(ctx, err)
+	log.Event(ctx, "Edge spent",
+		attr.String("sr.character.id", string(char.ID)),
+		attr.Int("sr.character.edge", char.EdgePoints),
+	)
+	// Refunds are made even if the request is canceled
+	refundCtx := trace.ContextWithSpan(context.Background(), trace.SpanFromContext(ctx))
+	return &edgeSpend{ctx: refundCtx, client: client, gameID: sess.GameID, charID: charID}
+}
+
+// markPosted marks the roll the Edge was spent on as posted. Nil spends are
+// ignored, for rolls which did not spend Edge.
+func (s *edgeSpend) markPosted() {
+	if s != nil {
+		s.posted = true
+	}
+}
+
+// refundUnlessPosted refunds the Edge if the request halted before the roll it
+// was spent on was posted.
+func (s *edgeSpend) refundUnlessPosted() {
+	if s == nil || s.posted {
+		return
+	}
+	char, err := game.RefundEdge(s.ctx, s.client, s.gameID, s.charID)
+	if err != nil {
+		log.Printf(s.ctx, "Unable to refund Edge of %v: %v", s.charID, err)
+		return
+	}
+	log.Event(s.ctx, "Edge refunded",
+		attr.String("sr.character.id", string(char.ID)),
+		attr.Int("sr.character.edge", char.EdgePoints),
+	)
+}
+
+// $ GET /characters
+var _ = srHTTP.Handle(gameRouter, "GET /characters", handleGetCharacters)
+
+func handleGetCharacters(args *srHTTP.Args) {
+	ctx, response, _, client, sess := args.MustSession()
+
+	gms, err := game.GetGMs(ctx, client, sess.GameID)
+	srHTTP.HaltInternal(ctx, err)
+
+	var chars []character.Character
+	if game.IsGM(gms, sess.PlayerID) {
+		chars, err = character.GetInGame(ctx, client, sess.GameID)
+	} else {
+		chars, err = character.GetOfPlayer(ctx, client, sess.GameID, sess.PlayerID)
+	}
+	srHTTP.HaltInternal(ctx, err)
+
+	found := make([]characterResponse, len(chars))
+	for ix := range chars {
+		found[ix] = makeCharacterResponse(&chars[ix])
+	}
+	srHTTP.MustWriteBodyJSON(ctx, response, found)
+	srHTTP.LogSuccessf(ctx, "%v characters", len(found))
+}
+
+// $ GET /character id
+var _ = srHTTP.Handle(gameRouter, "GET /character", handleGetCharacter)
+
+func handleGetCharacter(args *srHTTP.Args) {
+	ctx, response, request, client, sess := args.MustSession()
+
+	char := mustGetCharacter(ctx, client, sess, id.UID(request.FormValue("id")))
+	srHTTP.MustWriteBodyJSON(ctx, response, makeCharacterResponse(char))
+	srHTTP.LogSuccessf(ctx, "Character %v", char)
+}
+
+type characterRequest struct {
+	ID             id.UID               `json:"id"`
+	Name           string               `json:"name"`
+	Attributes     character.Attributes `json:"attributes"`
+	Skills         map[string]int       `json:"skills"`
+	InitiativeMod  int                  `json:"initMod"`
+	InitiativeDice int                  `json:"initDice"`
+	Armor          int                  `json:"armor"`
+
+	// Only GMs may set Edge and condition, which are otherwise changed by
+	// rolls, damage, and refreshing Edge.
+	EdgePoints *int                 `json:"edgePoints"`
+	Condition  *character.Condition `json:"condition"`
+}
+
+// mustCheckGMFields halts if a player who is not a GM sets fields only GMs
+// may set.
+func (r *characterRequest) mustCheckGMFields(ctx context.Context, client redis.Cmdable, sess *session.Session) {
+	if r.EdgePoints != nil || r.Condition != nil {
+		mustBeGM(ctx, client, sess, "set Edge or condition")
+	}
+}
+
+// apply sets the requested values on the character and validates it. Edge and
+// condition are kept within the character's new limits if they are not set.
+func (r *characterRequest) apply(char *character.Character) error {
+	char.Name = r.Name
+	char.Attributes = r.Attributes
+	char.Skills = r.Skills
+	if char.Skills == nil {
+		char.Skills = make(map[string]int)
+	}
+	char.InitiativeMod = r.InitiativeMod
+	char.InitiativeDice = r.InitiativeDice
+	char.Armor = r.Armor
+	if r.EdgePoints != nil {
+		char.EdgePoints = *r.EdgePoints
+	} else if char.EdgePoints > char.Attributes.Edge {
+		char.EdgePoints = char.Attributes.Edge
+	}
+	if r.Condition != nil {
+		char.Condition = *r.Condition
+	} else {
+		if maxPhysical := char.PhysicalBoxes() + char.MaxOverflow(); char.Condition.Physical > maxPhysical {
+			char.Condition.Physical = maxPhysical
+		}
+		if char.Condition.Stun > char.StunBoxes() {
+			char.Condition.Stun = char.StunBoxes()
+		}
+	}
+	return char.Validate()
+}
+
+// $ POST /character/create name attributes skills ...
+var _ = srHTTP.Handle(gameRouter, "POST /character/create", handleCreateCharacter)
+
+func handleCreateCharacter(args *srHTTP.Args) {
+	ctx, response, request, client, sess := args.MustSession()
+
+	var charRequest characterRequest
+	srHTTP.MustReadBodyJSON(request, &charRequest)
+	if charRequest.ID != "" {
+		srHTTP.Halt(ctx, errs.BadRequestf("id: cannot choose character ID"))
+	}
+	charRequest.mustCheckGMFields(ctx, client, sess)
+
+	// New characters start with all of their Edge
+	char := character.Make(sess.GameID, sess.PlayerID, charRequest.Name)
+	char.EdgePoints = charRequest.Attributes.Edge
+	srHTTP.Halt(ctx, charRequest.apply(&char))
+
+	err := game.CreateCharacter(ctx, client, &char)
+	srHTTP.HaltInternal(ctx, err)
+	srHTTP.MustWriteBodyJSON(ctx, response, makeCharacterResponse(&char))
+
+	log.Event(ctx, "Character created",
+		attr.String("sr.character.id", string(char.ID)),
+	)
+	srHTTP.LogSuccessf(ctx, "Created character %v", &char)
+}
+
+// $ POST /character/update id name attributes skills ...
+var _ = srHTTP.Handle(gameRouter, "POST /character/update", handleUpdateCharacter)
+
+func handleUpdateCharacter(args *srHTTP.Args) {
+	ctx, response, request, client, sess := args.MustSession()
+
+	var charRequest characterRequest
+	srHTTP.MustReadBodyJSON(request, &charRequest)
+
+	mustGetCharacter(ctx, client, sess, charRequest.ID)
+	charRequest.mustCheckGMFields(ctx, client, sess)
+
+	char, err := game.UpdateCharacter(ctx, client, sess.GameID, charRequest.ID, charRequest.apply)
+	if errors.Is(err, errs.ErrNotFound) || errors.Is(err, errs.ErrBadRequest) {
+		srHTTP.Halt(ctx, err)
+	}
+	srHTTP.HaltInternal(ctx, err)
+	srHTTP.MustWriteBodyJSON(ctx, response, makeCharacterResponse(char))
+
+	log.Event(ctx, "Character updated",
+		attr.String("sr.character.id", string(char.ID)),
+	)
+	srHTTP.LogSuccessf(ctx, "Updated character %v", char)
+}
+
+type deleteCharacterRequest struct {
+	ID id.UID `json:"id"`
+}
+
+// $ POST /character/delete id
+var _ = srHTTP.Handle(gameRouter, "POST /character/delete", handleDeleteCharacter)
+
+func handleDeleteCharacter(args *srHTTP.Args) {
+	ctx, _, request, client, sess := args.MustSession()
+
+	var deleteRequest deleteCharacterRequest
+	srHTTP.MustReadBodyJSON(request, &deleteRequest)
+
+	char := mustGetCharacter(ctx, client, sess, deleteRequest.ID)
+	gms, err := game.GetGMs(ctx, client, sess.GameID)
+	srHTTP.HaltInternal(ctx, err)
+	err = game.DeleteCharacter(ctx, client, char, game.IsGM(gms, sess.PlayerID))
+	if errors.Is(err, errs.ErrNotFound) {
+		srHTTP.Halt(ctx, err)
+	}
+	srHTTP.HaltInternal(ctx, err)
+
+	log.Event(ctx, "Character deleted",
+		attr.String("sr.character.id", string(char.ID)),
+	)
+	srHTTP.LogSuccessf(ctx, "Deleted character %v", char)
+}
+
+type refreshEdgeRequest struct {
+	ID id.UID `json:"id"`
+}
+
+// $ POST /character/refresh-edge [id]
+var _ = srHTTP.Handle(gameRouter, "POST /character/refresh-edge", handleRefreshEdge)
+
+func handleRefreshEdge(args *srHTTP.Args) {
+	ctx, response, request, client, sess := args.MustSession()
+
+	var refreshRequest refreshEdgeRequest
+	srHTTP.MustReadBodyJSON(request, &refreshRequest)
+
+	gms, err := game.GetGMs(ctx, client, sess.GameID)
+	srHTTP.HaltInternal(ctx, err)
+	if !game.IsGM(gms, sess.PlayerID) {
+		srHTTP.Halt(ctx, errs.NoAccessf("Only GMs may refresh Edge"))
+	}
+
+	// Refresh everyone in the game if no character is given
+	charIDs := []id.UID{refreshRequest.ID}
+	if refreshRequest.ID == "" {
+		chars, err := character.GetInGame(ctx, client, sess.GameID)
+		srHTTP.HaltInternal(ctx, err)
+		charIDs = make([]id.UID, len(chars))
+		for ix := range chars {
+			charIDs[ix] = chars[ix].ID
+		}
+	}
+
+	refreshed := make([]characterResponse, 0, len(charIDs))
+	for _, charID := range charIDs {
+		char, err := game.RefreshEdge(ctx, client, sess.GameID, charID)
+		if errors.Is(err, errs.ErrNotFound) {
+			if refreshRequest.ID != "" {
+				srHTTP.Halt(ctx, err)
+			}
+			continue // Deleted while refreshing
+		}
+		srHTTP.HaltInternal(ctx, err)
+		refreshed = append(refreshed, makeCharacterResponse(char))
+	}
+	srHTTP.MustWriteBodyJSON(ctx, response, refreshed)
+
+	log.Event(ctx, "Edge refreshed",
+		attr.Int("sr.characters", len(refreshed)),
+	)
+	srHTTP.LogSuccessf(ctx, "Refreshed Edge of %v characters", len(refreshed))
+}
+
+type importCharacterResponse struct {
+	Character characterResponse `json:"character"`
+	Unmapped  []string          `json:"unmapped"`
+}
+
+// $ POST /character/import <chummer file>
+var _ = srHTTP.Handle(gameRouter, "POST /character/import", handleImportCharacter)
+
+func handleImportCharacter(args *srHTTP.Args) {
+	ctx, response, request, client, sess := args.MustSession()
+
+	file := netHTTP.MaxBytesReader(response, request.Body, int64(config.MaxImportSize))
+	char, unmapped, err := character.ParseChummer(file, sess.GameID, sess.PlayerID)
+	srHTTP.Halt(ctx, err)
+
+	err = game.CreateCharacter(ctx, client, char)
+	srHTTP.HaltInternal(ctx, err)
+	if unmapped == nil {
+		unmapped = []string{}
+	}
+	srHTTP.MustWriteBodyJSON(ctx, response, importCharacterResponse{
+		Character: makeCharacterResponse(char),
+		Unmapped:  unmapped,
+	})
+
+	log.Event(ctx, "Character imported",
+		attr.String("sr.character.id", string(char.ID)),
+		attr.Int("sr.character.unmapped", len(unmapped)),
+	)
+	srHTTP.LogSuccessf(ctx, "Imported character %v, %v fields unmapped", char, len(unmapped))
+}
@@ -0,0 +1,437 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"sr/auth"
+	"sr/config"
+	"sr/credential"
+	"sr/errs"
+	"sr/game"
+	srHTTP "sr/http"
+	"sr/id"
+	"sr/log"
+	"sr/player"
+	"sr/session"
+
+	attr "go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/semconv/v1.4.0"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// relyingParty is the site passkeys are registered with: the frontend.
+func relyingParty() *credential.RelyingParty {
+	origin := config.FrontendOrigin
+	return &credential.RelyingParty{
+		ID:     origin.Hostname(),
+		Origin: origin.Scheme + "://" + origin.Host,
+	}
+}
+
+// mustManageCredentials halts unless the session may change its player's
+// credentials. Once a player has credentials, only sessions made with them
+// may change them. Before then, only sessions in a game the player is still
+// part of may set the first ones.
+func mustManageCredentials(ctx context.Context, client redis.Cmdable, sess *session.Session, plr *player.Player) {
+	if sess.Verified {
+		return
+	}
+	hasCredentials, err := credential.HasCredentials(ctx, client, plr)
+	srHTTP.HaltInternal(ctx, err)
+	if hasCredentials {
+		srHTTP.Halt(ctx, errs.NoAccessf("Log in with your password, passkey or provider to change them"))
+	}
+	if sess.GameID == "" {
+		srHTTP.Halt(ctx, errs.NoAccessf("Log in to a game to set up your login"))
+	}
+	inGame, err := game.HasPlayer(ctx, client, sess.GameID, plr.ID)
+	srHTTP.HaltInternal(ctx, err)
+	if !inGame {
+		inGame, err = game.HasSpectator(ctx, client, sess.GameID, plr.ID)
+		srHTTP.HaltInternal(ctx, err)
+	}
+	if !inGame {
+		srHTTP.Halt(ctx, errs.NoAccessf("%v is no longer in %v", plr.ID, sess.GameID))
+	}
+}
+
+// mustRevokeOtherSessions removes the player's other sessions after their
+// credentials change, so only the session which changed them stays logged in.
+func mustRevokeOtherSessions(ctx context.Context, client redis.Cmdable, sess *session.Session) {
+	removed, err := session.RemoveOthersOf(ctx, client, sess)
+	srHTTP.HaltInternal(ctx, err)
+	log.Printf(ctx, "Revoked %v other sessions of %v", removed, sess.PlayerID)
+}
+
+type setPasswordRequest struct {
+	Current  string `json:"current"` // for players changing their password
+	Password string `json:"password"`
+}
+
+// POST /auth/password { current, password } -> OK
+var _ = srHTTP.Handle(authRouter, "POST /password", handleSetPassword)
+
+func handleSetPassword(args *srHTTP.Args) {
+	ctx, _, request, client, sess := args.MustPlayerSession()
+	var passwordRequest setPasswordRequest
+	srHTTP.MustReadBodyJSON(request, &passwordRequest)
+
+	plr, err := player.GetByID(ctx, client, string(sess.PlayerID))
+	srHTTP.HaltInternal(ctx, err)
+	changing := plr.HasPassword()
+	if changing {
+		if !credential.CheckPassword(plr.PasswordHash, passwordRequest.Current) {
+			srHTTP.Halt(ctx, errs.NoAccessf("Current password is incorrect"))
+		}
+	} else {
+		mustManageCredentials(ctx, client, sess, plr)
+	}
+
+	err = credential.SetPassword(ctx, client, plr.ID, passwordRequest.Password)
+	if errors.Is(err, errs.ErrBadRequest) {
+		srHTTP.Halt(ctx, err)
+	}
+	srHTTP.HaltInternal(ctx, err)
+	err = session.SetVerified(ctx, client, sess)
+	srHTTP.HaltInternal(ctx, err)
+	mustRevokeOtherSessions(ctx, client, sess)
+
+	log.Event(ctx, "Password set",
+		semconv.EnduserIDKey.String(sess.PlayerID.String()),
+		attr.Bool("sr.credential.changed", changing),
+	)
+	srHTTP.LogSuccessf(ctx, "%v set a password", plr.ID)
+}
+
+// GET /auth/passkeys -> [passkey]
+var _ = srHTTP.Handle(authRouter, "GET /passkeys", handleGetPasskeys)
+
+func handleGetPasskeys(args *srHTTP.Args) {
+	ctx, response, _, client, sess := args.MustPlayerSession()
+
+	passkeys, err := credential.GetPasskeys(ctx, client, sess.PlayerID)
+	srHTTP.HaltInternal(ctx, err)
+	srHTTP.MustWriteBodyJSON(ctx, response, passkeys)
+	srHTTP.LogSuccessf(ctx, "%v passkeys", len(passkeys))
+}
+
+type passkeyRP struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type passkeyUser struct {
+	ID          credential.Bytes `json:"id"`
+	Name        string           `json:"name"`
+	DisplayName string           `json:"displayName"`
+}
+
+type passkeyParam struct {
+	Type string `json:"type"`
+	Alg  int    `json:"alg"`
+}
+
+type passkeyDescriptor struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+type passkeySelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+// passkeyCreationOptions are PublicKeyCredentialCreationOptions, encoded for
+// PublicKeyCredential.parseCreationOptionsFromJSON().
+type passkeyCreationOptions struct {
+	Challenge              credential.Bytes    `json:"challenge"`
+	RP                     passkeyRP           `json:"rp"`
+	User                   passkeyUser         `json:"user"`
+	PubKeyCredParams       []passkeyParam      `json:"pubKeyCredParams"`
+	ExcludeCredentials     []passkeyDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection passkeySelection    `json:"authenticatorSelection"`
+	Timeout                int64               `json:"timeout"`
+	Attestation            string              `json:"attestation"`
+}
+
+// passkeyRequestOptions are PublicKeyCredentialRequestOptions, encoded for
+// PublicKeyCredential.parseRequestOptionsFromJSON().
+type passkeyRequestOptions struct {
+	Challenge        credential.Bytes    `json:"challenge"`
+	RPID             string              `json:"rpId"`
+	AllowCredentials []passkeyDescriptor `json:"allowCredentials"`
+	UserVerification string              `json:"userVerification"`
+	Timeout          int64               `json:"timeout"`
+}
+
+type passkeyChallengeResponse struct {
+	ChallengeID id.UID      `json:"challengeID"`
+	PublicKey   interface{} `json:"publicKey"`
+}
+
+func passkeyDescriptors(passkeys []credential.Passkey) []passkeyDescriptor {
+	descriptors := make([]passkeyDescriptor, len(passkeys))
+	for ix, passkey := range passkeys {
+		descriptors[ix] = passkeyDescriptor{Type: "public-key", ID: passkey.ID}
+	}
+	return descriptors
+}
+
+// POST /auth/passkey/register/begin -> { challengeID, publicKey: creation options }
+var _ = srHTTP.Handle(authRouter, "POST /passkey/register/begin", handleBeginPasskeyRegister)
+
+func handleBeginPasskeyRegister(args *srHTTP.Args) {
+	ctx, response, _, client, sess := args.MustPlayerSession()
+
+	plr, err := player.GetByID(ctx, client, string(sess.PlayerID))
+	srHTTP.HaltInternal(ctx, err)
+	mustManageCredentials(ctx, client, sess, plr)
+	passkeys, err := credential.GetPasskeys(ctx, client, plr.ID)
+	srHTTP.HaltInternal(ctx, err)
+	if len(passkeys) >= credential.MaxPasskeys {
+		srHTTP.Halt(ctx, errs.BadRequestf("You already have %v passkeys", credential.MaxPasskeys))
+	}
+
+	challenge, err := credential.MakeChallenge(plr.ID, credential.PurposeRegister)
+	srHTTP.HaltInternal(ctx, err)
+	err = credential.CreateChallenge(ctx, client, &challenge)
+	srHTTP.HaltInternal(ctx, err)
+
+	rp := relyingParty()
+	params := make([]passkeyParam, len(credential.Algorithms))
+	for ix, alg := range credential.Algorithms {
+		params[ix] = passkeyParam{Type: "public-key", Alg: alg}
+	}
+	srHTTP.MustWriteBodyJSON(ctx, response, passkeyChallengeResponse{
+		ChallengeID: challenge.ID,
+		PublicKey: passkeyCreationOptions{
+			Challenge: challenge.Challenge,
+			RP:        passkeyRP{ID: rp.ID, Name: "Shadowroller"},
+			User: passkeyUser{
+				ID:          credential.Bytes(plr.ID),
+				Name:        plr.Username,
+				DisplayName: plr.Name,
+			},
+			PubKeyCredParams:   params,
+			ExcludeCredentials: passkeyDescriptors(passkeys),
+			AuthenticatorSelection: passkeySelection{
+				ResidentKey:      "preferred",
+				UserVerification: "preferred",
+			},
+			Timeout:     credential.ChallengeTTL.Milliseconds(),
+			Attestation: "none",
+		},
+	})
+	srHTTP.LogSuccessf(ctx, "Challenge %v for %v", challenge.ID, plr.ID)
+}
+
+type finishPasskeyRegisterRequest struct {
+	ChallengeID       id.UID           `json:"challengeID"`
+	Name              string           `json:"name"`
+	ClientDataJSON    credential.Bytes `json:"clientDataJSON"`
+	AttestationObject credential.Bytes `json:"attestationObject"`
+}
+
+// POST /auth/passkey/register/finish { challengeID, name, clientDataJSON, attestationObject } -> passkey
+var _ = srHTTP.Handle(authRouter, "POST /passkey/register/finish", handleFinishPasskeyRegister)
+
+func handleFinishPasskeyRegister(args *srHTTP.Args) {
+	ctx, response, request, client, sess := args.MustPlayerSession()
+	var registerRequest finishPasskeyRegisterRequest
+	srHTTP.MustReadBodyJSON(request, &registerRequest)
+
+	name := strings.TrimSpace(registerRequest.Name)
+	if !credential.ValidPasskeyName(name) {
+		srHTTP.Halt(ctx, errs.BadRequestf("name: invalid"))
+	}
+	challenge, err := credential.TakeChallenge(ctx, client, registerRequest.ChallengeID, credential.PurposeRegister)
+	if errors.Is(err, errs.ErrNotFound) {
+		srHTTP.Halt(ctx, errs.BadRequest(err))
+	}
+	srHTTP.HaltInternal(ctx, err)
+	if challenge.PlayerID != sess.PlayerID {
+		srHTTP.Halt(ctx, errs.NoAccessf("Challenge %v is for another player", challenge.ID))
+	}
+
+	passkey, err := relyingParty().VerifyRegistration(
+		challenge.Challenge, registerRequest.ClientDataJSON, registerRequest.AttestationObject,
+	)
+	srHTTP.Halt(ctx, err)
+	passkey.Name = name
+	passkey.Created = id.TimestampNow()
+	err = credential.AddPasskey(ctx, client, sess.PlayerID, passkey)
+	if errors.Is(err, errs.ErrBadRequest) {
+		srHTTP.Halt(ctx, err)
+	}
+	srHTTP.HaltInternal(ctx, err)
+	err = session.SetVerified(ctx, client, sess)
+	srHTTP.HaltInternal(ctx, err)
+	mustRevokeOtherSessions(ctx, client, sess)
+
+	srHTTP.MustWriteBodyJSON(ctx, response, passkey)
+	log.Event(ctx, "Passkey registered",
+		semconv.EnduserIDKey.String(sess.PlayerID.String()),
+		attr.String("sr.credential.passkeyID", passkey.ID),
+	)
+	srHTTP.LogSuccessf(ctx, "Passkey %v for %v", passkey.ID, sess.PlayerID)
+}
+
+type removePasskeyRequest struct {
+	ID string `json:"id"`
+}
+
+// POST /auth/passkey/remove { id } -> OK
+var _ = srHTTP.Handle(authRouter, "POST /passkey/remove", handleRemovePasskey)
+
+func handleRemovePasskey(args *srHTTP.Args) {
+	ctx, _, request, client, sess := args.MustPlayerSession()
+	var removeRequest removePasskeyRequest
+	srHTTP.MustReadBodyJSON(request, &removeRequest)
+	if !sess.Verified {
+		srHTTP.Halt(ctx, errs.NoAccessf("Log in with your password, passkey or provider to change them"))
+	}
+
+	err := credential.RemovePasskey(ctx, client, sess.PlayerID, removeRequest.ID)
+	if errors.Is(err, errs.ErrNotFound) {
+		srHTTP.Halt(ctx, err)
+	}
+	srHTTP.HaltInternal(ctx, err)
+	log.Event(ctx, "Passkey removed",
+		semconv.EnduserIDKey.String(sess.PlayerID.String()),
+		attr.String("sr.credential.passkeyID", removeRequest.ID),
+	)
+	srHTTP.LogSuccessf(ctx, "Removed passkey %v", removeRequest.ID)
+}
+
+type beginPasskeyLoginRequest struct {
+	Username string `json:"username"`
+}
+
+// POST /auth/passkey/login/begin { username } -> { challengeID, publicKey: request options }
+var _ = srHTTP.Handle(authRouter, "POST /passkey/login/begin", handleBeginPasskeyLogin)
+
+func handleBeginPasskeyLogin(args *srHTTP.Args) {
+	ctx, response, request, client, _ := args.Get()
+	var loginRequest beginPasskeyLoginRequest
+	srHTTP.MustReadBodyJSON(request, &loginRequest)
+
+	challenge, passkeys, err := auth.BeginPasskeyLogin(ctx, client, loginRequest.Username)
+	if errors.Is(err, errs.ErrNoAccess) || errors.Is(err, errs.ErrNotFound) {
+		srHTTP.Halt(ctx, errs.NoAccess(err))
+	}
+	srHTTP.HaltInternal(ctx, err)
+
+	srHTTP.MustWriteBodyJSON(ctx, response, passkeyChallengeResponse{
+		ChallengeID: challenge.ID,
+		PublicKey: passkeyRequestOptions{
+			Challenge:        challenge.Challenge,
+			RPID:             relyingParty().ID,
+			AllowCredentials: passkeyDescriptors(passkeys),
+			UserVerification: "preferred",
+			Timeout:          credential.ChallengeTTL.Milliseconds(),
+		},
+	})
+	srHTTP.LogSuccessf(ctx, "Challenge %v for %v", challenge.ID, challenge.PlayerID)
+}
+
+type finishPasskeyLoginRequest struct {
+	credential.Assertion
+	GameID  string `json:"gameID"`
+	Persist bool   `json:"persist"`
+}
+
+// POST /auth/passkey/login/finish { gameID, persist, challengeID, id, clientDataJSON, authenticatorData, signature } -> { login response }
+var _ = srHTTP.Handle(authRouter, "POST /passkey/login/finish", handleFinishPasskeyLogin)
+
+func handleFinishPasskeyLogin(args *srHTTP.Args) {
+	ctx, response, request, client, _ := args.Get()
+	var login finishPasskeyLoginRequest
+	srHTTP.MustReadBodyJSON(request, &login)
+
+	gameInfo, plr, err := auth.LogPasskeyIn(ctx, client, relyingParty(), login.GameID, &login.Assertion)
+	if err != nil {
+		log.Printf(ctx, "Login result: %v", err)
+	}
+	if errors.Is(err, errs.ErrNoAccess) ||
+		errors.Is(err, errs.ErrNotFound) {
+		srHTTP.Halt(ctx, errs.NoAccess(err))
+	}
+	srHTTP.HaltInternal(ctx, err)
+
+	sess := session.New(plr, login.GameID, login.Persist)
+	if gameInfo != nil {
+		_, sess.Spectator = gameInfo.Spectators[string(plr.ID)]
+	}
+	sess.Verified = true
+	sess.UserAgent = request.UserAgent()
+	err = session.Create(ctx, client, sess)
+	srHTTP.HaltInternal(ctx, err)
+
+	srHTTP.MustWriteBodyJSON(ctx, response, loginResponse{
+		Player:   plr,
+		GameInfo: gameInfo,
+		Session:  string(sess.ID),
+	})
+
+	log.Event(ctx, "Player login",
+		semconv.EnduserIDKey.String(sess.PlayerID.String()),
+		attr.String("sr.login.sessionID", sess.ID.String()),
+		attr.String("sr.login.sessionType", sess.Type()),
+		attr.String("sr.credential.passkeyID", login.PasskeyID),
+	)
+	srHTTP.LogSuccessf(ctx, "%v %v for %v in %v with passkey",
+		sess.Type(), sess.ID,
+		sess.PlayerID, login.GameID,
+	)
+}
+
+// $ GET /credentials -> { playerID: has credentials }
+var _ = srHTTP.Handle(gameRouter, "GET /credentials", handleGetCredentialStatuses)
+
+func handleGetCredentialStatuses(args *srHTTP.Args) {
+	ctx, response, _, client, sess := args.MustSession()
+	mustBeGM(ctx, client, sess, "view who has set up credentials")
+
+	info, err := game.GetInfo(ctx, client, sess.GameID)
+	srHTTP.HaltInternal(ctx, err)
+	playerIDs := make([]id.UID, 0, len(info.Players)+len(info.Spectators))
+	for playerID := range info.Players {
+		playerIDs = append(playerIDs, id.UID(playerID))
+	}
+	for playerID := range info.Spectators {
+		playerIDs = append(playerIDs, id.UID(playerID))
+	}
+	statuses, err := credential.GetStatuses(ctx, client, playerIDs)
+	srHTTP.HaltInternal(ctx, err)
+
+	srHTTP.MustWriteBodyJSON(ctx, response, statuses)
+	srHTTP.LogSuccessf(ctx, "%v players", len(statuses))
+}
+
+type requireCredentialsRequest struct {
+	Require bool `json:"require"`
+}
+
+// $ POST /require-credentials require
+var _ = srHTTP.Handle(gameRouter, "POST /require-credentials", handleRequireCredentials)
+
+func handleRequireCredentials(args *srHTTP.Args) {
+	ctx, _, request, client, sess := args.MustSession()
+	mustNotUseToken(ctx, sess, "change whether credentials are required")
+	var requireRequest requireCredentialsRequest
+	srHTTP.MustReadBodyJSON(request, &requireRequest)
+	mustBeGM(ctx, client, sess, "change whether credentials are required")
+	if requireRequest.Require && !sess.Verified {
+		srHTTP.Halt(ctx, errs.BadRequestf("Log in with a password or passkey before requiring them"))
+	}
+
+	err := game.SetRequireCredentials(ctx, client, sess.GameID, requireRequest.Require)
+	srHTTP.HaltInternal(ctx, err)
+	log.Event(ctx, "Game credentials setting",
+		attr.Bool("sr.game.requireCredentials", requireRequest.Require),
+	)
+	srHTTP.LogSuccessf(ctx, "%v requires credentials: %v", sess.GameID, requireRequest.Require)
+}
@@ -0,0 +1,119 @@
+package routes
+
+import (
+	"errors"
+
+	"sr/character"
+	"sr/config"
+	"sr/errs"
+	"sr/event"
+	"sr/game"
+	srHTTP "sr/http"
+	"sr/id"
+	"sr/log"
+	"sr/player"
+	"sr/roll"
+
+	attr "go.opentelemetry.io/otel/attribute"
+)
+
+type damageRequest struct {
+	Character id.UID `json:"character"`
+	Value     int    `json:"value"`
+	Stun      bool   `json:"stun"`
+	AP        int    `json:"ap"`
+}
+
+// $ POST /damage character value stun ap
+var _ = srHTTP.Handle(gameRouter, "POST /damage", handleDamage)
+
+func handleDamage(args *srHTTP.Args) {
+	ctx, response, request, client, sess := args.MustSession()
+
+	var damageRequest damageRequest
+	srHTTP.MustReadBodyJSON(request, &damageRequest)
+
+	if damageRequest.Value < 1 || damageRequest.Value > config.MaxSingleRoll {
+		srHTTP.Halt(ctx, errs.BadRequestf("value: invalid"))
+	}
+	if damageRequest.AP < -config.MaxSingleRoll || damageRequest.AP > config.MaxSingleRoll {
+		srHTTP.Halt(ctx, errs.BadRequestf("ap: invalid"))
+	}
+
+	gms, err := game.GetGMs(ctx, client, sess.GameID)
+	srHTTP.HaltInternal(ctx, err)
+	if !game.IsGM(gms, sess.PlayerID) {
+		srHTTP.Halt(ctx, errs.NoAccessf("Only GMs may deal damage"))
+	}
+	char := mustGetCharacter(ctx, client, sess, damageRequest.Character)
+
+	plr, err := player.GetByID(ctx, client, string(sess.PlayerID))
+	srHTTP.HaltInternal(ctx, err)
+
+	evt := event.ForDamage(
+		plr, char.ID, char.Name, char.PlayerID,
+		damageRequest.Value, damageRequest.Stun, damageRequest.AP,
+	)
+	err = game.PostDamage(ctx, client, sess.GameID, &evt)
+	srHTTP.HaltInternal(ctx, err)
+	srHTTP.MustWriteBodyJSON(ctx, response, evt.GetID())
+
+	log.Event(ctx, "Damage dealt",
+		attr.Int64("sr.event.id", evt.GetID()),
+		attr.String("sr.character.id", string(char.ID)),
+		attr.Int("sr.damage.value", damageRequest.Value),
+		attr.Bool("sr.damage.stun", damageRequest.Stun),
+		attr.Int("sr.damage.ap", damageRequest.AP),
+	)
+	srHTTP.LogSuccessf(ctx, "Damage %v dealt to %v", evt.GetID(), char)
+}
+
+type soakRequest struct {
+	ID int64 `json:"id"`
+}
+
+// $ POST /soak id
+var _ = srHTTP.Handle(gameRouter, "POST /soak", handleSoak)
+
+func handleSoak(args *srHTTP.Args) {
+	ctx, response, request, client, sess := args.MustSession()
+
+	var soakRequest soakRequest
+	srHTTP.MustReadBodyJSON(request, &soakRequest)
+
+	damage, err := game.GetDamage(ctx, client, sess.GameID, soakRequest.ID)
+	if errors.Is(err, errs.ErrNotFound) || errors.Is(err, errs.ErrBadRequest) {
+		srHTTP.Halt(ctx, err)
+	}
+	srHTTP.HaltInternal(ctx, err)
+	srHTTP.Halt(ctx, damage.CheckSoak(sess.PlayerID))
+
+	char, err := character.GetByID(ctx, client, damage.CharID)
+	if errors.Is(err, errs.ErrNotFound) {
+		srHTTP.Halt(ctx, errs.BadRequest(err))
+	}
+	srHTTP.HaltInternal(ctx, err)
+
+	soak := char.SoakBreakdown(damage.AP)
+	dice := make([]int, soak.Pool())
+	hits, err := roll.Rolls.Fill(request.Context(), dice)
+	srHTTP.HaltInternal(ctx, err)
+
+	damage, char, err = game.SoakDamage(
+		ctx, client, sess.GameID, soakRequest.ID, sess.PlayerID, soak, dice, hits,
+	)
+	if errors.Is(err, errs.ErrNotFound) || errors.Is(err, errs.ErrNoAccess) || errors.Is(err, errs.ErrBadRequest) {
+		srHTTP.Halt(ctx, err)
+	}
+	srHTTP.HaltInternal(ctx, err)
+	srHTTP.MustWriteBodyJSON(ctx, response, makeCharacterResponse(char))
+
+	log.Event(ctx, "Damage soaked",
+		attr.Int64("sr.event.id", damage.GetID()),
+		attr.String("sr.character.id", string(char.ID)),
+		attr.Int("sr.roll.pool", len(dice)),
+		attr.IntSlice("sr.roll.dice", dice),
+		attr.Int("sr.damage.taken", damage.Taken),
+	)
+	srHTTP.LogSuccessf(ctx, "%v", damage.Summary())
+}
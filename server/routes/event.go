@@ -1,19 +1,63 @@
 package routes
 
 import (
+	"context"
+	"errors"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"sr/config"
 	"sr/errs"
 	"sr/event"
 	"sr/game"
 	srHTTP "sr/http"
 	"sr/id"
 	"sr/log"
+	"sr/player"
+	"sr/session"
 
+	"github.com/go-redis/redis/v8"
 	attr "go.opentelemetry.io/otel/attribute"
 )
 
+// mustCheckRecipients halts unless the recipients are valid for the share of
+// an event by the sender. Whispered events must be sent to players in the game
+// other than the sender, and other shares may not have recipients.
+func mustCheckRecipients(
+	ctx context.Context, client redis.Cmdable, sess *session.Session,
+	sender id.UID, share event.Share, recipients []id.UID,
+) {
+	if share != event.ShareWhisper {
+		if len(recipients) != 0 {
+			srHTTP.Halt(ctx, errs.BadRequestf("to: only whispers have recipients"))
+		}
+		return
+	}
+	if len(recipients) == 0 {
+		srHTTP.Halt(ctx, errs.BadRequestf("to: expected recipients"))
+	}
+	seen := make(map[id.UID]bool, len(recipients))
+	for _, recipient := range recipients {
+		if recipient == sender {
+			srHTTP.Halt(ctx, errs.BadRequestf("to: cannot whisper to the sender"))
+		}
+		if seen[recipient] {
+			srHTTP.Halt(ctx, errs.BadRequestf("to: %v listed twice", recipient))
+		}
+		seen[recipient] = true
+		inGame, err := game.HasPlayer(ctx, client, sess.GameID, recipient)
+		srHTTP.HaltInternal(ctx, err)
+		if !inGame {
+			srHTTP.Halt(ctx, errs.BadRequestf("to: %v is not in this game", recipient))
+		}
+	}
+}
+
 type shareEventRequest struct {
-	ID    int64 `json:"id"`
-	Share int   `json:"share"`
+	ID    int64    `json:"id"`
+	Share int      `json:"share"`
+	To    []id.UID `json:"to"`
 }
 
 var _ = srHTTP.Handle(gameRouter, "POST /edit-share", handleShareEvent)
@@ -38,16 +82,37 @@ func handleShareEvent(args *srHTTP.Args) {
 	srHTTP.Halt(ctx, errs.BadRequest(err))
 	evt, err := event.Parse([]byte(eventText))
 	srHTTP.HaltInternal(ctx, err)
+	// GMs revealing a blind roll whisper it on behalf of its sender
+	mustCheckRecipients(ctx, client, sess, evt.GetPlayerID(), share, shareRequest.To)
 
-	if evt.GetPlayerID() != sess.PlayerID {
+	if evt.GetShare() == event.ShareBlind {
+		// Only GMs may reveal a blind roll, which they may do for any player
+		gms, err := game.GetGMs(ctx, client, sess.GameID)
+		srHTTP.HaltInternal(ctx, err)
+		if !game.IsGM(gms, sess.PlayerID) {
+			srHTTP.Halt(ctx, errs.NoAccessf("You may not edit this event"))
+		}
+	} else if evt.GetPlayerID() != sess.PlayerID {
 		srHTTP.Halt(ctx, errs.NoAccessf("You may not edit this event"))
 	}
-	if evt.GetType() == event.EventTypePlayerJoin {
+	// Awards must keep matching the characters' ledgers
+	if evt.GetType() == event.EventTypePlayerJoin || evt.GetType() == event.EventTypeAward {
 		srHTTP.Halt(ctx, errs.NoAccessf("You may not edit this event"))
 	}
+	// Answers to a GMs-only roll request stay with the GMs
+	if requestID := event.AnsweredRequest(evt); requestID != 0 && share != event.ShareGMs {
+		requested, err := game.GetRollRequest(ctx, client, sess.GameID, requestID)
+		if !errors.Is(err, errs.ErrNotFound) && !errors.Is(err, errs.ErrBadRequest) {
+			srHTTP.HaltInternal(ctx, err)
+			if requested.GMsOnly {
+				mustBeGM(ctx, client, sess, "share answers to a GMs-only request")
+			}
+		}
+	}
 
 	// Gotta be idempotent
-	if evt.GetShare() == share {
+	if evt.GetShare() == share &&
+		(share != event.ShareWhisper || event.HasRecipients(evt, shareRequest.To)) {
 		srHTTP.LogSuccess(ctx, "No change")
 		return
 	}
@@ -55,7 +120,7 @@ func handleShareEvent(args *srHTTP.Args) {
 	updateTime := id.NewEventID()
 	evt.SetEdit(updateTime)
 
-	err = game.UpdateEventShare(ctx, client, sess.GameID, evt, share)
+	err = game.UpdateEventShare(ctx, client, sess.GameID, evt, share, shareRequest.To)
 	srHTTP.HaltInternal(ctx, err)
 
 	log.Event(ctx, "Event share changed",
@@ -66,3 +131,47 @@ func handleShareEvent(args *srHTTP.Args) {
 		evt.GetID(), share.String(),
 	)
 }
+
+type reactRequest struct {
+	ID       int64  `json:"id"`
+	Reaction string `json:"reaction"`
+}
+
+// $ POST /react id reaction
+var _ = srHTTP.Handle(gameRouter, "POST /react", handleReact)
+
+func handleReact(args *srHTTP.Args) {
+	ctx, response, request, client, sess := args.MustSession()
+
+	var reactRequest reactRequest
+	srHTTP.MustReadBodyJSON(request, &reactRequest)
+
+	reaction := strings.TrimSpace(reactRequest.Reaction)
+	if reaction == "" || utf8.RuneCountInString(reaction) > config.MaxReactionLength {
+		srHTTP.Halt(ctx, errs.BadRequestf("reaction: invalid"))
+	}
+	if strings.IndexFunc(reaction, unicode.IsControl) != -1 {
+		srHTTP.Halt(ctx, errs.BadRequestf("reaction: invalid"))
+	}
+
+	plr, err := player.GetByID(ctx, client, string(sess.PlayerID))
+	srHTTP.HaltInternal(ctx, err)
+	gms, err := game.GetGMs(ctx, client, sess.GameID)
+	srHTTP.HaltInternal(ctx, err)
+
+	added, err := game.ToggleReaction(
+		ctx, client, sess.GameID, reactRequest.ID, reaction, plr, game.IsGM(gms, plr.ID),
+	)
+	if errors.Is(err, errs.ErrNotFound) || errors.Is(err, errs.ErrNoAccess) ||
+		errors.Is(err, errs.ErrBadRequest) {
+		srHTTP.Halt(ctx, err)
+	}
+	srHTTP.HaltInternal(ctx, err)
+	srHTTP.MustWriteBodyJSON(ctx, response, added)
+
+	log.Event(ctx, "Event reaction",
+		attr.Int64("sr.event.id", reactRequest.ID),
+		attr.Bool("sr.reaction.added", added),
+	)
+	srHTTP.LogSuccessf(ctx, "Reaction on %v toggled (added = %v)", reactRequest.ID, added)
+}
@@ -1,11 +1,14 @@
 package routes
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 
+	"sr/apitoken"
 	"sr/config"
 	"sr/errs"
 	"sr/event"
@@ -15,15 +18,26 @@ import (
 	"sr/log"
 	"sr/player"
 	"sr/roll"
+	"sr/session"
 	"sr/update"
 
+	"github.com/go-redis/redis/v8"
 	attr "go.opentelemetry.io/otel/attribute"
 )
 
 var gameRouter = RESTRouter.PathPrefix("/game").Subrouter()
 
+// mustBeGM halts unless the session's player is a GM of their game.
+func mustBeGM(ctx context.Context, client redis.Cmdable, sess *session.Session, action string) {
+	gms, err := game.GetGMs(ctx, client, sess.GameID)
+	srHTTP.HaltInternal(ctx, err)
+	if !game.IsGM(gms, sess.PlayerID) {
+		srHTTP.Halt(ctx, errs.NoAccessf("Only GMs may %v", action))
+	}
+}
+
 // GET /info {gameInfo}
-var _ = srHTTP.Handle(gameRouter, "GET /info", handleInfo)
+var _ = srHTTP.HandleScoped(gameRouter, "GET /info", apitoken.ScopeReadEvents, handleInfo)
 
 func handleInfo(args *srHTTP.Args) {
 	ctx, response, _, client, sess := args.MustSession()
@@ -37,6 +51,56 @@ func handleInfo(args *srHTTP.Args) {
 	)
 }
 
+type createGameRequest struct {
+	GameID string `json:"gameID"`
+}
+
+// $ POST /create gameID -> { login response }
+var _ = srHTTP.Handle(gameRouter, "POST /create", handleCreateOwnGame)
+
+func handleCreateOwnGame(args *srHTTP.Args) {
+	ctx, response, request, client, sess := args.MustPlayerSession()
+
+	var createRequest createGameRequest
+	srHTTP.MustReadBodyJSON(request, &createRequest)
+	if !game.ValidID(createRequest.GameID) {
+		srHTTP.Halt(ctx, errs.BadRequestf("gameID: invalid"))
+	}
+
+	plr, err := player.GetByID(ctx, client, string(sess.PlayerID))
+	srHTTP.HaltInternal(ctx, err)
+
+	err = game.CreateWithGM(ctx, client, createRequest.GameID, plr)
+	if errors.Is(err, errs.ErrBadRequest) {
+		srHTTP.Halt(ctx, errs.BadRequestf("Game already exists"))
+	}
+	srHTTP.HaltInternal(ctx, err)
+
+	info, err := game.GetInfo(ctx, client, createRequest.GameID)
+	srHTTP.HaltInternal(ctx, err)
+
+	// Game sessions are for one game, so the creator gets a new one to play in
+	// theirs. Player sessions can be used with the new game as they are.
+	gameSess := sess
+	if sess.GameID != "" {
+		gameSess = session.New(plr, createRequest.GameID, sess.Persist)
+		gameSess.Verified = sess.Verified
+		gameSess.UserAgent = request.UserAgent()
+		err = session.Create(ctx, client, gameSess)
+		srHTTP.HaltInternal(ctx, err)
+	}
+
+	srHTTP.MustWriteBodyJSON(ctx, response, loginResponse{
+		Player:   plr,
+		GameInfo: info,
+		Session:  string(gameSess.ID),
+	})
+	log.Event(ctx, "Game created",
+		attr.String("sr.game.createdID", createRequest.GameID),
+	)
+	srHTTP.LogSuccessf(ctx, "%v created %v", plr.ID, createRequest.GameID)
+}
+
 type renameRequest struct {
 	Name string `json:"name"`
 }
@@ -70,6 +134,7 @@ func handleUpdateEvent(args *srHTTP.Args) {
 	evt.SetEdit(updateTime)
 	diff := make(map[string]interface{}, len(updateRequest.Diff))
 	keys := make([]string, 0, len(updateRequest.Diff))
+	var newMentions []id.UID
 	for key, value := range updateRequest.Diff {
 		keys = append(keys, key)
 		switch key {
@@ -82,7 +147,7 @@ func handleUpdateEvent(args *srHTTP.Args) {
 			// Title is common to many events to be worth type switch
 			titleField := reflect.Indirect(reflect.ValueOf(evt)).FieldByName("Title")
 			if !titleField.CanSet() {
-				srHTTP.Halt(ctx, errs.Internalf("could not set Title field"))
+				srHTTP.Halt(ctx, errs.BadRequestf("event diff: title: event has no title"))
 			}
 			titleField.SetString(title)
 			diff["title"] = title
@@ -93,10 +158,30 @@ func handleUpdateEvent(args *srHTTP.Args) {
 			}
 			glitchyField := reflect.Indirect(reflect.ValueOf(evt)).FieldByName("Glitchy")
 			if !glitchyField.CanSet() {
-				srHTTP.Halt(ctx, errs.Internalf("could not set Glitchy field"))
+				srHTTP.Halt(ctx, errs.BadRequestf("event diff: glitchy: event has no glitchy"))
 			}
 			glitchyField.SetInt(int64(glitchy))
 			diff["glitchy"] = glitchy
+		// Text: the player can edit a message, possibly mentioning more players.
+		case "text":
+			message, ok := evt.(*event.Message)
+			if !ok {
+				srHTTP.Halt(ctx, errs.BadRequestf("event diff: text: event is not a message"))
+			}
+			text, ok := value.(string)
+			if !ok {
+				srHTTP.Halt(ctx, errs.BadRequestf("event diff: text: expected string"))
+			}
+			message.Text = mustCheckMessageText(ctx, text)
+			mentions := mustResolveMentions(ctx, client, sess, message)
+			for _, mentioned := range mentions {
+				if !message.IsMentioned(mentioned) {
+					newMentions = append(newMentions, mentioned)
+				}
+			}
+			message.Mentions = mentions
+			diff["text"] = message.Text
+			diff["mentions"] = mentions
 		case "share":
 			srHTTP.Halt(ctx, errs.BadRequestf("event diff: cannot update share here"))
 		default:
@@ -112,6 +197,10 @@ func handleUpdateEvent(args *srHTTP.Args) {
 
 	err = game.UpdateEvent(ctx, client, sess.GameID, evt, update)
 	srHTTP.HaltInternal(ctx, err)
+	if len(newMentions) > 0 {
+		err = game.NotifyMentions(ctx, client, sess.GameID, evt.(*event.Message), newMentions)
+		srHTTP.HaltInternal(ctx, err)
+	}
 
 	srHTTP.LogSuccessf(ctx,
 		"updated %v fields on event %v", len(keys), evt.GetID(),
@@ -145,7 +234,8 @@ func handleDeleteEvent(args *srHTTP.Args) {
 	if evt.GetPlayerID() != sess.PlayerID {
 		srHTTP.Halt(ctx, errs.NoAccessf("You may not delete this event."))
 	}
-	if evt.GetType() == event.EventTypePlayerJoin {
+	// Awards must keep matching the characters' ledgers
+	if evt.GetType() == event.EventTypePlayerJoin || evt.GetType() == event.EventTypeAward {
 		srHTTP.Halt(ctx, errs.NoAccessf("You may not delete this event."))
 	}
 
@@ -164,15 +254,29 @@ func handleDeleteEvent(args *srHTTP.Args) {
 }
 
 type rollRequest struct {
-	Count   int    `json:"count"`
-	Title   string `json:"title"`
-	Share   int    `json:"share"`
-	Edge    bool   `json:"edge"`
-	Glitchy int    `json:"glitchy"`
+	Count     int      `json:"count"`
+	Title     string   `json:"title"`
+	Share     int      `json:"share"`
+	To        []id.UID `json:"to"`
+	Edge      bool     `json:"edge"`
+	Glitchy   int      `json:"glitchy"`
+	RequestID int64    `json:"requestID"`
+
+	// Rolling from a character sheet, i.e. Agility + Firearms.
+	Character id.UID   `json:"character"`
+	Pool      []string `json:"pool"`
+	Limit     string   `json:"limit"`
+	Modifier  int      `json:"modifier"`
+
+	// Illegal Matrix actions add the opposing roll's hits to Overwatch Score.
+	Illegal      bool `json:"illegal"`
+	OpposingHits int  `json:"opposingHits"`
+
+	macro string // Name of the macro being rolled
 }
 
 // $ POST /roll count
-var _ = srHTTP.Handle(gameRouter, "POST /roll", handleRoll)
+var _ = srHTTP.HandleScoped(gameRouter, "POST /roll", apitoken.ScopeRoll, handleRoll)
 
 func handleRoll(args *srHTTP.Args) {
 	ctx, _, request, client, sess := args.MustSession()
@@ -180,6 +284,33 @@ func handleRoll(args *srHTTP.Args) {
 	var rollRequest rollRequest
 	srHTTP.MustReadBodyJSON(request, &rollRequest)
 
+	// Rolling from a character computes the pool from their sheet
+	var breakdown *event.Breakdown
+	if rollRequest.Character != "" {
+		char := mustGetCharacter(ctx, client, sess, rollRequest.Character)
+		var err error
+		breakdown, err = char.Breakdown(rollRequest.Pool, rollRequest.Limit, rollRequest.Modifier)
+		srHTTP.Halt(ctx, err)
+		rollRequest.Count = breakdown.Pool()
+	} else if len(rollRequest.Pool) != 0 || rollRequest.Limit != "" || rollRequest.Modifier != 0 {
+		srHTTP.Halt(ctx, errs.BadRequestf("character: expected a character to roll from"))
+	}
+	if rollRequest.Illegal && rollRequest.Character == "" {
+		srHTTP.Halt(ctx, errs.BadRequestf("illegal: expected a character to add Overwatch Score to"))
+	}
+	if rollRequest.OpposingHits < 0 || rollRequest.OpposingHits > config.MaxSingleRoll ||
+		(rollRequest.OpposingHits != 0 && !rollRequest.Illegal) {
+		srHTTP.Halt(ctx, errs.BadRequestf("opposingHits: invalid"))
+	}
+	postRoll(ctx, request, client, sess, &rollRequest, breakdown)
+}
+
+// postRoll rolls and posts the requested roll, with the breakdown of its pool
+// if it was computed from a character or macro.
+func postRoll(
+	ctx context.Context, request srHTTP.Request, client *redis.Client, sess *session.Session,
+	rollRequest *rollRequest, breakdown *event.Breakdown,
+) {
 	if rollRequest.Count < 1 {
 		srHTTP.Halt(ctx, errs.BadRequestf("Invalid roll count"))
 	}
@@ -190,10 +321,32 @@ func handleRoll(args *srHTTP.Args) {
 		srHTTP.Halt(ctx, errs.BadRequestf("share: invalid"))
 	}
 	share := event.Share(rollRequest.Share)
+	recipients := rollRequest.To
+	mustCheckRecipients(ctx, client, sess, sess.PlayerID, share, recipients)
+
+	// Answering a GM's roll request
+	if rollRequest.RequestID != 0 {
+		requested, err := game.GetRollRequest(ctx, client, sess.GameID, rollRequest.RequestID)
+		if errors.Is(err, errs.ErrNotFound) || errors.Is(err, errs.ErrBadRequest) {
+			srHTTP.Halt(ctx, errs.BadRequest(err))
+		}
+		srHTTP.HaltInternal(ctx, err)
+		srHTTP.Halt(ctx, requested.CheckAnswer(sess.PlayerID))
+		if requested.GMsOnly {
+			share = event.ShareGMs
+			recipients = nil
+		}
+	}
 
 	player, err := player.GetByID(ctx, client, string(sess.PlayerID))
 	srHTTP.HaltInternal(ctx, err)
 
+	var edge *edgeSpend
+	if rollRequest.Edge && rollRequest.Character != "" {
+		edge = mustSpendEdge(ctx, client, sess, rollRequest.Character)
+	}
+	defer edge.refundUnlessPosted()
+
 	var evt event.Event
 	if rollRequest.Edge {
 		rolls, hits, err := roll.Rolls.ExplodingSixes(request.Context(), rollRequest.Count)
@@ -201,6 +354,9 @@ func handleRoll(args *srHTTP.Args) {
 		rollEvent := event.ForEdgeRoll(
 			player, share, rollRequest.Title, rolls, rollRequest.Glitchy,
 		)
+		rollEvent.RequestID = rollRequest.RequestID
+		rollEvent.Breakdown = breakdown
+		rollEvent.Macro = rollRequest.macro
 		evt = &rollEvent
 		log.Event(ctx, "Dice roll",
 			attr.Int64("sr.event.id", evt.GetID()),
@@ -219,6 +375,12 @@ func handleRoll(args *srHTTP.Args) {
 		rollEvent := event.ForRoll(
 			player, share, rollRequest.Title, dice, rollRequest.Glitchy,
 		)
+		rollEvent.RequestID = rollRequest.RequestID
+		rollEvent.Breakdown = breakdown
+		rollEvent.Macro = rollRequest.macro
+		if breakdown != nil && breakdown.Limit > 0 && hits > breakdown.Limit {
+			hits = breakdown.Limit
+		}
 		evt = &rollEvent
 		log.Event(ctx, "Dice roll",
 			attr.Int64("sr.event.id", evt.GetID()),
@@ -231,17 +393,145 @@ func handleRoll(args *srHTTP.Args) {
 			attr.Int("sr.roll.hits", hits),
 		)
 	}
+	evt.SetRecipients(recipients)
+	if rollRequest.Illegal && rollRequest.OpposingHits != 0 {
+		char, score, err := game.PostIllegalRoll(
+			ctx, client, sess.GameID, evt, rollRequest.RequestID,
+			rollRequest.Character, player, rollRequest.OpposingHits,
+		)
+		if errors.Is(err, errs.ErrNotFound) || errors.Is(err, errs.ErrNoAccess) || errors.Is(err, errs.ErrBadRequest) {
+			srHTTP.Halt(ctx, err)
+		}
+		srHTTP.HaltInternal(ctx, err)
+		edge.markPosted()
+		log.Event(ctx, "Overwatch added",
+			attr.String("sr.character.id", string(char.ID)),
+			attr.Int("sr.overwatch.added", rollRequest.OpposingHits),
+			attr.Int("sr.overwatch.score", score),
+		)
+		srHTTP.LogSuccessf(ctx, "Illegal roll %v posted, %v now has %v Overwatch", evt.GetID(), char.ID, score)
+		return
+	}
+	if rollRequest.RequestID != 0 {
+		err = game.AnswerRollRequest(ctx, client, sess.GameID, rollRequest.RequestID, evt)
+		if errors.Is(err, errs.ErrNoAccess) || errors.Is(err, errs.ErrBadRequest) {
+			srHTTP.Halt(ctx, err)
+		}
+		srHTTP.HaltInternal(ctx, err)
+		edge.markPosted()
+		srHTTP.LogSuccessf(ctx, "Roll %v posted answering %v", evt.GetID(), rollRequest.RequestID)
+		return
+	}
 	err = game.PostEvent(ctx, client, sess.GameID, evt)
 	srHTTP.HaltInternal(ctx, err)
+	edge.markPosted()
 	srHTTP.LogSuccessf(ctx, "Roll %v posted", evt.GetID())
 }
 
+type groupRollPool struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+	Dice  int    `json:"dice"`
+}
+
+type groupRollRequest struct {
+	Title     string          `json:"title"`
+	Share     int             `json:"share"`
+	To        []id.UID        `json:"to"`
+	Glitchy   int             `json:"glitchy"`
+	Threshold int             `json:"threshold"`
+	Pools     []groupRollPool `json:"pools"`
+}
+
+// $ POST /roll-group title [{name, count, dice}]
+var _ = srHTTP.HandleScoped(gameRouter, "POST /roll-group", apitoken.ScopeRoll, handleRollGroup)
+
+func handleRollGroup(args *srHTTP.Args) {
+	ctx, _, request, client, sess := args.MustSession()
+
+	var groupRequest groupRollRequest
+	srHTTP.MustReadBodyJSON(request, &groupRequest)
+
+	if len(groupRequest.Pools) == 0 {
+		srHTTP.Halt(ctx, errs.BadRequestf("No pools requested"))
+	}
+	if !event.IsShare(groupRequest.Share) {
+		srHTTP.Halt(ctx, errs.BadRequestf("share: invalid"))
+	}
+	if groupRequest.Threshold < 0 || groupRequest.Threshold > config.MaxSingleRoll {
+		srHTTP.Halt(ctx, errs.BadRequestf("threshold: invalid"))
+	}
+	share := event.Share(groupRequest.Share)
+	mustCheckRecipients(ctx, client, sess, sess.PlayerID, share, groupRequest.To)
+
+	// Expand "Ganger x6" into "Ganger 1" ... "Ganger 6"
+	var entries []event.GroupRollEntry
+	for _, pool := range groupRequest.Pools {
+		name := strings.TrimSpace(pool.Name)
+		if name == "" || len(name) > 32 || strings.ContainsAny(name, "\r\n") {
+			srHTTP.Halt(ctx, errs.BadRequestf("name: invalid"))
+		}
+		if pool.Dice < 1 {
+			srHTTP.Halt(ctx, errs.BadRequestf("Invalid roll count"))
+		}
+		if pool.Dice > config.MaxSingleRoll {
+			srHTTP.Halt(ctx, errs.BadRequestf("Roll count too high"))
+		}
+		count := pool.Count
+		if count == 0 {
+			count = 1
+		}
+		if count < 0 || len(entries)+count > config.MaxGroupRollActors {
+			srHTTP.Halt(ctx, errs.BadRequestf("Too many actors"))
+		}
+		for i := 1; i <= count; i++ {
+			actorName := name
+			if count > 1 {
+				actorName = fmt.Sprintf("%v %v", name, i)
+			}
+			entries = append(entries, event.GroupRollEntry{
+				Name: actorName, Dice: make([]int, pool.Dice),
+			})
+		}
+	}
+
+	player, err := player.GetByID(ctx, client, string(sess.PlayerID))
+	srHTTP.HaltInternal(ctx, err)
+
+	totalHits := 0
+	for i := range entries {
+		hits, err := roll.Rolls.Fill(request.Context(), entries[i].Dice)
+		srHTTP.HaltInternal(ctx, err)
+		totalHits += hits
+	}
+	groupRoll := event.ForGroupRoll(
+		player, share, groupRequest.Title, entries,
+		groupRequest.Glitchy, groupRequest.Threshold,
+	)
+	groupRoll.SetRecipients(groupRequest.To)
+	err = game.PostEvent(ctx, client, sess.GameID, &groupRoll)
+	srHTTP.HaltInternal(ctx, err)
+
+	log.Event(ctx, "Group roll",
+		attr.Int64("sr.event.id", groupRoll.GetID()),
+		attr.String("sr.event.share", share.String()),
+		attr.Int("sr.roll.actors", len(entries)),
+		attr.Int("sr.roll.glitchy", groupRequest.Glitchy),
+		attr.Int("sr.roll.threshold", groupRequest.Threshold),
+		attr.Int("sr.roll.hits", totalHits),
+		attr.Int("sr.roll.succeeded", groupRoll.Succeeded()),
+	)
+	srHTTP.LogSuccessf(ctx, "Group roll %v posted for %v actors",
+		groupRoll.GetID(), len(entries),
+	)
+}
+
 type rerollRequest struct {
 	RollID int64  `json:"rollID"`
 	Type   string `json:"rerollType"`
 }
 
-var _ = srHTTP.Handle(gameRouter, "POST /reroll", handleReroll)
+var _ = srHTTP.HandleScoped(gameRouter, "POST /reroll", apitoken.ScopeRoll, handleReroll)
 
 func handleReroll(args *srHTTP.Args) {
 	ctx, _, request, client, sess := args.MustSession()
@@ -284,6 +574,13 @@ func handleReroll(args *srHTTP.Args) {
 		srHTTP.Halt(ctx, errs.BadRequestf("Invalid previous roll"))
 	}
 
+	// Second Chance spends Edge of the character who rolled
+	var edge *edgeSpend
+	if previousRoll.Breakdown != nil && previousRoll.Breakdown.CharID != "" {
+		edge = mustSpendEdge(ctx, client, sess, previousRoll.Breakdown.CharID)
+	}
+	defer edge.refundUnlessPosted()
+
 	player, err := player.GetByID(ctx, client, string(sess.PlayerID))
 	srHTTP.HaltInternal(ctx, err)
 
@@ -295,6 +592,7 @@ func handleReroll(args *srHTTP.Args) {
 	srHTTP.HaltInternal(ctx, err)
 	err = game.PostEvent(ctx, client, sess.GameID, &rerolled)
 	srHTTP.HaltInternal(ctx, err)
+	edge.markPosted()
 
 	log.Event(ctx, "Dice rerolled",
 		attr.Int64("sr.event.id", previousRoll.ID),
@@ -331,7 +629,7 @@ type eventRangeResponse struct {
   if there's < max responses, client knows it's hit the boundary.
 */
 // GET /event-range { start: <id>, end: <id>, max: int }
-var _ = srHTTP.Handle(gameRouter, "GET /events", handleEvents)
+var _ = srHTTP.HandleScoped(gameRouter, "GET /events", apitoken.ScopeReadEvents, handleEvents)
 
 func handleEvents(args *srHTTP.Args) {
 	ctx, response, request, client, sess := args.MustSession()
@@ -384,7 +682,9 @@ func handleEvents(args *srHTTP.Args) {
 				err := fmt.Errorf("error parsing event %v: %w", i, err)
 				srHTTP.HaltInternal(ctx, err)
 			}
-			if !game.PlayerCanSeeEvent(plr, isGM, evt) {
+			if game.PlayerSeesPlaceholder(plr, isGM, evt) {
+				evt = event.ForHidden(evt)
+			} else if !game.PlayerCanSeeEvent(plr, isGM, evt) {
 				continue
 			}
 			parsed = append(parsed, evt)
@@ -27,22 +27,24 @@ func pingStream(stream *sse.Conn) error {
 }
 
 func shouldSendUpdate(ctx context.Context, message *redis.Message, playerID id.UID, isGM bool) (inner string, should bool) {
-	excludeID, excludeGMs, inner, found := update.ParseExclude(message.Payload)
+	excludeIDs, excludeGMs, inner, found := update.ParseExclude(message.Payload)
 	if config.UpdatesDebug {
 		if found {
 			log.Printf(ctx, "Exclude update on %v: !id=%v, !gms=%v",
-				message.Channel, excludeID, excludeGMs,
+				message.Channel, excludeIDs, excludeGMs,
 			)
 		} else {
 			log.Printf(ctx, "Regular update from %v", message.Channel)
 		}
 	}
 	if found {
-		if excludeID == playerID {
-			if config.UpdatesDebug {
-				log.Printf(ctx, "-> skipping because player ID matched")
+		for _, excludeID := range excludeIDs {
+			if excludeID == playerID {
+				if config.UpdatesDebug {
+					log.Printf(ctx, "-> skipping because player ID matched")
+				}
+				return inner, false
 			}
-			return inner, false
 		}
 		if isGM && excludeGMs {
 			if config.UpdatesDebug {
@@ -64,6 +66,18 @@ func writeUpdateToStream(updateText string, stream *sse.Conn) error {
 	return stream.WriteEventWithID(fmt.Sprintf("%v", messageID), "upd", []byte(updateText))
 }
 
+// subscriptionEnded determines if an update changes the player's membership in
+// the game, requiring them to reconnect for the subscription to match it.
+func subscriptionEnded(updateText string, playerID id.UID, isGM bool) bool {
+	if removedID, ok := update.ParsePlayerDel(updateText); ok {
+		return removedID == playerID
+	}
+	if gms, ok := update.ParseGMs(updateText); ok {
+		return game.IsGM(gms, playerID) != isGM
+	}
+	return false
+}
+
 var removeDecimal = regexp.MustCompile(`\.\d+`)
 
 var _ = srHTTP.Handle(gameRouter, "GET /subscription", handleSubscription)
@@ -72,6 +86,7 @@ func handleSubscription(args *srHTTP.Args) {
 	requestCtx, response, request, client, _ := args.Get()
 	sess, err := srHTTP.RequestParamSession(request, client)
 	srHTTP.Halt(requestCtx, errs.NoAccess(err))
+	srHTTP.Halt(requestCtx, srHTTP.RequestGame(request, client, sess))
 
 	log.Printf(requestCtx, "Player %v to connect to %v", sess.PlayerID, sess.GameID)
 
@@ -118,7 +133,14 @@ func handleSubscription(args *srHTTP.Args) {
 	// Subscribe to redis forwarder
 	cancelCtx, cancel := context.WithCancel(shutdownCtx)
 	defer cancel()
-	updates, errors, cleanup := game.Subscribe(requestCtx, client, sess.GameID, sess.PlayerID, isGM)
+	var updates <-chan *redis.Message
+	var errors <-chan error
+	var cleanup func()
+	if sess.Spectator {
+		updates, errors, cleanup = game.SubscribeSpectator(requestCtx, client, sess.GameID, sess.PlayerID, session.RevokedChannel(sess.ID))
+	} else {
+		updates, errors, cleanup = game.Subscribe(requestCtx, client, sess.GameID, sess.PlayerID, isGM, session.RevokedChannel(sess.ID))
+	}
 	srHTTP.HaltInternal(requestCtx, err)
 	defer cleanup()
 	if config.StreamDebug {
@@ -187,6 +209,10 @@ func handleSubscription(args *srHTTP.Args) {
 		}
 		select { // Receive message/error and wait out interval
 		case updateMessage := <-updates:
+			if updateMessage.Channel == session.RevokedChannel(sess.ID) {
+				log.Printf(requestCtx, "Closing subscription after session was removed")
+				return
+			}
 			inner, shouldSend := shouldSendUpdate(requestCtx, updateMessage, sess.PlayerID, isGM)
 			if !shouldSend {
 				if config.StreamDebug {
@@ -201,6 +227,10 @@ func handleSubscription(args *srHTTP.Args) {
 			} else if config.StreamDebug {
 				log.Printf(requestCtx, "Sent update %v to %v", update.ParseType(inner), sess.PlayerID)
 			}
+			if subscriptionEnded(inner, sess.PlayerID, isGM) {
+				log.Printf(requestCtx, "Closing subscription after membership change")
+				return
+			}
 		case <-pollTicker.C:
 			// Time to re-check stream.IsOpen()
 			continue
@@ -3,6 +3,7 @@ package routes
 import (
 	"math"
 
+	"sr/apitoken"
 	"sr/errs"
 	"sr/event"
 	"sr/game"
@@ -17,16 +18,20 @@ import (
 )
 
 type initiativeRollRequest struct {
-	Title   string `json:"title"`
-	Share   int    `json:"share"`
-	Base    int    `json:"base"`
-	Dice    int    `json:"dice"`
-	Seized  bool   `json:"seized"`
-	Blitzed bool   `json:"blitzed"`
+	Title   string   `json:"title"`
+	Share   int      `json:"share"`
+	To      []id.UID `json:"to"`
+	Base    int      `json:"base"`
+	Dice    int      `json:"dice"`
+	Seized  bool     `json:"seized"`
+	Blitzed bool     `json:"blitzed"`
+
+	// Rolling from a character sheet, which sets the base and dice.
+	Character id.UID `json:"character"`
 }
 
 // $ POST /roll-initiative title base dice
-var _ = srHTTP.Handle(gameRouter, "POST /roll-initiative", handleRollInitiative)
+var _ = srHTTP.HandleScoped(gameRouter, "POST /roll-initiative", apitoken.ScopeRoll, handleRollInitiative)
 
 func handleRollInitiative(args *srHTTP.Args) {
 	ctx, _, request, client, sess := args.MustSession()
@@ -34,6 +39,14 @@ func handleRollInitiative(args *srHTTP.Args) {
 	var initRequest initiativeRollRequest
 	srHTTP.MustReadBodyJSON(request, &initRequest)
 
+	var breakdown *event.Breakdown
+	if initRequest.Character != "" {
+		char := mustGetCharacter(ctx, client, sess, initRequest.Character)
+		breakdown = char.InitiativeBreakdown()
+		initRequest.Base = breakdown.Pool()
+		initRequest.Dice = char.InitiativeDice
+	}
+
 	if initRequest.Dice < 1 {
 		srHTTP.Halt(ctx, errs.BadRequestf("Invalid dice count"))
 	}
@@ -53,6 +66,7 @@ func handleRollInitiative(args *srHTTP.Args) {
 		srHTTP.Halt(ctx, errs.BadRequestf("share: invalid"))
 	}
 	share := event.Share(initRequest.Share)
+	mustCheckRecipients(ctx, client, sess, sess.PlayerID, share, initRequest.To)
 
 	log.Printf(ctx, "%v to roll %v + %vd6 %v (blitz = %v, seize = %v) %v",
 		sess.PlayerID, initRequest.Base, initRequest.Dice, share.String(), initRequest.Blitzed, initRequest.Seized, initRequest.Title,
@@ -61,6 +75,17 @@ func handleRollInitiative(args *srHTTP.Args) {
 	plr, err := player.GetByID(ctx, client, string(sess.PlayerID))
 	srHTTP.HaltInternal(ctx, err)
 
+	// Seizing the initiative and blitzing each spend a point of the character's Edge
+	var seizeEdge, blitzEdge *edgeSpend
+	if initRequest.Character != "" && initRequest.Seized {
+		seizeEdge = mustSpendEdge(ctx, client, sess, initRequest.Character)
+	}
+	defer seizeEdge.refundUnlessPosted()
+	if initRequest.Character != "" && initRequest.Blitzed {
+		blitzEdge = mustSpendEdge(ctx, client, sess, initRequest.Character)
+	}
+	defer blitzEdge.refundUnlessPosted()
+
 	dice := make([]int, initRequest.Dice)
 	roll.Rolls.Fill(request.Context(), dice)
 	log.Printf(ctx, "Rolled %v + %v = %v", initRequest.Base, dice, initRequest.Base+roll.SumDice(dice))
@@ -68,8 +93,12 @@ func handleRollInitiative(args *srHTTP.Args) {
 	event := event.ForInitiativeRoll(
 		plr, share, initRequest.Title, initRequest.Base, dice, initRequest.Seized, initRequest.Blitzed,
 	)
+	event.SetRecipients(initRequest.To)
+	event.Breakdown = breakdown
 	err = game.PostEvent(ctx, client, sess.GameID, &event)
 	srHTTP.HaltInternal(ctx, err)
+	seizeEdge.markPosted()
+	blitzEdge.markPosted()
 
 	log.Event(ctx, "Initiative rolled",
 		attr.Int64("sr.event.id", event.GetID()),
@@ -144,7 +173,7 @@ func handleEditInitiative(args *srHTTP.Args) {
 			srHTTP.Halt(ctx, errs.BadRequestf("Cannot set dice at this time"))
 		case "seized":
 			seized, ok := value.(bool)
-			if !ok {
+			if !ok || seized {
 				srHTTP.Halt(ctx, errs.BadRequestf("seized: can only be unset"))
 			}
 			if initEvent.Seized == seized {
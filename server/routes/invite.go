@@ -0,0 +1,89 @@
+package routes
+
+import (
+	"errors"
+
+	"sr/errs"
+	srHTTP "sr/http"
+	"sr/id"
+	"sr/invite"
+	"sr/log"
+	"sr/player"
+
+	attr "go.opentelemetry.io/otel/attribute"
+)
+
+// $ GET /invites
+var _ = srHTTP.Handle(gameRouter, "GET /invites", handleGetInvites)
+
+func handleGetInvites(args *srHTTP.Args) {
+	ctx, response, _, client, sess := args.MustSession()
+	mustBeGM(ctx, client, sess, "view invites")
+
+	invites, err := invite.GetAll(ctx, client, sess.GameID)
+	srHTTP.HaltInternal(ctx, err)
+	srHTTP.MustWriteBodyJSON(ctx, response, invites)
+	srHTTP.LogSuccessf(ctx, "%v invites", len(invites))
+}
+
+type createInviteRequest struct {
+	TTL      int64 `json:"ttl"` // Milliseconds
+	MaxUses  int   `json:"maxUses"`
+	Spectate bool  `json:"spectate"`
+}
+
+// $ POST /invite/create ttl maxUses spectate
+var _ = srHTTP.Handle(gameRouter, "POST /invite/create", handleCreateInvite)
+
+func handleCreateInvite(args *srHTTP.Args) {
+	ctx, response, request, client, sess := args.MustSession()
+
+	var inviteRequest createInviteRequest
+	srHTTP.MustReadBodyJSON(request, &inviteRequest)
+	mustNotUseToken(ctx, sess, "create invites")
+	mustBeGM(ctx, client, sess, "create invites")
+
+	plr, err := player.GetByID(ctx, client, string(sess.PlayerID))
+	srHTTP.HaltInternal(ctx, err)
+
+	now := id.TimestampNow()
+	created := invite.Make(sess.GameID, plr, inviteRequest.TTL, inviteRequest.MaxUses, inviteRequest.Spectate, now)
+	srHTTP.Halt(ctx, created.Validate(now))
+
+	err = invite.Create(ctx, client, &created, now)
+	if errors.Is(err, errs.ErrBadRequest) {
+		srHTTP.Halt(ctx, err)
+	}
+	srHTTP.HaltInternal(ctx, err)
+	srHTTP.MustWriteBodyJSON(ctx, response, &created)
+
+	log.Event(ctx, "Invite created",
+		attr.Int64("sr.invite.ttl", inviteRequest.TTL),
+		attr.Int("sr.invite.maxUses", created.MaxUses),
+		attr.Bool("sr.invite.spectate", created.Spectate),
+	)
+	srHTTP.LogSuccessf(ctx, "Invite %v", created.String())
+}
+
+type revokeInviteRequest struct {
+	Code id.UID `json:"code"`
+}
+
+// $ POST /invite/revoke code
+var _ = srHTTP.Handle(gameRouter, "POST /invite/revoke", handleRevokeInvite)
+
+func handleRevokeInvite(args *srHTTP.Args) {
+	ctx, _, request, client, sess := args.MustSession()
+
+	var revokeRequest revokeInviteRequest
+	srHTTP.MustReadBodyJSON(request, &revokeRequest)
+	mustNotUseToken(ctx, sess, "revoke invites")
+	mustBeGM(ctx, client, sess, "revoke invites")
+
+	err := invite.Revoke(ctx, client, sess.GameID, revokeRequest.Code)
+	if errors.Is(err, errs.ErrNotFound) {
+		srHTTP.Halt(ctx, err)
+	}
+	srHTTP.HaltInternal(ctx, err)
+	srHTTP.LogSuccessf(ctx, "Revoked invite %v", revokeRequest.Code)
+}
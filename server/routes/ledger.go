@@ -0,0 +1,111 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"sr/character"
+	"sr/errs"
+	"sr/event"
+	"sr/game"
+	srHTTP "sr/http"
+	"sr/id"
+	"sr/log"
+	"sr/player"
+
+	attr "go.opentelemetry.io/otel/attribute"
+)
+
+type awardRequest struct {
+	Character id.UID `json:"character"`
+	Amount    int    `json:"amount"`
+	Currency  string `json:"currency"`
+	Reason    string `json:"reason"`
+}
+
+// $ POST /character/award character amount currency reason
+var _ = srHTTP.Handle(gameRouter, "POST /character/award", handleAward)
+
+func handleAward(args *srHTTP.Args) {
+	ctx, response, request, client, sess := args.MustSession()
+
+	var awardRequest awardRequest
+	srHTTP.MustReadBodyJSON(request, &awardRequest)
+
+	if awardRequest.Amount == 0 || awardRequest.Amount > character.MaxAward || awardRequest.Amount < -character.MaxAward {
+		srHTTP.Halt(ctx, errs.BadRequestf("amount: invalid"))
+	}
+	if !character.ValidCurrency(awardRequest.Currency) {
+		srHTTP.Halt(ctx, errs.BadRequestf("currency: expected karma or nuyen"))
+	}
+	reason := strings.TrimSpace(awardRequest.Reason)
+	if reason == "" || len(reason) > 256 {
+		srHTTP.Halt(ctx, errs.BadRequestf("reason: expected a reason"))
+	}
+
+	mustBeGM(ctx, client, sess, "give awards")
+	char := mustGetCharacter(ctx, client, sess, awardRequest.Character)
+
+	plr, err := player.GetByID(ctx, client, string(sess.PlayerID))
+	srHTTP.HaltInternal(ctx, err)
+
+	evt := event.ForAward(
+		plr, char.ID, char.Name, awardRequest.Amount, awardRequest.Currency, reason,
+	)
+	err = game.PostAward(ctx, client, sess.GameID, &evt)
+	if errors.Is(err, errs.ErrNotFound) {
+		srHTTP.Halt(ctx, err)
+	}
+	srHTTP.HaltInternal(ctx, err)
+	srHTTP.MustWriteBodyJSON(ctx, response, evt.GetID())
+
+	log.Event(ctx, "Award given",
+		attr.Int64("sr.event.id", evt.GetID()),
+		attr.String("sr.character.id", string(char.ID)),
+		attr.Int("sr.award.amount", awardRequest.Amount),
+		attr.String("sr.award.currency", awardRequest.Currency),
+	)
+	srHTTP.LogSuccessf(ctx, "%v", evt.Summary())
+}
+
+type ledgerResponse struct {
+	Entries []character.LedgerEntry `json:"entries"`
+	Balance character.Balance       `json:"balance"`
+}
+
+// $ GET /character/ledger id
+var _ = srHTTP.Handle(gameRouter, "GET /character/ledger", handleGetLedger)
+
+func handleGetLedger(args *srHTTP.Args) {
+	ctx, response, request, client, sess := args.MustSession()
+
+	char := mustGetCharacter(ctx, client, sess, id.UID(request.FormValue("id")))
+	entries, err := character.GetLedger(ctx, client, char.ID)
+	srHTTP.HaltInternal(ctx, err)
+
+	srHTTP.MustWriteBodyJSON(ctx, response, ledgerResponse{
+		Entries: entries,
+		Balance: character.FoldLedger(entries),
+	})
+	srHTTP.LogSuccessf(ctx, "%v ledger entries of %v", len(entries), char)
+}
+
+// $ GET /character/ledger/csv id
+var _ = srHTTP.Handle(gameRouter, "GET /character/ledger/csv", handleExportLedger)
+
+func handleExportLedger(args *srHTTP.Args) {
+	ctx, response, request, client, sess := args.MustSession()
+
+	char := mustGetCharacter(ctx, client, sess, id.UID(request.FormValue("id")))
+	entries, err := character.GetLedger(ctx, client, char.ID)
+	srHTTP.HaltInternal(ctx, err)
+
+	response.Header().Set("Content-Type", "text/csv")
+	response.Header().Set("Content-Disposition",
+		fmt.Sprintf(`attachment; filename="ledger-%v.csv"`, char.ID),
+	)
+	err = character.WriteLedgerCSV(response, entries)
+	srHTTP.HaltInternal(ctx, err)
+	srHTTP.LogSuccessf(ctx, "Exported %v ledger entries of %v", len(entries), char)
+}
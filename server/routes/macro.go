@@ -0,0 +1,214 @@
+package routes
+
+import (
+	"errors"
+
+	"sr/apitoken"
+	"sr/errs"
+	srHTTP "sr/http"
+	"sr/id"
+	"sr/log"
+	"sr/macro"
+
+	attr "go.opentelemetry.io/otel/attribute"
+)
+
+type macroRequest struct {
+	ID      id.UID `json:"id"`
+	Name    string `json:"name"`
+	Title   string `json:"title"`
+	Pool    int    `json:"pool"`
+	Edge    bool   `json:"edge"`
+	Glitchy int    `json:"glitchy"`
+	Limit   int    `json:"limit"`
+	Share   int    `json:"share"`
+}
+
+// apply sets the requested values on the macro and validates it.
+func (r *macroRequest) apply(m *macro.Macro) error {
+	m.Name = r.Name
+	m.Title = r.Title
+	m.Pool = r.Pool
+	m.Edge = r.Edge
+	m.Glitchy = r.Glitchy
+	m.Limit = r.Limit
+	m.Share = r.Share
+	return m.Validate()
+}
+
+// $ GET /macros
+var _ = srHTTP.Handle(gameRouter, "GET /macros", handleGetMacros)
+
+func handleGetMacros(args *srHTTP.Args) {
+	ctx, response, _, client, sess := args.MustSession()
+
+	macros, err := macro.GetAll(ctx, client, sess.GameID, sess.PlayerID)
+	srHTTP.HaltInternal(ctx, err)
+	srHTTP.MustWriteBodyJSON(ctx, response, macros)
+	srHTTP.LogSuccessf(ctx, "%v macros", len(macros))
+}
+
+// $ POST /macro/create name title pool edge glitchy limit share
+var _ = srHTTP.Handle(gameRouter, "POST /macro/create", handleCreateMacro)
+
+func handleCreateMacro(args *srHTTP.Args) {
+	ctx, response, request, client, sess := args.MustSession()
+
+	var macroRequest macroRequest
+	srHTTP.MustReadBodyJSON(request, &macroRequest)
+	if macroRequest.ID != "" {
+		srHTTP.Halt(ctx, errs.BadRequestf("id: cannot choose macro ID"))
+	}
+
+	created := macro.Make(macroRequest.Name)
+	srHTTP.Halt(ctx, macroRequest.apply(&created))
+
+	err := macro.Save(ctx, client, sess.GameID, sess.PlayerID, true, created)
+	if errors.Is(err, errs.ErrBadRequest) {
+		srHTTP.Halt(ctx, err)
+	}
+	srHTTP.HaltInternal(ctx, err)
+	srHTTP.MustWriteBodyJSON(ctx, response, &created)
+
+	log.Event(ctx, "Macro created",
+		attr.String("sr.macro.id", string(created.ID)),
+	)
+	srHTTP.LogSuccessf(ctx, "Created macro %v", &created)
+}
+
+// $ POST /macro/update id name title pool edge glitchy limit share
+var _ = srHTTP.Handle(gameRouter, "POST /macro/update", handleUpdateMacro)
+
+func handleUpdateMacro(args *srHTTP.Args) {
+	ctx, response, request, client, sess := args.MustSession()
+
+	var macroRequest macroRequest
+	srHTTP.MustReadBodyJSON(request, &macroRequest)
+	if macroRequest.ID == "" {
+		srHTTP.Halt(ctx, errs.BadRequestf("id: expected a macro ID"))
+	}
+
+	updated := macro.Macro{ID: macroRequest.ID}
+	srHTTP.Halt(ctx, macroRequest.apply(&updated))
+
+	err := macro.Save(ctx, client, sess.GameID, sess.PlayerID, false, updated)
+	if errors.Is(err, errs.ErrNotFound) {
+		srHTTP.Halt(ctx, err)
+	}
+	srHTTP.HaltInternal(ctx, err)
+	srHTTP.MustWriteBodyJSON(ctx, response, &updated)
+
+	log.Event(ctx, "Macro updated",
+		attr.String("sr.macro.id", string(updated.ID)),
+	)
+	srHTTP.LogSuccessf(ctx, "Updated macro %v", &updated)
+}
+
+type macroIDRequest struct {
+	ID id.UID `json:"id"`
+}
+
+// $ POST /macro/delete id
+var _ = srHTTP.Handle(gameRouter, "POST /macro/delete", handleDeleteMacro)
+
+func handleDeleteMacro(args *srHTTP.Args) {
+	ctx, _, request, client, sess := args.MustSession()
+
+	var deleteRequest macroIDRequest
+	srHTTP.MustReadBodyJSON(request, &deleteRequest)
+
+	err := macro.Delete(ctx, client, sess.GameID, sess.PlayerID, deleteRequest.ID)
+	if errors.Is(err, errs.ErrNotFound) {
+		srHTTP.Halt(ctx, err)
+	}
+	srHTTP.HaltInternal(ctx, err)
+
+	log.Event(ctx, "Macro deleted",
+		attr.String("sr.macro.id", string(deleteRequest.ID)),
+	)
+	srHTTP.LogSuccessf(ctx, "Deleted macro %v", deleteRequest.ID)
+}
+
+// $ GET /macro/export
+var _ = srHTTP.Handle(gameRouter, "GET /macro/export", handleExportMacros)
+
+func handleExportMacros(args *srHTTP.Args) {
+	ctx, response, _, client, sess := args.MustSession()
+
+	macros, err := macro.GetAll(ctx, client, sess.GameID, sess.PlayerID)
+	srHTTP.HaltInternal(ctx, err)
+
+	response.Header().Set("Content-Disposition", `attachment; filename="macros.json"`)
+	srHTTP.MustWriteBodyJSON(ctx, response, macros)
+	srHTTP.LogSuccessf(ctx, "Exported %v macros", len(macros))
+}
+
+// $ POST /macro/import [macros]
+var _ = srHTTP.Handle(gameRouter, "POST /macro/import", handleImportMacros)
+
+func handleImportMacros(args *srHTTP.Args) {
+	ctx, response, request, client, sess := args.MustSession()
+
+	var macroRequests []macroRequest
+	srHTTP.MustReadBodyJSON(request, &macroRequests)
+	if len(macroRequests) == 0 {
+		srHTTP.Halt(ctx, errs.BadRequestf("expected macros to import"))
+	}
+	if len(macroRequests) > macro.MaxMacros {
+		srHTTP.Halt(ctx, errs.BadRequestf("cannot import more than %v macros", macro.MaxMacros))
+	}
+
+	// Imported macros are given new IDs in this game
+	imported := make([]macro.Macro, len(macroRequests))
+	for ix := range macroRequests {
+		imported[ix] = macro.Make(macroRequests[ix].Name)
+		if err := macroRequests[ix].apply(&imported[ix]); err != nil {
+			srHTTP.Halt(ctx, errs.BadRequestf("macro #%v: %v", ix, err))
+		}
+	}
+
+	err := macro.Save(ctx, client, sess.GameID, sess.PlayerID, true, imported...)
+	if errors.Is(err, errs.ErrBadRequest) {
+		srHTTP.Halt(ctx, err)
+	}
+	srHTTP.HaltInternal(ctx, err)
+	srHTTP.MustWriteBodyJSON(ctx, response, imported)
+
+	log.Event(ctx, "Macros imported",
+		attr.Int("sr.macros", len(imported)),
+	)
+	srHTTP.LogSuccessf(ctx, "Imported %v macros", len(imported))
+}
+
+// $ POST /roll-macro id
+var _ = srHTTP.HandleScoped(gameRouter, "POST /roll-macro", apitoken.ScopeRoll, handleRollMacro)
+
+func handleRollMacro(args *srHTTP.Args) {
+	ctx, _, request, client, sess := args.MustSession()
+
+	var macroRequest macroIDRequest
+	srHTTP.MustReadBodyJSON(request, &macroRequest)
+
+	rolled, err := macro.GetByID(ctx, client, sess.GameID, sess.PlayerID, macroRequest.ID)
+	if errors.Is(err, errs.ErrNotFound) {
+		srHTTP.Halt(ctx, err)
+	}
+	srHTTP.HaltInternal(ctx, err)
+
+	title := rolled.Title
+	if title == "" {
+		title = rolled.Name
+	}
+	rollRequest := rollRequest{
+		Count:   rolled.Pool,
+		Title:   title,
+		Share:   rolled.Share,
+		Edge:    rolled.Edge,
+		Glitchy: rolled.Glitchy,
+		macro:   rolled.Name,
+	}
+	log.Event(ctx, "Macro rolled",
+		attr.String("sr.macro.id", string(rolled.ID)),
+	)
+	postRoll(ctx, request, client, sess, &rollRequest, rolled.Breakdown())
+}
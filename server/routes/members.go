@@ -0,0 +1,85 @@
+package routes
+
+import (
+	"errors"
+
+	"sr/errs"
+	"sr/game"
+	srHTTP "sr/http"
+	"sr/id"
+	"sr/log"
+	"sr/session"
+
+	attr "go.opentelemetry.io/otel/attribute"
+)
+
+type memberRequest struct {
+	Player id.UID `json:"player"`
+}
+
+// $ POST /gm/promote player
+var _ = srHTTP.Handle(gameRouter, "POST /gm/promote", handlePromoteGM)
+
+func handlePromoteGM(args *srHTTP.Args) {
+	handleSetGM(args, true)
+}
+
+// $ POST /gm/demote player
+var _ = srHTTP.Handle(gameRouter, "POST /gm/demote", handleDemoteGM)
+
+func handleDemoteGM(args *srHTTP.Args) {
+	handleSetGM(args, false)
+}
+
+func handleSetGM(args *srHTTP.Args, isGM bool) {
+	ctx, response, request, client, sess := args.MustSession()
+
+	var gmRequest memberRequest
+	srHTTP.MustReadBodyJSON(request, &gmRequest)
+	mustNotUseToken(ctx, sess, "change GMs")
+	mustBeGM(ctx, client, sess, "change GMs")
+
+	gms, err := game.SetGM(ctx, client, sess.GameID, gmRequest.Player, isGM)
+	if errors.Is(err, errs.ErrNotFound) || errors.Is(err, errs.ErrBadRequest) {
+		srHTTP.Halt(ctx, errs.BadRequest(err))
+	}
+	srHTTP.HaltInternal(ctx, err)
+	srHTTP.MustWriteBodyJSON(ctx, response, gms)
+
+	log.Event(ctx, "GM role changed",
+		attr.String("sr.gm.playerID", gmRequest.Player.String()),
+		attr.Bool("sr.gm.isGM", isGM),
+	)
+	srHTTP.LogSuccessf(ctx, "%v GM: %v", gmRequest.Player, isGM)
+}
+
+// $ POST /player/kick player
+var _ = srHTTP.Handle(gameRouter, "POST /player/kick", handleKickPlayer)
+
+func handleKickPlayer(args *srHTTP.Args) {
+	ctx, _, request, client, sess := args.MustSession()
+
+	var kickRequest memberRequest
+	srHTTP.MustReadBodyJSON(request, &kickRequest)
+	if kickRequest.Player == sess.PlayerID {
+		srHTTP.Halt(ctx, errs.BadRequestf("You may not kick yourself"))
+	}
+	mustNotUseToken(ctx, sess, "kick players")
+	mustBeGM(ctx, client, sess, "kick players")
+
+	err := game.KickPlayer(ctx, client, sess.GameID, kickRequest.Player)
+	if errors.Is(err, errs.ErrNotFound) || errors.Is(err, errs.ErrBadRequest) {
+		srHTTP.Halt(ctx, errs.BadRequest(err))
+	}
+	srHTTP.HaltInternal(ctx, err)
+
+	// Live subscriptions end when they see the player removal.
+	removed, err := session.RemoveAllFor(ctx, client, sess.GameID, kickRequest.Player)
+	srHTTP.HaltInternal(ctx, err)
+
+	log.Event(ctx, "Player kicked",
+		attr.String("sr.kick.playerID", kickRequest.Player.String()),
+		attr.Int("sr.kick.sessions", removed),
+	)
+	srHTTP.LogSuccessf(ctx, "Kicked %v, removed %v sessions", kickRequest.Player, removed)
+}
@@ -0,0 +1,102 @@
+package routes
+
+import (
+	"context"
+	"strings"
+	"unicode/utf8"
+
+	"sr/config"
+	"sr/errs"
+	"sr/event"
+	"sr/game"
+	srHTTP "sr/http"
+	"sr/id"
+	"sr/log"
+	"sr/player"
+	"sr/session"
+
+	"github.com/go-redis/redis/v8"
+	attr "go.opentelemetry.io/otel/attribute"
+)
+
+// mustCheckMessageText halts unless the text is a valid message, returning it trimmed.
+func mustCheckMessageText(ctx context.Context, text string) string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		srHTTP.Halt(ctx, errs.BadRequestf("text: expected a message"))
+	}
+	if utf8.RuneCountInString(text) > config.MaxMessageLength {
+		srHTTP.Halt(ctx, errs.BadRequestf("text: message too long"))
+	}
+	return text
+}
+
+// mustResolveMentions finds the other players mentioned in a message who are
+// able to see it.
+func mustResolveMentions(
+	ctx context.Context, client *redis.Client, sess *session.Session, message *event.Message,
+) []id.UID {
+	usernames := event.ParseMentions(message.Text)
+	if len(usernames) == 0 {
+		return nil
+	}
+	players, err := game.GetPlayers(ctx, client, sess.GameID)
+	srHTTP.HaltInternal(ctx, err)
+	gms, err := game.GetGMs(ctx, client, sess.GameID)
+	srHTTP.HaltInternal(ctx, err)
+
+	var mentions []id.UID
+	for _, username := range usernames {
+		for ix := range players {
+			plr := &players[ix]
+			if plr.ID == sess.PlayerID || strings.ToLower(plr.Username) != username {
+				continue
+			}
+			if game.PlayerCanSeeEvent(plr, game.IsGM(gms, plr.ID), message) {
+				mentions = append(mentions, plr.ID)
+			}
+		}
+	}
+	return mentions
+}
+
+type messageRequest struct {
+	Text  string   `json:"text"`
+	Share int      `json:"share"`
+	To    []id.UID `json:"to"`
+}
+
+// $ POST /message text share [to]
+var _ = srHTTP.Handle(gameRouter, "POST /message", handleMessage)
+
+func handleMessage(args *srHTTP.Args) {
+	ctx, _, request, client, sess := args.MustSession()
+
+	var messageRequest messageRequest
+	srHTTP.MustReadBodyJSON(request, &messageRequest)
+
+	text := mustCheckMessageText(ctx, messageRequest.Text)
+	if !event.IsShare(messageRequest.Share) {
+		srHTTP.Halt(ctx, errs.BadRequestf("share: invalid"))
+	}
+	share := event.Share(messageRequest.Share)
+	mustCheckRecipients(ctx, client, sess, sess.PlayerID, share, messageRequest.To)
+
+	plr, err := player.GetByID(ctx, client, string(sess.PlayerID))
+	srHTTP.HaltInternal(ctx, err)
+
+	message := event.ForMessage(plr, share, text)
+	message.SetRecipients(messageRequest.To)
+	message.Mentions = mustResolveMentions(ctx, client, sess, &message)
+
+	err = game.PostMessage(ctx, client, sess.GameID, &message)
+	srHTTP.HaltInternal(ctx, err)
+
+	log.Event(ctx, "Message sent",
+		attr.Int64("sr.event.id", message.GetID()),
+		attr.String("sr.event.share", share.String()),
+		attr.Int("sr.message.length", utf8.RuneCountInString(text)),
+		attr.Int("sr.message.mentions", len(message.Mentions)),
+	)
+	srHTTP.LogSuccessf(ctx, "Message %v posted", message.GetID())
+}
@@ -0,0 +1,264 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	netHTTP "net/http"
+	"net/url"
+	"time"
+
+	"sr/auth"
+	"sr/config"
+	"sr/errs"
+	srHTTP "sr/http"
+	"sr/id"
+	"sr/log"
+	"sr/oidc"
+	"sr/player"
+	"sr/session"
+
+	attr "go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/semconv/v1.4.0"
+)
+
+var oidcRouter = authRouter.PathPrefix("/oidc").Subrouter()
+
+// oidcClient is the relying party of the configured OpenID Connect provider,
+// or nil if none is configured.
+var oidcClient = makeOIDCClient()
+
+// oidcPath is the path of oidcRouter as seen by browsers.
+func oidcPath() string {
+	if config.HostFrontend == "subroute" {
+		return "/api/auth/oidc"
+	}
+	return "/auth/oidc"
+}
+
+func makeOIDCClient() *oidc.Client {
+	if config.OIDCIssuer == "" {
+		return nil
+	}
+	origin := config.BackendOrigin
+	return oidc.NewClient(
+		config.OIDCIssuer, config.OIDCClientID, config.OIDCClientSecret,
+		origin.Scheme+"://"+origin.Host+oidcPath()+"/callback",
+	)
+}
+
+// browserCookie holds the secret of the browser which started a login, so
+// that a login cannot be finished in another browser.
+const browserCookie = "sr-oidc-browser"
+
+// mustBindBrowser ties the state to the requesting browser by setting its
+// browserCookie.
+func mustBindBrowser(ctx context.Context, response srHTTP.Response, state *oidc.State) {
+	secret, err := oidc.MakeBrowserSecret()
+	srHTTP.HaltInternal(ctx, err)
+	state.BindBrowser(secret)
+	netHTTP.SetCookie(response, &netHTTP.Cookie{
+		Name:     browserCookie,
+		Value:    secret,
+		Path:     oidcPath(),
+		MaxAge:   int(oidc.StateTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   config.BackendOrigin.Scheme == "https",
+		SameSite: netHTTP.SameSiteLaxMode,
+	})
+}
+
+// takeBrowserSecret reads and clears the requesting browser's browserCookie.
+func takeBrowserSecret(response srHTTP.Response, request srHTTP.Request) string {
+	cookie, err := request.Cookie(browserCookie)
+	if err != nil {
+		return ""
+	}
+	netHTTP.SetCookie(response, &netHTTP.Cookie{
+		Name:     browserCookie,
+		Path:     oidcPath(),
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   config.BackendOrigin.Scheme == "https",
+		SameSite: netHTTP.SameSiteLaxMode,
+	})
+	return cookie.Value
+}
+
+func mustOIDCClient(ctx context.Context) *oidc.Client {
+	if oidcClient == nil {
+		srHTTP.Halt(ctx, errs.NotFoundf("OpenID Connect login is not set up"))
+	}
+	return oidcClient
+}
+
+// redirectToFrontend sends the player back to the frontend from the provider,
+// with the result of the login in the URL fragment so it is not sent on to
+// any server. The frontend exchanges a successful login's code for its session
+// with /auth/oidc/session, and finishes logging in with /auth/reauth.
+func redirectToFrontend(response srHTTP.Response, request srHTTP.Request, result url.Values) {
+	origin := config.FrontendOrigin
+	target := origin.Scheme + "://" + origin.Host + "/#" + result.Encode()
+	netHTTP.Redirect(response, request, target, netHTTP.StatusFound)
+}
+
+// redirectLoginFailed sends the player back to the frontend after a login
+// which did not succeed.
+func redirectLoginFailed(ctx context.Context, response srHTTP.Response, request srHTTP.Request, err error) {
+	log.Printf(ctx, "OIDC login result: %v", err)
+	message := "Unable to log in"
+	if errors.Is(err, errs.ErrNotFound) {
+		message = "Your login expired or the game was not found"
+	} else if errors.Is(err, errs.ErrBadRequest) {
+		message = "Your account is linked to another player"
+	}
+	redirectToFrontend(response, request, url.Values{"oidc-error": {message}})
+	srHTTP.LogSuccessf(ctx, "Login failed")
+}
+
+// GET /auth/oidc/login?game=gameID&persist=true -> redirect to the provider
+var _ = srHTTP.Handle(oidcRouter, "GET /login", handleOIDCLogin)
+
+func handleOIDCLogin(args *srHTTP.Args) {
+	ctx, response, request, client, _ := args.Get()
+	provider := mustOIDCClient(ctx)
+	query := request.URL.Query()
+
+	state, err := oidc.MakeState(query.Get("game"), query.Get("persist") == "true", "")
+	srHTTP.HaltInternal(ctx, err)
+	mustBindBrowser(ctx, response, &state)
+	err = oidc.CreateState(ctx, client, &state)
+	srHTTP.HaltInternal(ctx, err)
+	authURL, err := provider.AuthURL(ctx, &state)
+	srHTTP.HaltInternal(ctx, err)
+
+	netHTTP.Redirect(response, request, authURL, netHTTP.StatusFound)
+	srHTTP.LogSuccessf(ctx, "Redirected to provider with state %v", state.ID)
+}
+
+type oidcLinkResponse struct {
+	URL string `json:"url"`
+}
+
+// POST /auth/oidc/link -> { url of the provider }
+var _ = srHTTP.Handle(oidcRouter, "POST /link", handleOIDCLink)
+
+func handleOIDCLink(args *srHTTP.Args) {
+	ctx, response, _, client, sess := args.MustPlayerSession()
+	provider := mustOIDCClient(ctx)
+
+	plr, err := player.GetByID(ctx, client, string(sess.PlayerID))
+	srHTTP.HaltInternal(ctx, err)
+	mustManageCredentials(ctx, client, sess, plr)
+
+	state, err := oidc.MakeState("", sess.Persist, plr.ID)
+	srHTTP.HaltInternal(ctx, err)
+	mustBindBrowser(ctx, response, &state)
+	err = oidc.CreateState(ctx, client, &state)
+	srHTTP.HaltInternal(ctx, err)
+	authURL, err := provider.AuthURL(ctx, &state)
+	srHTTP.HaltInternal(ctx, err)
+
+	srHTTP.MustWriteBodyJSON(ctx, response, oidcLinkResponse{URL: authURL})
+	srHTTP.LogSuccessf(ctx, "Linking %v with state %v", plr.ID, state.ID)
+}
+
+// GET /auth/oidc/callback?state&code -> redirect to the frontend
+var _ = srHTTP.Handle(oidcRouter, "GET /callback", handleOIDCCallback)
+
+func handleOIDCCallback(args *srHTTP.Args) {
+	ctx, response, request, client, _ := args.Get()
+	provider := mustOIDCClient(ctx)
+	query := request.URL.Query()
+
+	state, err := oidc.TakeState(ctx, client, id.UID(query.Get("state")))
+	if errors.Is(err, errs.ErrNotFound) {
+		redirectLoginFailed(ctx, response, request, err)
+		return
+	}
+	srHTTP.HaltInternal(ctx, err)
+	if !state.FromBrowser(takeBrowserSecret(response, request)) {
+		redirectLoginFailed(ctx, response, request, errs.NoAccessf("login state %v is from another browser", state.ID))
+		return
+	}
+	if providerError := query.Get("error"); providerError != "" {
+		redirectLoginFailed(ctx, response, request, errs.NoAccessf("provider: %v", providerError))
+		return
+	}
+
+	idToken, err := provider.Exchange(ctx, query.Get("code"), state.Verifier)
+	if errors.Is(err, errs.ErrNoAccess) {
+		redirectLoginFailed(ctx, response, request, err)
+		return
+	}
+	srHTTP.HaltInternal(ctx, err)
+	claims, err := provider.VerifyIDToken(ctx, idToken, state.Nonce, time.Now())
+	if errors.Is(err, errs.ErrNoAccess) {
+		redirectLoginFailed(ctx, response, request, err)
+		return
+	}
+	srHTTP.HaltInternal(ctx, err)
+
+	gameInfo, plr, err := auth.LogOIDCIn(ctx, client, claims, state)
+	if errors.Is(err, errs.ErrNoAccess) ||
+		errors.Is(err, errs.ErrNotFound) ||
+		errors.Is(err, errs.ErrBadRequest) {
+		redirectLoginFailed(ctx, response, request, err)
+		return
+	}
+	srHTTP.HaltInternal(ctx, err)
+
+	sess := session.New(plr, state.GameID, state.Persist)
+	if gameInfo != nil {
+		_, sess.Spectator = gameInfo.Spectators[string(plr.ID)]
+	}
+	sess.Verified = true
+	sess.UserAgent = request.UserAgent()
+	err = session.Create(ctx, client, sess)
+	srHTTP.HaltInternal(ctx, err)
+	if state.PlayerID != "" {
+		mustRevokeOtherSessions(ctx, client, sess)
+	}
+
+	code, err := oidc.CreateCode(ctx, client, sess.ID)
+	srHTTP.HaltInternal(ctx, err)
+
+	redirectToFrontend(response, request, url.Values{"oidc-code": {string(code)}})
+
+	log.Event(ctx, "Player login",
+		semconv.EnduserIDKey.String(sess.PlayerID.String()),
+		attr.String("sr.login.sessionID", sess.ID.String()),
+		attr.String("sr.login.sessionType", sess.Type()),
+		attr.Bool("sr.login.oidc", true),
+		attr.Bool("sr.login.oidcLink", state.PlayerID != ""),
+	)
+	srHTTP.LogSuccessf(ctx, "%v %v for %v in %v with OIDC",
+		sess.Type(), sess.ID,
+		sess.PlayerID, state.GameID,
+	)
+}
+
+type oidcSessionRequest struct {
+	Code string `json:"code"`
+}
+
+type oidcSessionResponse struct {
+	Session string `json:"session"`
+}
+
+// POST /auth/oidc/session { code } -> { session }
+var _ = srHTTP.Handle(oidcRouter, "POST /session", handleOIDCSession)
+
+func handleOIDCSession(args *srHTTP.Args) {
+	ctx, response, request, client, _ := args.Get()
+	var sessionRequest oidcSessionRequest
+	srHTTP.MustReadBodyJSON(request, &sessionRequest)
+
+	sessionID, err := oidc.TakeCode(ctx, client, id.UID(sessionRequest.Code))
+	if errors.Is(err, errs.ErrNotFound) {
+		srHTTP.Halt(ctx, errs.NoAccess(err))
+	}
+	srHTTP.HaltInternal(ctx, err)
+
+	srHTTP.MustWriteBodyJSON(ctx, response, oidcSessionResponse{Session: string(sessionID)})
+	srHTTP.LogSuccessf(ctx, "Exchanged login code for %v", sessionID)
+}
@@ -0,0 +1,290 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/url"
+	"strconv"
+	"time"
+
+	"sr/config"
+	"sr/errs"
+	"sr/game"
+	srHTTP "sr/http"
+	"sr/id"
+	"sr/log"
+	"sr/overlay"
+	"sr/player"
+	"sr/shutdown"
+	"sr/taskCtx"
+	"sr/update"
+
+	"github.com/gorilla/mux"
+	attr "go.opentelemetry.io/otel/attribute"
+)
+
+var overlayRouter = RESTRouter.PathPrefix("/overlay").Subrouter()
+
+// $ GET /overlays
+var _ = srHTTP.Handle(gameRouter, "GET /overlays", handleGetOverlays)
+
+func handleGetOverlays(args *srHTTP.Args) {
+	ctx, response, _, client, sess := args.MustSession()
+	mustBeGM(ctx, client, sess, "view overlays")
+
+	tokens, err := overlay.GetAll(ctx, client, sess.GameID)
+	srHTTP.HaltInternal(ctx, err)
+	srHTTP.MustWriteBodyJSON(ctx, response, tokens)
+	srHTTP.LogSuccessf(ctx, "%v overlay tokens", len(tokens))
+}
+
+type createOverlayRequest struct {
+	Title string `json:"title"`
+}
+
+// $ POST /overlay/create title
+var _ = srHTTP.Handle(gameRouter, "POST /overlay/create", handleCreateOverlay)
+
+func handleCreateOverlay(args *srHTTP.Args) {
+	ctx, response, request, client, sess := args.MustSession()
+
+	var overlayRequest createOverlayRequest
+	srHTTP.MustReadBodyJSON(request, &overlayRequest)
+	if !player.ValidName(overlayRequest.Title) {
+		srHTTP.Halt(ctx, errs.BadRequestf("title: invalid"))
+	}
+	mustNotUseToken(ctx, sess, "create overlays")
+	mustBeGM(ctx, client, sess, "create overlays")
+
+	plr, err := player.GetByID(ctx, client, string(sess.PlayerID))
+	srHTTP.HaltInternal(ctx, err)
+
+	token := overlay.Make(sess.GameID, plr, overlayRequest.Title, id.TimestampNow())
+	err = overlay.Create(ctx, client, &token)
+	if errors.Is(err, errs.ErrBadRequest) {
+		srHTTP.Halt(ctx, err)
+	}
+	srHTTP.HaltInternal(ctx, err)
+	srHTTP.MustWriteBodyJSON(ctx, response, &token)
+
+	log.Event(ctx, "Overlay created")
+	srHTTP.LogSuccessf(ctx, "Created %v", token.String())
+}
+
+type revokeOverlayRequest struct {
+	Token id.UID `json:"token"`
+}
+
+// $ POST /overlay/revoke token
+var _ = srHTTP.Handle(gameRouter, "POST /overlay/revoke", handleRevokeOverlay)
+
+func handleRevokeOverlay(args *srHTTP.Args) {
+	ctx, _, request, client, sess := args.MustSession()
+
+	var revokeRequest revokeOverlayRequest
+	srHTTP.MustReadBodyJSON(request, &revokeRequest)
+	mustNotUseToken(ctx, sess, "revoke overlays")
+	mustBeGM(ctx, client, sess, "revoke overlays")
+
+	err := overlay.Revoke(ctx, client, sess.GameID, revokeRequest.Token)
+	if errors.Is(err, errs.ErrNotFound) {
+		srHTTP.Halt(ctx, err)
+	}
+	srHTTP.HaltInternal(ctx, err)
+	srHTTP.LogSuccessf(ctx, "Revoked overlay token")
+}
+
+// mustCheckOverlay halts unless the request has a valid overlay token for the
+// game in its path, and returns the game ID and number of rolls to show.
+func mustCheckOverlay(ctx context.Context, args *srHTTP.Args) (string, int) {
+	gameID := mux.Vars(args.Request)["gameID"]
+	query := args.Request.URL.Query()
+	_, err := overlay.Check(ctx, args.Client, gameID, id.UID(query.Get("token")))
+	if errors.Is(err, errs.ErrNoAccess) {
+		srHTTP.Halt(ctx, err)
+	}
+	srHTTP.HaltInternal(ctx, err)
+
+	rolls := overlay.DefaultRolls
+	if rollsParam := query.Get("rolls"); rollsParam != "" {
+		rolls, err = strconv.Atoi(rollsParam)
+		if err != nil || rolls < 1 || rolls > overlay.MaxRolls {
+			srHTTP.Halt(ctx, errs.BadRequestf("rolls: invalid"))
+		}
+	}
+	return gameID, rolls
+}
+
+var overlayPage = template.Must(template.New("overlay").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Shadowroller overlay</title>
+<style>
+body { background: transparent; color: #fff; font: 20px sans-serif; text-shadow: 0 0 4px #000; }
+ol, ul { list-style: none; padding: 0; }
+.seized { color: #ff6; }
+</style>
+</head>
+<body>
+<ol id="initiative"></ol>
+<ul id="rolls"></ul>
+<script>
+function hits(dice) {
+	return (dice || []).filter(function(d) { return d >= 5; }).length;
+}
+function item(text, className) {
+	var li = document.createElement("li");
+	li.textContent = text;
+	if (className) { li.className = className; }
+	return li;
+}
+function show(feed) {
+	var initiative = document.getElementById("initiative");
+	var rolls = document.getElementById("rolls");
+	initiative.replaceChildren.apply(initiative, feed.initiative.map(function(entry) {
+		return item(entry.total + " " + (entry.title || entry.name), entry.seized ? "seized" : "");
+	}));
+	rolls.replaceChildren.apply(rolls, feed.rolls.map(function(roll) {
+		var dice = roll.rounds ? roll.rounds[roll.rounds.length - 1] : roll.dice;
+		var text = roll.pName + (roll.title ? " - " + roll.title : "");
+		return item(dice ? text + ": " + hits(dice) + " hits" : text);
+	}));
+}
+var stream = new EventSource({{.StreamURL}});
+stream.addEventListener("feed", function(e) { show(JSON.parse(e.data)); });
+</script>
+</body>
+</html>
+`))
+
+// $ GET /overlay/{gameID} token rolls
+var _ = srHTTP.Handle(overlayRouter, "GET /{gameID}", handleOverlayPage)
+
+func handleOverlayPage(args *srHTTP.Args) {
+	ctx, response, request, _, _ := args.Get()
+	gameID, rolls := mustCheckOverlay(ctx, args)
+
+	streamURL := fmt.Sprintf("%v/stream?token=%v&rolls=%v",
+		request.URL.Path, url.QueryEscape(request.URL.Query().Get("token")), rolls,
+	)
+	response.Header().Set("Content-Type", "text/html; charset=utf-8")
+	err := overlayPage.Execute(response, struct{ StreamURL string }{streamURL})
+	srHTTP.HaltInternal(ctx, err)
+	srHTTP.LogSuccessf(ctx, "Overlay page for %v", gameID)
+}
+
+// $ GET /overlay/{gameID}/feed token rolls
+var _ = srHTTP.Handle(overlayRouter, "GET /{gameID}/feed", handleOverlayFeed)
+
+func handleOverlayFeed(args *srHTTP.Args) {
+	ctx, response, _, client, _ := args.Get()
+	gameID, rolls := mustCheckOverlay(ctx, args)
+
+	feed, err := overlay.GetFeed(ctx, client, gameID, rolls)
+	srHTTP.HaltInternal(ctx, err)
+	srHTTP.MustWriteBodyJSON(ctx, response, feed)
+	srHTTP.LogSuccessf(ctx, "%v rolls, %v in initiative", len(feed.Rolls), len(feed.Initiative))
+}
+
+// $ GET /overlay/{gameID}/stream token rolls
+var _ = srHTTP.Handle(overlayRouter, "GET /{gameID}/stream", handleOverlayStream)
+
+func handleOverlayStream(args *srHTTP.Args) {
+	requestCtx, response, request, client, _ := args.Get()
+	gameID, rolls := mustCheckOverlay(requestCtx, args)
+	token := id.UID(request.URL.Query().Get("token"))
+
+	taskName := fmt.Sprintf("request %v overlay", taskCtx.GetName(requestCtx))
+	shutdownCtx, release := shutdown.Register(context.Background(), taskName)
+	defer release()
+
+	// See handleSubscription for why the response is unwrapped.
+	if inner, ok := response.(srHTTP.WrappedResponse); ok {
+		response = inner.Inner()
+	}
+	stream, err := sseUpgrader.Upgrade(response, request)
+	srHTTP.Halt(requestCtx, errs.BadRequest(err))
+	defer func() {
+		if stream.IsOpen() {
+			stream.Close()
+		}
+	}()
+
+	updates, errors, cleanup := game.SubscribeSpectator(requestCtx, client, gameID, "", "")
+	defer cleanup()
+
+	// Sends the feed, returning false if the stream should end.
+	sendFeed := func() bool {
+		if _, err := overlay.Check(requestCtx, client, gameID, token); err != nil {
+			log.Printf(requestCtx, "Overlay token no longer valid: %v", err)
+			return false
+		}
+		feed, err := overlay.GetFeed(requestCtx, client, gameID, rolls)
+		if err != nil {
+			log.Printf(requestCtx, "Error getting overlay feed: %v", err)
+			return false
+		}
+		feedBytes, err := json.Marshal(feed)
+		if err != nil {
+			log.Printf(requestCtx, "Error marshaling overlay feed: %v", err)
+			return false
+		}
+		if err := stream.WriteEvent("feed", feedBytes); err != nil {
+			log.Printf(requestCtx, "Error writing feed to stream: %v", err)
+			return false
+		}
+		return true
+	}
+	if !sendFeed() {
+		return
+	}
+
+	pingTicker := time.NewTicker(time.Duration(config.SSEPingSecs) * time.Second)
+	defer pingTicker.Stop()
+	pollTicker := time.NewTicker(time.Duration(2) * time.Second)
+	defer pollTicker.Stop()
+
+	log.Event(requestCtx, "Overlay stream started", attr.String("sr.overlay.gameID", gameID))
+	defer log.Event(requestCtx, "Overlay stream ended")
+
+	for {
+		if !stream.IsOpen() {
+			log.Printf(requestCtx, "Connection closed by remote host")
+			return
+		}
+		select {
+		case updateMessage := <-updates:
+			_, _, inner, _ := update.ParseExclude(updateMessage.Payload)
+			switch update.ParseType(inner) {
+			case update.TypeEventNew, update.TypeEventMod, update.TypeEventDel:
+				if !sendFeed() {
+					return
+				}
+			}
+		case <-pollTicker.C:
+			continue
+		case <-pingTicker.C:
+			if _, err := overlay.Check(requestCtx, client, gameID, token); err != nil {
+				log.Printf(requestCtx, "Overlay token no longer valid: %v", err)
+				return
+			}
+			if err := pingStream(stream); err != nil {
+				log.Printf(requestCtx, "Unable to write to stream: %v", err)
+				return
+			}
+		case err := <-errors:
+			log.Printf(requestCtx, "<= Error from subscription task: %v", err)
+			return
+		case <-requestCtx.Done():
+			log.Printf(requestCtx, "<= Request cancelled")
+			return
+		case err := <-shutdownCtx.Done():
+			log.Printf(requestCtx, "<= Cancellation due to shutdown: %v", err)
+			return
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package routes
+
+import (
+	"errors"
+
+	"sr/errs"
+	"sr/game"
+	srHTTP "sr/http"
+	"sr/id"
+	"sr/log"
+	"sr/player"
+
+	attr "go.opentelemetry.io/otel/attribute"
+)
+
+type overwatchResponse struct {
+	Overwatch int `json:"overwatch"` // Score known to the player
+	Score     int `json:"score"`     // Full score, including secret increments
+}
+
+// $ GET /character/overwatch id
+var _ = srHTTP.Handle(gameRouter, "GET /character/overwatch", handleGetOverwatch)
+
+func handleGetOverwatch(args *srHTTP.Args) {
+	ctx, response, request, client, sess := args.MustSession()
+
+	mustBeGM(ctx, client, sess, "see secret Overwatch Scores")
+	char := mustGetCharacter(ctx, client, sess, id.UID(request.FormValue("id")))
+	score, err := game.GetOverwatch(ctx, client, sess.GameID, char)
+	srHTTP.HaltInternal(ctx, err)
+
+	srHTTP.MustWriteBodyJSON(ctx, response, overwatchResponse{
+		Overwatch: char.Overwatch,
+		Score:     score,
+	})
+	srHTTP.LogSuccessf(ctx, "Overwatch of %v is %v", char, score)
+}
+
+type addOverwatchRequest struct {
+	Character id.UID `json:"character"`
+	Amount    int    `json:"amount"`
+	Secret    bool   `json:"secret"`
+}
+
+// $ POST /character/overwatch character amount secret
+var _ = srHTTP.Handle(gameRouter, "POST /character/overwatch", handleAddOverwatch)
+
+func handleAddOverwatch(args *srHTTP.Args) {
+	ctx, response, request, client, sess := args.MustSession()
+
+	var overwatchRequest addOverwatchRequest
+	srHTTP.MustReadBodyJSON(request, &overwatchRequest)
+	if overwatchRequest.Amount == 0 || overwatchRequest.Amount > 100 || overwatchRequest.Amount < -100 {
+		srHTTP.Halt(ctx, errs.BadRequestf("amount: invalid"))
+	}
+	mustBeGM(ctx, client, sess, "add Overwatch Score")
+	char := mustGetCharacter(ctx, client, sess, overwatchRequest.Character)
+
+	plr, err := player.GetByID(ctx, client, string(sess.PlayerID))
+	srHTTP.HaltInternal(ctx, err)
+
+	char, score, err := game.AddOverwatch(
+		ctx, client, sess.GameID, char.ID, plr, overwatchRequest.Amount, overwatchRequest.Secret, 0,
+	)
+	if errors.Is(err, errs.ErrNotFound) {
+		srHTTP.Halt(ctx, err)
+	}
+	srHTTP.HaltInternal(ctx, err)
+	srHTTP.MustWriteBodyJSON(ctx, response, overwatchResponse{
+		Overwatch: char.Overwatch,
+		Score:     score,
+	})
+
+	log.Event(ctx, "Overwatch added",
+		attr.String("sr.character.id", string(char.ID)),
+		attr.Int("sr.overwatch.added", overwatchRequest.Amount),
+		attr.Bool("sr.overwatch.secret", overwatchRequest.Secret),
+		attr.Int("sr.overwatch.score", score),
+	)
+	srHTTP.LogSuccessf(ctx, "Overwatch of %v is now %v", char, score)
+}
+
+type resetOverwatchRequest struct {
+	Character id.UID `json:"character"`
+}
+
+// $ POST /character/overwatch/reset character
+var _ = srHTTP.Handle(gameRouter, "POST /character/overwatch/reset", handleResetOverwatch)
+
+func handleResetOverwatch(args *srHTTP.Args) {
+	ctx, _, request, client, sess := args.MustSession()
+
+	var resetRequest resetOverwatchRequest
+	srHTTP.MustReadBodyJSON(request, &resetRequest)
+	mustBeGM(ctx, client, sess, "reset Overwatch Score")
+	char := mustGetCharacter(ctx, client, sess, resetRequest.Character)
+
+	char, err := game.ResetOverwatch(ctx, client, sess.GameID, char.ID)
+	if errors.Is(err, errs.ErrNotFound) {
+		srHTTP.Halt(ctx, err)
+	}
+	srHTTP.HaltInternal(ctx, err)
+
+	log.Event(ctx, "Overwatch reset",
+		attr.String("sr.character.id", string(char.ID)),
+	)
+	srHTTP.LogSuccessf(ctx, "Reset Overwatch of %v", char)
+}
@@ -22,7 +22,7 @@ type playerUpdateRequest struct {
 var _ = srHTTP.Handle(playerRouter, "POST /update", handleUpdatePlayer)
 
 func handleUpdatePlayer(args *srHTTP.Args) {
-	ctx, response, request, client, sess := args.MustSession()
+	ctx, response, request, client, sess := args.MustPlayerSession()
 
 	var updateRequest playerUpdateRequest
 	srHTTP.MustReadBodyJSON(request, &updateRequest)
@@ -92,7 +92,17 @@ func handleUpdatePlayer(args *srHTTP.Args) {
 		return // Idempotent return
 	}
 
-	err := game.UpdatePlayer(ctx, client, sess.GameID, sess.PlayerID, externalUpdate, internalUpdate)
+	games, err := game.GetPlayerGames(ctx, client, sess.PlayerID)
+	srHTTP.HaltInternal(ctx, err)
+	gameIDs := make([]string, 0, len(games)+1)
+	for _, membership := range games {
+		gameIDs = append(gameIDs, membership.GameID)
+	}
+	if sess.GameID != "" && !stringArrayContains(gameIDs, sess.GameID) {
+		gameIDs = append(gameIDs, sess.GameID)
+	}
+
+	err = game.UpdatePlayer(ctx, client, gameIDs, sess.PlayerID, externalUpdate, internalUpdate)
 	srHTTP.HaltInternal(ctx, err)
 	srHTTP.MustWriteBodyJSON(ctx, response, internalDiff)
 
@@ -103,3 +113,15 @@ func handleUpdatePlayer(args *srHTTP.Args) {
 
 	srHTTP.LogSuccessf(ctx, "Player %v update %v", sess.PlayerID, internalDiff)
 }
+
+// $ GET /games
+var _ = srHTTP.Handle(playerRouter, "GET /games", handleGetPlayerGames)
+
+func handleGetPlayerGames(args *srHTTP.Args) {
+	ctx, response, _, client, sess := args.MustPlayerSession()
+
+	games, err := game.GetPlayerGames(ctx, client, sess.PlayerID)
+	srHTTP.HaltInternal(ctx, err)
+	srHTTP.MustWriteBodyJSON(ctx, response, games)
+	srHTTP.LogSuccessf(ctx, "%v in %v games", sess.PlayerID, len(games))
+}
@@ -0,0 +1,49 @@
+package routes
+
+import (
+	"sr/config"
+	srHTTP "sr/http"
+	"sr/ratelimit"
+)
+
+// apiPathPrefix is the prefix of API routes, removed to find their rate limits.
+func apiPathPrefix() string {
+	if config.HostFrontend == "subroute" {
+		return "/api"
+	}
+	return ""
+}
+
+// loginLimit is shared by routes which check credentials, to slow down
+// guessing passwords.
+var loginLimit = ratelimit.Limit{Bucket: "login", Budget: 10, Refill: 6, Cost: 1}
+
+// rollLimit is shared by routes which roll dice, with the given cost per
+// request, so routes rolling for several characters at once cost more.
+func rollLimit(cost int) ratelimit.Limit {
+	return ratelimit.Limit{Bucket: "roll", Budget: 30, Refill: 30, Cost: cost}
+}
+
+// frontendLimit is shared by the frontend's pages and assets, so that loading
+// them does not use up the API's budget.
+var frontendLimit = ratelimit.Limit{Bucket: "frontend", Budget: 300, Refill: 300, Cost: 1}
+
+// rateLimits are the rate limits of API routes which do not use the default one.
+var rateLimits = srHTTP.RateLimits{
+	// Subscriptions are long-lived, and reconnect on their own.
+	"GET /game/subscription":       {Exempt: true},
+	"GET /overlay/{gameID}/stream": {Exempt: true},
+
+	"POST /auth/login":                loginLimit,
+	"POST /auth/join":                 loginLimit,
+	"POST /auth/password":             loginLimit,
+	"POST /auth/passkey/login/begin":  loginLimit,
+	"POST /auth/passkey/login/finish": loginLimit,
+	"GET /auth/oidc/login":            loginLimit,
+
+	"POST /game/roll":            rollLimit(2),
+	"POST /game/reroll":          rollLimit(2),
+	"POST /game/roll-macro":      rollLimit(2),
+	"POST /game/roll-initiative": rollLimit(2),
+	"POST /game/roll-group":      rollLimit(6),
+}
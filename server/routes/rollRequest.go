@@ -0,0 +1,87 @@
+package routes
+
+import (
+	"sr/config"
+	"sr/errs"
+	"sr/event"
+	"sr/game"
+	srHTTP "sr/http"
+	"sr/id"
+	"sr/log"
+	"sr/player"
+
+	attr "go.opentelemetry.io/otel/attribute"
+)
+
+type requestRollRequest struct {
+	Title     string   `json:"title"`
+	Targets   []id.UID `json:"targets"`
+	Threshold int      `json:"threshold"`
+	GMsOnly   bool     `json:"gmsOnly"`
+}
+
+// $ POST /request-roll title [targets] threshold gmsOnly
+var _ = srHTTP.Handle(gameRouter, "POST /request-roll", handleRequestRoll)
+
+func handleRequestRoll(args *srHTTP.Args) {
+	ctx, response, request, client, sess := args.MustSession()
+
+	var rollRequest requestRollRequest
+	srHTTP.MustReadBodyJSON(request, &rollRequest)
+
+	if rollRequest.Title == "" {
+		srHTTP.Halt(ctx, errs.BadRequestf("title: expected a title"))
+	}
+	if rollRequest.Threshold < 0 || rollRequest.Threshold > config.MaxSingleRoll {
+		srHTTP.Halt(ctx, errs.BadRequestf("threshold: invalid"))
+	}
+
+	info, err := game.GetInfo(ctx, client, sess.GameID)
+	srHTTP.HaltInternal(ctx, err)
+	if !game.IsGMOf(info, sess.PlayerID) {
+		srHTTP.Halt(ctx, errs.NoAccessf("Only GMs may request rolls"))
+	}
+
+	targets := rollRequest.Targets
+	if len(targets) == 0 {
+		// Ask everyone who isn't a GM
+		for playerID := range info.Players {
+			if !game.IsGMOf(info, id.UID(playerID)) {
+				targets = append(targets, id.UID(playerID))
+			}
+		}
+		if len(targets) == 0 {
+			srHTTP.Halt(ctx, errs.BadRequestf("targets: there are no players to ask"))
+		}
+	}
+	seen := make(map[id.UID]bool, len(targets))
+	for _, target := range targets {
+		if _, found := info.Players[string(target)]; !found {
+			srHTTP.Halt(ctx, errs.BadRequestf("targets: %v is not in this game", target))
+		}
+		if seen[target] {
+			srHTTP.Halt(ctx, errs.BadRequestf("targets: %v listed twice", target))
+		}
+		seen[target] = true
+	}
+
+	plr, err := player.GetByID(ctx, client, string(sess.PlayerID))
+	srHTTP.HaltInternal(ctx, err)
+
+	evt := event.ForRollRequest(
+		plr, rollRequest.Title, targets, rollRequest.Threshold, rollRequest.GMsOnly,
+	)
+	err = game.PostRollRequest(ctx, client, sess.GameID, &evt)
+	srHTTP.HaltInternal(ctx, err)
+	srHTTP.MustWriteBodyJSON(ctx, response, evt.GetID())
+
+	log.Event(ctx, "Roll requested",
+		attr.Int64("sr.event.id", evt.GetID()),
+		attr.Int("sr.request.targets", len(targets)),
+		attr.Int("sr.request.threshold", rollRequest.Threshold),
+		attr.Bool("sr.request.gmsOnly", rollRequest.GMsOnly),
+	)
+	srHTTP.LogSuccessf(ctx, "Roll request %v posted for %v players",
+		evt.GetID(), len(targets),
+	)
+}
@@ -22,7 +22,6 @@ func makeBaseRouter() *mux.Router {
 		srHTTP.RequestContextMiddleware,
 		srHTTP.RecoveryMiddleware,
 		srHTTP.HaltMiddleware,
-		srHTTP.RateLimitedMiddleware,
 		srHTTP.OtelMiddleware,
 		//srHTTP.requestShutdownMiddleware,
 		srHTTP.UniversalHeadersMiddleware,
@@ -35,7 +34,10 @@ func makeBaseRouter() *mux.Router {
 
 func makeAPIRouter() *mux.Router {
 	router := mux.NewRouter()
-	router.Use(mux.MiddlewareFunc(srHTTP.RESTHeadersMiddleware))
+	router.Use(
+		mux.MiddlewareFunc(srHTTP.RESTHeadersMiddleware),
+		srHTTP.RateLimitMiddleware(rateLimits, srHTTP.DefaultRateLimit(), apiPathPrefix()),
+	)
 	// This is a requirement for use of PathPrefix, it's pretty annoying
 	if config.HostFrontend == "subroute" {
 		router = router.PathPrefix("/api").Subrouter()
@@ -45,7 +47,10 @@ func makeAPIRouter() *mux.Router {
 
 func makeFrontendRouter() *mux.Router {
 	router := mux.NewRouter()
-	router.Use(mux.MiddlewareFunc(srHTTP.FrontendHeadersMiddleware))
+	router.Use(
+		mux.MiddlewareFunc(srHTTP.FrontendHeadersMiddleware),
+		srHTTP.RateLimitMiddleware(nil, frontendLimit, ""),
+	)
 	router.PathPrefix("/static").HandlerFunc(handleFrontendStatic).Methods("GET")
 	router.NewRoute().Name("/").HandlerFunc(handleFrontendBase).Methods("GET")
 	return router
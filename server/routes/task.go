@@ -108,20 +108,11 @@ func handleDeleteGame(response srHTTP.Response, request srHTTP.Request, client *
 		srHTTP.Halt(ctx, errs.BadRequestf("Invalid game ID"))
 	}
 
-	if exists, err := game.Exists(ctx, client, gameID); !exists || err != nil {
-		srHTTP.HaltInternal(ctx, err) // Halt for redis error first
+	err := game.Delete(ctx, client, gameID)
+	if errors.Is(err, errs.ErrNotFound) {
 		srHTTP.Halt(ctx, errs.BadRequestf("Game does not exist"))
 	}
-
-	results, err := client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
-		pipe.Del(ctx, "game:"+gameID)
-		pipe.Del(ctx, "players:"+gameID)
-		return nil
-	})
 	srHTTP.HaltInternal(ctx, err)
-	if len(results) != 2 {
-		srHTTP.Halt(ctx, errs.Internalf("expected 2 keys deleted, got %v", results))
-	}
 	srHTTP.LogSuccessf(ctx, "Game %v deleted", gameID)
 }
 
@@ -0,0 +1,173 @@
+package routes
+
+import (
+	"errors"
+
+	"sr/errs"
+	"sr/game"
+	srHTTP "sr/http"
+	"sr/id"
+	"sr/log"
+	"sr/player"
+	"sr/timer"
+
+	attr "go.opentelemetry.io/otel/attribute"
+)
+
+// $ GET /timers
+var _ = srHTTP.Handle(gameRouter, "GET /timers", handleGetTimers)
+
+func handleGetTimers(args *srHTTP.Args) {
+	ctx, response, _, client, sess := args.MustSession()
+
+	timers, err := timer.GetAll(ctx, client, sess.GameID)
+	srHTTP.HaltInternal(ctx, err)
+	srHTTP.MustWriteBodyJSON(ctx, response, timers)
+	srHTTP.LogSuccessf(ctx, "%v timers", len(timers))
+}
+
+type createTimerRequest struct {
+	Title    string `json:"title"`
+	Kind     string `json:"kind"`
+	Duration int64  `json:"duration"` // Milliseconds or turns
+}
+
+// $ POST /timer/create title kind duration
+var _ = srHTTP.Handle(gameRouter, "POST /timer/create", handleCreateTimer)
+
+func handleCreateTimer(args *srHTTP.Args) {
+	ctx, response, request, client, sess := args.MustSession()
+
+	var timerRequest createTimerRequest
+	srHTTP.MustReadBodyJSON(request, &timerRequest)
+	if timerRequest.Duration < 1 {
+		srHTTP.Halt(ctx, errs.BadRequestf("duration: invalid"))
+	}
+	mustBeGM(ctx, client, sess, "start timers")
+
+	plr, err := player.GetByID(ctx, client, string(sess.PlayerID))
+	srHTTP.HaltInternal(ctx, err)
+
+	created := timer.Make(plr, timerRequest.Title, timerRequest.Kind, timerRequest.Duration, id.TimestampNow())
+	srHTTP.Halt(ctx, created.Validate())
+
+	err = game.CreateTimer(ctx, client, sess.GameID, &created)
+	if errors.Is(err, errs.ErrBadRequest) {
+		srHTTP.Halt(ctx, err)
+	}
+	srHTTP.HaltInternal(ctx, err)
+	srHTTP.MustWriteBodyJSON(ctx, response, &created)
+
+	log.Event(ctx, "Timer started",
+		attr.String("sr.timer.id", string(created.ID)),
+		attr.String("sr.timer.kind", created.Kind),
+		attr.Int64("sr.timer.duration", created.Remaining),
+	)
+	srHTTP.LogSuccessf(ctx, "Started timer %v", &created)
+}
+
+type modifyTimerRequest struct {
+	ID     id.UID `json:"id"`
+	Action string `json:"action"` // pause, resume, or adjust
+	Delta  int64  `json:"delta"`  // Milliseconds or turns to adjust by
+}
+
+// $ POST /timer/modify id action delta
+var _ = srHTTP.Handle(gameRouter, "POST /timer/modify", handleModifyTimer)
+
+func handleModifyTimer(args *srHTTP.Args) {
+	ctx, response, request, client, sess := args.MustSession()
+
+	var timerRequest modifyTimerRequest
+	srHTTP.MustReadBodyJSON(request, &timerRequest)
+	mustBeGM(ctx, client, sess, "change timers")
+
+	var modify func(t *timer.Timer, now int64) error
+	switch timerRequest.Action {
+	case "pause":
+		modify = func(t *timer.Timer, now int64) error { return t.Pause(now) }
+	case "resume":
+		modify = func(t *timer.Timer, now int64) error { return t.Resume(now) }
+	case "adjust":
+		if timerRequest.Delta == 0 {
+			srHTTP.Halt(ctx, errs.BadRequestf("delta: expected a change"))
+		}
+		// Larger changes could overflow the remaining time
+		if timerRequest.Delta > timer.MaxRealTime || timerRequest.Delta < -timer.MaxRealTime {
+			srHTTP.Halt(ctx, errs.BadRequestf("delta: cannot be longer than a week"))
+		}
+		modify = func(t *timer.Timer, now int64) error {
+			t.Adjust(timerRequest.Delta, now)
+			return t.Validate()
+		}
+	default:
+		srHTTP.Halt(ctx, errs.BadRequestf("action: expected pause, resume, or adjust"))
+	}
+
+	modified, err := game.ModifyTimer(ctx, client, sess.GameID, timerRequest.ID, modify)
+	if errors.Is(err, errs.ErrNotFound) || errors.Is(err, errs.ErrBadRequest) {
+		srHTTP.Halt(ctx, err)
+	}
+	srHTTP.HaltInternal(ctx, err)
+	srHTTP.MustWriteBodyJSON(ctx, response, modified)
+
+	log.Event(ctx, "Timer modified",
+		attr.String("sr.timer.id", string(modified.ID)),
+		attr.String("sr.timer.action", timerRequest.Action),
+	)
+	srHTTP.LogSuccessf(ctx, "Timer %v %v", modified, timerRequest.Action)
+}
+
+type deleteTimerRequest struct {
+	ID id.UID `json:"id"`
+}
+
+// $ POST /timer/delete id
+var _ = srHTTP.Handle(gameRouter, "POST /timer/delete", handleDeleteTimer)
+
+func handleDeleteTimer(args *srHTTP.Args) {
+	ctx, _, request, client, sess := args.MustSession()
+
+	var timerRequest deleteTimerRequest
+	srHTTP.MustReadBodyJSON(request, &timerRequest)
+	mustBeGM(ctx, client, sess, "remove timers")
+
+	err := game.DeleteTimer(ctx, client, sess.GameID, timerRequest.ID)
+	if errors.Is(err, errs.ErrNotFound) {
+		srHTTP.Halt(ctx, err)
+	}
+	srHTTP.HaltInternal(ctx, err)
+
+	log.Event(ctx, "Timer removed",
+		attr.String("sr.timer.id", string(timerRequest.ID)),
+	)
+	srHTTP.LogSuccessf(ctx, "Removed timer %v", timerRequest.ID)
+}
+
+type advanceTurnsRequest struct {
+	Turns int64 `json:"turns"`
+}
+
+// $ POST /timer/advance turns
+var _ = srHTTP.Handle(gameRouter, "POST /timer/advance", handleAdvanceTurns)
+
+func handleAdvanceTurns(args *srHTTP.Args) {
+	ctx, response, request, client, sess := args.MustSession()
+
+	var turnsRequest advanceTurnsRequest
+	srHTTP.MustReadBodyJSON(request, &turnsRequest)
+	if turnsRequest.Turns < 1 || turnsRequest.Turns > timer.MaxTurns {
+		srHTTP.Halt(ctx, errs.BadRequestf("turns: invalid"))
+	}
+	mustBeGM(ctx, client, sess, "advance turns")
+
+	expired, err := game.AdvanceTurns(ctx, client, sess.GameID, turnsRequest.Turns)
+	srHTTP.HaltInternal(ctx, err)
+	srHTTP.MustWriteBodyJSON(ctx, response, expired)
+
+	log.Event(ctx, "Turns advanced",
+		attr.Int64("sr.timer.turns", turnsRequest.Turns),
+		attr.Int("sr.timer.expired", expired),
+	)
+	srHTTP.LogSuccessf(ctx, "Advanced %v turns, %v timers expired", turnsRequest.Turns, expired)
+}
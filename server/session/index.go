@@ -0,0 +1,193 @@
+package session
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"sr/errs"
+	"sr/id"
+	srOtel "sr/otel"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// LastSeenInterval is how often a session's LastSeen time is updated.
+const LastSeenInterval = time.Minute
+
+// MaxUserAgentLength is the length user agents of sessions are truncated to.
+const MaxUserAgentLength = 256
+
+// IndexKey is the key of the hash of a player's sessions, from their public
+// IDs to their session IDs.
+func IndexKey(playerID id.UID) string {
+	return "sessions:" + string(playerID)
+}
+
+// RevokedChannel is the Redis channel which is published to when the session
+// is removed, so its subscriptions can close.
+func RevokedChannel(sessionID id.UID) string {
+	return "revoked:" + string(sessionID)
+}
+
+// PublicID identifies the session to its player when listing their sessions.
+// Session IDs are bearer tokens and are never listed.
+func (s *Session) PublicID() string {
+	sum := sha256.Sum256([]byte(s.ID))
+	return base64.RawURLEncoding.EncodeToString(sum[:12])
+}
+
+// Info is the information about a session shown to its player.
+type Info struct {
+	ID        string `json:"id"`
+	GameID    string `json:"gameID"`
+	Type      string `json:"type"`
+	Verified  bool   `json:"verified"`
+	Created   int64  `json:"created"`
+	LastSeen  int64  `json:"lastSeen"`
+	UserAgent string `json:"userAgent"`
+}
+
+// Info returns the info of the session to show its player.
+func (s *Session) Info() Info {
+	return Info{
+		ID:        s.PublicID(),
+		GameID:    s.GameID,
+		Type:      s.Type(),
+		Verified:  s.Verified,
+		Created:   s.Created,
+		LastSeen:  s.LastSeen,
+		UserAgent: s.UserAgent,
+	}
+}
+
+// index adds the session to its player's index, removing sessions from it
+// which have expired.
+func index(ctx context.Context, client redis.Cmdable, sess *Session) error {
+	if _, err := ListFor(ctx, client, sess.PlayerID); err != nil {
+		return err
+	}
+	return client.HSet(ctx, IndexKey(sess.PlayerID), sess.PublicID(), string(sess.ID)).Err()
+}
+
+// ListFor retrieves the sessions of a player, most recently seen first.
+// Sessions which have expired are removed from the index.
+func ListFor(ctx context.Context, client redis.Cmdable, playerID id.UID) ([]Session, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "session.ListFor")
+	defer span.End()
+	indexed, err := client.HGetAll(ctx, IndexKey(playerID)).Result()
+	if err != nil {
+		return nil, srOtel.WithSetErrorf(span, "getting index: %w", err)
+	}
+	publicIDs := make([]string, 0, len(indexed))
+	gets := make([]*redis.StringStringMapCmd, 0, len(indexed))
+	_, err = client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for publicID, sessionID := range indexed {
+			publicIDs = append(publicIDs, publicID)
+			gets = append(gets, pipe.HGetAll(ctx, "session:"+sessionID))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, srOtel.WithSetErrorf(span, "getting sessions: %w", err)
+	}
+
+	sessions := make([]Session, 0, len(gets))
+	expired := make([]string, 0)
+	for ix, get := range gets {
+		var sess Session
+		if len(get.Val()) == 0 {
+			expired = append(expired, publicIDs[ix])
+			continue
+		}
+		if err := get.Scan(&sess); err != nil {
+			return nil, srOtel.WithSetErrorf(span, "parsing session: %w", err)
+		}
+		sess.ID = id.UID(indexed[publicIDs[ix]])
+		sessions = append(sessions, sess)
+	}
+	if len(expired) != 0 {
+		if err := client.HDel(ctx, IndexKey(playerID), expired...).Err(); err != nil {
+			return nil, srOtel.WithSetErrorf(span, "removing expired sessions: %w", err)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeen > sessions[j].LastSeen
+	})
+	return sessions, nil
+}
+
+// GetByPublicID retrieves one of a player's sessions by its public ID.
+// Returns ErrNotFound if the player has no such session.
+func GetByPublicID(ctx context.Context, client redis.Cmdable, playerID id.UID, publicID string) (*Session, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "session.GetByPublicID")
+	defer span.End()
+	sessionID, err := client.HGet(ctx, IndexKey(playerID), publicID).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, errs.NotFoundf("session %v", publicID)
+	} else if err != nil {
+		return nil, srOtel.WithSetErrorf(span, "getting index: %w", err)
+	}
+	sess, err := GetByID(ctx, client, sessionID)
+	if errors.Is(err, errNoSessionData) {
+		return nil, errs.NotFoundf("session %v", publicID)
+	} else if err != nil {
+		return nil, srOtel.WithSetErrorf(span, "getting session: %w", err)
+	}
+	return sess, nil
+}
+
+// touchScript sets the lastSeen of a session if it has not been removed.
+var touchScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	return redis.call("HSET", KEYS[1], "lastSeen", ARGV[1])
+end
+return 0
+`)
+
+// Touch updates the session's LastSeen time, if it was last updated more than
+// LastSeenInterval ago.
+func Touch(ctx context.Context, client redis.Cmdable, sess *Session) error {
+	now := id.TimestampNow()
+	if now-sess.LastSeen < LastSeenInterval.Milliseconds() {
+		return nil
+	}
+	ctx, span := srOtel.Tracer.Start(ctx, "session.Touch")
+	defer span.End()
+	if err := touchScript.Run(ctx, client, []string{sess.redisKey()}, now).Err(); err != nil {
+		return srOtel.WithSetErrorf(span, "setting lastSeen: %w", err)
+	}
+	sess.LastSeen = now
+	return nil
+}
+
+// IndexSessions adds existing sessions to their players' indexes, returning
+// the number of sessions indexed.
+func IndexSessions(ctx context.Context, client redis.Cmdable) (int, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "session.IndexSessions")
+	defer span.End()
+	indexed := 0
+	iter := client.Scan(ctx, 0, "session:*", 100).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		playerID, err := client.HGet(ctx, key, "playerID").Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		} else if err != nil {
+			return indexed, srOtel.WithSetErrorf(span, "getting %v: %w", key, err)
+		}
+		sess := Session{ID: id.UID(strings.TrimPrefix(key, "session:"))}
+		if err := client.HSet(ctx, IndexKey(id.UID(playerID)), sess.PublicID(), string(sess.ID)).Err(); err != nil {
+			return indexed, srOtel.WithSetErrorf(span, "indexing %v: %w", key, err)
+		}
+		indexed++
+	}
+	if err := iter.Err(); err != nil {
+		return indexed, srOtel.WithSetErrorf(span, "scanning sessions: %w", err)
+	}
+	return indexed, nil
+}
@@ -25,12 +25,33 @@ import (
 // reading from a game subscription.
 //
 // Persistent sessions are set to expire with a longer-term TTL.
+//
+// Player sessions have no GameID, and are used with any of the player's games.
+//
+// Spectator sessions are read-only, and may not be used for requests which
+// change the game. Whether a session is a spectator's is checked with each
+// request.
+//
+// Verified sessions were created with a password or passkey, and may be used in
+// games which require credentials.
+//
+// Sessions are indexed by player so they can list and revoke them. Removing a
+// session closes any of its open subscriptions.
+//
+// Requests made with API tokens are given a session for the token's player in
+// its game, with the token's ID. These sessions are never stored.
 type Session struct {
-	ID       id.UID `redis:"-"`
-	GameID   string `redis:"gameID"`
-	PlayerID id.UID `redis:"playerID"`
-	Persist  bool   `redis:"persist"`
-	Username string `redis:"username"`
+	ID        id.UID `redis:"-"`
+	GameID    string `redis:"gameID"`
+	PlayerID  id.UID `redis:"playerID"`
+	Persist   bool   `redis:"persist"`
+	Username  string `redis:"username"`
+	Spectator bool   `redis:"spectator"`
+	Verified  bool   `redis:"verified"`
+	Created   int64  `redis:"created"`   // Millisecond timestamp
+	LastSeen  int64  `redis:"lastSeen"`  // Millisecond timestamp, updated every LastSeenInterval
+	UserAgent string `redis:"userAgent"` // User-Agent of the request which created it
+	TokenID   id.UID `redis:"-"`         // API token the request was made with
 }
 
 // Type returns "persist" for persistent sessions and "temp" for temp sessions.
@@ -50,6 +71,12 @@ func (s *Session) PlayerInfo() string {
 }
 
 func (s *Session) String() string {
+	if s.Spectator {
+		return fmt.Sprintf(
+			"%v (%s spectator %v in %v)",
+			s.ID, s.Type(), s.PlayerID, s.GameID,
+		)
+	}
 	return fmt.Sprintf(
 		"%v (%s %v in %v)",
 		s.ID, s.Type(), s.PlayerID, s.GameID,
@@ -66,12 +93,15 @@ func (s *Session) redisKey() string {
 // New constructs a new session.
 func New(plr *player.Player, gameID string, persist bool) *Session {
 	sessionID := id.GenSessionID()
+	now := id.TimestampNow()
 	return &Session{
 		ID:       sessionID,
 		GameID:   gameID,
 		PlayerID: plr.ID,
 		Username: plr.Username,
 		Persist:  persist,
+		Created:  now,
+		LastSeen: now,
 	}
 }
 
@@ -80,6 +110,9 @@ func New(plr *player.Player, gameID string, persist bool) *Session {
 func Create(ctx context.Context, client redis.Cmdable, sess *Session) error {
 	ctx, span := srOtel.Tracer.Start(ctx, "session.Create")
 	defer span.End()
+	if len(sess.UserAgent) > MaxUserAgentLength {
+		sess.UserAgent = sess.UserAgent[:MaxUserAgentLength]
+	}
 	sessionFields, err := redisUtil.StructToStringMap(sess)
 	if err != nil {
 		return srOtel.WithSetErrorf(span, "getting fields for session %v: %w", sess, err)
@@ -92,6 +125,21 @@ func Create(ctx context.Context, client redis.Cmdable, sess *Session) error {
 	if err != nil {
 		return srOtel.WithSetErrorf(span, "Redis error expiring session %v: %w", sess, err)
 	}
+	if err := index(ctx, client, sess); err != nil {
+		return srOtel.WithSetErrorf(span, "indexing session %v: %w", sess, err)
+	}
+	return nil
+}
+
+// SetVerified marks the session as verified, once its player has set up a
+// password or passkey.
+func SetVerified(ctx context.Context, client redis.Cmdable, sess *Session) error {
+	ctx, span := srOtel.Tracer.Start(ctx, "session.SetVerified")
+	defer span.End()
+	if err := client.HSet(ctx, sess.redisKey(), "verified", true).Err(); err != nil {
+		return srOtel.WithSetErrorf(span, "setting verified: %w", err)
+	}
+	sess.Verified = true
 	return nil
 }
 
@@ -130,7 +178,7 @@ func GetByID(ctx context.Context, client redis.Cmdable, sessionID string) (*Sess
 	if err != nil {
 		return nil, srOtel.WithSetErrorf(span, "parsing session struct: %w", err)
 	}
-	if sess.GameID == "" || sess.PlayerID == "" {
+	if sess.PlayerID == "" {
 		return nil, errNoSessionData
 	}
 	sess.ID = id.UID(sessionID)
@@ -138,18 +186,95 @@ func GetByID(ctx context.Context, client redis.Cmdable, sessionID string) (*Sess
 	return &sess, nil
 }
 
-// Remove removes a session from Redis.
+// Remove removes a session from Redis, and closes its subscriptions.
+// Removing a session which has already expired or been revoked is not an error.
 func Remove(ctx context.Context, client redis.Cmdable, sess *Session) error {
+	_, err := remove(ctx, client, sess)
+	return err
+}
+
+// remove removes a session, returning whether its key was still present.
+func remove(ctx context.Context, client redis.Cmdable, sess *Session) (bool, error) {
 	ctx, span := srOtel.Tracer.Start(ctx, "session.Remove")
 	defer span.End()
-	result, err := client.Del(ctx, sess.redisKey()).Result()
+	var del *redis.IntCmd
+	_, err := client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		del = pipe.Del(ctx, sess.redisKey())
+		pipe.HDel(ctx, IndexKey(sess.PlayerID), sess.PublicID())
+		pipe.Publish(ctx, RevokedChannel(sess.ID), "revoked")
+		return nil
+	})
 	if err != nil {
-		return srOtel.WithSetErrorf(span, "sending redis DEL: %w", err)
+		return false, srOtel.WithSetErrorf(span, "sending redis DEL: %w", err)
 	}
-	if result != 1 {
-		return srOtel.WithSetErrorf(span, "expected 1 key deleted, got %v", result)
+	if result := del.Val(); result > 1 {
+		return false, srOtel.WithSetErrorf(span, "expected 1 key deleted, got %v", result)
 	}
-	return nil
+	return del.Val() == 1, nil
+}
+
+// removeMatching removes each of a player's sessions for which keep returns
+// false, returning the number removed.
+func removeMatching(ctx context.Context, client redis.Cmdable, playerID id.UID, keep func(*Session) bool) (int, error) {
+	sessions, err := ListFor(ctx, client, playerID)
+	if err != nil {
+		return 0, fmt.Errorf("listing sessions: %w", err)
+	}
+	removed := 0
+	for ix := range sessions {
+		if keep(&sessions[ix]) {
+			continue
+		}
+		found, err := remove(ctx, client, &sessions[ix])
+		if err != nil {
+			return removed, fmt.Errorf("removing %v: %w", sessions[ix].ID, err)
+		}
+		if found {
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// RemoveAllFor removes all of a player's sessions in a game, returning the
+// number removed.
+func RemoveAllFor(ctx context.Context, client redis.Cmdable, gameID string, playerID id.UID) (int, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "session.RemoveAllFor")
+	defer span.End()
+	removed, err := removeMatching(ctx, client, playerID, func(sess *Session) bool {
+		return sess.GameID != gameID
+	})
+	if err != nil {
+		return removed, srOtel.WithSetErrorf(span, "%w", err)
+	}
+	return removed, nil
+}
+
+// RemoveAllOf removes all of a player's sessions, returning the number removed.
+func RemoveAllOf(ctx context.Context, client redis.Cmdable, playerID id.UID) (int, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "session.RemoveAllOf")
+	defer span.End()
+	removed, err := removeMatching(ctx, client, playerID, func(*Session) bool {
+		return false
+	})
+	if err != nil {
+		return removed, srOtel.WithSetErrorf(span, "%w", err)
+	}
+	return removed, nil
+}
+
+// RemoveOthersOf removes all of the session's player's other sessions,
+// returning the number removed.
+func RemoveOthersOf(ctx context.Context, client redis.Cmdable, current *Session) (int, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "session.RemoveOthersOf")
+	defer span.End()
+	removed, err := removeMatching(ctx, client, current.PlayerID, func(sess *Session) bool {
+		return sess.ID == current.ID
+	})
+	if err != nil {
+		return removed, srOtel.WithSetErrorf(span, "%w", err)
+	}
+	return removed, nil
 }
 
 // Expire sets the session to expire in `config.SesssionExpirySecs`.
@@ -0,0 +1,154 @@
+package session_test
+
+import (
+	"context"
+	"testing"
+
+	genGame "sr/gen/game"
+	genPlayer "sr/gen/player"
+
+	"sr/errs"
+	"sr/session"
+	"sr/test"
+)
+
+func TestListFor(t *testing.T) {
+	ctx := context.Background()
+	_, client := test.GetRedis(t)
+	rng := test.RNG()
+
+	plr := genPlayer.Player(rng)
+	gameID := genGame.GameID(rng)
+	first := session.New(plr, gameID, true)
+	first.UserAgent = "Firefox"
+	second := session.New(plr, "", false)
+	second.LastSeen = first.LastSeen + 1
+	test.Must(t, session.Create(ctx, client, first), session.Create(ctx, client, second))
+
+	sessions, err := session.ListFor(ctx, client, plr.ID)
+	test.AssertSuccess(t, err, "listing sessions")
+	test.AssertEqual(t, []session.Session{*second, *first}, sessions)
+	test.AssertEqual(t, "Firefox", sessions[1].Info().UserAgent)
+	test.AssertEqual(t, "persist", sessions[1].Info().Type)
+
+	found, err := session.GetByPublicID(ctx, client, plr.ID, first.PublicID())
+	test.AssertSuccess(t, err, "getting by public ID")
+	test.AssertEqual(t, first, found)
+	other := genPlayer.Player(rng)
+	_, err = session.GetByPublicID(ctx, client, other.ID, first.PublicID())
+	test.AssertErrorIs(t, err, errs.ErrNotFound)
+
+	test.Must(t, client.Del(ctx, "session:"+string(second.ID)).Err())
+	sessions, err = session.ListFor(ctx, client, plr.ID)
+	test.AssertSuccess(t, err, "listing after expiry")
+	test.AssertEqual(t, []session.Session{*first}, sessions)
+	indexed, err := client.HLen(ctx, session.IndexKey(plr.ID)).Result()
+	test.AssertSuccess(t, err, "getting index")
+	test.AssertEqual(t, int64(1), indexed)
+}
+
+func TestRemove(t *testing.T) {
+	ctx := context.Background()
+	db, client := test.GetRedis(t)
+	rng := test.RNG()
+
+	plr := genPlayer.Player(rng)
+	sess := session.New(plr, genGame.GameID(rng), true)
+	test.Must(t, session.Create(ctx, client, sess))
+
+	sub := db.NewSubscriber()
+	defer sub.Close()
+	sub.Subscribe(session.RevokedChannel(sess.ID))
+	wait := test.WaitForMessage(t, sub.Messages(), "revoked")
+
+	test.Must(t, session.Remove(ctx, client, sess))
+	wait.Wait()
+	_, err := session.GetByPublicID(ctx, client, plr.ID, sess.PublicID())
+	test.AssertErrorIs(t, err, errs.ErrNotFound)
+}
+
+func TestRemoveAll(t *testing.T) {
+	ctx := context.Background()
+	_, client := test.GetRedis(t)
+	rng := test.RNG()
+
+	plr := genPlayer.Player(rng)
+	gameID := genGame.GameID(rng)
+	inGame := session.New(plr, gameID, true)
+	otherGame := session.New(plr, genGame.GameID(rng), true)
+	playerSess := session.New(plr, "", false)
+	test.Must(t,
+		session.Create(ctx, client, inGame),
+		session.Create(ctx, client, otherGame),
+		session.Create(ctx, client, playerSess),
+	)
+
+	removed, err := session.RemoveAllFor(ctx, client, gameID, plr.ID)
+	test.AssertSuccess(t, err, "removing sessions in game")
+	test.AssertEqual(t, 1, removed)
+	exists, err := session.Exists(ctx, client, string(inGame.ID))
+	test.AssertSuccess(t, err, "checking session")
+	test.AssertEqual(t, false, exists)
+
+	removed, err = session.RemoveAllOf(ctx, client, plr.ID)
+	test.AssertSuccess(t, err, "removing all sessions")
+	test.AssertEqual(t, 2, removed)
+	sessions, err := session.ListFor(ctx, client, plr.ID)
+	test.AssertSuccess(t, err, "listing sessions")
+	test.AssertEqual(t, 0, len(sessions))
+	test.AssertSuccess(t, session.Remove(ctx, client, inGame), "removing a revoked session")
+}
+
+func TestRemoveOthersOf(t *testing.T) {
+	ctx := context.Background()
+	_, client := test.GetRedis(t)
+	rng := test.RNG()
+
+	plr := genPlayer.Player(rng)
+	current := session.New(plr, "", true)
+	other := session.New(plr, genGame.GameID(rng), false)
+	otherPlayer := session.New(genPlayer.Player(rng), "", false)
+	test.Must(t,
+		session.Create(ctx, client, current),
+		session.Create(ctx, client, other),
+		session.Create(ctx, client, otherPlayer),
+	)
+
+	removed, err := session.RemoveOthersOf(ctx, client, current)
+	test.AssertSuccess(t, err, "removing other sessions")
+	test.AssertEqual(t, 1, removed)
+	sessions, err := session.ListFor(ctx, client, plr.ID)
+	test.AssertSuccess(t, err, "listing sessions")
+	test.AssertEqual(t, []session.Session{*current}, sessions)
+	exists, err := session.Exists(ctx, client, string(otherPlayer.ID))
+	test.AssertSuccess(t, err, "checking other player's session")
+	test.AssertEqual(t, true, exists)
+}
+
+func TestTouch(t *testing.T) {
+	ctx := context.Background()
+	_, client := test.GetRedis(t)
+	rng := test.RNG()
+
+	plr := genPlayer.Player(rng)
+	sess := session.New(plr, "", true)
+	test.Must(t, session.Create(ctx, client, sess))
+
+	created := sess.LastSeen
+	test.Must(t, session.Touch(ctx, client, sess))
+	test.AssertEqual(t, created, sess.LastSeen)
+
+	sess.LastSeen -= session.LastSeenInterval.Milliseconds()
+	test.Must(t, session.Touch(ctx, client, sess))
+	found, err := session.GetByID(ctx, client, string(sess.ID))
+	test.AssertSuccess(t, err, "getting session")
+	test.AssertEqual(t, sess.LastSeen, found.LastSeen)
+	test.AssertCheck(t, found.LastSeen, found.LastSeen >= created, "last seen is updated")
+
+	test.Must(t, session.Remove(ctx, client, sess))
+	sess.LastSeen = 0
+	test.Must(t, session.Touch(ctx, client, sess))
+	exists, err := session.Exists(ctx, client, string(sess.ID))
+	test.AssertSuccess(t, err, "checking session")
+	test.AssertEqual(t, false, exists)
+}
@@ -0,0 +1,43 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"sr/character"
+	"sr/game"
+	"sr/id"
+	"sr/log"
+
+	"github.com/go-redis/redis/v8"
+)
+
+func handleImportCharacterTask(ctx context.Context, client *redis.Client, gameID string, playerID id.UID, path string) error {
+	inGame, err := game.HasPlayer(ctx, client, gameID, playerID)
+	if err != nil {
+		return fmt.Errorf("checking player %v: %w", playerID, err)
+	}
+	if !inGame {
+		return fmt.Errorf("player %v is not in game %v", playerID, gameID)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("opening %v: %w", path, err)
+	}
+	defer file.Close()
+
+	char, unmapped, err := character.ParseChummer(file, gameID, playerID)
+	for _, field := range unmapped {
+		log.Printf(ctx, "Not imported: %v", field)
+	}
+	if err != nil {
+		return fmt.Errorf("parsing %v: %w", path, err)
+	}
+	if err := character.Create(ctx, client, char); err != nil {
+		return fmt.Errorf("creating %v: %w", char, err)
+	}
+	log.Printf(ctx, "Imported character %v", char)
+	return nil
+}
@@ -80,6 +80,39 @@ func printEvent(evt event.Event) string {
 		return fmt.Sprintf("%v rerolls %v dice",
 			reroll.PlayerName, len(reroll.Rounds[1]),
 		)
+	case *event.GroupRoll:
+		groupRoll := evt.(*event.GroupRoll)
+		if groupRoll.Title != "" {
+			return fmt.Sprintf("%v rolls for %v actors to %v",
+				groupRoll.PlayerName, len(groupRoll.Rolls), groupRoll.Title,
+			)
+		}
+		return fmt.Sprintf("%v rolls for %v actors",
+			groupRoll.PlayerName, len(groupRoll.Rolls),
+		)
+	case *event.Damage:
+		damage := evt.(*event.Damage)
+		return damage.Summary()
+	case *event.Award:
+		award := evt.(*event.Award)
+		return award.Summary()
+	case *event.Timer:
+		timer := evt.(*event.Timer)
+		return fmt.Sprintf("Timer %q ran out", timer.Title)
+	case *event.Overwatch:
+		overwatch := evt.(*event.Overwatch)
+		return overwatch.Summary()
+	case *event.Convergence:
+		convergence := evt.(*event.Convergence)
+		return fmt.Sprintf("%v is convergenced", convergence.CharName)
+	case *event.Message:
+		message := evt.(*event.Message)
+		return fmt.Sprintf("%v says %q", message.PlayerName, message.Text)
+	case *event.RollRequest:
+		request := evt.(*event.RollRequest)
+		return fmt.Sprintf("%v asks %v players to roll %v",
+			request.PlayerName, len(request.Targets), request.Title,
+		)
 	case *event.InitiativeRoll:
 		initRoll := evt.(*event.InitiativeRoll)
 		title := "initiative"
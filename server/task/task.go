@@ -5,9 +5,13 @@ import (
 	"fmt"
 	"os"
 
+	"sr/credential"
 	"sr/game"
+	"sr/id"
 	"sr/log"
 	srOtel "sr/otel"
+	"sr/player"
+	"sr/session"
 	"sr/shutdown"
 
 	"github.com/go-redis/redis/v8"
@@ -15,7 +19,7 @@ import (
 
 // PrintAvailableTasks prints the list of CLI tasks
 func PrintAvailableTasks(ctx context.Context) {
-	tasks := []string{"migrate", "ppr"}
+	tasks := []string{"migrate", "import-character", "index-player-games", "index-sessions", "clear-credentials", "ppr"}
 	log.Stdoutf(ctx, "Available tasks:\n\t%v", tasks)
 }
 
@@ -45,6 +49,49 @@ func RunSelectedTask(ctx context.Context, client *redis.Client, task string, arg
 			os.Exit(1)
 		}
 		break
+	case "import-character":
+		if len(args) != 3 {
+			log.Print(ctx, "Usage: import-character <gameID> <playerID> <file.chum5>")
+			os.Exit(1)
+		}
+		gameID := args[0]
+		if ok, err := game.Exists(ctx, client, gameID); !ok || err != nil {
+			log.Printf(ctx, "Game %v does not exist (%v)", args[0], err)
+			os.Exit(1)
+		}
+		if err := handleImportCharacterTask(ctx, client, gameID, id.UID(args[1]), args[2]); err != nil {
+			log.Printf(ctx, "Error with task: %v", err)
+			os.Exit(1)
+		}
+	case "index-player-games":
+		indexed, err := game.IndexPlayerGames(ctx, client)
+		if err != nil {
+			log.Printf(ctx, "Error with task: %v", err)
+			os.Exit(1)
+		}
+		log.Printf(ctx, "Indexed players of %v games", indexed)
+	case "index-sessions":
+		indexed, err := session.IndexSessions(ctx, client)
+		if err != nil {
+			log.Printf(ctx, "Error with task: %v", err)
+			os.Exit(1)
+		}
+		log.Printf(ctx, "Indexed %v sessions", indexed)
+	case "clear-credentials":
+		if len(args) != 1 {
+			log.Print(ctx, "Usage: clear-credentials <username>")
+			os.Exit(1)
+		}
+		plr, err := player.GetByUsername(ctx, client, args[0])
+		if err != nil {
+			log.Printf(ctx, "Player %v does not exist (%v)", args[0], err)
+			os.Exit(1)
+		}
+		if err := credential.Clear(ctx, client, plr.ID); err != nil {
+			log.Printf(ctx, "Error with task: %v", err)
+			os.Exit(1)
+		}
+		log.Printf(ctx, "Cleared password, passkeys and provider links of %v", plr.ID)
 	default:
 		log.Printf(ctx, "No task %v found", task)
 		os.Exit(1)
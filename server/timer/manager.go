@@ -0,0 +1,62 @@
+package timer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+
+	"sr/errs"
+	"sr/id"
+	srOtel "sr/otel"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// GetAll retrieves the timers in a game, sorted by title.
+func GetAll(ctx context.Context, client redis.Cmdable, gameID string) ([]Timer, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "timer.GetAll")
+	defer span.End()
+	timerTexts, err := client.HGetAll(ctx, RedisKey(gameID)).Result()
+	if err != nil {
+		return nil, srOtel.WithSetErrorf(span, "getting timers: %w", err)
+	}
+	timers := make([]Timer, 0, len(timerTexts))
+	for timerID, text := range timerTexts {
+		var timer Timer
+		if err := json.Unmarshal([]byte(text), &timer); err != nil {
+			return nil, srOtel.WithSetErrorf(span, "parsing timer %v: %w", timerID, err)
+		}
+		timers = append(timers, timer)
+	}
+	sort.Slice(timers, func(i, j int) bool {
+		return timers[i].Title < timers[j].Title
+	})
+	return timers, nil
+}
+
+// GetByID retrieves a timer in a game.
+// Returns ErrNotFound if the timer does not exist.
+func GetByID(ctx context.Context, client redis.Cmdable, gameID string, timerID id.UID) (*Timer, error) {
+	ctx, span := srOtel.Tracer.Start(ctx, "timer.GetByID")
+	defer span.End()
+	text, err := client.HGet(ctx, RedisKey(gameID), string(timerID)).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, errs.NotFoundf("timer %v", timerID)
+	} else if err != nil {
+		return nil, srOtel.WithSetErrorf(span, "redis error retrieving %v: %w", timerID, err)
+	}
+	var timer Timer
+	if err := json.Unmarshal([]byte(text), &timer); err != nil {
+		return nil, srOtel.WithSetErrorf(span, "parsing timer %v: %w", timerID, err)
+	}
+	return &timer, nil
+}
+
+// GetDue retrieves the members of DueKey for timers expiring by the given time.
+func GetDue(ctx context.Context, client redis.Cmdable, now int64) ([]string, error) {
+	return client.ZRangeByScore(ctx, DueKey, &redis.ZRangeBy{
+		Min: "-inf", Max: strconv.FormatInt(now, 10),
+	}).Result()
+}
@@ -0,0 +1,164 @@
+package timer
+
+import (
+	"fmt"
+	"strings"
+
+	"sr/errs"
+	"sr/id"
+	"sr/player"
+)
+
+// Kinds of timers.
+const (
+	KindRealTime = "real"  // Counts down milliseconds
+	KindTurns    = "turns" // Counts down combat turns
+)
+
+// MaxRealTime is the longest real-time timer, in milliseconds.
+const MaxRealTime = 7 * 24 * 60 * 60 * 1000
+
+// MaxTurns is the longest turn-based timer.
+const MaxTurns = 1000
+
+// MaxTimers is the largest number of timers in a game.
+const MaxTimers = 20
+
+// DueKey is the sorted set of running real-time timers across all games,
+// scored by the time they expire.
+const DueKey = "timers-due"
+
+// Timer is a countdown shared with a whole game.
+//
+// Running real-time timers count down to Ends; paused real-time timers and
+// turn-based timers keep the amount left in Remaining.
+type Timer struct {
+	ID         id.UID `json:"id"`
+	Title      string `json:"title"`
+	Kind       string `json:"kind"`
+	Remaining  int64  `json:"remaining"`      // Milliseconds or turns left
+	Ends       int64  `json:"ends,omitempty"` // Timestamp a running real-time timer expires
+	Paused     bool   `json:"paused"`
+	PlayerID   id.UID `json:"playerID"` // GM who started the timer
+	PlayerName string `json:"playerName"`
+}
+
+// Make constructs a new running Timer, giving it a UID.
+func Make(plr *player.Player, title string, kind string, duration int64, now int64) Timer {
+	timer := Timer{
+		ID:         id.GenUID(),
+		Title:      title,
+		Kind:       kind,
+		Remaining:  duration,
+		PlayerID:   plr.ID,
+		PlayerName: plr.Name,
+	}
+	if kind == KindRealTime {
+		timer.Ends = now + duration
+	}
+	return timer
+}
+
+// RedisKey is the key of the hash of timers in a game.
+func RedisKey(gameID string) string {
+	return "timers:" + gameID
+}
+
+// DueMember is the member of DueKey for a timer in a game.
+func DueMember(gameID string, timerID id.UID) string {
+	return gameID + ":" + string(timerID)
+}
+
+// ParseDueMember parses the game and timer IDs from a member of DueKey.
+func ParseDueMember(member string) (string, id.UID, bool) {
+	ix := strings.LastIndex(member, ":")
+	if ix < 1 || ix == len(member)-1 {
+		return "", "", false
+	}
+	return member[:ix], id.UID(member[ix+1:]), true
+}
+
+// IsRunning determines if the timer is counting down in real time.
+func (t *Timer) IsRunning() bool {
+	return t.Kind == KindRealTime && !t.Paused
+}
+
+// RemainingAt computes how much is left on the timer at the given time.
+func (t *Timer) RemainingAt(now int64) int64 {
+	if !t.IsRunning() {
+		return t.Remaining
+	}
+	if t.Ends < now {
+		return 0
+	}
+	return t.Ends - now
+}
+
+// IsExpired determines if the timer has run out at the given time.
+func (t *Timer) IsExpired(now int64) bool {
+	return t.RemainingAt(now) <= 0
+}
+
+// Pause stops the timer from counting down in real time.
+// Returns ErrBadRequest if the timer is already paused.
+func (t *Timer) Pause(now int64) error {
+	if t.Paused {
+		return errs.BadRequestf("timer %v is already paused", t.ID)
+	}
+	t.Remaining = t.RemainingAt(now)
+	t.Ends = 0
+	t.Paused = true
+	return nil
+}
+
+// Resume starts the timer counting down again.
+// Returns ErrBadRequest if the timer is not paused.
+func (t *Timer) Resume(now int64) error {
+	if !t.Paused {
+		return errs.BadRequestf("timer %v is not paused", t.ID)
+	}
+	t.Paused = false
+	if t.Kind == KindRealTime {
+		t.Ends = now + t.Remaining
+	}
+	return nil
+}
+
+// Adjust adds the given milliseconds or turns to the timer.
+func (t *Timer) Adjust(delta int64, now int64) {
+	t.Remaining = t.RemainingAt(now) + delta
+	if t.Remaining < 0 {
+		t.Remaining = 0
+	}
+	if t.IsRunning() {
+		t.Ends = now + t.Remaining
+	}
+}
+
+// Validate checks that the timer's values are in range.
+// Returns ErrBadRequest describing the first invalid value.
+func (t *Timer) Validate() error {
+	if len(t.Title) == 0 || len(t.Title) > 64 || strings.ContainsAny(t.Title, "\r\n") {
+		return errs.BadRequestf("title: invalid")
+	}
+	switch t.Kind {
+	case KindRealTime:
+		if t.Remaining > MaxRealTime {
+			return errs.BadRequestf("duration: cannot be longer than a week")
+		}
+	case KindTurns:
+		if t.Remaining > MaxTurns {
+			return errs.BadRequestf("duration: cannot be longer than %v turns", MaxTurns)
+		}
+	default:
+		return errs.BadRequestf("kind: expected %v or %v", KindRealTime, KindTurns)
+	}
+	if t.Remaining < 0 {
+		return errs.BadRequestf("duration: invalid")
+	}
+	return nil
+}
+
+func (t *Timer) String() string {
+	return fmt.Sprintf("%v (%v)", t.ID, t.Title)
+}
@@ -0,0 +1,55 @@
+package timer_test
+
+import (
+	"testing"
+
+	"sr/errs"
+	"sr/id"
+	"sr/player"
+	"sr/test"
+	"sr/timer"
+)
+
+var gm = &player.Player{ID: id.UID("gm"), Name: "GM"}
+
+func TestTimer_RealTime(t *testing.T) {
+	tmr := timer.Make(gm, "Security response", timer.KindRealTime, 60000, 1000)
+	test.AssertSuccess(t, tmr.Validate(), "validating timer")
+	test.AssertEqual(t, true, tmr.IsRunning())
+	test.AssertEqual(t, int64(61000), tmr.Ends)
+	test.AssertEqual(t, int64(50000), tmr.RemainingAt(11000))
+
+	test.AssertSuccess(t, tmr.Pause(11000), "pausing timer")
+	test.AssertErrorIs(t, tmr.Pause(12000), errs.ErrBadRequest)
+	test.AssertEqual(t, int64(50000), tmr.RemainingAt(99000))
+
+	test.AssertSuccess(t, tmr.Resume(20000), "resuming timer")
+	test.AssertErrorIs(t, tmr.Resume(20000), errs.ErrBadRequest)
+	test.AssertEqual(t, int64(70000), tmr.Ends)
+
+	tmr.Adjust(-30000, 30000)
+	test.AssertEqual(t, int64(40000), tmr.Ends)
+	test.AssertEqual(t, false, tmr.IsExpired(39999))
+	test.AssertEqual(t, true, tmr.IsExpired(40000))
+}
+
+func TestTimer_Turns(t *testing.T) {
+	tmr := timer.Make(gm, "Spell", timer.KindTurns, 3, 1000)
+	test.AssertEqual(t, false, tmr.IsRunning())
+	test.AssertEqual(t, int64(3), tmr.RemainingAt(99999))
+	tmr.Adjust(-5, 2000)
+	test.AssertEqual(t, int64(0), tmr.Remaining)
+	test.AssertEqual(t, true, tmr.IsExpired(2000))
+
+	tmr.Kind = "moons"
+	test.AssertErrorIs(t, tmr.Validate(), errs.ErrBadRequest)
+}
+
+func TestParseDueMember(t *testing.T) {
+	gameID, timerID, ok := timer.ParseDueMember(timer.DueMember("game:1", id.UID("abc")))
+	test.AssertEqual(t, true, ok)
+	test.AssertEqual(t, "game:1", gameID)
+	test.AssertEqual(t, id.UID("abc"), timerID)
+	_, _, ok = timer.ParseDueMember("nope")
+	test.AssertEqual(t, false, ok)
+}
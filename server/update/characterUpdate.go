@@ -0,0 +1,28 @@
+package update
+
+import (
+	"encoding/json"
+
+	"sr/id"
+)
+
+// characterDiff updates various fields on a character.
+type characterDiff struct {
+	id   id.UID
+	diff map[string]interface{}
+}
+
+func (update *characterDiff) Type() string {
+	return TypeCharacterMod
+}
+
+func (update *characterDiff) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{
+		TypeCharacterMod, update.id, update.diff,
+	})
+}
+
+// ForCharacterDiff constructs an update for a character changing.
+func ForCharacterDiff(charID id.UID, diff map[string]interface{}) Update {
+	return &characterDiff{id: charID, diff: diff}
+}
@@ -89,9 +89,12 @@ func ForEventDiff(event event.Event, diff map[string]interface{}) Event {
 }
 
 // ForEventShare constructs an update for changing an event share.
-func ForEventShare(event event.Event, newShare event.Share) Event {
-	update := makeEventDiff(event)
+func ForEventShare(evt event.Event, newShare event.Share) Event {
+	update := makeEventDiff(evt)
 	update.diff["share"] = newShare // TODO make sure we don't need to stringify
+	if newShare == event.ShareWhisper {
+		update.diff["to"] = evt.GetRecipients()
+	}
 	return &update
 }
 
@@ -125,6 +128,105 @@ func ForSeizeInitiative(event event.Event) Event {
 	return &update
 }
 
+// ForRollRequestAnswered constructs an update for a player answering a roll request.
+func ForRollRequestAnswered(request *event.RollRequest) Event {
+	update := makeEventDiff(request)
+	update.diff["answered"] = request.Answered
+	return &update
+}
+
+// rollPrompt asks a player to answer a roll request.
+type rollPrompt struct {
+	request *event.RollRequest
+}
+
+func (update *rollPrompt) Type() string {
+	return TypeRollRequested
+}
+
+func (update *rollPrompt) EventID() int64 {
+	return update.request.GetID()
+}
+
+func (update *rollPrompt) Time() int64 {
+	return update.request.GetID()
+}
+
+func (update *rollPrompt) MarshalJSON() ([]byte, error) {
+	fields := []interface{}{TypeRollRequested, update.request.GetID()}
+	return json.Marshal(fields)
+}
+
+// ForRollPrompt constructs an update asking a player to answer a roll request.
+func ForRollPrompt(request *event.RollRequest) Event {
+	return &rollPrompt{request}
+}
+
+// soakPrompt asks a player to soak damage dealt to their character.
+type soakPrompt struct {
+	damage *event.Damage
+}
+
+func (update *soakPrompt) Type() string {
+	return TypeSoakRequested
+}
+
+func (update *soakPrompt) EventID() int64 {
+	return update.damage.GetID()
+}
+
+func (update *soakPrompt) Time() int64 {
+	return update.damage.GetID()
+}
+
+func (update *soakPrompt) MarshalJSON() ([]byte, error) {
+	fields := []interface{}{TypeSoakRequested, update.damage.GetID()}
+	return json.Marshal(fields)
+}
+
+// ForSoakPrompt constructs an update asking a player to soak damage.
+func ForSoakPrompt(damage *event.Damage) Event {
+	return &soakPrompt{damage}
+}
+
+// ForDamageSoaked constructs an update for damage having been soaked.
+func ForDamageSoaked(damage *event.Damage) Event {
+	update := makeEventDiff(damage)
+	update.diff["resolved"] = damage.Resolved
+	update.diff["soak"] = damage.Soak
+	update.diff["soakDice"] = damage.SoakDice
+	update.diff["soaked"] = damage.Soaked
+	update.diff["taken"] = damage.Taken
+	return &update
+}
+
+// mention notifies a player that they were mentioned in a message.
+type mention struct {
+	message *event.Message
+}
+
+func (update *mention) Type() string {
+	return TypeMentioned
+}
+
+func (update *mention) EventID() int64 {
+	return update.message.GetID()
+}
+
+func (update *mention) Time() int64 {
+	return update.message.GetID()
+}
+
+func (update *mention) MarshalJSON() ([]byte, error) {
+	fields := []interface{}{TypeMentioned, update.message.GetID(), update.message.GetPlayerID()}
+	return json.Marshal(fields)
+}
+
+// ForMention constructs an update notifying a player of a mention in a message.
+func ForMention(message *event.Message) Event {
+	return &mention{message}
+}
+
 // eventDelete is a specific update type for deleting events
 type eventDelete struct {
 	eventID int64
@@ -73,29 +73,29 @@ func (f *withFilter) Serialize() (string, error) {
 	return fmt.Sprintf("-%s %s", filter, inner), nil
 }
 
-// ParseExclude attempts to find an exclude ID for the given update text.
+// ParseExclude attempts to find the excluded IDs for the given update text.
 // If the exclude is found, it is trimmed from the front of the event.
-func ParseExclude(input string) (excludeID id.UID, excludeGMs bool, inner string, found bool) {
+func ParseExclude(input string) (excludeIDs []id.UID, excludeGMs bool, inner string, found bool) {
 	// "-{filters:filter,...} {inner:[\"ty\", ...]}"
 	if !strings.HasPrefix(input, "-") {
-		return id.UID(""), false, input, false
+		return nil, false, input, false
 	}
 	// -{parts[0]} {parts[1]}
 	parts := strings.SplitN(input[1:], " ", 2)
 	if len(parts) != 2 { // Should not happen with well-formatted arguments
-		return id.UID(""), false, input, false
+		return nil, false, input, false
 	}
 	// {filter[,...]} {inner}
 	filters, inner := strings.Split(parts[0], ","), parts[1]
 	if len(filters) == 0 { // Should not happen with well-formated arguments
-		return id.UID(""), false, input, false
+		return nil, false, input, false
 	}
 	for _, filter := range filters {
 		if filter == "gms" {
 			excludeGMs = true
 		} else {
-			excludeID = id.UID(filter)
+			excludeIDs = append(excludeIDs, id.UID(filter))
 		}
 	}
-	return excludeID, excludeGMs, inner, true
+	return excludeIDs, excludeGMs, inner, true
 }
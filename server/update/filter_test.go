@@ -13,10 +13,10 @@ func TestParseExlude(t *testing.T) {
 		ud := playerOnline{id: id.UID("someplayer"), online: false}
 		udBytes, err := json.Marshal(ud)
 		test.AssertSuccess(t, err, "marshaling json")
-		excludeID, excludeGMs, inner, found := ParseExclude(string(udBytes))
+		excludeIDs, excludeGMs, inner, found := ParseExclude(string(udBytes))
 		test.Assert(t, !found, "exclude found", false, true)
 		test.AssertEqual(t, inner, string(udBytes))
-		test.AssertEqual(t, excludeID, id.UID(""))
+		test.AssertEqual(t, len(excludeIDs), 0)
 		test.AssertEqual(t, excludeGMs, false)
 	})
 	test.RunParallel(t, "it parses an exclude", func(t *testing.T) {
@@ -28,10 +28,20 @@ func TestParseExlude(t *testing.T) {
 		excludeStr, err := exclude.Serialize()
 		test.AssertSuccess(t, err, "serializing exclude")
 		t.Logf("Exclude: %v", excludeStr)
-		excludeID, excludeGMs, inner, found := ParseExclude(excludeStr)
+		excludeIDs, excludeGMs, inner, found := ParseExclude(excludeStr)
 		test.Assert(t, found, "exclude found", true, false)
 		test.AssertEqual(t, inner, string(udBytes))
-		test.AssertEqual(t, excludeID, id.UID("someotherplayer"))
+		test.AssertEqual(t, excludeIDs, []id.UID{"someotherplayer"})
 		test.AssertEqual(t, excludeGMs, true)
 	})
+	test.RunParallel(t, "it parses multiple excluded players", func(t *testing.T) {
+		ud := playerOnline{id: id.UID("someplayer"), online: false}
+		exclude := WithFilters([]string{"first", "second", "third"}, &ud)
+		excludeStr, err := exclude.Serialize()
+		test.AssertSuccess(t, err, "serializing exclude")
+		excludeIDs, excludeGMs, _, found := ParseExclude(excludeStr)
+		test.Assert(t, found, "exclude found", true, false)
+		test.AssertEqual(t, excludeIDs, []id.UID{"first", "second", "third"})
+		test.AssertEqual(t, excludeGMs, false)
+	})
 }
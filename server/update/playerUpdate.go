@@ -122,3 +122,92 @@ func (update *playerAdd) MakeRedisCommand() (string, map[string]interface{}, err
 func ForPlayerAdd(player *player.Player) Player {
 	return &playerAdd{player}
 }
+
+type playerDel struct {
+	id id.UID
+}
+
+func (update *playerDel) Type() string {
+	return TypePlayerDel
+}
+
+func (update *playerDel) PlayerID() id.UID {
+	return update.id
+}
+
+func (update *playerDel) IsEmpty() bool {
+	return false
+}
+
+func (update *playerDel) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{TypePlayerDel, update.id})
+}
+
+func (update *playerDel) MakeRedisCommand() (string, map[string]interface{}, error) {
+	return "", nil, fmt.Errorf("cannot call MakeRedisCommand on playerDel.")
+}
+
+// ForPlayerDel constructs an update for removing a player from a game
+func ForPlayerDel(playerID id.UID) Player {
+	return &playerDel{playerID}
+}
+
+// ParsePlayerDel parses the ID of the removed player from a JSON-encoded
+// update, if it is a player removal.
+func ParsePlayerDel(update string) (id.UID, bool) {
+	if ParseType(update) != TypePlayerDel {
+		return "", false
+	}
+	var fields []json.RawMessage
+	if err := json.Unmarshal([]byte(update), &fields); err != nil || len(fields) != 2 {
+		return "", false
+	}
+	var ty string
+	var playerID id.UID
+	if json.Unmarshal(fields[0], &ty) != nil || ty != TypePlayerDel {
+		return "", false
+	}
+	if json.Unmarshal(fields[1], &playerID) != nil {
+		return "", false
+	}
+	return playerID, true
+}
+
+// GMs is an update for the GMs of a game changing.
+type GMs struct {
+	gms []string
+}
+
+func (update *GMs) Type() string {
+	return TypeGMs
+}
+
+func (update *GMs) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{TypeGMs, update.gms})
+}
+
+// ForGMs constructs an update for the full list of GMs in a game
+func ForGMs(gms []string) *GMs {
+	return &GMs{gms}
+}
+
+// ParseGMs parses the list of GMs from a JSON-encoded update, if it is a GM
+// list update.
+func ParseGMs(update string) ([]string, bool) {
+	if ParseType(update) != TypeGMs {
+		return nil, false
+	}
+	var fields []json.RawMessage
+	if err := json.Unmarshal([]byte(update), &fields); err != nil || len(fields) != 2 {
+		return nil, false
+	}
+	var ty string
+	var gms []string
+	if json.Unmarshal(fields[0], &ty) != nil || ty != TypeGMs {
+		return nil, false
+	}
+	if json.Unmarshal(fields[1], &gms) != nil {
+		return nil, false
+	}
+	return gms, true
+}
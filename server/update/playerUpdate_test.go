@@ -0,0 +1,28 @@
+package update
+
+import (
+	"encoding/json"
+	"testing"
+
+	"sr/id"
+	"sr/test"
+)
+
+func TestParsePlayerDel(t *testing.T) {
+	udBytes, err := json.Marshal(ForPlayerDel(id.UID("kicked")))
+	test.AssertSuccess(t, err, "marshaling json")
+	playerID, ok := ParsePlayerDel(string(udBytes))
+	test.AssertEqual(t, true, ok)
+	test.AssertEqual(t, id.UID("kicked"), playerID)
+
+	_, ok = ParsePlayerDel(`["~plr","kicked",{"online":false}]`)
+	test.AssertEqual(t, false, ok)
+}
+
+func TestParseGMs(t *testing.T) {
+	udBytes, err := json.Marshal(ForGMs([]string{"a", "b"}))
+	test.AssertSuccess(t, err, "marshaling json")
+	gms, ok := ParseGMs(string(udBytes))
+	test.AssertEqual(t, true, ok)
+	test.AssertEqual(t, []string{"a", "b"}, gms)
+}
@@ -0,0 +1,50 @@
+package update
+
+import (
+	"encoding/json"
+
+	"sr/id"
+	"sr/timer"
+)
+
+// timerChange sends the current state of a timer.
+type timerChange struct {
+	ty    string
+	timer *timer.Timer
+}
+
+func (update *timerChange) Type() string {
+	return update.ty
+}
+
+func (update *timerChange) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{update.ty, update.timer})
+}
+
+// ForTimerNew constructs an update for a timer being started.
+func ForTimerNew(t *timer.Timer) Update {
+	return &timerChange{TypeTimerNew, t}
+}
+
+// ForTimerMod constructs an update for a timer changing.
+func ForTimerMod(t *timer.Timer) Update {
+	return &timerChange{TypeTimerMod, t}
+}
+
+// timerDelete removes a timer.
+type timerDelete struct {
+	id id.UID
+}
+
+func (update *timerDelete) Type() string {
+	return TypeTimerDel
+}
+
+func (update *timerDelete) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{TypeTimerDel, update.id})
+}
+
+// ForTimerDelete constructs an update for a timer being removed.
+func ForTimerDelete(timerID id.UID) Update {
+	return &timerDelete{timerID}
+}
@@ -12,10 +12,21 @@ const (
 
 	TypeRollSecondChance = "^roll" // A roll is rerolled
 	TypeInitSeized       = "!init" // Initiative is seized
+	TypeRollRequested    = "?roll" // A player is asked to roll
+	TypeMentioned        = "@msg"  // A player is mentioned in a message
+	TypeSoakRequested    = "?soak" // A player is asked to soak damage
+
+	TypeCharacterMod = "~chr" // A character property changes
+
+	TypeTimerNew = "+tmr" // A timer is started
+	TypeTimerMod = "~tmr" // A timer is paused, resumed or adjusted
+	TypeTimerDel = "-tmr" // A timer runs out or is removed
 
 	TypePlayerAdd = "+plr" // A player is added to the game
 	TypePlayerMod = "~plr" // A player property changes
 	TypePlayerDel = "-plr" // A player leaves the game
+
+	TypeGMs = "~gms" // A player is promoted to or demoted from GM
 )
 
 // Update is the basic interface for update structs